@@ -0,0 +1,222 @@
+package arrow
+
+import (
+	"io"
+	"strconv"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	arrowarray "github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/ipc"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+	"github.com/influxdata/flux"
+	"github.com/influxdata/flux/array"
+	"github.com/influxdata/flux/codes"
+	"github.com/influxdata/flux/internal/errors"
+	"github.com/influxdata/flux/iocounter"
+)
+
+// Metadata keys used to describe flux tables within an Arrow IPC stream.
+const (
+	// ResultMetadataKey is the schema metadata key holding the result name.
+	ResultMetadataKey = "flux.result"
+	// TableMetadataKey is the schema metadata key holding the table index
+	// within the result.
+	TableMetadataKey = "flux.table"
+	// TypeMetadataKey is the field metadata key holding the flux column type.
+	TypeMetadataKey = "flux.type"
+	// GroupMetadataKey is the field metadata key that is "true" when
+	// the column is part of the group key.
+	GroupMetadataKey = "flux.group"
+	// KeyValueMetadataKey is the field metadata key holding the group key
+	// value of a group key column as text, so the key of a table without
+	// rows is known. Times are integer nanoseconds. It is absent when the
+	// value is null.
+	KeyValueMetadataKey = "flux.keyValue"
+)
+
+// IPCMultiResultEncoder encodes results as a sequence of Arrow IPC streams.
+//
+// Each table is written as its own stream because tables within a
+// result may have different schemas. The schema of each stream carries
+// the result name and table index as metadata and each field records
+// its flux type and whether it is part of the group key. Time columns are
+// written as nanosecond timestamps in UTC.
+//
+// A reader can consume the output by repeatedly opening an ipc.Reader
+// on the same underlying reader until it is exhausted.
+type IPCMultiResultEncoder struct {
+	mem memory.Allocator
+}
+
+// NewIPCMultiResultEncoder creates a new Arrow IPC encoder.
+// If mem is nil, the default Go allocator will be used.
+func NewIPCMultiResultEncoder(mem memory.Allocator) *IPCMultiResultEncoder {
+	if mem == nil {
+		mem = memory.DefaultAllocator
+	}
+	return &IPCMultiResultEncoder{mem: mem}
+}
+
+func (e *IPCMultiResultEncoder) Encode(w io.Writer, results flux.ResultIterator) (int64, error) {
+	wc := &iocounter.Writer{Writer: w}
+	for results.More() {
+		result := results.Next()
+		tableID := 0
+		if err := result.Tables().Do(func(tbl flux.Table) error {
+			if err := e.encodeTable(wc, result.Name(), tableID, tbl); err != nil {
+				return err
+			}
+			tableID++
+			return nil
+		}); err != nil {
+			results.Release()
+			return wc.Count(), err
+		}
+	}
+	results.Release()
+	return wc.Count(), results.Err()
+}
+
+func (e *IPCMultiResultEncoder) encodeTable(w io.Writer, name string, id int, tbl flux.Table) error {
+	schema := ipcSchema(name, id, tbl.Cols(), tbl.Key())
+	writer := ipc.NewWriter(w, ipc.WithSchema(schema), ipc.WithAllocator(e.mem))
+	if err := tbl.Do(func(cr flux.ColReader) error {
+		record := e.newRecord(schema, cr)
+		defer record.Release()
+		return writer.Write(record)
+	}); err != nil {
+		_ = writer.Close()
+		return err
+	}
+	return writer.Close()
+}
+
+func (e *IPCMultiResultEncoder) newRecord(schema *arrow.Schema, cr flux.ColReader) arrow.Record {
+	cols := make([]arrow.Array, len(cr.Cols()))
+	for j, c := range cr.Cols() {
+		cols[j] = e.ipcColumn(schema.Field(j).Type, c.Type, cr, j)
+	}
+	record := arrowarray.NewRecord(schema, cols, int64(cr.Len()))
+	for _, col := range cols {
+		col.Release()
+	}
+	return record
+}
+
+// ipcColumn converts column j of the ColReader into an arrow array
+// with the data type used in the IPC schema.
+func (e *IPCMultiResultEncoder) ipcColumn(dt arrow.DataType, typ flux.ColType, cr flux.ColReader, j int) arrow.Array {
+	switch typ {
+	case flux.TTime:
+		// Reinterpret the int64 buffers as a timestamp.
+		data := cr.Times(j).Data()
+		tdata := arrowarray.NewData(dt, data.Len(), data.Buffers(), nil, data.NullN(), data.Offset())
+		defer tdata.Release()
+		return arrowarray.MakeFromData(tdata)
+	case flux.TString:
+		vs := cr.Strings(j)
+		if vs.DataType().ID() == arrow.STRING {
+			return arrowarray.MakeFromData(vs.Data())
+		}
+		// Dictionary and run-end encoded strings are
+		// written out as plain strings.
+		b := arrowarray.NewStringBuilder(e.mem)
+		defer b.Release()
+		b.Reserve(vs.Len())
+		for i, l := 0, vs.Len(); i < l; i++ {
+			if vs.IsNull(i) {
+				b.AppendNull()
+				continue
+			}
+			b.Append(vs.Value(i))
+		}
+		return b.NewArray()
+	default:
+		return arrowarray.MakeFromData(columnArray(typ, cr, j).Data())
+	}
+}
+
+func columnArray(typ flux.ColType, cr flux.ColReader, j int) array.Array {
+	switch typ {
+	case flux.TBool:
+		return cr.Bools(j)
+	case flux.TInt:
+		return cr.Ints(j)
+	case flux.TUInt:
+		return cr.UInts(j)
+	case flux.TFloat:
+		return cr.Floats(j)
+	default:
+		panic(errors.Newf(codes.Internal, "unsupported column type %s", typ))
+	}
+}
+
+func ipcSchema(name string, id int, cols []flux.ColMeta, key flux.GroupKey) *arrow.Schema {
+	fields := make([]arrow.Field, len(cols))
+	for j, c := range cols {
+		keys := []string{TypeMetadataKey, GroupMetadataKey}
+		vals := []string{c.Type.String(), strconv.FormatBool(key.HasCol(c.Label))}
+		if v, ok := keyValueText(key, c.Label); ok {
+			keys = append(keys, KeyValueMetadataKey)
+			vals = append(vals, v)
+		}
+		fields[j] = arrow.Field{
+			Name:     c.Label,
+			Type:     ipcDataType(c.Type),
+			Nullable: true,
+			Metadata: arrow.NewMetadata(keys, vals),
+		}
+	}
+	md := arrow.NewMetadata(
+		[]string{ResultMetadataKey, TableMetadataKey},
+		[]string{name, strconv.Itoa(id)},
+	)
+	return arrow.NewSchema(fields, &md)
+}
+
+// keyValueText returns the group key value of the column as text.
+// It returns false if the column is not part of the key or its value is null.
+func keyValueText(key flux.GroupKey, label string) (string, bool) {
+	for j, c := range key.Cols() {
+		if c.Label != label {
+			continue
+		}
+		if key.IsNull(j) {
+			return "", false
+		}
+		switch c.Type {
+		case flux.TBool:
+			return strconv.FormatBool(key.ValueBool(j)), true
+		case flux.TInt:
+			return strconv.FormatInt(key.ValueInt(j), 10), true
+		case flux.TUInt:
+			return strconv.FormatUint(key.ValueUInt(j), 10), true
+		case flux.TFloat:
+			return strconv.FormatFloat(key.ValueFloat(j), 'g', -1, 64), true
+		case flux.TString:
+			return key.ValueString(j), true
+		case flux.TTime:
+			return strconv.FormatInt(int64(key.ValueTime(j)), 10), true
+		}
+	}
+	return "", false
+}
+
+func ipcDataType(typ flux.ColType) arrow.DataType {
+	switch typ {
+	case flux.TBool:
+		return array.BooleanType
+	case flux.TInt:
+		return array.IntType
+	case flux.TUInt:
+		return array.UintType
+	case flux.TFloat:
+		return array.FloatType
+	case flux.TString:
+		return array.StringType
+	case flux.TTime:
+		return arrow.FixedWidthTypes.Timestamp_ns
+	default:
+		panic(errors.Newf(codes.Internal, "unsupported column type %s", typ))
+	}
+}
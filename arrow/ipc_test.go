@@ -0,0 +1,139 @@
+package arrow_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/ipc"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+	"github.com/influxdata/flux"
+	fluxarrow "github.com/influxdata/flux/arrow"
+	"github.com/influxdata/flux/execute"
+	"github.com/influxdata/flux/execute/executetest"
+)
+
+func TestIPCMultiResultEncoder(t *testing.T) {
+	results := flux.NewSliceResultIterator([]flux.Result{&executetest.Result{
+		Nm: "_result",
+		Tbls: []*executetest.Table{
+			{
+				KeyCols: []string{"host"},
+				ColMeta: []flux.ColMeta{
+					{Label: "_time", Type: flux.TTime},
+					{Label: "host", Type: flux.TString},
+					{Label: "_value", Type: flux.TFloat},
+				},
+				Data: [][]interface{}{
+					{execute.Time(1), "A", 1.0},
+					{execute.Time(2), "A", nil},
+				},
+			},
+			{
+				KeyCols: []string{"host"},
+				ColMeta: []flux.ColMeta{
+					{Label: "host", Type: flux.TString},
+					{Label: "_value", Type: flux.TInt},
+				},
+				Data: [][]interface{}{
+					{"B", int64(3)},
+				},
+			},
+		},
+	}})
+
+	mem := memory.NewCheckedAllocator(memory.DefaultAllocator)
+	defer mem.AssertSize(t, 0)
+
+	var buf bytes.Buffer
+	if _, err := fluxarrow.NewIPCMultiResultEncoder(mem).Encode(&buf, results); err != nil {
+		t.Fatal(err)
+	}
+
+	r := bytes.NewReader(buf.Bytes())
+	var got []string
+	for r.Len() > 0 {
+		rdr, err := ipc.NewReader(r, ipc.WithAllocator(mem))
+		if err != nil {
+			t.Fatal(err)
+		}
+		schema := rdr.Schema()
+		table, _ := schema.Metadata().GetValue(fluxarrow.TableMetadataKey)
+		result, _ := schema.Metadata().GetValue(fluxarrow.ResultMetadataKey)
+		got = append(got, result+"/"+table)
+		if idx := schema.FieldIndices("host"); len(idx) == 1 {
+			key, _ := schema.Field(idx[0]).Metadata.GetValue(fluxarrow.KeyValueMetadataKey)
+			got = append(got, "host="+key)
+		}
+		for rdr.Next() {
+			rec := rdr.Record()
+			got = append(got, recordString(t, rec))
+		}
+		if err := rdr.Err(); err != nil && err != io.EOF {
+			t.Fatal(err)
+		}
+		rdr.Release()
+	}
+
+	want := []string{
+		"_result/0",
+		"host=A",
+		`1970-01-01 00:00:00.000000001Z,A,1;1970-01-01 00:00:00.000000002Z,A,(null)`,
+		"_result/1",
+		"host=B",
+		`B,3`,
+	}
+	if len(got) != len(want) {
+		t.Fatalf("unexpected number of entries: want %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("unexpected entry %d: want %q, got %q", i, want[i], got[i])
+		}
+	}
+}
+
+// releaseResultIterator records whether the iterator was released.
+type releaseResultIterator struct {
+	flux.ResultIterator
+	released bool
+}
+
+func (r *releaseResultIterator) Release() {
+	r.released = true
+	r.ResultIterator.Release()
+}
+
+func TestIPCMultiResultEncoder_Error(t *testing.T) {
+	results := &releaseResultIterator{
+		ResultIterator: flux.NewSliceResultIterator([]flux.Result{
+			&executetest.Result{Nm: "failed", Err: errors.New("expected error")},
+		}),
+	}
+	_, err := fluxarrow.NewIPCMultiResultEncoder(nil).Encode(io.Discard, results)
+	if err == nil || err.Error() != "expected error" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !results.released {
+		t.Error("results were not released")
+	}
+}
+
+func recordString(t *testing.T, rec arrow.Record) string {
+	t.Helper()
+	var buf bytes.Buffer
+	for i := 0; i < int(rec.NumRows()); i++ {
+		if i > 0 {
+			buf.WriteByte(';')
+		}
+		for j, col := range rec.Columns() {
+			if j > 0 {
+				buf.WriteByte(',')
+			}
+			buf.WriteString(col.ValueStr(i))
+		}
+	}
+	return buf.String()
+}
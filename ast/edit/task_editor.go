@@ -1,6 +1,8 @@
 package edit
 
 import (
+	"strings"
+
 	"github.com/influxdata/flux"
 	"github.com/influxdata/flux/ast"
 	"github.com/influxdata/flux/codes"
@@ -35,27 +37,47 @@ func GetOption(file *ast.File, name string) (ast.Expression, error) {
 }
 
 // SetOption replaces an existing option's init with the provided init or adds
-// the option if it doesn't exist. The file AST is mutated in place.
+// the option if it doesn't exist. The name of an option of another package is
+// qualified with the name the package is imported as, like "v.timeRangeStart".
+// The file AST is mutated in place.
 func SetOption(file *ast.File, name string, expr ast.Expression) {
 	// check for the correct file
 	for _, st := range file.Body {
 		if val, ok := st.(*ast.OptionStatement); ok {
 			assign := val.Assignment
-			if va, ok := assign.(*ast.VariableAssignment); ok {
-				if va.ID.Name == name {
+			switch a := assign.(type) {
+			case *ast.VariableAssignment:
+				if a.ID.Name == name {
 					// replace the variable assignment's init
-					va.Init = expr
+					a.Init = expr
 					return
 				}
+			case *ast.MemberAssignment:
+				if ident, ok := a.Member.Object.(*ast.Identifier); ok {
+					if ident.Name+"."+a.Member.Property.Key() == name {
+						a.Init = expr
+						return
+					}
+				}
 			}
 		}
 	}
 	// option was not found. prepend new option to body
-	file.Body = append([]ast.Statement{&ast.OptionStatement{
-		Assignment: &ast.VariableAssignment{
-			ID:   &ast.Identifier{Name: name},
+	var assign ast.Assignment = &ast.VariableAssignment{
+		ID:   &ast.Identifier{Name: name},
+		Init: expr,
+	}
+	if pkg, option, ok := strings.Cut(name, "."); ok {
+		assign = &ast.MemberAssignment{
+			Member: &ast.MemberExpression{
+				Object:   &ast.Identifier{Name: pkg},
+				Property: &ast.Identifier{Name: option},
+			},
 			Init: expr,
-		},
+		}
+	}
+	file.Body = append([]ast.Statement{&ast.OptionStatement{
+		Assignment: assign,
 	}}, file.Body...)
 }
 
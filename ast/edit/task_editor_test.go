@@ -217,6 +217,63 @@ func TestSetDeleteOption(t *testing.T) {
 				},
 			},
 		},
+		{
+			testName: "test set package option",
+			testType: "setOption",
+			optionID: "v.timeRangeStart",
+			got: &ast.File{
+				Name: "foo.flux",
+				Body: []ast.Statement{
+					&ast.OptionStatement{
+						Assignment: &ast.MemberAssignment{
+							Member: &ast.MemberExpression{
+								Object:   &ast.Identifier{Name: "v"},
+								Property: &ast.Identifier{Name: "timeRangeStart"},
+							},
+							Init: &ast.IntegerLiteral{Value: 1},
+						},
+					},
+				},
+			},
+			opt: &ast.IntegerLiteral{Value: 2},
+			want: &ast.File{
+				Name: "foo.flux",
+				Body: []ast.Statement{
+					&ast.OptionStatement{
+						Assignment: &ast.MemberAssignment{
+							Member: &ast.MemberExpression{
+								Object:   &ast.Identifier{Name: "v"},
+								Property: &ast.Identifier{Name: "timeRangeStart"},
+							},
+							Init: &ast.IntegerLiteral{Value: 2},
+						},
+					},
+				},
+			},
+		},
+		{
+			testName: "test set new package option",
+			testType: "setOption",
+			optionID: "v.timeRangeStart",
+			got: &ast.File{
+				Name: "foo.flux",
+			},
+			opt: &ast.IntegerLiteral{Value: 2},
+			want: &ast.File{
+				Name: "foo.flux",
+				Body: []ast.Statement{
+					&ast.OptionStatement{
+						Assignment: &ast.MemberAssignment{
+							Member: &ast.MemberExpression{
+								Object:   &ast.Identifier{Name: "v"},
+								Property: &ast.Identifier{Name: "timeRangeStart"},
+							},
+							Init: &ast.IntegerLiteral{Value: 2},
+						},
+					},
+				},
+			},
+		},
 		{
 			testName: "test deleteOption",
 			testType: "deleteOption",
@@ -0,0 +1,55 @@
+package edit
+
+import (
+	"path"
+
+	"github.com/influxdata/flux"
+	"github.com/influxdata/flux/ast"
+	"github.com/influxdata/flux/codes"
+)
+
+// VariableNotFoundError variable is to handle the error gracefully in the client code
+var VariableNotFoundError = &flux.Error{Code: codes.Invalid, Msg: "variable not found"}
+
+// GetVariable finds and returns the init of the last assignment to the variable in the statements.
+func GetVariable(body []ast.Statement, name string) (ast.Expression, error) {
+	if va := lastAssignment(body, name); va != nil {
+		return va.Init, nil
+	}
+	return nil, VariableNotFoundError
+}
+
+// SetVariable replaces the init of the last assignment to the variable in the statements
+// with the provided init. The statements are mutated in place.
+func SetVariable(body []ast.Statement, name string, expr ast.Expression) error {
+	va := lastAssignment(body, name)
+	if va == nil {
+		return VariableNotFoundError
+	}
+	va.Init = expr
+	return nil
+}
+
+func lastAssignment(body []ast.Statement, name string) *ast.VariableAssignment {
+	for i := len(body) - 1; i >= 0; i-- {
+		if va, ok := body[i].(*ast.VariableAssignment); ok && va.ID.Name == name {
+			return va
+		}
+	}
+	return nil
+}
+
+// ImportName returns the name the package is imported as in the file
+// and whether the file imports the package.
+func ImportName(file *ast.File, pkgPath string) (string, bool) {
+	for _, imp := range file.Imports {
+		if imp.Path.Value != pkgPath {
+			continue
+		}
+		if imp.As != nil {
+			return imp.As.Name, true
+		}
+		return path.Base(pkgPath), true
+	}
+	return "", false
+}
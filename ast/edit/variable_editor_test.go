@@ -0,0 +1,93 @@
+package edit_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/influxdata/flux/ast"
+	"github.com/influxdata/flux/ast/asttest"
+	"github.com/influxdata/flux/ast/edit"
+)
+
+func TestGetSetVariable(t *testing.T) {
+	body := []ast.Statement{
+		&ast.VariableAssignment{
+			ID:   &ast.Identifier{Name: "a"},
+			Init: &ast.IntegerLiteral{Value: 1},
+		},
+		&ast.OptionStatement{
+			Assignment: &ast.VariableAssignment{
+				ID:   &ast.Identifier{Name: "b"},
+				Init: &ast.IntegerLiteral{Value: 2},
+			},
+		},
+		&ast.VariableAssignment{
+			ID:   &ast.Identifier{Name: "a"},
+			Init: &ast.IntegerLiteral{Value: 3},
+		},
+	}
+
+	got, err := edit.GetVariable(body, "a")
+	if err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	if want := (&ast.IntegerLiteral{Value: 3}); !cmp.Equal(want, got, asttest.IgnoreBaseNodeOptions...) {
+		t.Errorf("Unexpected value -want/+got:\n%s", cmp.Diff(want, got, asttest.IgnoreBaseNodeOptions...))
+	}
+	// Options are not variables.
+	if _, err := edit.GetVariable(body, "b"); err != edit.VariableNotFoundError {
+		t.Errorf("unexpected error %v, want %v", err, edit.VariableNotFoundError)
+	}
+
+	if err := edit.SetVariable(body, "a", &ast.IntegerLiteral{Value: 4}); err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	want := []ast.Statement{
+		&ast.VariableAssignment{
+			ID:   &ast.Identifier{Name: "a"},
+			Init: &ast.IntegerLiteral{Value: 1},
+		},
+		&ast.OptionStatement{
+			Assignment: &ast.VariableAssignment{
+				ID:   &ast.Identifier{Name: "b"},
+				Init: &ast.IntegerLiteral{Value: 2},
+			},
+		},
+		&ast.VariableAssignment{
+			ID:   &ast.Identifier{Name: "a"},
+			Init: &ast.IntegerLiteral{Value: 4},
+		},
+	}
+	if !cmp.Equal(want, body, asttest.IgnoreBaseNodeOptions...) {
+		t.Errorf("Unexpected value -want/+got:\n%s", cmp.Diff(want, body, asttest.IgnoreBaseNodeOptions...))
+	}
+	if err := edit.SetVariable(body, "c", &ast.IntegerLiteral{Value: 5}); err != edit.VariableNotFoundError {
+		t.Errorf("unexpected error %v, want %v", err, edit.VariableNotFoundError)
+	}
+}
+
+func TestImportName(t *testing.T) {
+	file := &ast.File{
+		Imports: []*ast.ImportDeclaration{
+			{Path: &ast.StringLiteral{Value: "experimental/array"}},
+			{
+				As:   &ast.Identifier{Name: "t"},
+				Path: &ast.StringLiteral{Value: "testing"},
+			},
+		},
+	}
+	for _, tc := range []struct {
+		path string
+		name string
+		ok   bool
+	}{
+		{path: "experimental/array", name: "array", ok: true},
+		{path: "testing", name: "t", ok: true},
+		{path: "csv"},
+	} {
+		name, ok := edit.ImportName(file, tc.path)
+		if name != tc.name || ok != tc.ok {
+			t.Errorf("unexpected import name of %q: got %q, %v want %q, %v", tc.path, name, ok, tc.name, tc.ok)
+		}
+	}
+}
@@ -0,0 +1,328 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	goruntime "runtime"
+	"sort"
+	"time"
+
+	"github.com/influxdata/flux"
+	"github.com/influxdata/flux/ast"
+	"github.com/influxdata/flux/ast/testcase"
+	fluxcmd "github.com/influxdata/flux/cmd/flux/cmd"
+	"github.com/influxdata/flux/codes"
+	"github.com/influxdata/flux/dependencies/testing"
+	"github.com/influxdata/flux/fluxinit"
+	"github.com/influxdata/flux/internal/errors"
+	"github.com/influxdata/flux/lang"
+	"github.com/influxdata/flux/memory"
+	"github.com/influxdata/flux/parser"
+	"github.com/influxdata/flux/runtime"
+	"github.com/spf13/cobra"
+)
+
+var benchFlags struct {
+	Count     int
+	Warmup    int
+	Testcase  string
+	Baseline  string
+	Save      string
+	Threshold float64
+	Format    string
+}
+
+// benchRegression is returned when the benchmark is slower or uses more
+// memory than the baseline. The comparison has already been written.
+type benchRegression struct{}
+
+func (benchRegression) Error() string { return "benchmark regressed" }
+func (benchRegression) Silent()       {}
+
+// benchResult is the summary of the measured runs of a benchmark.
+// Durations are in nanoseconds and memory in bytes.
+// It is also the format of the baseline file.
+type benchResult struct {
+	Runs           int       `json:"runs"`
+	Warmup         int       `json:"warmup"`
+	WallTime       benchTime `json:"wall_time"`
+	Rows           int64     `json:"rows"`
+	MaxAllocated   int64     `json:"max_allocated"`
+	TotalAllocated int64     `json:"total_allocated"`
+	GCCycles       float64   `json:"gc_cycles"`
+}
+
+type benchTime struct {
+	Min  int64 `json:"min"`
+	Mean int64 `json:"mean"`
+	P50  int64 `json:"p50"`
+	P90  int64 `json:"p90"`
+	P99  int64 `json:"p99"`
+	Max  int64 `json:"max"`
+}
+
+// benchRun holds the measurements of a single run.
+type benchRun struct {
+	duration       time.Duration
+	rows           int64
+	maxAllocated   int64
+	totalAllocated int64
+	gcCycles       uint32
+}
+
+func benchE(cmd *cobra.Command, args []string) error {
+	if benchFlags.Count < 1 {
+		return errors.New(codes.Invalid, "--count must be at least 1")
+	}
+	if benchFlags.Warmup < 0 {
+		return errors.New(codes.Invalid, "--warmup must not be negative")
+	}
+	if benchFlags.Format != "text" && benchFlags.Format != "json" {
+		return errors.Newf(codes.Invalid, "unknown output format %q, must be one of: text,json", benchFlags.Format)
+	}
+
+	var baseline *benchResult
+	if benchFlags.Baseline != "" {
+		b, err := readBenchResult(benchFlags.Baseline)
+		if err != nil {
+			return err
+		}
+		baseline = b
+	}
+
+	content, err := os.ReadFile(args[0])
+	if err != nil {
+		return err
+	}
+
+	fluxinit.FluxInit()
+	ctx, span := injectDependencies(context.Background())
+	defer span.Finish()
+
+	ctx, err = fluxcmd.WithFeatureFlags(ctx, flags.Features)
+	if err != nil {
+		return err
+	}
+
+	var compiler flux.Compiler = lang.FluxCompiler{Query: string(content)}
+	if benchFlags.Testcase != "" {
+		c, err := testcaseCompiler(ctx, args[0], string(content), benchFlags.Testcase)
+		if err != nil {
+			return err
+		}
+		compiler = c
+		ctx = testing.Inject(ctx)
+	}
+
+	runs := make([]benchRun, 0, benchFlags.Count)
+	for i := 0; i < benchFlags.Warmup+benchFlags.Count; i++ {
+		run, err := benchOnce(ctx, compiler)
+		if err != nil {
+			return err
+		}
+		if i >= benchFlags.Warmup {
+			runs = append(runs, run)
+		}
+	}
+
+	result := summarizeBench(runs, benchFlags.Warmup)
+	if benchFlags.Save != "" {
+		if err := writeBenchResult(benchFlags.Save, result); err != nil {
+			return err
+		}
+	}
+
+	out := cmd.OutOrStdout()
+	if benchFlags.Format == "json" {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(result); err != nil {
+			return err
+		}
+	} else {
+		writeBenchText(out, result)
+	}
+
+	if baseline == nil {
+		return nil
+	}
+	regressions := compareBench(baseline, result, benchFlags.Threshold)
+	for _, r := range regressions {
+		fmt.Fprintln(cmd.OutOrStderr(), r)
+	}
+	if len(regressions) > 0 {
+		return benchRegression{}
+	}
+	return nil
+}
+
+// testcaseCompiler returns a compiler for the named testcase in the file.
+func testcaseCompiler(ctx context.Context, filename, src, name string) (flux.Compiler, error) {
+	pkg := parser.ParseSourceWithFileName(src, filename)
+	if ast.Check(pkg) > 0 {
+		return nil, ast.GetError(pkg)
+	}
+	idens, pkgs, err := testcase.Transform(ctx, pkg, nil)
+	if err != nil {
+		return nil, err
+	}
+	for i, id := range idens {
+		if id.Name != name {
+			continue
+		}
+		jsonAST, err := json.Marshal(pkgs[i])
+		if err != nil {
+			return nil, err
+		}
+		return lang.ASTCompiler{AST: jsonAST}, nil
+	}
+	return nil, errors.Newf(codes.NotFound, "testcase %q not found in %s", name, filename)
+}
+
+// benchOnce compiles and runs the program once and reads all of its results.
+func benchOnce(ctx context.Context, compiler flux.Compiler) (benchRun, error) {
+	var stats goruntime.MemStats
+	goruntime.ReadMemStats(&stats)
+	gcBefore := stats.NumGC
+
+	start := time.Now()
+	prog, err := compiler.Compile(ctx, runtime.Default)
+	if err != nil {
+		return benchRun{}, err
+	}
+	mem := &memory.ResourceAllocator{}
+	q, err := prog.Start(ctx, mem)
+	if err != nil {
+		return benchRun{}, err
+	}
+
+	var rows int64
+	results := flux.NewResultIteratorFromQuery(q)
+	for results.More() {
+		if err := results.Next().Tables().Do(func(tbl flux.Table) error {
+			return tbl.Do(func(cr flux.ColReader) error {
+				rows += int64(cr.Len())
+				return nil
+			})
+		}); err != nil {
+			results.Release()
+			return benchRun{}, err
+		}
+	}
+	results.Release()
+	if err := results.Err(); err != nil {
+		return benchRun{}, err
+	}
+	duration := time.Since(start)
+
+	goruntime.ReadMemStats(&stats)
+	return benchRun{
+		duration:       duration,
+		rows:           rows,
+		maxAllocated:   mem.MaxAllocated(),
+		totalAllocated: mem.TotalAllocated(),
+		gcCycles:       stats.NumGC - gcBefore,
+	}, nil
+}
+
+// summarizeBench computes the wall time percentiles of the runs.
+// The memory statistics are the largest maximum across the runs and
+// the mean total, the number of GC cycles is the mean per run.
+func summarizeBench(runs []benchRun, warmup int) benchResult {
+	durations := make([]int64, len(runs))
+	var (
+		sum, total int64
+		gcCycles   uint32
+	)
+	result := benchResult{Runs: len(runs), Warmup: warmup}
+	for i, run := range runs {
+		durations[i] = run.duration.Nanoseconds()
+		sum += durations[i]
+		total += run.totalAllocated
+		gcCycles += run.gcCycles
+		if run.maxAllocated > result.MaxAllocated {
+			result.MaxAllocated = run.maxAllocated
+		}
+		result.Rows = run.rows
+	}
+	sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+
+	n := int64(len(runs))
+	result.WallTime = benchTime{
+		Min:  durations[0],
+		Mean: sum / n,
+		P50:  percentile(durations, 0.5),
+		P90:  percentile(durations, 0.9),
+		P99:  percentile(durations, 0.99),
+		Max:  durations[len(durations)-1],
+	}
+	result.TotalAllocated = total / n
+	result.GCCycles = float64(gcCycles) / float64(n)
+	return result
+}
+
+// percentile returns the nearest rank percentile of the sorted values.
+func percentile(sorted []int64, p float64) int64 {
+	rank := int(math.Ceil(p*float64(len(sorted)))) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	return sorted[rank]
+}
+
+// compareBench returns a description of each statistic of the result
+// that exceeds the baseline by more than the threshold ratio.
+func compareBench(baseline *benchResult, result benchResult, threshold float64) []string {
+	var regressions []string
+	check := func(name string, base, cur int64, format func(int64) string) {
+		if base <= 0 {
+			return
+		}
+		if change := float64(cur-base) / float64(base); change > threshold {
+			regressions = append(regressions, fmt.Sprintf("%s regressed by %.1f%%: baseline %s, current %s",
+				name, 100*change, format(base), format(cur)))
+		}
+	}
+	formatDuration := func(v int64) string { return time.Duration(v).String() }
+	formatBytes := func(v int64) string { return fmt.Sprintf("%d B", v) }
+	check("p50 wall time", baseline.WallTime.P50, result.WallTime.P50, formatDuration)
+	check("p90 wall time", baseline.WallTime.P90, result.WallTime.P90, formatDuration)
+	check("max allocated", baseline.MaxAllocated, result.MaxAllocated, formatBytes)
+	check("total allocated", baseline.TotalAllocated, result.TotalAllocated, formatBytes)
+	return regressions
+}
+
+func writeBenchText(w io.Writer, r benchResult) {
+	d := func(v int64) time.Duration { return time.Duration(v) }
+	fmt.Fprintf(w, "runs:            %d (warmup %d)\n", r.Runs, r.Warmup)
+	fmt.Fprintf(w, "wall time:       min %v, mean %v, p50 %v, p90 %v, p99 %v, max %v\n",
+		d(r.WallTime.Min), d(r.WallTime.Mean), d(r.WallTime.P50), d(r.WallTime.P90), d(r.WallTime.P99), d(r.WallTime.Max))
+	fmt.Fprintf(w, "rows:            %d\n", r.Rows)
+	fmt.Fprintf(w, "max allocated:   %d B\n", r.MaxAllocated)
+	fmt.Fprintf(w, "total allocated: %d B/run\n", r.TotalAllocated)
+	fmt.Fprintf(w, "gc cycles:       %.2f/run\n", r.GCCycles)
+}
+
+func readBenchResult(filename string) (*benchResult, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var r benchResult
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, errors.Wrapf(err, codes.Invalid, "invalid baseline file %s", filename)
+	}
+	return &r, nil
+}
+
+func writeBenchResult(filename string, r benchResult) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filename, append(data, '\n'), 0644)
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSummarizeBench(t *testing.T) {
+	var runs []benchRun
+	for i := 1; i <= 10; i++ {
+		runs = append(runs, benchRun{
+			duration:       time.Duration(11-i) * time.Millisecond,
+			rows:           100,
+			maxAllocated:   int64(i * 10),
+			totalAllocated: int64(i * 100),
+			gcCycles:       1,
+		})
+	}
+
+	want := benchResult{
+		Runs:   10,
+		Warmup: 2,
+		WallTime: benchTime{
+			Min:  int64(1 * time.Millisecond),
+			Mean: int64(5500 * time.Microsecond),
+			P50:  int64(5 * time.Millisecond),
+			P90:  int64(9 * time.Millisecond),
+			P99:  int64(10 * time.Millisecond),
+			Max:  int64(10 * time.Millisecond),
+		},
+		Rows:           100,
+		MaxAllocated:   100,
+		TotalAllocated: 550,
+		GCCycles:       1,
+	}
+	if got := summarizeBench(runs, 2); !cmp.Equal(want, got) {
+		t.Errorf("unexpected result -want/+got:\n%s", cmp.Diff(want, got))
+	}
+}
+
+func TestCompareBench(t *testing.T) {
+	baseline := &benchResult{
+		WallTime:       benchTime{P50: 100, P90: 200},
+		MaxAllocated:   1000,
+		TotalAllocated: 2000,
+	}
+	for _, tt := range []struct {
+		name   string
+		result benchResult
+		want   int
+	}{
+		{
+			name: "within threshold",
+			result: benchResult{
+				WallTime:       benchTime{P50: 109, P90: 150},
+				MaxAllocated:   1100,
+				TotalAllocated: 1000,
+			},
+		},
+		{
+			name: "slower",
+			result: benchResult{
+				WallTime:       benchTime{P50: 111, P90: 250},
+				MaxAllocated:   1000,
+				TotalAllocated: 2000,
+			},
+			want: 2,
+		},
+		{
+			name: "more memory",
+			result: benchResult{
+				WallTime:       benchTime{P50: 100, P90: 200},
+				MaxAllocated:   2000,
+				TotalAllocated: 2000,
+			},
+			want: 1,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := compareBench(baseline, tt.result, 0.1); len(got) != tt.want {
+				t.Errorf("unexpected regressions got %v want %d", got, tt.want)
+			}
+		})
+	}
+}
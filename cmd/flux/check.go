@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	fluxcmd "github.com/influxdata/flux/cmd/flux/cmd"
+	"github.com/influxdata/flux/codes"
+	"github.com/influxdata/flux/internal/errors"
+	"github.com/influxdata/flux/lint"
+	"github.com/spf13/cobra"
+)
+
+var checkFlags struct {
+	Format string
+}
+
+// checkFailed is returned when any of the checked files has errors.
+// The diagnostics have already been written so it is not printed.
+type checkFailed struct{}
+
+func (checkFailed) Error() string { return "check failed" }
+func (checkFailed) Silent()       {}
+
+func checkE(cmd *cobra.Command, args []string) error {
+	if checkFlags.Format != "text" && checkFlags.Format != "json" {
+		return errors.Newf(codes.Invalid, "unknown output format %q, must be one of: text,json", checkFlags.Format)
+	}
+
+	ctx, err := fluxcmd.WithFeatureFlags(context.Background(), flags.Features)
+	if err != nil {
+		return err
+	}
+
+	var diags []lint.Diagnostic
+	for _, arg := range args {
+		err := filepath.Walk(arg, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if info.IsDir() || filepath.Ext(info.Name()) != ".flux" {
+				return nil
+			}
+			src, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			fileDiags, err := lint.Check(ctx, path, string(src))
+			if err != nil {
+				return errors.Wrapf(err, codes.Inherit, "failed to check %s", path)
+			}
+			diags = append(diags, fileDiags...)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := writeDiagnostics(cmd.OutOrStdout(), checkFlags.Format, diags); err != nil {
+		return err
+	}
+	if lint.HasErrors(diags) {
+		return checkFailed{}
+	}
+	return nil
+}
+
+// writeDiagnostics writes the diagnostics as lines of text
+// or as a JSON array.
+func writeDiagnostics(w io.Writer, format string, diags []lint.Diagnostic) error {
+	if format == "json" {
+		if diags == nil {
+			diags = []lint.Diagnostic{}
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(diags)
+	}
+	for _, d := range diags {
+		if _, err := fmt.Fprintln(w, d); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,250 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/influxdata/flux/ast"
+	"github.com/influxdata/flux/interpreter"
+	"github.com/influxdata/flux/parser"
+)
+
+// Kinds of symbols listed in a coverage report.
+const (
+	coverageBuiltin  = "builtin"
+	coverageFunction = "function"
+	coverageBranch   = "branch"
+)
+
+type coverageReport struct {
+	Packages []*coveragePackage `json:"packages"`
+}
+
+// coveragePackage is the coverage of a single Flux package.
+// Uncovered lists the functions and branches that were never evaluated.
+type coveragePackage struct {
+	Path             string         `json:"path"`
+	Functions        int            `json:"functions"`
+	CoveredFunctions int            `json:"covered_functions"`
+	Branches         int            `json:"branches"`
+	CoveredBranches  int            `json:"covered_branches"`
+	Uncovered        []coverageItem `json:"uncovered"`
+}
+
+type coverageItem struct {
+	Kind   string `json:"kind"`
+	Name   string `json:"name"`
+	File   string `json:"file"`
+	Line   int    `json:"line"`
+	Column int    `json:"column"`
+}
+
+// newCoverageReport creates a coverage report for the Flux packages that
+// contain the test files found under the roots. Archives are not included
+// in the report since their packages are not part of the Flux runtime.
+//
+// Package paths and file names are relative to the directory of the nearest
+// fluxtest.root file so they match the source locations of the stdlib.
+func newCoverageReport(roots []string, cov *interpreter.Coverage) (*coverageReport, error) {
+	dirs := make(map[string]string)
+	for _, root := range roots {
+		if strings.HasSuffix(root, ".tar.gz") || strings.HasSuffix(root, ".tar") || strings.HasSuffix(root, ".zip") {
+			continue
+		}
+		err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if isTestFile(info, path) {
+				dir := filepath.Dir(path)
+				if _, ok := dirs[dir]; !ok {
+					dirs[dir] = coverageBase(dir, root)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	report := &coverageReport{}
+	for dir, base := range dirs {
+		pkg, err := readCoveragePackage(dir, base, cov)
+		if err != nil {
+			return nil, err
+		}
+		if pkg != nil {
+			report.Packages = append(report.Packages, pkg)
+		}
+	}
+	sort.Slice(report.Packages, func(i, j int) bool {
+		return report.Packages[i].Path < report.Packages[j].Path
+	})
+	return report, nil
+}
+
+// coverageBase returns the directory of the nearest test root
+// that contains dir or root when there is no test root.
+func coverageBase(dir, root string) string {
+	cur, err := filepath.Abs(dir)
+	if err != nil {
+		return root
+	}
+	for {
+		if _, err := os.Stat(filepath.Join(cur, testRootFilename)); err == nil {
+			return cur
+		}
+		parent := filepath.Dir(cur)
+		if parent == cur {
+			return root
+		}
+		cur = parent
+	}
+}
+
+// readCoveragePackage reads the package source files in dir and checks
+// the coverage of its functions and conditional branches. A nil package
+// is returned when the directory only contains tests.
+func readCoveragePackage(dir, base string, cov *interpreter.Coverage) (*coveragePackage, error) {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	absBase, err := filepath.Abs(base)
+	if err != nil {
+		return nil, err
+	}
+	pkgPath, err := filepath.Rel(absBase, absDir)
+	if err != nil {
+		return nil, err
+	}
+	pkg := &coveragePackage{Path: filepath.ToSlash(pkgPath)}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	found := false
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || filepath.Ext(name) != ".flux" || strings.HasSuffix(name, "_test.flux") {
+			continue
+		}
+		found = true
+		src, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+		fileName := name
+		if pkg.Path != "." {
+			fileName = pkg.Path + "/" + name
+		}
+		astPkg := parser.ParseSourceWithFileName(string(src), fileName)
+		if ast.Check(astPkg) > 0 {
+			return nil, ast.GetError(astPkg)
+		}
+		for _, file := range astPkg.Files {
+			pkg.addFile(file, cov)
+		}
+	}
+	if !found {
+		return nil, nil
+	}
+	return pkg, nil
+}
+
+func (p *coveragePackage) addFile(file *ast.File, cov *interpreter.Coverage) {
+	for _, stmt := range file.Body {
+		switch s := stmt.(type) {
+		case *ast.BuiltinStatement:
+			if _, ok := s.Ty.Ty.(*ast.FunctionType); ok {
+				p.addFunction(coverageBuiltin, s.ID.Name, s.Location(), cov.CalledBuiltin(p.Path, s.ID.Name))
+			}
+		case *ast.VariableAssignment:
+			if fn, ok := s.Init.(*ast.FunctionExpression); ok {
+				p.addFunction(coverageFunction, s.ID.Name, fn.Location(), cov.Evaluated(fn.Location()))
+			}
+		}
+	}
+
+	ast.Walk(ast.CreateVisitor(func(node ast.Node) {
+		cond, ok := node.(*ast.ConditionalExpression)
+		if !ok {
+			return
+		}
+		p.addBranch("then", cond.Consequent.Location(), cov.Evaluated(cond.Consequent.Location()))
+		p.addBranch("else", cond.Alternate.Location(), cov.Evaluated(cond.Alternate.Location()))
+	}), file)
+}
+
+func (p *coveragePackage) addFunction(kind, name string, loc ast.SourceLocation, covered bool) {
+	p.Functions++
+	if covered {
+		p.CoveredFunctions++
+		return
+	}
+	p.Uncovered = append(p.Uncovered, newCoverageItem(kind, name, loc))
+}
+
+func (p *coveragePackage) addBranch(name string, loc ast.SourceLocation, covered bool) {
+	p.Branches++
+	if covered {
+		p.CoveredBranches++
+		return
+	}
+	p.Uncovered = append(p.Uncovered, newCoverageItem(coverageBranch, name, loc))
+}
+
+func newCoverageItem(kind, name string, loc ast.SourceLocation) coverageItem {
+	return coverageItem{
+		Kind:   kind,
+		Name:   name,
+		File:   loc.File,
+		Line:   loc.Start.Line,
+		Column: loc.Start.Column,
+	}
+}
+
+// WriteText writes the coverage of each package followed by
+// the functions in the package that were never called.
+func (r *coverageReport) WriteText(w io.Writer) error {
+	if _, err := fmt.Fprintf(w, "\nCoverage:\n"); err != nil {
+		return err
+	}
+	for _, pkg := range r.Packages {
+		if _, err := fmt.Fprintf(w, "%s\tfunctions %s\tbranches %s\n", pkg.Path,
+			percent(pkg.CoveredFunctions, pkg.Functions),
+			percent(pkg.CoveredBranches, pkg.Branches)); err != nil {
+			return err
+		}
+		for _, item := range pkg.Uncovered {
+			if item.Kind == coverageBranch {
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "\tuncovered %s %s.%s (%s:%d)\n", item.Kind, pkg.Path, item.Name, item.File, item.Line); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// WriteJSON writes the report as a JSON object.
+func (r *coverageReport) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+func percent(covered, total int) string {
+	if total == 0 {
+		return "0/0"
+	}
+	return fmt.Sprintf("%d/%d (%.1f%%)", covered, total, 100*float64(covered)/float64(total))
+}
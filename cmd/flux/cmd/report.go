@@ -0,0 +1,200 @@
+package cmd
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// reportFunc writes a report of the tests that were run.
+type reportFunc func(w io.Writer, tests []*Test) error
+
+// reporters maps the values of the --reporter flag to the
+// function that writes the report.
+var reporters = map[string]reportFunc{
+	"junit": writeJUnitReport,
+	"json":  writeJSONReport,
+}
+
+const (
+	statusPass = "pass"
+	statusFail = "fail"
+	statusSkip = "skip"
+)
+
+// Status returns the outcome of the test, one of pass, fail or skip.
+func (t *Test) Status() string {
+	if t.skip {
+		return statusSkip
+	} else if t.err != nil {
+		return statusFail
+	}
+	return statusPass
+}
+
+// Location returns the file and line of the testcase statement.
+func (t *Test) Location() (file string, line int) {
+	if len(t.ast.Files) > 0 {
+		file = t.ast.Files[0].Name
+	}
+	if t.loc != nil {
+		if t.loc.File != "" {
+			file = t.loc.File
+		}
+		line = t.loc.Start.Line
+	}
+	return file, line
+}
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Time     float64          `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Skipped   int             `xml:"skipped,attr"`
+	Time      float64         `xml:"time,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	ClassName string        `xml:"classname,attr"`
+	Name      string        `xml:"name,attr"`
+	File      string        `xml:"file,attr,omitempty"`
+	Line      int           `xml:"line,attr,omitempty"`
+	Time      float64       `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	Skipped   *struct{}     `xml:"skipped,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Body    string `xml:",chardata"`
+}
+
+// writeJUnitReport writes the tests in the JUnit XML format
+// with one test suite for each Flux package.
+func writeJUnitReport(w io.Writer, tests []*Test) error {
+	var report junitTestSuites
+	suites := make(map[string]int)
+	for _, test := range tests {
+		idx, ok := suites[test.pkg]
+		if !ok {
+			idx = len(report.Suites)
+			suites[test.pkg] = idx
+			report.Suites = append(report.Suites, junitTestSuite{Name: test.pkg})
+		}
+		suite := &report.Suites[idx]
+
+		file, line := test.Location()
+		tc := junitTestCase{
+			ClassName: test.pkg,
+			Name:      test.name,
+			File:      file,
+			Line:      line,
+			Time:      test.duration.Seconds(),
+		}
+		switch test.Status() {
+		case statusFail:
+			msg := test.err.Error()
+			tc.Failure = &junitFailure{
+				Message: firstLine(msg),
+				Type:    "failure",
+				Body:    msg,
+			}
+			suite.Failures++
+		case statusSkip:
+			tc.Skipped = &struct{}{}
+			suite.Skipped++
+		}
+		suite.Tests++
+		suite.Time += tc.Time
+		suite.TestCases = append(suite.TestCases, tc)
+	}
+	for _, suite := range report.Suites {
+		report.Tests += suite.Tests
+		report.Failures += suite.Failures
+		report.Skipped += suite.Skipped
+		report.Time += suite.Time
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintln(w)
+	return err
+}
+
+type jsonReport struct {
+	Tests     int              `json:"tests"`
+	Passed    int              `json:"passed"`
+	Failed    int              `json:"failed"`
+	Skipped   int              `json:"skipped"`
+	TestCases []jsonReportCase `json:"testcases"`
+}
+
+type jsonReportCase struct {
+	Package  string `json:"package"`
+	Name     string `json:"name"`
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+	Status   string `json:"status"`
+	Duration int64  `json:"duration"`
+	Failure  string `json:"failure,omitempty"`
+}
+
+// writeJSONReport writes the tests as a JSON object with a summary of
+// the test run. Durations are reported in nanoseconds.
+func writeJSONReport(w io.Writer, tests []*Test) error {
+	report := jsonReport{
+		Tests:     len(tests),
+		TestCases: make([]jsonReportCase, 0, len(tests)),
+	}
+	for _, test := range tests {
+		file, line := test.Location()
+		tc := jsonReportCase{
+			Package:  test.pkg,
+			Name:     test.name,
+			File:     file,
+			Line:     line,
+			Status:   test.Status(),
+			Duration: test.duration.Nanoseconds(),
+		}
+		switch tc.Status {
+		case statusPass:
+			report.Passed++
+		case statusFail:
+			tc.Failure = test.err.Error()
+			report.Failed++
+		case statusSkip:
+			report.Skipped++
+		}
+		report.TestCases = append(report.TestCases, tc)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(report)
+}
+
+func firstLine(s string) string {
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		return s[:i]
+	}
+	return s
+}
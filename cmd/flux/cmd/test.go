@@ -16,6 +16,7 @@ import (
 	"sort"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/fatih/color"
 	"github.com/influxdata/flux"
@@ -30,6 +31,7 @@ import (
 	"github.com/influxdata/flux/execute/executetest"
 	"github.com/influxdata/flux/fluxinit"
 	"github.com/influxdata/flux/internal/errors"
+	"github.com/influxdata/flux/interpreter"
 	"github.com/influxdata/flux/parser"
 	"github.com/spf13/cobra"
 )
@@ -46,6 +48,11 @@ type TestFlags struct {
 	parallel      bool
 	verbosity     int
 	noinit        bool
+	update        bool
+	coverage      bool
+	coverageOut   string
+	reporter      string
+	output        string
 }
 
 type failedTests struct{}
@@ -73,7 +80,13 @@ error running the tests or at least one test failed.
 			if !flags.noinit {
 				fluxinit.FluxInit()
 			}
-			if passed, err := runFluxTests(cmd.OutOrStdout(), setup, flags); err != nil {
+			out, reportOut := cmd.OutOrStdout(), cmd.OutOrStdout()
+			if flags.reporter != "" && flags.output == "" {
+				// The report is written to stdout so write
+				// the test progress to stderr instead.
+				out = cmd.OutOrStderr()
+			}
+			if passed, err := runFluxTests(out, reportOut, setup, flags); err != nil {
 				return err
 			} else if !passed {
 				// Tests failed return a silent error since
@@ -94,6 +107,11 @@ error running the tests or at least one test failed.
 	testCommand.Flags().BoolVarP(&flags.parallel, "parallel", "", false, "Enables parallel test execution.")
 	testCommand.Flags().CountVarP(&flags.verbosity, "verbose", "v", "verbose (-v, -vv, or -vvv)")
 	testCommand.Flags().BoolVarP(&flags.noinit, "noinit", "", false, "Disables Flux initialization, used for testing this command.")
+	testCommand.Flags().BoolVar(&flags.update, "update", false, "Rewrite the expected output of failing tests with their actual output.")
+	testCommand.Flags().BoolVar(&flags.coverage, "coverage", false, "Report the functions and conditional branches of the tested packages that were evaluated.")
+	testCommand.Flags().StringVar(&flags.coverageOut, "coverage-output", "", "File to write the coverage report to as JSON. Implies --coverage.")
+	testCommand.Flags().StringVar(&flags.reporter, "reporter", "", "Write a test report in the given format, one of: junit,json.")
+	testCommand.Flags().StringVar(&flags.output, "output", "", "File to write the test report to. Defaults to stdout.")
 
 	testCommand.SetOutput(color.Output)
 
@@ -101,12 +119,22 @@ error running the tests or at least one test failed.
 }
 
 // runFluxTests invokes the test runner.
+// The test report, if requested, is written to reportOut when no output file is set.
 // Returns true if no tests failed or an error if one was encountered.
-func runFluxTests(out io.Writer, setup TestSetupFunc, flags TestFlags) (bool, error) {
+func runFluxTests(out, reportOut io.Writer, setup TestSetupFunc, flags TestFlags) (bool, error) {
 	if len(flags.paths) == 0 {
 		flags.paths = []string{"."}
 	}
 
+	var writeReport reportFunc
+	if flags.reporter != "" {
+		fn, ok := reporters[flags.reporter]
+		if !ok {
+			return false, errors.Newf(codes.Invalid, "unknown test reporter %q, must be one of: junit,json", flags.reporter)
+		}
+		writeReport = fn
+	}
+
 	reporter := TestReporter{
 		out:       out,
 		verbosity: flags.verbosity,
@@ -130,6 +158,12 @@ func runFluxTests(out io.Writer, setup TestSetupFunc, flags TestFlags) (bool, er
 		return false, err
 	}
 
+	var cov *interpreter.Coverage
+	if flags.coverage || flags.coverageOut != "" {
+		cov = interpreter.NewCoverage()
+		ctx = interpreter.WithCoverage(ctx, cov)
+	}
+
 	executor, err := setup(ctx)
 	if err != nil {
 		return false, err
@@ -141,7 +175,25 @@ func runFluxTests(out io.Writer, setup TestSetupFunc, flags TestFlags) (bool, er
 	} else {
 		runner.Run(executor, flags.verbosity)
 	}
-	return runner.Finish(), nil
+	if flags.update {
+		if err := runner.Update(executor); err != nil {
+			return false, err
+		}
+	}
+	passed := runner.Finish()
+
+	if cov != nil {
+		if err := writeCoverage(out, cov, flags); err != nil {
+			return false, err
+		}
+	}
+
+	if writeReport != nil {
+		if err := runner.WriteReport(writeReport, reportOut, flags.output); err != nil {
+			return false, err
+		}
+	}
+	return passed, nil
 }
 
 var defaultCmdFeatureFlags = executetest.TestFlagger{
@@ -170,6 +222,13 @@ type Test struct {
 	// indicates if the test should be skipped
 	skip bool
 	err  error
+	// location of the testcase statement
+	loc *ast.SourceLocation
+	// time spent running the test
+	duration time.Duration
+	// parsed source file containing the testcase, only set
+	// when the file can be updated on disk
+	source *ast.File
 }
 
 // NewTest creates a new Test instance from an ast.Package.
@@ -202,8 +261,9 @@ func (t *Test) Error() error {
 
 // Run the test, saving the error to the err property of the struct.
 func (t *Test) Run(executor TestExecutor) {
+	start := time.Now()
 	t.err = executor.Run(t.ast, t.consume)
-
+	t.duration = time.Since(start)
 }
 
 func (t *Test) consume(ctx context.Context, results flux.ResultIterator) error {
@@ -343,6 +403,7 @@ func (t *TestRunner) Gather(roots []string) error {
 			return err
 		}
 		defer func() { _ = fs.Close() }()
+		_, onDisk := fs.(systemfs)
 
 		// Gather valid tags from modules
 		for _, m := range mods {
@@ -387,6 +448,10 @@ func (t *TestRunner) Gather(roots []string) error {
 					return errors.Newf(codes.AlreadyExists, "duplicate testcase name %q, found in package %q, at locations %v and %v", tcidens[i].Name, pkg, seen[pkgTest].loc.String(), tcidens[i].Loc.String())
 				}
 				test := NewTest(tcidens[i].Name, astf, tags, pkg)
+				test.loc = tcidens[i].Loc
+				if onDisk {
+					test.source = baseAST.Files[0]
+				}
 				t.tests = append(t.tests, &test)
 				seen[pkgTest] = testcaseLoc{tcidens[i].Loc}
 			}
@@ -969,6 +1034,47 @@ func (t *TestRunner) Finish() bool {
 	return t.reporter.Summarize(t.tests)
 }
 
+// writeCoverage writes the coverage report for the tested packages as
+// text and as JSON to the coverage output file if one is set.
+func writeCoverage(out io.Writer, cov *interpreter.Coverage, flags TestFlags) error {
+	report, err := newCoverageReport(flags.paths, cov)
+	if err != nil {
+		return err
+	}
+	if err := report.WriteText(out); err != nil {
+		return err
+	}
+	if flags.coverageOut == "" {
+		return nil
+	}
+	f, err := os.Create(flags.coverageOut)
+	if err != nil {
+		return err
+	}
+	if err := report.WriteJSON(f); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// WriteReport writes a report of the test run to the named file
+// or to w if the filename is empty.
+func (t *TestRunner) WriteReport(fn reportFunc, w io.Writer, filename string) error {
+	if filename == "" {
+		return fn(w, t.tests)
+	}
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	if err := fn(f, t.tests); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
 // TestReporter handles reporting of test results.
 type TestReporter struct {
 	out       io.Writer
@@ -1003,6 +1109,15 @@ func (t *TestReporter) ReportTestRun(test *Test) {
 	}
 }
 
+// ReportUpdate reports the result of updating the expected output of a test.
+func (t *TestReporter) ReportUpdate(test *Test, err error) {
+	if err != nil {
+		fmt.Fprintf(t.out, "%s ... %s: %s\n", test.FullName(), color.RedString("cannot update"), err)
+	} else {
+		fmt.Fprintf(t.out, "%s ... %s\n", test.FullName(), color.YellowString("updated"))
+	}
+}
+
 // Summarize summarizes the test run.
 func (t *TestReporter) Summarize(tests []*Test) bool {
 	failures := 0
@@ -0,0 +1,314 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"strings"
+
+	"github.com/influxdata/flux"
+	"github.com/influxdata/flux/ast"
+	"github.com/influxdata/flux/ast/astutil"
+	"github.com/influxdata/flux/ast/edit"
+	"github.com/influxdata/flux/codes"
+	"github.com/influxdata/flux/csv"
+	"github.com/influxdata/flux/internal/errors"
+)
+
+// updateYield is the name of the result that holds the actual
+// output of a test when it is rerun to update its expected output.
+const updateYield = "_update"
+
+// Update reruns the failed tests that compare their output to an expected
+// table with testing.diff or testing.assertEquals. When the expected table is
+// read by csv.from from a string literal, the literal is rewritten with the
+// actual output and the source file is formatted and saved. An expected table
+// that is read by several tests is not updated since the tests may produce
+// different outputs.
+//
+// Tests that are updated are no longer reported as failures.
+func (t *TestRunner) Update(executor TestExecutor) error {
+	readers := make(map[*ast.StringLiteral][]string)
+	for _, test := range t.tests {
+		if out, err := test.expectedOutput(); err == nil {
+			readers[out.data] = append(readers[out.data], test.name)
+		}
+	}
+
+	var files []*ast.File
+	for _, test := range t.tests {
+		if test.skip || test.err == nil {
+			continue
+		}
+		err := test.update(executor, readers)
+		t.reporter.ReportUpdate(test, err)
+		if err != nil {
+			continue
+		}
+		test.err = nil
+		if !containsFile(files, test.source) {
+			files = append(files, test.source)
+		}
+	}
+
+	for _, file := range files {
+		if err := writeFormatted(file); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func containsFile(files []*ast.File, file *ast.File) bool {
+	for _, f := range files {
+		if f == file {
+			return true
+		}
+	}
+	return false
+}
+
+// writeFormatted formats the file and writes it back to its path.
+func writeFormatted(file *ast.File) error {
+	src, err := astutil.Format(file)
+	if err != nil {
+		return errors.Wrapf(err, codes.Inherit, "failed to format %s", file.Name)
+	}
+	info, err := os.Stat(file.Name)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(file.Name, []byte(src), info.Mode())
+}
+
+// update reruns the test yielding the table it compares against the
+// expected table and replaces the expected table literal with it.
+// The readers are the names of the tests that read each expected table.
+func (t *Test) update(executor TestExecutor, readers map[*ast.StringLiteral][]string) error {
+	if t.source == nil {
+		return errors.New(codes.FailedPrecondition, "tests read from an archive cannot be updated")
+	}
+	out, err := t.expectedOutput()
+	if err != nil {
+		return err
+	}
+	if !containsNode(t.source, out.data) {
+		return errors.Newf(codes.FailedPrecondition, "expected output of test is not defined in %s", t.source.Name)
+	}
+	if names := readers[out.data]; len(names) > 1 {
+		return errors.Newf(codes.FailedPrecondition, "expected output of test is shared by the tests %s", strings.Join(names, ", "))
+	}
+
+	// Replace the comparison with a yield of the actual output.
+	// Statements are shared with the source file so the body
+	// is copied instead of modified.
+	file := t.ast.Files[0]
+	updateFile := *file
+	updateFile.Body = make([]ast.Statement, len(file.Body))
+	copy(updateFile.Body, file.Body)
+	updateFile.Body[out.idx] = &ast.ExpressionStatement{
+		Expression: &ast.PipeExpression{
+			Argument: out.got,
+			Call: &ast.CallExpression{
+				Callee: &ast.Identifier{Name: "yield"},
+				Arguments: []ast.Expression{&ast.ObjectExpression{
+					Properties: []*ast.Property{{
+						Key:   &ast.Identifier{Name: "name"},
+						Value: &ast.StringLiteral{Value: updateYield},
+					}},
+				}},
+			},
+		},
+	}
+	pkg := *t.ast
+	pkg.Files = []*ast.File{&updateFile}
+
+	var buf bytes.Buffer
+	found := false
+	err = executor.Run(&pkg, func(ctx context.Context, results flux.ResultIterator) error {
+		for results.More() {
+			result := results.Next()
+			if result.Name() != updateYield {
+				if err := result.Tables().Do(func(flux.Table) error { return nil }); err != nil {
+					return err
+				}
+				continue
+			}
+			found = true
+			enc := csv.NewResultEncoder(csv.DefaultEncoderConfig())
+			if _, err := enc.Encode(&buf, renamedResult{Result: result, name: "_result"}); err != nil {
+				return err
+			}
+		}
+		results.Release()
+		return results.Err()
+	})
+	if err != nil {
+		return err
+	}
+	if !found {
+		return errors.New(codes.Internal, "test did not produce any output")
+	}
+
+	return out.set(&ast.StringLiteral{
+		Value: "\n" + strings.ReplaceAll(buf.String(), "\r\n", "\n"),
+	})
+}
+
+// renamedResult changes the name of a result so the encoded
+// tables match the annotations used in expected output.
+type renamedResult struct {
+	flux.Result
+	name string
+}
+
+func (r renamedResult) Name() string {
+	return r.name
+}
+
+// expectedOutput is the expected table of a test.
+type expectedOutput struct {
+	// idx is the index of the statement that compares
+	// the output of the test to the expected table.
+	idx int
+	// got is the output of the test.
+	got ast.Expression
+	// data is the csv data of the expected table.
+	data *ast.StringLiteral
+	// set replaces the csv data of the expected table.
+	set func(data *ast.StringLiteral) error
+}
+
+// expectedOutput finds the comparison of the test and resolves its
+// expected table to the string literal read by csv.from.
+func (t *Test) expectedOutput() (*expectedOutput, error) {
+	file := t.ast.Files[0]
+	idx, got, want, err := findComparison(file)
+	if err != nil {
+		return nil, err
+	}
+	out := &expectedOutput{idx: idx, got: got}
+	body := file.Body[:idx]
+
+	csvName, _ := edit.ImportName(file, "csv")
+	csvFrom := &ast.CallExpression{
+		Callee: &ast.MemberExpression{
+			Object:   &ast.Identifier{Name: csvName},
+			Property: &ast.Identifier{Name: "from"},
+		},
+	}
+	for {
+		id, ok := want.(*ast.Identifier)
+		if !ok {
+			break
+		}
+		if want, err = edit.GetVariable(body, id.Name); err != nil {
+			return nil, errors.Newf(codes.FailedPrecondition, "could not find the definition of %s", id.Name)
+		}
+	}
+	call, ok := want.(*ast.CallExpression)
+	if !ok || csvName == "" || !matches(call, csvFrom) || len(call.Arguments) != 1 {
+		return nil, errors.New(codes.FailedPrecondition, "expected output must be read with csv.from")
+	}
+	args, ok := call.Arguments[0].(*ast.ObjectExpression)
+	if !ok {
+		return nil, errors.New(codes.FailedPrecondition, "expected output must be read with csv.from")
+	}
+	data := argument(args, "csv")
+	if data == nil {
+		return nil, errors.New(codes.FailedPrecondition, "expected output must be read with csv.from")
+	}
+	out.set = func(lit *ast.StringLiteral) error {
+		edit.SetProperty(args, "csv", lit)
+		return nil
+	}
+	for {
+		id, ok := data.(*ast.Identifier)
+		if !ok {
+			break
+		}
+		if data, err = edit.GetVariable(body, id.Name); err != nil {
+			return nil, errors.Newf(codes.FailedPrecondition, "could not find the definition of %s", id.Name)
+		}
+		out.set = func(lit *ast.StringLiteral) error {
+			return edit.SetVariable(body, id.Name, lit)
+		}
+	}
+	if out.data, ok = data.(*ast.StringLiteral); !ok {
+		return nil, errors.New(codes.FailedPrecondition, "expected output must be a string literal")
+	}
+	return out, nil
+}
+
+// findComparison finds the last top-level statement that calls testing.diff
+// or testing.assertEquals and returns its index with the got and want arguments.
+func findComparison(file *ast.File) (int, ast.Expression, ast.Expression, error) {
+	testingName, ok := edit.ImportName(file, "testing")
+	if !ok {
+		return 0, nil, nil, errors.New(codes.FailedPrecondition, "test does not import the testing package")
+	}
+	for i := len(file.Body) - 1; i >= 0; i-- {
+		for _, fn := range []string{"diff", "assertEquals"} {
+			comparison := &ast.CallExpression{
+				Callee: &ast.MemberExpression{
+					Object:   &ast.Identifier{Name: testingName},
+					Property: &ast.Identifier{Name: fn},
+				},
+			}
+			calls := edit.Match(file.Body[i], comparison, true)
+			if len(calls) == 0 {
+				continue
+			}
+			call := calls[0].(*ast.CallExpression)
+
+			var got, want ast.Expression
+			for _, node := range edit.Match(file.Body[i], &ast.PipeExpression{Call: call}, true) {
+				if pipe := node.(*ast.PipeExpression); pipe.Call == call {
+					got = pipe.Argument
+				}
+			}
+			if len(call.Arguments) == 1 {
+				if args, ok := call.Arguments[0].(*ast.ObjectExpression); ok {
+					if v := argument(args, "got"); v != nil {
+						got = v
+					}
+					want = argument(args, "want")
+				}
+			}
+			if got == nil || want == nil {
+				return 0, nil, nil, errors.New(codes.FailedPrecondition, "could not find the got and want arguments of the comparison")
+			}
+			return i, got, want, nil
+		}
+	}
+	return 0, nil, nil, errors.New(codes.FailedPrecondition, "test does not call testing.diff or testing.assertEquals")
+}
+
+// argument returns the value of the named argument or nil if it is not set.
+func argument(args *ast.ObjectExpression, name string) ast.Expression {
+	v, err := edit.GetProperty(args, name)
+	if err != nil {
+		return nil
+	}
+	if v == nil {
+		// Shorthand properties reference a variable with the same name.
+		return &ast.Identifier{Name: name}
+	}
+	return v
+}
+
+// matches reports whether the node itself matches the pattern.
+func matches(node, pattern ast.Node) bool {
+	m := edit.Match(node, pattern, true)
+	return len(m) > 0 && m[0] == node
+}
+
+func containsNode(root, node ast.Node) bool {
+	found := false
+	ast.Walk(ast.CreateVisitor(func(n ast.Node) {
+		if n == node {
+			found = true
+		}
+	}), root)
+	return found
+}
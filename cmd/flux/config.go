@@ -0,0 +1,287 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/influxdata/flux"
+	"github.com/influxdata/flux/codes"
+	"github.com/influxdata/flux/dependencies"
+	"github.com/influxdata/flux/dependencies/filesystem"
+	"github.com/influxdata/flux/dependencies/http"
+	"github.com/influxdata/flux/dependencies/influxdb"
+	"github.com/influxdata/flux/dependencies/secret"
+	"github.com/influxdata/flux/dependencies/url"
+	"github.com/influxdata/flux/internal/errors"
+	"gopkg.in/yaml.v2"
+)
+
+var configFlags struct {
+	Path    string
+	Profile string
+}
+
+// config is the profile selected from the configuration file
+// with the environment overrides applied.
+var config configProfile
+
+const defaultConfigProfile = "default"
+
+// configFile is the configuration file of the flux command.
+// It may be written as TOML or YAML and contains named profiles.
+//
+//	[profiles.default.influxdb]
+//	host = "http://localhost:8086"
+//	org = "my-org"
+//	token = "my-token"
+//
+//	[profiles.default.filesystem]
+//	root = "/var/lib/flux"
+type configFile struct {
+	Profiles map[string]configProfile `toml:"profiles" yaml:"profiles"`
+}
+
+type configProfile struct {
+	InfluxDB   influxDBConfig         `toml:"influxdb" yaml:"influxdb"`
+	Secrets    secretsConfig          `toml:"secrets" yaml:"secrets"`
+	Filesystem filesystemConfig       `toml:"filesystem" yaml:"filesystem"`
+	URL        urlConfig              `toml:"url" yaml:"url"`
+	HTTP       httpConfig             `toml:"http" yaml:"http"`
+	Features   map[string]interface{} `toml:"features" yaml:"features"`
+}
+
+type influxDBConfig struct {
+	Host  string `toml:"host" yaml:"host"`
+	Org   string `toml:"org" yaml:"org"`
+	OrgID string `toml:"org_id" yaml:"org_id"`
+	Token string `toml:"token" yaml:"token"`
+}
+
+// secretsConfig selects the secret service. The backend is one of none,
+// env to read secrets from environment variables, or static to read
+// secrets from the values in the configuration file.
+type secretsConfig struct {
+	Backend string            `toml:"backend" yaml:"backend"`
+	Values  map[string]string `toml:"values" yaml:"values"`
+}
+
+// filesystemConfig restricts access to the filesystem. When root is set
+// only files within the root directory can be read.
+type filesystemConfig struct {
+	Root     string `toml:"root" yaml:"root"`
+	Disabled bool   `toml:"disabled" yaml:"disabled"`
+}
+
+// urlConfig selects the URL validation policy, one of pass to allow
+// any URL or private to deny URLs that resolve to private IP addresses.
+type urlConfig struct {
+	Policy string `toml:"policy" yaml:"policy"`
+}
+
+type httpConfig struct {
+	Timeout string `toml:"timeout" yaml:"timeout"`
+}
+
+// configEnv maps the environment variables that override the
+// configuration file to the value they replace.
+func (p *configProfile) configEnv() map[string]*string {
+	return map[string]*string{
+		"FLUX_INFLUXDB_HOST":   &p.InfluxDB.Host,
+		"FLUX_INFLUXDB_ORG":    &p.InfluxDB.Org,
+		"FLUX_INFLUXDB_ORG_ID": &p.InfluxDB.OrgID,
+		"FLUX_INFLUXDB_TOKEN":  &p.InfluxDB.Token,
+		"FLUX_SECRETS_BACKEND": &p.Secrets.Backend,
+		"FLUX_FILESYSTEM_ROOT": &p.Filesystem.Root,
+		"FLUX_URL_POLICY":      &p.URL.Policy,
+		"FLUX_HTTP_TIMEOUT":    &p.HTTP.Timeout,
+	}
+}
+
+// loadConfig reads the named profile from the configuration file and applies
+// the environment overrides. When path is empty the FLUX_CONFIG environment
+// variable is used or else the first of flux.toml, flux.yaml and flux.yml in
+// the current directory and the user configuration directory. It is not an
+// error for there to be no configuration file unless a profile was requested.
+func loadConfig(path, profile string) (configProfile, error) {
+	if path == "" {
+		path = os.Getenv("FLUX_CONFIG")
+	}
+	if path == "" {
+		path = findConfigFile()
+	}
+	if profile == "" {
+		profile = os.Getenv("FLUX_CONFIG_PROFILE")
+	}
+
+	var cfg configFile
+	if path != "" {
+		if err := readConfigFile(path, &cfg); err != nil {
+			return configProfile{}, err
+		}
+	}
+
+	var p configProfile
+	if profile == "" {
+		p = cfg.Profiles[defaultConfigProfile]
+	} else if named, ok := cfg.Profiles[profile]; ok {
+		p = named
+	} else {
+		return configProfile{}, errors.Newf(codes.NotFound, "config profile %q not found, available profiles are %v", profile, profileNames(cfg))
+	}
+
+	for name, v := range p.configEnv() {
+		if value, ok := os.LookupEnv(name); ok {
+			*v = value
+		}
+	}
+	return p, p.validate()
+}
+
+func findConfigFile() string {
+	dirs := []string{"."}
+	if dir, err := os.UserConfigDir(); err == nil {
+		dirs = append(dirs, filepath.Join(dir, "flux"))
+	}
+	for _, dir := range dirs {
+		for _, name := range []string{"flux.toml", "flux.yaml", "flux.yml"} {
+			path := filepath.Join(dir, name)
+			if _, err := os.Stat(path); err == nil {
+				return path
+			}
+		}
+	}
+	return ""
+}
+
+func readConfigFile(path string, cfg *configFile) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	switch ext := filepath.Ext(path); ext {
+	case ".toml":
+		md, err := toml.Decode(string(data), cfg)
+		if err != nil {
+			return errors.Wrapf(err, codes.Invalid, "invalid config file %s", path)
+		}
+		if undecoded := md.Undecoded(); len(undecoded) > 0 {
+			return errors.Newf(codes.Invalid, "invalid config file %s: unknown key %q", path, undecoded[0].String())
+		}
+	case ".yaml", ".yml":
+		if err := yaml.UnmarshalStrict(data, cfg); err != nil {
+			return errors.Wrapf(err, codes.Invalid, "invalid config file %s", path)
+		}
+	default:
+		return errors.Newf(codes.Invalid, "unknown config file format %q, must be one of: .toml,.yaml,.yml", ext)
+	}
+	return nil
+}
+
+func profileNames(cfg configFile) []string {
+	names := make([]string, 0, len(cfg.Profiles))
+	for name := range cfg.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (p configProfile) validate() error {
+	switch p.Secrets.Backend {
+	case "", "none", "env", "static":
+	default:
+		return errors.Newf(codes.Invalid, "unknown secrets backend %q, must be one of: none,env,static", p.Secrets.Backend)
+	}
+	switch p.URL.Policy {
+	case "", "pass", "private":
+	default:
+		return errors.Newf(codes.Invalid, "unknown url policy %q, must be one of: pass,private", p.URL.Policy)
+	}
+	if p.HTTP.Timeout != "" {
+		if _, err := time.ParseDuration(p.HTTP.Timeout); err != nil {
+			return errors.Wrapf(err, codes.Invalid, "invalid http timeout %q", p.HTTP.Timeout)
+		}
+	}
+	if p.InfluxDB.Org != "" && p.InfluxDB.OrgID != "" {
+		return errors.New(codes.Invalid, "only one of the influxdb org and org_id may be set")
+	}
+	return nil
+}
+
+// dependencies creates the dependencies described by the profile.
+// Settings that are not configured keep the defaults of the flux command.
+// The profile must have been validated.
+func (p configProfile) dependencies() dependencies.Dependencies {
+	var validator url.Validator = url.PassValidator{}
+	if p.URL.Policy == "private" {
+		validator = url.PrivateIPValidator{}
+	}
+
+	client := http.NewLimitedDefaultClient(validator)
+	if p.HTTP.Timeout != "" {
+		client.Timeout, _ = time.ParseDuration(p.HTTP.Timeout)
+	}
+
+	var fs filesystem.Service = filesystem.SystemFS
+	if p.Filesystem.Disabled {
+		fs = nil
+	} else if p.Filesystem.Root != "" {
+		fs = filesystem.NewRootFS(p.Filesystem.Root)
+	}
+
+	var secrets secret.Service = secret.EmptySecretService{}
+	switch p.Secrets.Backend {
+	case "env":
+		secrets = secret.EnvironmentSecretService{}
+	case "static":
+		secrets = secret.MapSecretService(p.Secrets.Values)
+	}
+
+	host := p.InfluxDB.Host
+	if host == "" {
+		host = DefaultInfluxDBHost
+	}
+	return dependencies.NewDependencies(flux.Deps{
+		Deps: flux.WrappedDeps{
+			HTTPClient:        client,
+			FilesystemService: fs,
+			SecretService:     secrets,
+			URLValidator:      validator,
+		},
+	}, influxdb.Config{
+		Host:  host,
+		Org:   influxdb.NameOrID{Name: p.InfluxDB.Org, ID: p.InfluxDB.OrgID},
+		Token: p.InfluxDB.Token,
+	})
+}
+
+// featureFlags returns the feature flags of the profile overridden by
+// the JSON object of feature flags given on the command line.
+func (p configProfile) featureFlags(features string) (string, error) {
+	if len(p.Features) == 0 {
+		return features, nil
+	}
+	merged := make(map[string]interface{}, len(p.Features))
+	for k, v := range p.Features {
+		merged[k] = v
+	}
+	if strings.TrimSpace(features) != "" {
+		var overrides map[string]interface{}
+		if err := json.Unmarshal([]byte(features), &overrides); err != nil {
+			return "", errors.Newf(codes.Invalid, "Unable to unmarshal features as json: %s", err)
+		}
+		for k, v := range overrides {
+			merged[k] = v
+		}
+	}
+	bs, err := json.Marshal(merged)
+	if err != nil {
+		return "", err
+	}
+	return string(bs), nil
+}
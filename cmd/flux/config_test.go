@@ -0,0 +1,180 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/influxdata/flux/codes"
+	"github.com/influxdata/flux/internal/errors"
+)
+
+func writeConfig(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadConfig(t *testing.T) {
+	const tomlConfig = `
+[profiles.default.influxdb]
+host = "http://localhost:8086"
+org = "my-org"
+token = "my-token"
+
+[profiles.default.features]
+aggregateTransformationTransport = true
+
+[profiles.staging.influxdb]
+host = "http://staging:8086"
+org_id = "0000000000000001"
+
+[profiles.staging.secrets]
+backend = "static"
+values = { password = "secret" }
+
+[profiles.staging.filesystem]
+root = "/var/lib/flux"
+
+[profiles.staging.url]
+policy = "private"
+
+[profiles.staging.http]
+timeout = "30s"
+`
+	const yamlConfig = `
+profiles:
+  default:
+    influxdb:
+      host: http://localhost:8086
+      org: my-org
+      token: my-token
+    features:
+      aggregateTransformationTransport: true
+`
+	defaultProfile := configProfile{
+		InfluxDB: influxDBConfig{Host: "http://localhost:8086", Org: "my-org", Token: "my-token"},
+		Features: map[string]interface{}{"aggregateTransformationTransport": true},
+	}
+
+	for _, tc := range []struct {
+		name    string
+		file    string
+		content string
+		profile string
+		env     map[string]string
+		want    configProfile
+		code    codes.Code
+	}{
+		{
+			name:    "toml default",
+			file:    "flux.toml",
+			content: tomlConfig,
+			want:    defaultProfile,
+		},
+		{
+			name:    "yaml default",
+			file:    "flux.yaml",
+			content: yamlConfig,
+			want:    defaultProfile,
+		},
+		{
+			name:    "named profile",
+			file:    "flux.toml",
+			content: tomlConfig,
+			profile: "staging",
+			want: configProfile{
+				InfluxDB:   influxDBConfig{Host: "http://staging:8086", OrgID: "0000000000000001"},
+				Secrets:    secretsConfig{Backend: "static", Values: map[string]string{"password": "secret"}},
+				Filesystem: filesystemConfig{Root: "/var/lib/flux"},
+				URL:        urlConfig{Policy: "private"},
+				HTTP:       httpConfig{Timeout: "30s"},
+			},
+		},
+		{
+			name:    "env overrides",
+			file:    "flux.toml",
+			content: tomlConfig,
+			env: map[string]string{
+				"FLUX_INFLUXDB_HOST":  "http://other:8086",
+				"FLUX_INFLUXDB_TOKEN": "other-token",
+			},
+			want: configProfile{
+				InfluxDB: influxDBConfig{Host: "http://other:8086", Org: "my-org", Token: "other-token"},
+				Features: map[string]interface{}{"aggregateTransformationTransport": true},
+			},
+		},
+		{
+			name:    "missing profile",
+			file:    "flux.toml",
+			content: tomlConfig,
+			profile: "production",
+			code:    codes.NotFound,
+		},
+		{
+			name:    "unknown key",
+			file:    "flux.toml",
+			content: "[profiles.default.influxdb]\nhots = \"http://localhost:8086\"\n",
+			code:    codes.Invalid,
+		},
+		{
+			name:    "invalid url policy",
+			file:    "flux.toml",
+			content: "[profiles.default.url]\npolicy = \"deny\"\n",
+			code:    codes.Invalid,
+		},
+		{
+			name:    "invalid timeout",
+			file:    "flux.yml",
+			content: "profiles:\n  default:\n    http:\n      timeout: soon\n",
+			code:    codes.Invalid,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			for k, v := range tc.env {
+				t.Setenv(k, v)
+			}
+			path := writeConfig(t, tc.file, tc.content)
+
+			got, err := loadConfig(path, tc.profile)
+			if tc.code != codes.Inherit {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				if want, got := tc.code, errors.Code(err); want != got {
+					t.Fatalf("unexpected error code -want/+got:\n\t- %v\n\t+ %v", want, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !cmp.Equal(tc.want, got) {
+				t.Errorf("unexpected config -want/+got:\n%s", cmp.Diff(tc.want, got))
+			}
+		})
+	}
+}
+
+func TestConfigProfile_FeatureFlags(t *testing.T) {
+	p := configProfile{
+		Features: map[string]interface{}{"a": true, "b": "x"},
+	}
+	got, err := p.featureFlags(`{"b": "y", "c": 1}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var flags map[string]interface{}
+	if err := json.Unmarshal([]byte(got), &flags); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{"a": true, "b": "y", "c": float64(1)}
+	if !cmp.Equal(want, flags) {
+		t.Errorf("unexpected feature flags -want/+got:\n%s", cmp.Diff(want, flags))
+	}
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/influxdata/flux/codes"
+	"github.com/influxdata/flux/internal/errors"
+)
+
+// cronSchedule is a parsed cron expression. The expression has five fields,
+// minute hour day-of-month month day-of-week, or six fields when the first
+// field is the second. Each field is *, a value, a range a-b or a list of
+// those separated by commas, optionally followed by a step /n.
+// Schedules are evaluated in UTC like the InfluxDB task scheduler.
+type cronSchedule struct {
+	second, minute, hour, dom, month, dow uint64
+
+	// domStar and dowStar record whether the day fields were *.
+	// When both day fields are restricted a day matches either.
+	domStar, dowStar bool
+}
+
+type cronField struct {
+	name     string
+	min, max int
+}
+
+var (
+	cronSecond = cronField{"second", 0, 59}
+	cronMinute = cronField{"minute", 0, 59}
+	cronHour   = cronField{"hour", 0, 23}
+	cronDom    = cronField{"day of month", 1, 31}
+	cronMonth  = cronField{"month", 1, 12}
+	cronDow    = cronField{"day of week", 0, 7}
+)
+
+func parseCron(expr string) (*cronSchedule, error) {
+	fields := strings.Fields(expr)
+	switch len(fields) {
+	case 5:
+		fields = append([]string{"0"}, fields...)
+	case 6:
+	default:
+		return nil, errors.Newf(codes.Invalid, "invalid cron expression %q, must have 5 or 6 fields", expr)
+	}
+
+	s := &cronSchedule{
+		domStar: fields[3] == "*" || fields[3] == "?",
+		dowStar: fields[5] == "*" || fields[5] == "?",
+	}
+	for i, dst := range []struct {
+		bits  *uint64
+		field cronField
+	}{
+		{&s.second, cronSecond},
+		{&s.minute, cronMinute},
+		{&s.hour, cronHour},
+		{&s.dom, cronDom},
+		{&s.month, cronMonth},
+		{&s.dow, cronDow},
+	} {
+		bits, err := dst.field.parse(fields[i])
+		if err != nil {
+			return nil, errors.Wrapf(err, codes.Inherit, "invalid cron expression %q", expr)
+		}
+		*dst.bits = bits
+	}
+	// Sunday may be written as 0 or 7.
+	if s.dow&(1<<7) != 0 {
+		s.dow |= 1
+	}
+	return s, nil
+}
+
+func (f cronField) parse(s string) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(s, ",") {
+		rng, stepStr, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepStr)
+			if err != nil || n < 1 {
+				return 0, errors.Newf(codes.Invalid, "invalid step %q in %s field", stepStr, f.name)
+			}
+			step = n
+		}
+
+		lo, hi := f.min, f.max
+		if rng != "*" && rng != "?" {
+			loStr, hiStr, isRange := strings.Cut(rng, "-")
+			var err error
+			if lo, err = f.value(loStr); err != nil {
+				return 0, err
+			}
+			hi = lo
+			if isRange {
+				if hi, err = f.value(hiStr); err != nil {
+					return 0, err
+				}
+			} else if hasStep {
+				hi = f.max
+			}
+			if lo > hi {
+				return 0, errors.Newf(codes.Invalid, "invalid range %q in %s field", rng, f.name)
+			}
+		}
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+func (f cronField) value(s string) (int, error) {
+	v, err := strconv.Atoi(s)
+	if err != nil || v < f.min || v > f.max {
+		return 0, errors.Newf(codes.Invalid, "invalid value %q in %s field, must be between %d and %d", s, f.name, f.min, f.max)
+	}
+	return v, nil
+}
+
+// Next returns the first time after t that matches the schedule.
+// The zero time is returned when there is no such time within five years.
+func (s *cronSchedule) Next(t time.Time) time.Time {
+	t = t.UTC().Truncate(time.Second).Add(time.Second)
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		if s.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+			continue
+		}
+		if !s.matchDay(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, time.UTC)
+			continue
+		}
+		if s.hour&(1<<uint(t.Hour())) == 0 {
+			t = t.Truncate(time.Hour).Add(time.Hour)
+			continue
+		}
+		if s.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Truncate(time.Minute).Add(time.Minute)
+			continue
+		}
+		if s.second&(1<<uint(t.Second())) == 0 {
+			t = t.Add(time.Second)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+func (s *cronSchedule) matchDay(t time.Time) bool {
+	dom := s.dom&(1<<uint(t.Day())) != 0
+	dow := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.domStar || s.dowStar {
+		return dom && dow
+	}
+	return dom || dow
+}
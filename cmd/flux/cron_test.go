@@ -0,0 +1,62 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCronSchedule_Next(t *testing.T) {
+	mustParse := func(s string) time.Time {
+		t, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			panic(err)
+		}
+		return t
+	}
+	for _, tc := range []struct {
+		expr  string
+		after string
+		want  string
+	}{
+		{expr: "* * * * *", after: "2021-03-04T10:15:30Z", want: "2021-03-04T10:16:00Z"},
+		{expr: "*/15 * * * *", after: "2021-03-04T10:15:00Z", want: "2021-03-04T10:30:00Z"},
+		{expr: "0 * * * *", after: "2021-03-04T23:59:59Z", want: "2021-03-05T00:00:00Z"},
+		{expr: "30 2 * * *", after: "2021-03-04T10:00:00Z", want: "2021-03-05T02:30:00Z"},
+		{expr: "0 9 * * 1-5", after: "2021-03-05T09:00:00Z", want: "2021-03-08T09:00:00Z"},
+		{expr: "0 0 1 */3 *", after: "2021-03-04T00:00:00Z", want: "2021-04-01T00:00:00Z"},
+		{expr: "0 0 29 2 *", after: "2021-03-01T00:00:00Z", want: "2024-02-29T00:00:00Z"},
+		{expr: "0 0 13 * 5", after: "2021-03-01T00:00:00Z", want: "2021-03-05T00:00:00Z"},
+		{expr: "0 0 * * 7", after: "2021-03-01T00:00:00Z", want: "2021-03-07T00:00:00Z"},
+		{expr: "*/10 * * * * *", after: "2021-03-04T10:15:31Z", want: "2021-03-04T10:15:40Z"},
+		{expr: "0 0,12 * * *", after: "2021-03-04T10:00:00Z", want: "2021-03-04T12:00:00Z"},
+	} {
+		t.Run(tc.expr, func(t *testing.T) {
+			s, err := parseCron(tc.expr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, want := s.Next(mustParse(tc.after)), mustParse(tc.want); !got.Equal(want) {
+				t.Errorf("unexpected next time -want/+got:\n\t- %s\n\t+ %s", want, got)
+			}
+		})
+	}
+}
+
+func TestParseCron_Invalid(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"a * * * *",
+	} {
+		if _, err := parseCron(expr); err == nil {
+			t.Errorf("expected error for %q", expr)
+		}
+	}
+}
@@ -0,0 +1,522 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/influxdata/flux/ast"
+	"github.com/influxdata/flux/codes"
+	"github.com/influxdata/flux/internal/errors"
+	"github.com/influxdata/flux/interpreter"
+	"github.com/influxdata/flux/semantic"
+	"github.com/influxdata/flux/values"
+)
+
+// dapThreadID is the only thread of a debugged script.
+const dapThreadID = 1
+
+// dapLocalsReference is the variables reference of the local
+// variables. References of records and arrays follow it.
+const dapLocalsReference = 1
+
+// maxDAPValueLen is the length a value is truncated to
+// in the variables view.
+const maxDAPValueLen = 256
+
+// dapRequest is a request of the Debug Adapter Protocol.
+type dapRequest struct {
+	Seq       int             `json:"seq"`
+	Type      string          `json:"type"`
+	Command   string          `json:"command"`
+	Arguments json.RawMessage `json:"arguments,omitempty"`
+}
+
+type dapResponse struct {
+	Seq        int         `json:"seq"`
+	Type       string      `json:"type"`
+	RequestSeq int         `json:"request_seq"`
+	Success    bool        `json:"success"`
+	Command    string      `json:"command"`
+	Message    string      `json:"message,omitempty"`
+	Body       interface{} `json:"body,omitempty"`
+}
+
+type dapEvent struct {
+	Seq   int         `json:"seq"`
+	Type  string      `json:"type"`
+	Event string      `json:"event"`
+	Body  interface{} `json:"body,omitempty"`
+}
+
+type dapSource struct {
+	Name string `json:"name"`
+	Path string `json:"path,omitempty"`
+}
+
+type dapStackFrame struct {
+	ID     int       `json:"id"`
+	Name   string    `json:"name"`
+	Source dapSource `json:"source"`
+	Line   int       `json:"line"`
+	Column int       `json:"column"`
+}
+
+type dapVariable struct {
+	Name               string `json:"name"`
+	Value              string `json:"value"`
+	Type               string `json:"type,omitempty"`
+	VariablesReference int    `json:"variablesReference"`
+}
+
+// dapServer is a debug adapter that lets editors debug a Flux
+// script with the Debug Adapter Protocol over a pair of streams.
+// Requests are handled in order while the script is evaluated
+// in its own goroutine.
+type dapServer struct {
+	in  *bufio.Reader
+	out io.Writer
+	now time.Time
+	d   *interpreter.Debugger
+
+	program     string
+	script      string
+	stopOnEntry bool
+
+	writeMu sync.Mutex
+	seq     int
+
+	// mu guards the state of the paused script.
+	mu     sync.Mutex
+	ctx    context.Context
+	frame  *interpreter.DebugFrame
+	refs   []values.Value
+	resume chan interpreter.DebugAction
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func newDAPServer(in io.Reader, out io.Writer, now time.Time) *dapServer {
+	s := &dapServer{
+		in:     bufio.NewReader(in),
+		out:    out,
+		now:    now,
+		resume: make(chan interpreter.DebugAction),
+	}
+	s.d = interpreter.NewDebugger(s)
+	return s
+}
+
+// serve handles requests until the client disconnects.
+func (s *dapServer) serve(ctx context.Context) error {
+	defer s.stop()
+	for {
+		req, err := s.read()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		body, err := s.handle(ctx, req)
+		if err != nil {
+			s.send(&dapResponse{
+				Type:       "response",
+				RequestSeq: req.Seq,
+				Command:    req.Command,
+				Message:    err.Error(),
+			})
+			continue
+		}
+		s.send(&dapResponse{
+			Type:       "response",
+			RequestSeq: req.Seq,
+			Success:    true,
+			Command:    req.Command,
+			Body:       body,
+		})
+
+		switch req.Command {
+		case "initialize":
+			s.event("initialized", nil)
+		case "configurationDone":
+			s.start(ctx)
+		case "continue", "next", "stepIn", "stepOut":
+			s.resume <- dapActions[req.Command]
+		case "disconnect", "terminate":
+			return nil
+		}
+	}
+}
+
+var dapActions = map[string]interpreter.DebugAction{
+	"continue": interpreter.Continue,
+	"next":     interpreter.StepOver,
+	"stepIn":   interpreter.StepInto,
+	"stepOut":  interpreter.StepOut,
+}
+
+// handle returns the body of the response to the request.
+func (s *dapServer) handle(ctx context.Context, req *dapRequest) (interface{}, error) {
+	switch req.Command {
+	case "initialize":
+		return map[string]bool{
+			"supportsConfigurationDoneRequest": true,
+			"supportsEvaluateForHovers":        true,
+			"supportsTerminateRequest":         true,
+		}, nil
+	case "launch":
+		var args struct {
+			Program     string `json:"program"`
+			StopOnEntry bool   `json:"stopOnEntry"`
+		}
+		if err := s.arguments(req, &args); err != nil {
+			return nil, err
+		}
+		content, err := os.ReadFile(args.Program)
+		if err != nil {
+			return nil, err
+		}
+		s.program, s.script, s.stopOnEntry = args.Program, string(content), args.StopOnEntry
+		return nil, nil
+	case "setBreakpoints":
+		var args struct {
+			Breakpoints []struct {
+				Line int `json:"line"`
+			} `json:"breakpoints"`
+		}
+		if err := s.arguments(req, &args); err != nil {
+			return nil, err
+		}
+		lines := make([]int, len(args.Breakpoints))
+		verified := make([]map[string]interface{}, len(args.Breakpoints))
+		for i, bp := range args.Breakpoints {
+			lines[i] = bp.Line
+			verified[i] = map[string]interface{}{"verified": true, "line": bp.Line}
+		}
+		// The script is the only source with breakpoints and
+		// its statements have no file name.
+		s.d.SetBreakpoints("", lines)
+		return map[string]interface{}{"breakpoints": verified}, nil
+	case "setExceptionBreakpoints", "configurationDone", "disconnect", "terminate":
+		return nil, nil
+	case "threads":
+		return map[string]interface{}{
+			"threads": []map[string]interface{}{{"id": dapThreadID, "name": "main"}},
+		}, nil
+	case "continue":
+		if err := s.paused(); err != nil {
+			return nil, err
+		}
+		return map[string]bool{"allThreadsContinued": true}, nil
+	case "next", "stepIn", "stepOut":
+		return nil, s.paused()
+	case "stackTrace":
+		return s.stackTrace()
+	case "scopes":
+		if err := s.paused(); err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{
+			"scopes": []map[string]interface{}{{
+				"name":               "Locals",
+				"variablesReference": dapLocalsReference,
+				"expensive":          false,
+			}},
+		}, nil
+	case "variables":
+		var args struct {
+			VariablesReference int `json:"variablesReference"`
+		}
+		if err := s.arguments(req, &args); err != nil {
+			return nil, err
+		}
+		return s.variables(args.VariablesReference)
+	case "evaluate":
+		var args struct {
+			Expression string `json:"expression"`
+		}
+		if err := s.arguments(req, &args); err != nil {
+			return nil, err
+		}
+		return s.evaluate(args.Expression)
+	default:
+		return nil, errors.Newf(codes.Unimplemented, "unsupported request %q", req.Command)
+	}
+}
+
+// start evaluates the script in its own goroutine and sends
+// its results as output events.
+func (s *dapServer) start(ctx context.Context) {
+	if s.script == "" {
+		s.output("stderr", "no script was launched\n")
+		s.event("terminated", nil)
+		return
+	}
+	if s.stopOnEntry {
+		s.d.StopOnEntry()
+	}
+	ctx, s.cancel = context.WithCancel(interpreter.WithDebugger(ctx, s.d))
+	s.done = make(chan struct{})
+	go func() {
+		defer close(s.done)
+		exitCode := 0
+		rt, err := scriptRuntime(filepath.Dir(s.program))
+		if err == nil {
+			err = executeTo(ctx, dapOutput{s: s, category: "stdout"}, rt, s.script, "cli", nil, s.now, nil)
+		}
+		if err != nil {
+			exitCode = 1
+			if ctx.Err() == nil {
+				s.output("stderr", err.Error()+"\n")
+			}
+		}
+		s.event("exited", map[string]int{"exitCode": exitCode})
+		s.event("terminated", nil)
+	}()
+}
+
+// stop cancels the evaluation of the script and waits for it to return.
+func (s *dapServer) stop() {
+	if s.cancel == nil {
+		return
+	}
+	s.cancel()
+	<-s.done
+}
+
+// Paused reports the pause to the client and waits for
+// a request that continues the evaluation.
+func (s *dapServer) Paused(ctx context.Context, frame *interpreter.DebugFrame) (interpreter.DebugAction, error) {
+	s.mu.Lock()
+	s.ctx, s.frame, s.refs = ctx, frame, nil
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		s.ctx, s.frame, s.refs = nil, nil, nil
+		s.mu.Unlock()
+	}()
+
+	s.event("stopped", map[string]interface{}{
+		"reason":            frame.Reason.String(),
+		"threadId":          dapThreadID,
+		"allThreadsStopped": true,
+	})
+	select {
+	case action := <-s.resume:
+		return action, nil
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	}
+}
+
+func (s *dapServer) paused() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.frame == nil {
+		return errors.New(codes.FailedPrecondition, "the script is not paused")
+	}
+	return nil
+}
+
+// stackTrace returns the statement the script is paused at followed
+// by the calls of the functions it is in.
+func (s *dapServer) stackTrace() (interface{}, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.frame == nil {
+		return nil, errors.New(codes.FailedPrecondition, "the script is not paused")
+	}
+
+	name := func(i int) string {
+		if i < len(s.frame.Stack) {
+			return s.frame.Stack[i].FunctionName
+		}
+		return "main"
+	}
+	frames := []dapStackFrame{s.stackFrame(0, name(0), s.frame.Location())}
+	for i, e := range s.frame.Stack {
+		frames = append(frames, s.stackFrame(i+1, name(i+1), e.Location))
+	}
+	return map[string]interface{}{
+		"stackFrames": frames,
+		"totalFrames": len(frames),
+	}, nil
+}
+
+func (s *dapServer) stackFrame(id int, name string, loc ast.SourceLocation) dapStackFrame {
+	source := dapSource{Name: loc.File}
+	if loc.File == "" {
+		source = dapSource{Name: filepath.Base(s.program), Path: s.program}
+	}
+	return dapStackFrame{
+		ID:     id,
+		Name:   name,
+		Source: source,
+		Line:   loc.Start.Line,
+		Column: loc.Start.Column,
+	}
+}
+
+// variables returns the local variables or the
+// properties or elements of a record or array.
+func (s *dapServer) variables(ref int) (interface{}, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.frame == nil {
+		return nil, errors.New(codes.FailedPrecondition, "the script is not paused")
+	}
+
+	vars := []dapVariable{}
+	if ref == dapLocalsReference {
+		for _, v := range s.frame.Variables() {
+			vars = append(vars, s.variable(v.Name, v.Value))
+		}
+	} else if i := ref - dapLocalsReference - 1; i >= 0 && i < len(s.refs) {
+		switch v := s.refs[i]; v.Type().Nature() {
+		case semantic.Object:
+			v.Object().Range(func(name string, v values.Value) {
+				vars = append(vars, s.variable(name, v))
+			})
+		case semantic.Array:
+			v.Array().Range(func(i int, v values.Value) {
+				vars = append(vars, s.variable(strconv.Itoa(i), v))
+			})
+		}
+	} else {
+		return nil, errors.Newf(codes.NotFound, "unknown variables reference %d", ref)
+	}
+	return map[string]interface{}{"variables": vars}, nil
+}
+
+// variable describes the value and adds a reference to
+// it when it is a record or array with members.
+func (s *dapServer) variable(name string, v values.Value) dapVariable {
+	dv := dapVariable{
+		Name:  name,
+		Value: values.DisplayString(v),
+		Type:  v.Type().CanonicalString(),
+	}
+	if len(dv.Value) > maxDAPValueLen {
+		dv.Value = dv.Value[:maxDAPValueLen] + "..."
+	}
+	if v.IsNull() {
+		return dv
+	}
+	switch n := v.Type().Nature(); {
+	case n == semantic.Object && v.Object().Len() > 0,
+		n == semantic.Array && v.Array().Len() > 0:
+		s.refs = append(s.refs, v)
+		dv.VariablesReference = dapLocalsReference + len(s.refs)
+	}
+	return dv
+}
+
+// evaluate prints a variable or previews its table stream
+// when the expression is preview <name> [rows].
+func (s *dapServer) evaluate(expr string) (interface{}, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.frame == nil {
+		return nil, errors.New(codes.FailedPrecondition, "the script is not paused")
+	}
+
+	expr = strings.TrimSpace(expr)
+	if arg, ok := strings.CutPrefix(expr, "preview "); ok {
+		var buf bytes.Buffer
+		if err := preview(s.ctx, &buf, s.frame.Scope, arg, s.now); err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"result": buf.String(), "variablesReference": 0}, nil
+	}
+
+	v, err := lookupVariable(s.frame.Scope, expr)
+	if err != nil {
+		return nil, err
+	}
+	dv := s.variable(expr, v)
+	return map[string]interface{}{
+		"result":             dv.Value,
+		"type":               dv.Type,
+		"variablesReference": dv.VariablesReference,
+	}, nil
+}
+
+func (s *dapServer) arguments(req *dapRequest, args interface{}) error {
+	if len(req.Arguments) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(req.Arguments, args); err != nil {
+		return errors.Wrapf(err, codes.Invalid, "invalid arguments for %s", req.Command)
+	}
+	return nil
+}
+
+// read reads the next request. Each message has a Content-Length
+// header followed by a JSON body.
+func (s *dapServer) read() (*dapRequest, error) {
+	header, err := textproto.NewReader(s.in).ReadMIMEHeader()
+	if err != nil {
+		return nil, err
+	}
+	n, err := strconv.Atoi(header.Get("Content-Length"))
+	if err != nil {
+		return nil, errors.Wrap(err, codes.Invalid, "invalid Content-Length header")
+	}
+	body := make([]byte, n)
+	if _, err := io.ReadFull(s.in, body); err != nil {
+		return nil, err
+	}
+	var req dapRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		return nil, errors.Wrap(err, codes.Invalid, "invalid request")
+	}
+	return &req, nil
+}
+
+// send writes a response or event with the next sequence number.
+func (s *dapServer) send(msg interface{}) {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	s.seq++
+	switch m := msg.(type) {
+	case *dapResponse:
+		m.Seq = s.seq
+	case *dapEvent:
+		m.Seq = s.seq
+	}
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return
+	}
+	fmt.Fprintf(s.out, "Content-Length: %d\r\n\r\n%s", len(body), body)
+}
+
+func (s *dapServer) event(name string, body interface{}) {
+	s.send(&dapEvent{Type: "event", Event: name, Body: body})
+}
+
+func (s *dapServer) output(category, output string) {
+	s.event("output", map[string]string{"category": category, "output": output})
+}
+
+// dapOutput sends what is written to it as output events.
+type dapOutput struct {
+	s        *dapServer
+	category string
+}
+
+func (o dapOutput) Write(p []byte) (int, error) {
+	o.s.output(o.category, string(p))
+	return len(p), nil
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestDAPServer(t *testing.T) {
+	var in bytes.Buffer
+	for i, req := range []string{
+		`{"command": "initialize", "arguments": {"adapterID": "flux"}}`,
+		`{"command": "setBreakpoints", "arguments": {"source": {"path": "a.flux"}, "breakpoints": [{"line": 3}, {"line": 1}]}}`,
+		`{"command": "threads"}`,
+		`{"command": "stackTrace", "arguments": {"threadId": 1}}`,
+		`{"command": "unknown"}`,
+		`{"command": "disconnect"}`,
+	} {
+		body := fmt.Sprintf(`{"seq": %d, "type": "request", %s`, i+1, strings.TrimPrefix(req, "{"))
+		fmt.Fprintf(&in, "Content-Length: %d\r\n\r\n%s", len(body), body)
+	}
+
+	var out bytes.Buffer
+	s := newDAPServer(&in, &out, time.Now())
+	if err := s.serve(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := s.d.Breakpoints(""), []int{1, 3}; !cmp.Equal(want, got) {
+		t.Errorf("unexpected breakpoints -want/+got:\n%s", cmp.Diff(want, got))
+	}
+
+	type message struct {
+		Seq        int    `json:"seq"`
+		Type       string `json:"type"`
+		RequestSeq int    `json:"request_seq"`
+		Success    bool   `json:"success"`
+		Command    string `json:"command"`
+		Event      string `json:"event"`
+	}
+	var got []message
+	r := bufio.NewReader(&out)
+	for {
+		header, err := textproto.NewReader(r).ReadMIMEHeader()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		n, err := strconv.Atoi(header.Get("Content-Length"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		body := make([]byte, n)
+		if _, err := io.ReadFull(r, body); err != nil {
+			t.Fatal(err)
+		}
+		var m message
+		if err := json.Unmarshal(body, &m); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, m)
+	}
+
+	want := []message{
+		{Seq: 1, Type: "response", RequestSeq: 1, Success: true, Command: "initialize"},
+		{Seq: 2, Type: "event", Event: "initialized"},
+		{Seq: 3, Type: "response", RequestSeq: 2, Success: true, Command: "setBreakpoints"},
+		{Seq: 4, Type: "response", RequestSeq: 3, Success: true, Command: "threads"},
+		// The script is not paused.
+		{Seq: 5, Type: "response", RequestSeq: 4, Command: "stackTrace"},
+		{Seq: 6, Type: "response", RequestSeq: 5, Command: "unknown"},
+		{Seq: 7, Type: "response", RequestSeq: 6, Success: true, Command: "disconnect"},
+	}
+	if !cmp.Equal(want, got) {
+		t.Errorf("unexpected messages -want/+got:\n%s", cmp.Diff(want, got))
+	}
+}
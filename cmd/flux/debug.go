@@ -0,0 +1,311 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/influxdata/flux"
+	fluxcmd "github.com/influxdata/flux/cmd/flux/cmd"
+	"github.com/influxdata/flux/codes"
+	"github.com/influxdata/flux/execute"
+	"github.com/influxdata/flux/fluxinit"
+	"github.com/influxdata/flux/internal/errors"
+	"github.com/influxdata/flux/interpreter"
+	"github.com/influxdata/flux/lang"
+	"github.com/influxdata/flux/memory"
+	"github.com/influxdata/flux/semantic"
+	"github.com/influxdata/flux/values"
+	"github.com/spf13/cobra"
+)
+
+var debugFlags struct {
+	Breakpoints []int
+	DAP         bool
+	Now         string
+}
+
+// defaultPreviewRows is the number of rows of each
+// table that preview prints when it is not given.
+const defaultPreviewRows = 10
+
+const debugHelp = `continue, c             continue to the next breakpoint
+next, n                 step over the statement
+step, s                 step into the functions called by the statement
+out, o                  step out of the current function
+break, b <line>         set a breakpoint on the line
+clear <line>            remove the breakpoint on the line
+breakpoints             list the breakpoints
+print, p <name>         print the value of a variable, members are selected with dots
+locals                  list the variables in scope with their types
+preview <name> [rows]   run the table stream of a variable and print the first rows of each table
+stack, bt               print the call stack
+list                    print the source around the current line
+quit, q                 stop debugging
+help, h                 print this help
+`
+
+func debugE(cmd *cobra.Command, args []string) error {
+	if debugFlags.DAP {
+		if len(args) > 0 {
+			return errors.New(codes.Invalid, "the script is given by the launch request when using --dap")
+		}
+	} else if len(args) != 1 {
+		return errors.New(codes.Invalid, "usage: flux debug [--break line]... <file>")
+	}
+
+	now, err := parseNow(debugFlags.Now)
+	if err != nil {
+		return err
+	}
+
+	fluxinit.FluxInit()
+	ctx, span := injectDependencies(context.Background())
+	defer span.Finish()
+
+	ctx, err = fluxcmd.WithFeatureFlags(ctx, flags.Features)
+	if err != nil {
+		return err
+	}
+
+	if debugFlags.DAP {
+		return newDAPServer(os.Stdin, os.Stdout, now).serve(ctx)
+	}
+
+	content, err := os.ReadFile(args[0])
+	if err != nil {
+		return err
+	}
+	script := string(content)
+
+	c := &cliDebugger{
+		in:    bufio.NewScanner(os.Stdin),
+		out:   os.Stdout,
+		lines: strings.Split(script, "\n"),
+		now:   now,
+	}
+	c.d = interpreter.NewDebugger(c)
+	for _, line := range debugFlags.Breakpoints {
+		c.d.SetBreakpoint("", line)
+	}
+	if len(debugFlags.Breakpoints) == 0 {
+		c.d.StopOnEntry()
+	}
+
+	rt, err := scriptRuntime(filepath.Dir(args[0]))
+	if err != nil {
+		return err
+	}
+	ctx = interpreter.WithDebugger(ctx, c.d)
+	if err := executeTo(ctx, os.Stdout, rt, script, "cli", nil, now, nil); err != nil && !c.quit {
+		return err
+	}
+	return nil
+}
+
+// cliDebugger reads debugger commands from the terminal
+// each time the interpreter pauses.
+type cliDebugger struct {
+	d     *interpreter.Debugger
+	in    *bufio.Scanner
+	out   io.Writer
+	lines []string
+	now   time.Time
+	// quit is set when the user stopped debugging.
+	quit bool
+}
+
+func (c *cliDebugger) Paused(ctx context.Context, frame *interpreter.DebugFrame) (interpreter.DebugAction, error) {
+	line := frame.Location().Start.Line
+	if file := frame.Location().File; file != "" {
+		fmt.Fprintf(c.out, "Paused at %s:%d (%s)\n", file, line, frame.Reason)
+	} else {
+		fmt.Fprintf(c.out, "Paused at line %d (%s)\n", line, frame.Reason)
+		c.list(line, 0)
+	}
+
+	for {
+		fmt.Fprint(c.out, "(flux) ")
+		if !c.in.Scan() {
+			c.quit = true
+			return 0, errors.New(codes.Canceled, "debugger quit")
+		}
+		name, arg, _ := strings.Cut(strings.TrimSpace(c.in.Text()), " ")
+		arg = strings.TrimSpace(arg)
+
+		var err error
+		switch name {
+		case "continue", "c":
+			return interpreter.Continue, nil
+		case "next", "n":
+			return interpreter.StepOver, nil
+		case "step", "s":
+			return interpreter.StepInto, nil
+		case "out", "o":
+			return interpreter.StepOut, nil
+		case "quit", "q":
+			c.quit = true
+			return 0, errors.New(codes.Canceled, "debugger quit")
+		case "break", "b", "clear":
+			var n int
+			if n, err = strconv.Atoi(arg); err != nil {
+				err = errors.Newf(codes.Invalid, "usage: %s <line>", name)
+			} else if name == "clear" {
+				c.d.ClearBreakpoint("", n)
+			} else {
+				c.d.SetBreakpoint("", n)
+			}
+		case "breakpoints":
+			for _, n := range c.d.Breakpoints("") {
+				c.list(n, -1)
+			}
+		case "print", "p":
+			var v values.Value
+			if v, err = lookupVariable(frame.Scope, arg); err == nil {
+				err = values.Display(c.out, v)
+				fmt.Fprintln(c.out)
+			}
+		case "locals":
+			for _, v := range frame.Variables() {
+				fmt.Fprintf(c.out, "%s: %s\n", v.Name, v.Value.Type().CanonicalString())
+			}
+		case "preview":
+			err = preview(ctx, c.out, frame.Scope, arg, c.now)
+		case "stack", "bt":
+			fmt.Fprintf(c.out, "  line %d\n", line)
+			for _, e := range frame.Stack {
+				fmt.Fprintf(c.out, "  %s @%s\n", e.FunctionName, e.Location)
+			}
+		case "list":
+			c.list(line, 3)
+		case "help", "h", "":
+			fmt.Fprint(c.out, debugHelp)
+		default:
+			err = errors.Newf(codes.Invalid, "unknown command %q, use help to list the commands", name)
+		}
+		if err != nil {
+			fmt.Fprintln(c.out, "Error:", err)
+		}
+	}
+}
+
+// preview runs the preview command with the arguments <name> [rows].
+func preview(ctx context.Context, w io.Writer, scope values.Scope, arg string, now time.Time) error {
+	fields := strings.Fields(arg)
+	if len(fields) == 0 || len(fields) > 2 {
+		return errors.New(codes.Invalid, "usage: preview <name> [rows]")
+	}
+	rows := defaultPreviewRows
+	if len(fields) == 2 {
+		n, err := strconv.Atoi(fields[1])
+		if err != nil || n <= 0 {
+			return errors.Newf(codes.Invalid, "invalid number of rows %q", fields[1])
+		}
+		rows = n
+	}
+	v, err := lookupVariable(scope, fields[0])
+	if err != nil {
+		return err
+	}
+	return previewTables(ctx, w, scope, v, rows, now)
+}
+
+// list prints the lines of the script around the line. The current
+// line is marked with an arrow and lines with a breakpoint with a star.
+// A negative around prints the line without marking it as current.
+func (c *cliDebugger) list(line, around int) {
+	breakpoints := make(map[int]bool)
+	for _, n := range c.d.Breakpoints("") {
+		breakpoints[n] = true
+	}
+	from, to := line-around, line+around
+	if around < 0 {
+		from, to = line, line
+	}
+	for n := from; n <= to; n++ {
+		if n < 1 || n > len(c.lines) {
+			continue
+		}
+		marker := "  "
+		if n == line && around >= 0 {
+			marker = "=>"
+		} else if breakpoints[n] {
+			marker = " *"
+		}
+		fmt.Fprintf(c.out, "%s %4d  %s\n", marker, n, c.lines[n-1])
+	}
+}
+
+// lookupVariable returns the value of the variable in scope.
+// Properties of records are selected with dots such as r.a.
+func lookupVariable(scope values.Scope, name string) (values.Value, error) {
+	if name == "" {
+		return nil, errors.New(codes.Invalid, "missing variable name")
+	}
+	parts := strings.Split(name, ".")
+	v, ok := scope.Lookup(parts[0])
+	if !ok {
+		return nil, errors.Newf(codes.NotFound, "undefined variable %s", parts[0])
+	}
+	for _, p := range parts[1:] {
+		if v.Type().Nature() != semantic.Object {
+			return nil, errors.Newf(codes.Invalid, "cannot select %s from a value of type %s", p, v.Type())
+		}
+		if v, ok = v.Object().Get(p); !ok {
+			return nil, errors.Newf(codes.NotFound, "record has no property %s", p)
+		}
+	}
+	return v, nil
+}
+
+// previewTables runs the table stream with only its first rows
+// and writes the tables. The interpreter is paused while the
+// query runs so the rest of the script is not evaluated.
+func previewTables(ctx context.Context, w io.Writer, scope values.Scope, v values.Value, rows int, now time.Time) error {
+	to, ok := v.(*flux.TableObject)
+	if !ok {
+		return errors.Newf(codes.Invalid, "cannot preview a value of type %s, it is not a table stream", v.Type())
+	}
+	limit, ok := scope.Lookup("limit")
+	if !ok || limit.Type().Nature() != semantic.Function {
+		return errors.New(codes.Internal, "limit is not in scope")
+	}
+	limited, err := limit.Function().Call(ctx, values.NewObjectWithValues(map[string]values.Value{
+		"tables": to,
+		"n":      values.NewInt(int64(rows)),
+	}))
+	if err != nil {
+		return err
+	}
+	to, ok = limited.(*flux.TableObject)
+	if !ok {
+		return errors.Newf(codes.Internal, "limit returned a value of type %s", limited.Type())
+	}
+
+	prog, err := lang.CompileTableObject(ctx, to, now)
+	if err != nil {
+		return err
+	}
+	q, err := prog.Start(ctx, &memory.ResourceAllocator{})
+	if err != nil {
+		return err
+	}
+	results := flux.NewResultIteratorFromQuery(q)
+	defer results.Release()
+	for results.More() {
+		if err := results.Next().Tables().Do(func(tbl flux.Table) error {
+			_, err := execute.NewFormatter(tbl, nil).WriteTo(w)
+			return err
+		}); err != nil {
+			return err
+		}
+	}
+	results.Release()
+	return results.Err()
+}
@@ -0,0 +1,679 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/influxdata/flux/ast"
+	"github.com/influxdata/flux/codes"
+	"github.com/influxdata/flux/internal/errors"
+	"github.com/influxdata/flux/internal/stdlibsource"
+	"github.com/influxdata/flux/libflux/go/libflux"
+	"github.com/influxdata/flux/parser"
+	"github.com/influxdata/flux/semantic"
+	"github.com/spf13/cobra"
+)
+
+var docFlags struct {
+	JSON   bool
+	Tag    string
+	Roots  []string
+	Stdlib string
+}
+
+// docTestRootFilename marks the root directory of a tree of
+// Flux packages. Import paths are relative to this directory.
+const docTestRootFilename = "fluxtest.root"
+
+func docE(cmd *cobra.Command, args []string) error {
+	roots, err := docRoots()
+	if err != nil {
+		return err
+	}
+	if docFlags.Tag != "" {
+		if len(args) > 0 {
+			return errors.New(codes.Invalid, "cannot use --tag with a package name")
+		}
+		matches, err := searchDocTag(roots, docFlags.Tag)
+		if err != nil {
+			return err
+		}
+		if docFlags.JSON {
+			return writeDocJSON(os.Stdout, matches)
+		}
+		for _, m := range matches {
+			fmt.Printf("%-40s %s\n", m.Name, m.Headline)
+		}
+		return nil
+	}
+
+	if len(args) == 0 {
+		pkgs, err := listPackageDocs(roots)
+		if err != nil {
+			return err
+		}
+		if docFlags.JSON {
+			return writeDocJSON(os.Stdout, pkgs)
+		}
+		for _, pkg := range pkgs {
+			fmt.Printf("%-40s %s\n", pkg.Path, pkg.Headline)
+		}
+		return nil
+	}
+
+	pkgPath, member := splitDocName(args[0])
+	pkg, err := findPackageDoc(context.Background(), roots, pkgPath)
+	if err != nil {
+		return err
+	}
+	if pkg.TypeError != "" {
+		fmt.Fprintf(os.Stderr, "warning: failed to infer the signatures of package %q: %s\n", pkg.Path, pkg.TypeError)
+	}
+	if member == "" {
+		if docFlags.JSON {
+			return writeDocJSON(os.Stdout, pkg)
+		}
+		writePackageDoc(os.Stdout, pkg)
+		return nil
+	}
+	m := pkg.member(member)
+	if m == nil {
+		return errors.Newf(codes.NotFound, "package %q has no member %s", pkgPath, member)
+	}
+	if docFlags.JSON {
+		return writeDocJSON(os.Stdout, m)
+	}
+	writeMemberDoc(os.Stdout, pkg, m)
+	return nil
+}
+
+// packageDoc is the documentation of a Flux package.
+type packageDoc struct {
+	Path        string            `json:"path"`
+	Name        string            `json:"name"`
+	Headline    string            `json:"headline"`
+	Description string            `json:"description,omitempty"`
+	Examples    []exampleDoc      `json:"examples,omitempty"`
+	Metadata    map[string]string `json:"metadata,omitempty"`
+	Members     []*memberDoc      `json:"members,omitempty"`
+	// TypeError is the error of the analyzer when the signatures
+	// of the members could not be inferred.
+	TypeError string `json:"typeError,omitempty"`
+
+	// files are the parsed files of the package.
+	files []*ast.File
+}
+
+func (p *packageDoc) member(name string) *memberDoc {
+	for _, m := range p.Members {
+		if m.Name == name {
+			return m
+		}
+	}
+	return nil
+}
+
+// memberDoc is the documentation of a value, function
+// or option that a Flux package exports.
+type memberDoc struct {
+	Name string `json:"name"`
+	// Kind is one of function, value or option.
+	Kind        string            `json:"kind"`
+	Signature   string            `json:"signature,omitempty"`
+	Headline    string            `json:"headline"`
+	Description string            `json:"description,omitempty"`
+	Parameters  []parameterDoc    `json:"parameters,omitempty"`
+	Examples    []exampleDoc      `json:"examples,omitempty"`
+	Metadata    map[string]string `json:"metadata,omitempty"`
+}
+
+type parameterDoc struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+type exampleDoc struct {
+	Title string `json:"title"`
+	Code  string `json:"code"`
+}
+
+// docComment is a doc comment split into the sections
+// described by docs/fluxdoc.md.
+type docComment struct {
+	Headline    string
+	Description string
+	Parameters  []parameterDoc
+	Examples    []exampleDoc
+	Metadata    map[string]string
+}
+
+// tags returns the comma separated tags of the metadata.
+func tags(metadata map[string]string) []string {
+	var tags []string
+	for _, t := range strings.Split(metadata["tags"], ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			tags = append(tags, t)
+		}
+	}
+	return tags
+}
+
+// parseDocComment parses the text of a doc comment without
+// the comment markers. The first paragraph is the headline
+// and the rest up to the first known section is the description.
+// Sections that are not known are kept in the description.
+func parseDocComment(text string) docComment {
+	var (
+		doc         docComment
+		description []string
+		section     string
+		inFence     bool
+		example     *exampleDoc
+		code        []string
+	)
+	for _, line := range strings.Split(text, "\n") {
+		if !inFence && strings.HasPrefix(line, "## ") {
+			section = strings.TrimSpace(strings.TrimPrefix(line, "## "))
+			switch section {
+			case "Parameters", "Examples", "Metadata":
+				continue
+			}
+			section = ""
+		}
+
+		switch section {
+		case "Parameters":
+			trimmed := strings.TrimSpace(line)
+			if strings.HasPrefix(line, "- ") {
+				name, desc, _ := strings.Cut(strings.TrimPrefix(line, "- "), ":")
+				doc.Parameters = append(doc.Parameters, parameterDoc{
+					Name:        strings.TrimSpace(name),
+					Description: strings.TrimSpace(desc),
+				})
+			} else if trimmed != "" && len(doc.Parameters) > 0 {
+				p := &doc.Parameters[len(doc.Parameters)-1]
+				p.Description = strings.TrimSpace(p.Description + " " + trimmed)
+			}
+		case "Examples":
+			switch {
+			case strings.HasPrefix(line, "```"):
+				if inFence && example != nil {
+					example.Code = renderExampleCode(code)
+				}
+				inFence, code = !inFence, nil
+			case inFence:
+				code = append(code, line)
+			case strings.HasPrefix(line, "### "):
+				doc.Examples = append(doc.Examples, exampleDoc{
+					Title: strings.TrimSpace(strings.TrimPrefix(line, "### ")),
+				})
+				example = &doc.Examples[len(doc.Examples)-1]
+			}
+		case "Metadata":
+			if k, v, ok := strings.Cut(line, ":"); ok {
+				if doc.Metadata == nil {
+					doc.Metadata = make(map[string]string)
+				}
+				doc.Metadata[strings.TrimSpace(k)] = strings.TrimSpace(v)
+			}
+		default:
+			if strings.HasPrefix(line, "```") {
+				inFence = !inFence
+			}
+			description = append(description, line)
+		}
+	}
+
+	// The headline is the first paragraph joined into one line.
+	i := 0
+	for ; i < len(description) && strings.TrimSpace(description[i]) != ""; i++ {
+	}
+	doc.Headline = strings.Join(strings.Fields(strings.Join(description[:i], " ")), " ")
+	doc.Description = strings.TrimSpace(strings.Join(description[i:], "\n"))
+	return doc
+}
+
+// renderExampleCode removes the lines of an example that are only
+// used when the example is executed and the input and output markers.
+func renderExampleCode(lines []string) string {
+	var code []string
+	for _, line := range lines {
+		switch {
+		case line == "#" || strings.HasPrefix(line, "# ") ||
+			strings.HasPrefix(line, "#<") || strings.HasPrefix(line, "#>"):
+			continue
+		case strings.HasPrefix(line, "< "), strings.HasPrefix(line, "> "):
+			line = line[2:]
+		}
+		code = append(code, line)
+	}
+	return strings.TrimSpace(strings.Join(code, "\n"))
+}
+
+// commentText returns the text of the comments without the comment markers.
+func commentText(comments []ast.Comment) string {
+	lines := make([]string, 0, len(comments))
+	for _, c := range comments {
+		line := strings.TrimSuffix(strings.TrimSuffix(c.Text, "\n"), "\r")
+		line = strings.TrimPrefix(line, "//")
+		lines = append(lines, strings.TrimPrefix(line, " "))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// docRoot is a tree of Flux packages.
+type docRoot struct {
+	fsys fs.FS
+	// dir is the directory of the root. It is empty
+	// for the standard library embedded in the binary.
+	dir string
+	// stdlib is set for the root of the standard library whose
+	// packages are known to the analyzer.
+	stdlib bool
+}
+
+func newDocRoot(dir string, stdlib bool) docRoot {
+	return docRoot{fsys: os.DirFS(dir), dir: dir, stdlib: stdlib}
+}
+
+// docRoots returns the roots given by --root and the nearest
+// directory with a fluxtest.root file at or above the working
+// directory, followed by the standard library. The sources of
+// the standard library embedded in the binary are used when
+// no directory of the standard library is found.
+func docRoots() ([]docRoot, error) {
+	var roots []docRoot
+	for _, dir := range docFlags.Roots {
+		roots = append(roots, newDocRoot(dir, false))
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+	for dir := wd; ; dir = filepath.Dir(dir) {
+		if _, err := os.Stat(filepath.Join(dir, docTestRootFilename)); err == nil {
+			roots = append(roots, newDocRoot(dir, false))
+			break
+		}
+		if filepath.Dir(dir) == dir {
+			break
+		}
+	}
+
+	stdlibDir := docFlags.Stdlib
+	if stdlibDir == "" {
+		stdlibDir = os.Getenv("FLUX_STDLIB")
+	}
+	if stdlibDir == "" {
+		// Look for the stdlib directory of a Flux source tree.
+		for dir := wd; ; dir = filepath.Dir(dir) {
+			if _, err := os.Stat(filepath.Join(dir, "stdlib", docTestRootFilename)); err == nil {
+				stdlibDir = filepath.Join(dir, "stdlib")
+				break
+			}
+			if filepath.Dir(dir) == dir {
+				break
+			}
+		}
+	}
+	if stdlibDir == "" {
+		return append(roots, docRoot{fsys: stdlibsource.FS(), stdlib: true}), nil
+	}
+	// The stdlib may also be the root found above.
+	for i, r := range roots {
+		if filepath.Clean(r.dir) == filepath.Clean(stdlibDir) {
+			roots = append(roots[:i], roots[i+1:]...)
+			break
+		}
+	}
+	return append(roots, newDocRoot(stdlibDir, true)), nil
+}
+
+// splitDocName splits pkg.name into the package path and member name.
+// Package paths may contain dots only before their last slash.
+func splitDocName(name string) (string, string) {
+	slash := strings.LastIndex(name, "/")
+	if dot := strings.LastIndex(name, "."); dot > slash {
+		return name[:dot], name[dot+1:]
+	}
+	return name, ""
+}
+
+// findPackageDoc reads the documentation of the package
+// from the first root that has it.
+func findPackageDoc(ctx context.Context, roots []docRoot, pkgPath string) (*packageDoc, error) {
+	for _, root := range roots {
+		pkg, err := readPackageDoc(root, pkgPath)
+		if err != nil {
+			return nil, err
+		} else if pkg == nil {
+			continue
+		}
+		if err := addSignatures(ctx, root, pkg); err != nil {
+			pkg.TypeError = err.Error()
+		}
+		return pkg, nil
+	}
+	return nil, errors.Newf(codes.NotFound, "package %q not found", pkgPath)
+}
+
+// listPackageDocs reads the documentation of all packages
+// sorted by path. Packages in earlier roots hide those in later roots.
+func listPackageDocs(roots []docRoot) ([]*packageDoc, error) {
+	var pkgs []*packageDoc
+	seen := make(map[string]bool)
+	for _, root := range roots {
+		err := fs.WalkDir(root.fsys, ".", func(pkgPath string, d fs.DirEntry, err error) error {
+			if err != nil || !d.IsDir() || pkgPath == "." {
+				return err
+			}
+			if seen[pkgPath] {
+				return nil
+			}
+			pkg, err := readPackageDoc(root, pkgPath)
+			if err != nil || pkg == nil {
+				return err
+			}
+			seen[pkgPath] = true
+			pkgs = append(pkgs, pkg)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	sort.Slice(pkgs, func(i, j int) bool {
+		return pkgs[i].Path < pkgs[j].Path
+	})
+	return pkgs, nil
+}
+
+// docMatch is a package or member with a tag.
+type docMatch struct {
+	Name     string `json:"name"`
+	Headline string `json:"headline"`
+}
+
+// searchDocTag returns the packages and members that have the tag.
+func searchDocTag(roots []docRoot, tag string) ([]docMatch, error) {
+	pkgs, err := listPackageDocs(roots)
+	if err != nil {
+		return nil, err
+	}
+	hasTag := func(metadata map[string]string) bool {
+		for _, t := range tags(metadata) {
+			if strings.EqualFold(t, tag) {
+				return true
+			}
+		}
+		return false
+	}
+	var matches []docMatch
+	for _, pkg := range pkgs {
+		if hasTag(pkg.Metadata) {
+			matches = append(matches, docMatch{Name: pkg.Path, Headline: pkg.Headline})
+		}
+		for _, m := range pkg.Members {
+			if hasTag(m.Metadata) {
+				matches = append(matches, docMatch{Name: pkg.Path + "." + m.Name, Headline: m.Headline})
+			}
+		}
+	}
+	return matches, nil
+}
+
+// readPackageDoc parses the Flux files of the package in the root.
+// It returns nil if the directory has no Flux files.
+func readPackageDoc(root docRoot, pkgPath string) (*packageDoc, error) {
+	files, err := fs.Glob(root.fsys, path.Join(pkgPath, "*.flux"))
+	if err != nil {
+		return nil, err
+	}
+	pkg := &packageDoc{Path: pkgPath, Name: path.Base(pkgPath)}
+	found := false
+	for _, file := range files {
+		if strings.HasSuffix(file, "_test.flux") {
+			continue
+		}
+		found = true
+		src, err := fs.ReadFile(root.fsys, file)
+		if err != nil {
+			return nil, err
+		}
+		name := file
+		if root.dir != "" {
+			name = filepath.Join(root.dir, filepath.FromSlash(file))
+		}
+		astPkg := parser.ParseSourceWithFileName(string(src), name)
+		if ast.Check(astPkg) > 0 {
+			return nil, errors.Wrapf(ast.GetError(astPkg), codes.Invalid, "failed to parse %s", name)
+		}
+		addFileDoc(pkg, astPkg.Files[0])
+		pkg.files = append(pkg.files, astPkg.Files[0])
+	}
+	if !found {
+		return nil, nil
+	}
+	sort.Slice(pkg.Members, func(i, j int) bool {
+		return pkg.Members[i].Name < pkg.Members[j].Name
+	})
+	return pkg, nil
+}
+
+// addFileDoc adds the package doc and the exported
+// members of the file to the package documentation.
+func addFileDoc(pkg *packageDoc, file *ast.File) {
+	if file.Package != nil {
+		pkg.Name = file.Package.Name.Name
+		if text := commentText(file.Package.Comments); text != "" && pkg.Headline == "" {
+			doc := parseDocComment(text)
+			pkg.Headline, pkg.Description = doc.Headline, doc.Description
+			pkg.Examples, pkg.Metadata = doc.Examples, doc.Metadata
+		}
+	}
+
+	for _, stmt := range file.Body {
+		var (
+			m        memberDoc
+			comments []ast.Comment
+		)
+		switch s := stmt.(type) {
+		case *ast.BuiltinStatement:
+			m.Name, m.Kind, comments = s.ID.Name, "value", s.Comments
+			if _, ok := s.Ty.Ty.(*ast.FunctionType); ok {
+				m.Kind = "function"
+			}
+			m.Signature = strings.Join(strings.Fields(s.Ty.Location().Source), " ")
+		case *ast.VariableAssignment:
+			m.Name, m.Kind, comments = s.ID.Name, "value", s.Comments
+			if len(comments) == 0 {
+				comments = s.ID.Comments
+			}
+			if fn, ok := s.Init.(*ast.FunctionExpression); ok {
+				m.Kind, m.Signature = "function", paramsSignature(fn)
+			}
+		case *ast.OptionStatement:
+			a, ok := s.Assignment.(*ast.VariableAssignment)
+			if !ok {
+				continue
+			}
+			m.Name, m.Kind, comments = a.ID.Name, "option", s.Comments
+		default:
+			continue
+		}
+		if strings.HasPrefix(m.Name, "_") {
+			continue
+		}
+		doc := parseDocComment(commentText(comments))
+		m.Headline, m.Description = doc.Headline, doc.Description
+		m.Parameters, m.Examples, m.Metadata = doc.Parameters, doc.Examples, doc.Metadata
+		pkg.Members = append(pkg.Members, &m)
+	}
+}
+
+// paramsSignature returns the parameters of a function as written in
+// the source. It is used when the type of the function is not known.
+func paramsSignature(fn *ast.FunctionExpression) string {
+	params := make([]string, 0, len(fn.Params))
+	for _, p := range fn.Params {
+		params = append(params, strings.Join(strings.Fields(p.Location().Source), " "))
+	}
+	return "(" + strings.Join(params, ", ") + ") => ..."
+}
+
+// docMemberPrefix prefixes the variables that the members of a package
+// of the standard library are assigned to so the analyzer infers their types.
+const docMemberPrefix = "_doc_"
+
+// addSignatures replaces the signatures of the members with their types
+// as inferred by the analyzer. The members of a package of the standard
+// library are analyzed by importing the package and other packages are
+// analyzed from their files. The signatures are kept as they were written
+// when the package cannot be analyzed and the error is returned.
+func addSignatures(ctx context.Context, root docRoot, pkg *packageDoc) error {
+	if len(pkg.Members) == 0 {
+		return nil
+	}
+	var (
+		semPkg *libflux.SemanticPkg
+		err    error
+	)
+	if root.stdlib {
+		var sb strings.Builder
+		fmt.Fprintf(&sb, "import %q\n", pkg.Path)
+		for _, m := range pkg.Members {
+			fmt.Fprintf(&sb, "%s%s = %s.%s\n", docMemberPrefix, m.Name, pkg.Name, m.Name)
+		}
+		semPkg, err = libflux.AnalyzeWithOptions(libflux.ParseString(sb.String()), libflux.NewOptions(ctx))
+	} else {
+		var bs []byte
+		bs, err = json.Marshal(&ast.Package{Package: pkg.Name, Files: pkg.files})
+		if err != nil {
+			return err
+		}
+		var astPkg *libflux.ASTPkg
+		if astPkg, err = libflux.ParseJSON(bs); err != nil {
+			return err
+		}
+		semPkg, err = libflux.AnalyzeWithOptions(astPkg, libflux.NewOptions(ctx))
+	}
+	if err != nil {
+		return err
+	}
+	defer semPkg.Free()
+	bs, err := semPkg.MarshalFB()
+	if err != nil {
+		return err
+	}
+	semantics, err := semantic.DeserializeFromFlatBuffer(bs)
+	if err != nil {
+		return err
+	}
+
+	for _, file := range semantics.Files {
+		for _, stmt := range file.Body {
+			if opt, ok := stmt.(*semantic.OptionStatement); ok {
+				stmt = opt.Assignment
+			}
+			a, ok := stmt.(*semantic.NativeVariableAssignment)
+			if !ok {
+				continue
+			}
+			name := a.Identifier.Name.Name()
+			if root.stdlib {
+				name = strings.TrimPrefix(name, docMemberPrefix)
+			}
+			if m := pkg.member(name); m != nil {
+				m.Signature = a.Typ.CanonicalString()
+			}
+		}
+	}
+	return nil
+}
+
+func writeDocJSON(w io.Writer, v interface{}) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func writePackageDoc(w io.Writer, pkg *packageDoc) {
+	fmt.Fprintf(w, "package %s // import %q\n", pkg.Name, pkg.Path)
+	writeDocBody(w, pkg.Headline, pkg.Description, nil, pkg.Examples, pkg.Metadata)
+
+	for _, kind := range []string{"option", "value", "function"} {
+		var members []*memberDoc
+		for _, m := range pkg.Members {
+			if m.Kind == kind {
+				members = append(members, m)
+			}
+		}
+		if len(members) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "\n%s%ss:\n", strings.ToUpper(kind[:1]), kind[1:])
+		for _, m := range members {
+			if m.Signature != "" {
+				fmt.Fprintf(w, "    %s: %s\n", m.Name, m.Signature)
+			} else {
+				fmt.Fprintf(w, "    %s\n", m.Name)
+			}
+			if m.Headline != "" {
+				fmt.Fprintf(w, "        %s\n", m.Headline)
+			}
+		}
+	}
+}
+
+func writeMemberDoc(w io.Writer, pkg *packageDoc, m *memberDoc) {
+	if m.Signature != "" {
+		fmt.Fprintf(w, "%s.%s: %s\n", pkg.Name, m.Name, m.Signature)
+	} else {
+		fmt.Fprintf(w, "%s.%s\n", pkg.Name, m.Name)
+	}
+	writeDocBody(w, m.Headline, m.Description, m.Parameters, m.Examples, m.Metadata)
+}
+
+func writeDocBody(w io.Writer, headline, description string, params []parameterDoc, examples []exampleDoc, metadata map[string]string) {
+	if headline != "" {
+		fmt.Fprintf(w, "\n%s\n", headline)
+	}
+	if description != "" {
+		fmt.Fprintf(w, "\n%s\n", description)
+	}
+	if len(params) > 0 {
+		fmt.Fprint(w, "\nParameters:\n")
+		for _, p := range params {
+			fmt.Fprintf(w, "    %s: %s\n", p.Name, p.Description)
+		}
+	}
+	if len(examples) > 0 {
+		fmt.Fprint(w, "\nExamples:\n")
+		for _, e := range examples {
+			fmt.Fprintf(w, "\n    %s\n\n", e.Title)
+			for _, line := range strings.Split(e.Code, "\n") {
+				fmt.Fprintf(w, "        %s\n", line)
+			}
+		}
+	}
+	if len(metadata) > 0 {
+		keys := make([]string, 0, len(metadata))
+		for k := range metadata {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		fmt.Fprintln(w)
+		for _, k := range keys {
+			fmt.Fprintf(w, "%s: %s\n", k, metadata[k])
+		}
+	}
+}
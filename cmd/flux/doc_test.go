@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/influxdata/flux/internal/stdlibsource"
+)
+
+func TestParseDocComment(t *testing.T) {
+	text := "toUpper converts a string\n" +
+		"to uppercase.\n" +
+		"\n" +
+		"#### toUpper vs toTitle\n" +
+		"The results differ for some characters.\n" +
+		"\n" +
+		"## Parameters\n" +
+		"- v: String value to convert.\n" +
+		"- tables: Input data.\n" +
+		"  Default is piped-forward data (`<-`).\n" +
+		"\n" +
+		"## Examples\n" +
+		"\n" +
+		"### Convert a string to uppercase\n" +
+		"```\n" +
+		"# import \"array\"\n" +
+		"#< data = array.from(rows: [{_value: \"a\"}])\n" +
+		"import \"strings\"\n" +
+		"\n" +
+		"> strings.toUpper(v: \"a\")\n" +
+		"```\n" +
+		"\n" +
+		"## Metadata\n" +
+		"introduced: 0.18.0\n" +
+		"tags: transformations, strings\n"
+
+	want := docComment{
+		Headline:    "toUpper converts a string to uppercase.",
+		Description: "#### toUpper vs toTitle\nThe results differ for some characters.",
+		Parameters: []parameterDoc{
+			{Name: "v", Description: "String value to convert."},
+			{Name: "tables", Description: "Input data. Default is piped-forward data (`<-`)."},
+		},
+		Examples: []exampleDoc{{
+			Title: "Convert a string to uppercase",
+			Code:  "import \"strings\"\n\nstrings.toUpper(v: \"a\")",
+		}},
+		Metadata: map[string]string{
+			"introduced": "0.18.0",
+			"tags":       "transformations, strings",
+		},
+	}
+	got := parseDocComment(text)
+	if !cmp.Equal(want, got) {
+		t.Errorf("unexpected doc -want/+got:\n%s", cmp.Diff(want, got))
+	}
+	if want, got := []string{"transformations", "strings"}, tags(got.Metadata); !cmp.Equal(want, got) {
+		t.Errorf("unexpected tags -want/+got:\n%s", cmp.Diff(want, got))
+	}
+}
+
+func TestSplitDocName(t *testing.T) {
+	for _, tc := range []struct {
+		name, pkg, member string
+	}{
+		{name: "strings", pkg: "strings"},
+		{name: "strings.toUpper", pkg: "strings", member: "toUpper"},
+		{name: "influxdata/influxdb/tasks.lastSuccess", pkg: "influxdata/influxdb/tasks", member: "lastSuccess"},
+		{name: "example.com/pkg", pkg: "example.com/pkg"},
+	} {
+		pkg, member := splitDocName(tc.name)
+		if pkg != tc.pkg || member != tc.member {
+			t.Errorf("%s: got %q %q, want %q %q", tc.name, pkg, member, tc.pkg, tc.member)
+		}
+	}
+}
+
+func TestFindPackageDoc(t *testing.T) {
+	dir := t.TempDir()
+	for name, src := range map[string]string{
+		"math2/math2.flux": `// Package math2 adds numbers.
+package math2
+
+// add adds two values.
+add = (a, b) => a + b
+
+// offset is added by addOffset.
+option offset = 1
+`,
+		"broken/broken.flux": `package broken
+
+// f is not well typed.
+f = (a) => a + "b" + 1
+`,
+	} {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	roots := []docRoot{
+		newDocRoot(dir, false),
+		{fsys: stdlibsource.FS(), stdlib: true},
+	}
+
+	for _, tc := range []struct {
+		pkg, member string
+		want        string
+		wantErr     bool
+	}{
+		// Local packages are analyzed from their files.
+		{pkg: "math2", member: "add", want: "(a: A, b: A) => A where A: Addable"},
+		{pkg: "math2", member: "offset", want: "int"},
+		// The standard library is read from the embedded sources.
+		{pkg: "strings", member: "toUpper", want: "(v: string) => string"},
+		// The signatures of a package that cannot be
+		// analyzed are written as in the source.
+		{pkg: "broken", member: "f", want: "(a) => ...", wantErr: true},
+	} {
+		pkg, err := findPackageDoc(context.Background(), roots, tc.pkg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := pkg.TypeError != ""; got != tc.wantErr {
+			t.Errorf("%s: unexpected type error %q", tc.pkg, pkg.TypeError)
+		}
+		m := pkg.member(tc.member)
+		if m == nil {
+			t.Errorf("%s: member %s not found", tc.pkg, tc.member)
+			continue
+		}
+		if m.Signature != tc.want {
+			t.Errorf("%s.%s: unexpected signature got %q want %q", tc.pkg, tc.member, m.Signature, tc.want)
+		}
+	}
+}
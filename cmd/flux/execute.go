@@ -3,21 +3,73 @@ package main
 import (
 	"context"
 	"fmt"
+	"io"
 	"os"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/influxdata/flux"
+	"github.com/influxdata/flux/arrow"
+	"github.com/influxdata/flux/codes"
 	"github.com/influxdata/flux/csv"
 	"github.com/influxdata/flux/execute"
+	"github.com/influxdata/flux/execute/table"
+	"github.com/influxdata/flux/internal/errors"
+	"github.com/influxdata/flux/json"
 	"github.com/influxdata/flux/lang"
 	"github.com/influxdata/flux/memory"
+	"github.com/influxdata/flux/module"
 	"github.com/influxdata/flux/runtime"
 )
 
-func executeE(ctx context.Context, script, format string) error {
-	c := lang.FluxCompiler{
-		Query: script,
+func executeE(ctx context.Context, rt flux.Runtime, script, format string, profilers []string, now time.Time, params lang.Params, opts ...lang.CompileOption) error {
+	return executeTo(ctx, os.Stdout, rt, script, format, profilers, now, params, opts...)
+}
+
+// scriptRuntime returns the runtime for a script in dir
+// that imports the local packages found by newResolver.
+func scriptRuntime(dir string) (flux.Runtime, error) {
+	r, err := newResolver(dir)
+	if err != nil {
+		return nil, err
+	}
+	return module.NewRuntime(runtime.Default, r), nil
+}
+
+// newResolver returns the resolver of the local packages for dir.
+// Imports of local packages are resolved with the flux.mod file at or
+// above dir, the directories given by --path and those listed in $FLUXPATH.
+func newResolver(dir string) (*module.Resolver, error) {
+	mod, err := module.FindModFile(dir)
+	if err != nil {
+		return nil, err
+	}
+	paths := append(append([]string(nil), flags.Paths...), module.SearchPaths()...)
+	return module.NewResolver(mod, paths), nil
+}
+
+// executeTo executes the script with the params and writes its results to w.
+func executeTo(ctx context.Context, w io.Writer, rt flux.Runtime, script, format string, profilers []string, now time.Time, params lang.Params, opts ...lang.CompileOption) error {
+	// Resolve the encoder and profilers before doing any work
+	// so an unknown format or profiler fails early.
+	var encoder flux.MultiResultEncoder
+	if format != "cli" {
+		enc, err := newEncoder(format)
+		if err != nil {
+			return err
+		}
+		encoder = enc
+	}
+	for _, name := range profilers {
+		if _, ok := execute.AllProfilers[name]; !ok {
+			return errors.Newf(codes.Invalid, "unknown profiler %q, must be one of: %s", name, strings.Join(profilerNames(), ","))
+		}
 	}
-	prog, err := c.Compile(ctx, runtime.Default)
+
+	opts = append(opts, lang.WithProfilers(profilers...))
+	c := lang.FluxCompiler{Now: now, Params: params, Query: script}
+	prog, err := c.CompileWithOptions(ctx, rt, opts...)
 	if err != nil {
 		return err
 	}
@@ -31,25 +83,96 @@ func executeE(ctx context.Context, script, format string) error {
 	results := flux.NewResultIteratorFromQuery(q)
 	defer results.Release()
 
-	if format == "cli" {
+	if encoder == nil {
 		for results.More() {
 			res := results.Next()
-			fmt.Println("Result:", res.Name())
+			fmt.Fprintln(w, "Result:", res.Name())
 			if err := res.Tables().Do(func(table flux.Table) error {
-				_, err := execute.NewFormatter(table, nil).WriteTo(os.Stdout)
+				_, err := execute.NewFormatter(table, nil).WriteTo(w)
 				return err
 			}); err != nil {
 				return err
 			}
 		}
-	} else if format == "csv" {
-		config := csv.DefaultEncoderConfig()
-		encoder := csv.NewMultiResultEncoder(config)
-		_, err := encoder.Encode(os.Stdout, results)
+	} else if _, err := encoder.Encode(w, results); err != nil {
+		return err
+	}
+	results.Release()
+	if err := results.Err(); err != nil {
+		return err
+	}
+	if len(profilers) == 0 {
+		return nil
+	}
+	return writeProfilerResults(w, q, profilers, encoder, mem)
+}
+
+// writeProfilerResults writes the tables produced by the named profilers
+// after the query has finished. The tables are written with the encoder
+// used for the query results or in the cli format if the encoder is nil.
+// Operator profiles are sorted by their total duration so the most
+// expensive operators are listed first.
+func writeProfilerResults(w io.Writer, q flux.Query, profilers []string, encoder flux.MultiResultEncoder, mem memory.Allocator) error {
+	tables := make([]flux.Table, 0, len(profilers))
+	seen := make(map[string]bool, len(profilers))
+	for _, name := range profilers {
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		p := execute.AllProfilers[name]()
+		var (
+			tbl flux.Table
+			err error
+		)
+		if _, ok := p.(*execute.OperatorProfiler); ok {
+			tbl, err = p.GetSortedResult(q, mem, true, "DurationSum")
+		} else {
+			tbl, err = p.GetResult(q, mem)
+		}
 		if err != nil {
 			return err
 		}
+		tables = append(tables, tbl)
+	}
+
+	result := table.NewProfilerResult(tables...)
+	if encoder != nil {
+		results := flux.NewSliceResultIterator([]flux.Result{&result})
+		_, err := encoder.Encode(w, results)
+		return err
+	}
+
+	fmt.Fprintln(w, "Result:", result.Name())
+	return result.Tables().Do(func(tbl flux.Table) error {
+		_, err := execute.NewFormatter(tbl, nil).WriteTo(w)
+		return err
+	})
+}
+
+// profilerNames returns the sorted names of the available profilers.
+func profilerNames() []string {
+	names := make([]string, 0, len(execute.AllProfilers))
+	for name := range execute.AllProfilers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// newEncoder returns the MultiResultEncoder for the named output format.
+func newEncoder(format string) (flux.MultiResultEncoder, error) {
+	switch format {
+	case "csv":
+		return csv.NewMultiResultEncoder(csv.DefaultEncoderConfig()), nil
+	case "json":
+		return json.NewMultiResultEncoder(), nil
+	case "ndjson":
+		return json.NewNDJSONMultiResultEncoder(), nil
+	case "arrow":
+		return arrow.NewIPCMultiResultEncoder(nil), nil
+	default:
+		return nil, errors.Newf(codes.Invalid, "unknown output format %q, must be one of: cli,csv,json,ndjson,arrow", format)
 	}
-	results.Release()
-	return results.Err()
 }
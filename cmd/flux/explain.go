@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"time"
+
+	fluxcmd "github.com/influxdata/flux/cmd/flux/cmd"
+	"github.com/influxdata/flux/fluxinit"
+	"github.com/influxdata/flux/lang"
+	"github.com/influxdata/flux/memory"
+	"github.com/influxdata/flux/plan"
+	"github.com/influxdata/flux/runtime"
+	"github.com/spf13/cobra"
+)
+
+var explainFlags struct {
+	Physical bool
+	DOT      bool
+}
+
+func explainE(cmd *cobra.Command, args []string) error {
+	content, err := os.ReadFile(args[0])
+	if err != nil {
+		return err
+	}
+
+	fluxinit.FluxInit()
+	ctx, span := injectDependencies(context.Background())
+	defer span.Finish()
+
+	ctx, err = fluxcmd.WithFeatureFlags(ctx, flags.Features)
+	if err != nil {
+		return err
+	}
+	// The planners count every rule that changes the plan in this map.
+	rules := make(map[string]int)
+	prog, err := lang.Compile(ctx, string(content), runtime.Default, time.Now(),
+		lang.WithLogPlanOpts(plan.RecordLogicalRules(rules)),
+		lang.WithPhysPlanOpts(plan.RecordPhysicalRules(rules)),
+	)
+	if err != nil {
+		return err
+	}
+
+	mem := &memory.ResourceAllocator{}
+	var ps *plan.Spec
+	if explainFlags.Physical {
+		ps, err = prog.PhysicalPlan(ctx, mem)
+	} else {
+		ps, err = prog.LogicalPlan(ctx, mem)
+	}
+	if err != nil {
+		return err
+	}
+
+	out := cmd.OutOrStdout()
+	if explainFlags.DOT {
+		// Keep stdout a valid DOT document so it can be piped to Graphviz.
+		fmt.Fprint(out, plan.Formatted(ps, plan.WithDetails(), plan.WithLabels()))
+		writePlannerRules(cmd.OutOrStderr(), rules)
+		return nil
+	}
+
+	kind := "Logical"
+	if explainFlags.Physical {
+		kind = "Physical"
+	}
+	fmt.Fprintf(out, "%s plan:\n%v\n", kind, plan.Formatted(ps, plan.WithDetails(), plan.WithText()))
+	writePlannerRules(out, rules)
+	return nil
+}
+
+// writePlannerRules writes the names of the planner rules that
+// were applied and the number of times each one was applied.
+func writePlannerRules(w io.Writer, rules map[string]int) {
+	if len(rules) == 0 {
+		fmt.Fprintln(w, "No planner rules were applied.")
+		return
+	}
+
+	names := make([]string, 0, len(rules))
+	for name := range rules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintln(w, "Planner rules applied:")
+	for _, name := range names {
+		fmt.Fprintf(w, "  %s (%d)\n", name, rules[name])
+	}
+}
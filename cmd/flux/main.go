@@ -4,14 +4,17 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"path/filepath"
 
 	fluxcmd "github.com/influxdata/flux/cmd/flux/cmd"
 	"github.com/influxdata/flux/codes"
-	"github.com/influxdata/flux/dependencies"
 	"github.com/influxdata/flux/dependency"
+	"github.com/influxdata/flux/execute/remote"
 	"github.com/influxdata/flux/fluxinit"
 	"github.com/influxdata/flux/internal/errors"
+	"github.com/influxdata/flux/lang"
 	"github.com/influxdata/flux/repl"
+	"github.com/influxdata/flux/runtime"
 	"github.com/opentracing/opentracing-go"
 	"github.com/spf13/cobra"
 	jaegercfg "github.com/uber/jaeger-client-go/config"
@@ -26,6 +29,14 @@ var flags struct {
 	Format            string
 	Features          string
 	EnableSuggestions bool
+	NoHistory         bool
+	Profile           []string
+	Params            []string
+	Options           []string
+	Paths             []string
+	Now               string
+	Workers           []string
+	LocalWorkers      int
 }
 
 func runE(cmd *cobra.Command, args []string) error {
@@ -64,11 +75,67 @@ func runE(cmd *cobra.Command, args []string) error {
 	if flags.EnableSuggestions {
 		opts = append(opts, repl.EnableSuggestions())
 	}
+	if !flags.NoHistory {
+		if path, err := repl.DefaultHistoryFile(); err == nil {
+			opts = append(opts, repl.HistoryFile(path))
+		}
+	}
 
 	if len(args) == 0 {
+		if len(flags.Profile) > 0 {
+			return errors.New(codes.Invalid, "--profile is only supported when executing a script")
+		}
+		if len(flags.Params) > 0 || len(flags.Options) > 0 || flags.Now != "" {
+			return errors.New(codes.Invalid, "--param, --option and --now are only supported when executing a script")
+		}
+		if len(flags.Workers) > 0 || flags.LocalWorkers > 0 {
+			return errors.New(codes.Invalid, "--workers and --local-workers are only supported when executing a script")
+		}
+		resolver, err := newResolver(".")
+		if err != nil {
+			return err
+		}
+		opts = append(opts, repl.Resolver(resolver))
 		return replE(ctx, opts...)
 	}
-	return executeE(ctx, script, flags.Format)
+
+	now, err := parseNow(flags.Now)
+	if err != nil {
+		return err
+	}
+	params, err := parseParams(flags.Params)
+	if err != nil {
+		return err
+	}
+	options, err := parseOptions(flags.Options)
+	if err != nil {
+		return err
+	}
+	workers := flags.Workers
+	if flags.LocalWorkers > 0 {
+		urls, stop, err := spawnWorkers(flags.LocalWorkers)
+		if err != nil {
+			return err
+		}
+		defer stop()
+		workers = append(urls, workers...)
+	}
+	var compileOpts []lang.CompileOption
+	if len(workers) > 0 {
+		compileOpts = append(compileOpts, lang.WithPartitionExecutor(remote.NewClient(workers, nil)))
+	}
+	dir := "."
+	if !flags.ExecScript {
+		dir = filepath.Dir(args[0])
+	}
+	rt, err := scriptRuntime(dir)
+	if err != nil {
+		return err
+	}
+	if len(options) > 0 {
+		rt = optionRuntime{Runtime: rt, options: options}
+	}
+	return executeE(ctx, rt, script, flags.Format, flags.Profile, now, params, compileOpts...)
 }
 
 func configureTracing(ctx context.Context) (context.Context, func(), error) {
@@ -105,24 +172,58 @@ func configureTracing(ctx context.Context) (context.Context, func(), error) {
 
 const DefaultInfluxDBHost = "http://localhost:9999"
 
+// injectDependencies injects the dependencies configured
+// by the selected profile of the configuration file.
 func injectDependencies(ctx context.Context) (context.Context, *dependency.Span) {
-	deps := dependencies.NewDefaultDependencies(DefaultInfluxDBHost)
-	return dependency.Inject(ctx, deps)
+	return dependency.Inject(ctx, config.dependencies())
+}
+
+// loadConfigE loads the configuration file before any command runs.
+// The feature flags of the profile are merged with the --features flag.
+func loadConfigE(cmd *cobra.Command, args []string) error {
+	p, err := loadConfig(configFlags.Path, configFlags.Profile)
+	if err != nil {
+		return err
+	}
+	config = p
+	flags.Features, err = config.featureFlags(flags.Features)
+	return err
 }
 
 func main() {
+	// Cache the standard library in the user cache directory
+	// so repeated invocations do not pay to load it each time.
+	if os.Getenv(runtime.StdlibCacheEnv) == "" {
+		if dir, err := os.UserCacheDir(); err == nil {
+			runtime.SetStdlibCacheDir(filepath.Join(dir, "flux"))
+		}
+	}
+
 	fluxCmd := &cobra.Command{
 		Use:           "flux",
 		Args:          cobra.MaximumNArgs(1),
 		RunE:          runE,
 		SilenceUsage:  true,
 		SilenceErrors: true,
+
+		PersistentPreRunE: loadConfigE,
 	}
+	fluxCmd.PersistentFlags().StringVar(&configFlags.Path, "config", "", "Configuration file for the InfluxDB host, secrets, filesystem, URL policy, HTTP timeout and features. Defaults to $FLUX_CONFIG or the first flux.toml, flux.yaml or flux.yml in the current directory or the user configuration directory")
+	fluxCmd.PersistentFlags().StringVar(&configFlags.Profile, "config-profile", "", "Name of the profile to use from the configuration file. Defaults to $FLUX_CONFIG_PROFILE or default")
 	fluxCmd.Flags().BoolVarP(&flags.ExecScript, "exec", "e", false, "Interpret file argument as a raw flux script")
 	fluxCmd.Flags().BoolVarP(&flags.EnableSuggestions, "enable-suggestions", "", false, "enable suggestions in the repl")
+	fluxCmd.Flags().BoolVar(&flags.NoHistory, "no-history", false, "Do not read or save the history of the repl in the history file of the user configuration directory")
 	fluxCmd.Flags().StringVar(&flags.Trace, "trace", "", "Trace query execution")
-	fluxCmd.Flags().StringVarP(&flags.Format, "format", "", "cli", "Output format one of: cli,csv. Defaults to cli")
+	fluxCmd.Flags().StringVarP(&flags.Format, "format", "", "cli", "Output format one of: cli,csv,json,ndjson,arrow. Defaults to cli")
 	fluxCmd.Flag("trace").NoOptDefVal = "jaeger"
+	fluxCmd.Flags().StringSliceVar(&flags.Profile, "profile", nil, "Comma separated list of profilers to enable and print after the query results. Using --profile without a value enables the query and operator profilers")
+	fluxCmd.Flag("profile").NoOptDefVal = "query,operator"
+	fluxCmd.Flags().StringArrayVar(&flags.Params, "param", nil, "Parameter of the form key=value added to the "+lang.ParamsIdentifier+" record available to the script. Values that are Flux literals such as 1h or 2020-01-01T00:00:00Z keep their type, other values are strings. May be repeated")
+	fluxCmd.Flags().StringArrayVar(&flags.Options, "option", nil, "Option of the form name=value or pkg.name=value that overrides the option declared by the script, where pkg is the name a package is imported as or its path. Values are typed like --param. May be repeated")
+	fluxCmd.Flags().StringArrayVar(&flags.Paths, "path", nil, "Directory to search for local packages imported by the script or the REPL in addition to the flux.mod module of the script, or of the working directory for the REPL, and $FLUXPATH. May be repeated")
+	fluxCmd.Flags().StringSliceVar(&flags.Workers, "workers", nil, "Comma separated list of URLs of flux worker processes that execute the parallel parts of the query")
+	fluxCmd.Flags().IntVar(&flags.LocalWorkers, "local-workers", 0, "Number of flux worker processes to spawn on this machine for the duration of the query, in addition to any --workers. Annotated CSV sources are split into one partition per worker")
+	fluxCmd.Flags().StringVar(&flags.Now, "now", "", "RFC3339 timestamp to use as the value of now instead of the current time")
 	fluxCmd.Flags().StringVar(&flags.Features, "features", "", "JSON object specifying the features to execute with. See internal/feature/flags.yml for a list of the current features")
 
 	fmtCmd := &cobra.Command{
@@ -139,6 +240,120 @@ func main() {
 	testCmd := fluxcmd.TestCommand(NewTestExecutor)
 	fluxCmd.AddCommand(testCmd)
 
+	explainCmd := &cobra.Command{
+		Use:   "explain",
+		Short: "Print the query plan of a Flux script",
+		Long:  "Print the query plan of a Flux script and the planner rules that were applied (flux explain [--physical] [--dot] <file>). The plan is printed as text with one node per line, listed after the nodes it reads from. With --dot the plan is printed as a Graphviz DOT document and the planner rules are written to stderr",
+		Args:  cobra.ExactArgs(1),
+		RunE:  explainE,
+	}
+	explainCmd.Flags().BoolVar(&explainFlags.Physical, "physical", false, "Print the physical plan instead of the logical plan")
+	explainCmd.Flags().BoolVar(&explainFlags.DOT, "dot", false, "Print the plan as a Graphviz DOT document with node details as labels")
+	fluxCmd.AddCommand(explainCmd)
+
+	checkCmd := &cobra.Command{
+		Use:   "check",
+		Short: "Check Flux scripts for errors and common mistakes",
+		Long:  "Analyze Flux scripts without executing them and report errors and warnings (flux check [--format text|json] <directory | file>...). Unused symbols are reported when the unusedSymbolWarnings feature is enabled (--features '{\"unusedSymbolWarnings\": true}'). The exit code is non-zero when errors are found",
+		Args:  cobra.MinimumNArgs(1),
+		RunE:  checkE,
+	}
+	checkCmd.Flags().StringVar(&checkFlags.Format, "format", "text", "Output format one of: text,json")
+	fluxCmd.AddCommand(checkCmd)
+
+	serveCmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Serve Flux queries over HTTP",
+		Long:  "Serve Flux queries over HTTP using an endpoint compatible with the InfluxDB /api/v2/query endpoint",
+		Args:  cobra.NoArgs,
+		RunE:  serveE,
+	}
+	serveCmd.Flags().StringVar(&serveFlags.Addr, "addr", "localhost:8086", "Address to listen on")
+	fluxCmd.AddCommand(serveCmd)
+
+	workerCmd := &cobra.Command{
+		Use:   "worker",
+		Short: "Execute the parallel parts of queries for other flux processes",
+		Long:  "Serve the partitions of parallel sub-plans sent by flux processes started with --workers over HTTP",
+		Args:  cobra.NoArgs,
+		RunE:  workerE,
+	}
+	workerCmd.Flags().StringVar(&workerFlags.Addr, "addr", "localhost:8087", "Address to listen on. A port of 0 picks a free port, the address is printed once the worker accepts partitions")
+	workerCmd.Flags().StringVar(&flags.Features, "features", "", "JSON object specifying the features to execute with. See internal/feature/flags.yml for a list of the current features")
+	fluxCmd.AddCommand(workerCmd)
+
+	benchCmd := &cobra.Command{
+		Use:   "bench",
+		Short: "Benchmark a Flux script",
+		Long:  "Run a Flux script or one of its testcases repeatedly and report wall time percentiles, rows produced, memory allocated and GC cycles (flux bench [--count N] [--testcase name] [--baseline file] <file>). The exit code is non-zero when the benchmark regressed compared to the baseline",
+		Args:  cobra.ExactArgs(1),
+		RunE:  benchE,
+	}
+	benchCmd.Flags().IntVar(&benchFlags.Count, "count", 10, "Number of measured runs")
+	benchCmd.Flags().IntVar(&benchFlags.Warmup, "warmup", 1, "Number of runs before measuring that are not included in the results")
+	benchCmd.Flags().StringVar(&benchFlags.Testcase, "testcase", "", "Name of the testcase in the file to benchmark instead of the whole script")
+	benchCmd.Flags().StringVar(&benchFlags.Baseline, "baseline", "", "JSON file with the results of a previous run to compare against")
+	benchCmd.Flags().StringVar(&benchFlags.Save, "save", "", "File to save the results to as JSON for use as a baseline")
+	benchCmd.Flags().Float64Var(&benchFlags.Threshold, "threshold", 0.1, "Ratio by which a result may exceed the baseline before it is a regression")
+	benchCmd.Flags().StringVar(&benchFlags.Format, "format", "text", "Output format one of: text,json")
+	fluxCmd.AddCommand(benchCmd)
+
+	runTasksCmd := &cobra.Command{
+		Use:   "run-tasks",
+		Short: "Run Flux tasks locally on their schedules",
+		Long:  "Run the Flux scripts in a directory that declare option task on their every or cron schedules with now set to the scheduled time (flux run-tasks [--once] [--state file] [--history file] <directory>). The last successful run of each task is kept in the state file so tasks.lastSuccess works between runs",
+		Args:  cobra.ExactArgs(1),
+		RunE:  runTasksE,
+	}
+	runTasksCmd.Flags().StringVar(&runTasksFlags.State, "state", "", "File to keep the last run and last successful run of each task in. Defaults to "+defaultTasksState+" in the task directory")
+	runTasksCmd.Flags().StringVar(&runTasksFlags.History, "history", "", "File to append the run history to as JSON lines")
+	runTasksCmd.Flags().BoolVar(&runTasksFlags.Once, "once", false, "Run every task once with now set to the current time and exit")
+	fluxCmd.AddCommand(runTasksCmd)
+
+	mdCmd := &cobra.Command{
+		Use:   "md",
+		Short: "Work with Markdown notebooks that contain Flux code blocks",
+	}
+	mdRunCmd := &cobra.Command{
+		Use:   "run",
+		Short: "Execute the Flux code blocks of a Markdown notebook",
+		Long:  "Execute each flux code block of a Markdown document in order within one shared scope and embed the result of each block beneath it (flux md run [--check] [--write | --output file] <file.md>). Errors are embedded in place of the result. With --check the document is not written and the exit code is non-zero when an embedded result is stale",
+		Args:  cobra.ExactArgs(1),
+		RunE:  mdRunE,
+	}
+	mdRunCmd.Flags().BoolVar(&mdRunFlags.Check, "check", false, "Report the blocks whose embedded output differs from their result instead of writing the document")
+	mdRunCmd.Flags().BoolVarP(&mdRunFlags.Write, "write", "w", false, "Write the document with the results to the source file instead of stdout")
+	mdRunCmd.Flags().StringVarP(&mdRunFlags.Output, "output", "o", "", "File to write the document with the results to instead of stdout")
+	mdRunCmd.Flags().StringVar(&mdRunFlags.Format, "format", "markdown", "Format of the embedded results one of: markdown,csv")
+	mdRunCmd.Flags().StringVar(&mdRunFlags.Now, "now", "", "RFC3339 timestamp to use as the value of now so results are reproducible")
+	mdCmd.AddCommand(mdRunCmd)
+	fluxCmd.AddCommand(mdCmd)
+
+	debugCmd := &cobra.Command{
+		Use:   "debug",
+		Short: "Debug a Flux script",
+		Long:  "Evaluate a Flux script with breakpoints by line, stepping over, into and out of function calls, inspection of variables and previews of table streams (flux debug [--break line]... <file>). Without breakpoints the script pauses before its first statement. With --dap the Debug Adapter Protocol is served over stdin and stdout so editors can drive the debugger",
+		Args:  cobra.MaximumNArgs(1),
+		RunE:  debugE,
+	}
+	debugCmd.Flags().IntSliceVarP(&debugFlags.Breakpoints, "break", "b", nil, "Line to pause at before its statement is evaluated. May be repeated")
+	debugCmd.Flags().BoolVar(&debugFlags.DAP, "dap", false, "Serve the Debug Adapter Protocol over stdin and stdout, the script is given by the launch request")
+	debugCmd.Flags().StringVar(&debugFlags.Now, "now", "", "RFC3339 timestamp to use as the value of now instead of the current time")
+	fluxCmd.AddCommand(debugCmd)
+
+	docCmd := &cobra.Command{
+		Use:   "doc",
+		Short: "Print the documentation of Flux packages",
+		Long:  "Print the signature, description, parameters and examples of a package or one of its members (flux doc <pkg>[.<name>]), list all packages when no package is given or the packages and members with a tag (flux doc --tag <tag>). Packages are read from the Flux sources of the roots given by --root, the nearest directory at or above the working directory with a fluxtest.root file and the standard library",
+		Args:  cobra.MaximumNArgs(1),
+		RunE:  docE,
+	}
+	docCmd.Flags().BoolVar(&docFlags.JSON, "json", false, "Print the documentation as JSON")
+	docCmd.Flags().StringVar(&docFlags.Tag, "tag", "", "List the packages and members with the tag")
+	docCmd.Flags().StringArrayVar(&docFlags.Roots, "root", nil, "Directory of Flux packages whose import paths are relative to it. May be repeated")
+	docCmd.Flags().StringVar(&docFlags.Stdlib, "stdlib", "", "Directory of the standard library sources. Defaults to $FLUX_STDLIB, the stdlib directory of a Flux source tree at or above the working directory or the sources embedded in the binary")
+	fluxCmd.AddCommand(docCmd)
+
 	if err := fluxCmd.Execute(); err != nil {
 		if _, ok := err.(silentError); !ok {
 			fmt.Fprintln(fluxCmd.OutOrStderr(), err)
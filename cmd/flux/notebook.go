@@ -0,0 +1,294 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	fluxcmd "github.com/influxdata/flux/cmd/flux/cmd"
+	"github.com/influxdata/flux/codes"
+	"github.com/influxdata/flux/fluxinit"
+	"github.com/influxdata/flux/internal/errors"
+	"github.com/influxdata/flux/repl"
+	"github.com/spf13/cobra"
+)
+
+var mdRunFlags struct {
+	Check  bool
+	Write  bool
+	Output string
+	Format string
+	Now    string
+}
+
+// The output of a Flux code block is embedded in the document
+// between these markers right after the block. The markers are
+// HTML comments so they are not rendered.
+const (
+	outputStart = "<!-- flux:output -->"
+	outputEnd   = "<!-- flux:output:end -->"
+)
+
+// notebookStale is returned by --check when the embedded output of
+// a block differs from its result. The stale blocks have already
+// been reported so it is not printed.
+type notebookStale struct{}
+
+func (notebookStale) Error() string { return "notebook output is stale" }
+func (notebookStale) Silent()       {}
+
+func mdRunE(cmd *cobra.Command, args []string) error {
+	if mdRunFlags.Format != "markdown" && mdRunFlags.Format != "csv" {
+		return errors.Newf(codes.Invalid, "unknown output format %q, must be one of: markdown,csv", mdRunFlags.Format)
+	}
+	if mdRunFlags.Check && (mdRunFlags.Write || mdRunFlags.Output != "") {
+		return errors.New(codes.Invalid, "--check does not write the notebook and cannot be used with --write or --output")
+	}
+	if mdRunFlags.Write && mdRunFlags.Output != "" {
+		return errors.New(codes.Invalid, "only one of --write and --output may be used")
+	}
+
+	path := args[0]
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	nb, err := parseNotebook(string(content))
+	if err != nil {
+		return errors.Wrapf(err, codes.Inherit, "failed to parse %s", path)
+	}
+
+	fluxinit.FluxInit()
+	ctx, span := injectDependencies(context.Background())
+	defer span.Finish()
+
+	ctx, err = fluxcmd.WithFeatureFlags(ctx, flags.Features)
+	if err != nil {
+		return err
+	}
+
+	outputs, err := runNotebook(ctx, nb, mdRunFlags.Format, mdRunFlags.Now)
+	if err != nil {
+		return err
+	}
+
+	if mdRunFlags.Check {
+		stale := false
+		for i, b := range nb.blocks {
+			if b.output != outputs[i] {
+				fmt.Fprintf(os.Stderr, "%s:%d: output of flux block is stale\n", path, b.line)
+				stale = true
+			}
+		}
+		if stale {
+			return notebookStale{}
+		}
+		return nil
+	}
+
+	for i := range nb.blocks {
+		nb.blocks[i].output = outputs[i]
+	}
+	switch {
+	case mdRunFlags.Write:
+		return os.WriteFile(path, []byte(nb.String()), 0644)
+	case mdRunFlags.Output != "":
+		return os.WriteFile(mdRunFlags.Output, []byte(nb.String()), 0644)
+	default:
+		_, err := fmt.Fprint(cmd.OutOrStdout(), nb.String())
+		return err
+	}
+}
+
+// runNotebook executes the blocks of the notebook in order within
+// one REPL session so later blocks see the variables of earlier ones.
+// It returns the rendered output of each block. Errors of a block are
+// part of its output and do not stop the blocks that follow.
+func runNotebook(ctx context.Context, nb *notebook, format, now string) ([]string, error) {
+	var buf bytes.Buffer
+	r := repl.New(ctx, repl.Output(&buf), repl.Format(format))
+	if now != "" {
+		t, err := time.Parse(time.RFC3339Nano, now)
+		if err != nil {
+			return nil, errors.Wrapf(err, codes.Invalid, "invalid value for --now %q, must be an RFC3339 timestamp", now)
+		}
+		if _, err := r.Eval("option now = () => " + t.Format(time.RFC3339Nano)); err != nil {
+			return nil, err
+		}
+	}
+
+	outputs := make([]string, len(nb.blocks))
+	for i, b := range nb.blocks {
+		buf.Reset()
+		_, err := r.Input(b.src)
+		outputs[i] = renderOutput(buf.String(), format, err)
+	}
+	return outputs, nil
+}
+
+// renderOutput returns the Markdown embedded after a block. CSV is
+// written as a code block and an error is written as a quote after
+// the output that was written before it occurred.
+func renderOutput(out, format string, err error) string {
+	out = strings.TrimSpace(strings.ReplaceAll(out, "\r\n", "\n"))
+	if out != "" && format == "csv" {
+		out = "```csv\n" + out + "\n```"
+	}
+	if err != nil {
+		if out != "" {
+			out += "\n\n"
+		}
+		msg := strings.TrimSpace(err.Error())
+		out += "> **Error:** " + strings.ReplaceAll(msg, "\n", "\n> ")
+	}
+	return out
+}
+
+// notebook is a Markdown document with Flux code blocks. The text
+// before each block is kept as is so the document can be written
+// back with only the output of the blocks changed.
+type notebook struct {
+	// text holds the Markdown before each block and,
+	// as its last element, the Markdown after the last block.
+	text   []string
+	blocks []notebookBlock
+}
+
+// notebookBlock is a fenced code block with the flux info string.
+type notebookBlock struct {
+	// line is the line number of the opening fence.
+	line int
+	// fence is the code block including its fences.
+	fence string
+	// src is the Flux source within the fences.
+	src string
+	// output is the output embedded after the block
+	// or empty if it has none.
+	output string
+}
+
+// parseNotebook splits the document into its Flux code blocks and
+// the text between them. Output that is embedded right after a block
+// is removed from the text and kept with the block.
+func parseNotebook(doc string) (*notebook, error) {
+	lines := strings.SplitAfter(doc, "\n")
+	nb := &notebook{}
+	var text strings.Builder
+	for i := 0; i < len(lines); i++ {
+		indent, marker, info, ok := openingFence(lines[i])
+		if !ok {
+			text.WriteString(lines[i])
+			continue
+		}
+		end := i + 1
+		for end < len(lines) && !isClosingFence(lines[end], marker) {
+			end++
+		}
+		if end == len(lines) {
+			return nil, errors.Newf(codes.Invalid, "line %d: code block is not closed", i+1)
+		}
+		fence := strings.Join(lines[i:end+1], "")
+		if lang := strings.Fields(info); len(lang) == 0 || lang[0] != "flux" {
+			text.WriteString(fence)
+			i = end
+			continue
+		}
+
+		b := notebookBlock{line: i + 1, fence: fence}
+		var src strings.Builder
+		for _, l := range lines[i+1 : end] {
+			src.WriteString(trimIndent(l, indent))
+		}
+		b.src = src.String()
+		i = end
+
+		// The output may follow the block after blank lines.
+		next := i + 1
+		for next < len(lines) && strings.TrimSpace(lines[next]) == "" {
+			next++
+		}
+		if next < len(lines) && strings.TrimSpace(lines[next]) == outputStart {
+			stop := next + 1
+			for stop < len(lines) && strings.TrimSpace(lines[stop]) != outputEnd {
+				stop++
+			}
+			if stop == len(lines) {
+				return nil, errors.Newf(codes.Invalid, "line %d: output is not closed with %s", next+1, outputEnd)
+			}
+			b.output = strings.TrimSpace(strings.Join(lines[next+1:stop], ""))
+			i = stop
+		}
+
+		nb.text = append(nb.text, text.String())
+		text.Reset()
+		nb.blocks = append(nb.blocks, b)
+	}
+	nb.text = append(nb.text, text.String())
+	return nb, nil
+}
+
+// String returns the document with the output of each block
+// embedded after it.
+func (nb *notebook) String() string {
+	var sb strings.Builder
+	for i, b := range nb.blocks {
+		sb.WriteString(nb.text[i])
+		sb.WriteString(b.fence)
+		if !strings.HasSuffix(b.fence, "\n") {
+			sb.WriteString("\n")
+		}
+		if b.output != "" {
+			sb.WriteString("\n" + outputStart + "\n\n")
+			sb.WriteString(b.output)
+			sb.WriteString("\n\n" + outputEnd + "\n")
+		}
+	}
+	sb.WriteString(nb.text[len(nb.text)-1])
+	return sb.String()
+}
+
+// openingFence reports whether the line opens a fenced code block
+// and returns its indentation, fence marker and info string.
+func openingFence(line string) (indent int, marker, info string, ok bool) {
+	trimmed := strings.TrimLeft(line, " ")
+	indent = len(line) - len(trimmed)
+	if indent > 3 {
+		return 0, "", "", false
+	}
+	for _, c := range []byte{'`', '~'} {
+		n := 0
+		for n < len(trimmed) && trimmed[n] == c {
+			n++
+		}
+		if n < 3 {
+			continue
+		}
+		info = strings.TrimSpace(trimmed[n:])
+		if c == '`' && strings.Contains(info, "`") {
+			return 0, "", "", false
+		}
+		return indent, trimmed[:n], info, true
+	}
+	return 0, "", "", false
+}
+
+// isClosingFence reports whether the line closes a code block
+// that was opened with marker.
+func isClosingFence(line, marker string) bool {
+	trimmed := strings.TrimSpace(line)
+	if len(line)-len(strings.TrimLeft(line, " ")) > 3 || len(trimmed) < len(marker) {
+		return false
+	}
+	return strings.Trim(trimmed, marker[:1]) == ""
+}
+
+// trimIndent removes up to n spaces from the start of the line.
+func trimIndent(line string, n int) string {
+	for i := 0; i < n && strings.HasPrefix(line, " "); i++ {
+		line = line[1:]
+	}
+	return line
+}
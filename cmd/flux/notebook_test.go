@@ -0,0 +1,133 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/influxdata/flux/codes"
+	"github.com/influxdata/flux/internal/errors"
+)
+
+const testNotebook = "# Runbook\n" +
+	"\n" +
+	"```flux\n" +
+	"x = 1\n" +
+	"```\n" +
+	"\n" +
+	"Some text.\n" +
+	"\n" +
+	"```sh\n" +
+	"flux md run runbook.md\n" +
+	"```\n" +
+	"\n" +
+	"  ```flux title=\"query\"\n" +
+	"  x + 1\n" +
+	"  ```\n" +
+	"\n" +
+	"<!-- flux:output -->\n" +
+	"\n" +
+	"```\n" +
+	"1\n" +
+	"```\n" +
+	"\n" +
+	"<!-- flux:output:end -->\n" +
+	"\n" +
+	"The end.\n"
+
+func TestParseNotebook(t *testing.T) {
+	nb, err := parseNotebook(testNotebook)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(nb.blocks) != 2 {
+		t.Fatalf("unexpected number of blocks %d", len(nb.blocks))
+	}
+
+	type block struct {
+		Line   int
+		Src    string
+		Output string
+	}
+	var got []block
+	for _, b := range nb.blocks {
+		got = append(got, block{Line: b.line, Src: b.src, Output: b.output})
+	}
+	want := []block{
+		{Line: 3, Src: "x = 1\n"},
+		{Line: 13, Src: "x + 1\n", Output: "```\n1\n```"},
+	}
+	if !cmp.Equal(want, got) {
+		t.Fatalf("unexpected blocks -want/+got:\n%s", cmp.Diff(want, got))
+	}
+
+	// Writing the document with the output it was read with
+	// does not change it.
+	if got := nb.String(); got != testNotebook {
+		t.Errorf("unexpected document -want/+got:\n%s", cmp.Diff(testNotebook, got))
+	}
+
+	// The output replaces the previous output and reading
+	// the document again returns the new output.
+	nb.blocks[0].output = "> **Error:** failed"
+	nb.blocks[1].output = "```\n2\n```"
+	nb, err = parseNotebook(nb.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := nb.blocks[0].output; got != "> **Error:** failed" {
+		t.Errorf("unexpected output %q", got)
+	}
+	if got := nb.blocks[1].output; got != "```\n2\n```" {
+		t.Errorf("unexpected output %q", got)
+	}
+}
+
+func TestParseNotebook_Errors(t *testing.T) {
+	for _, doc := range []string{
+		"```flux\nx = 1\n",
+		"```flux\nx = 1\n```\n\n<!-- flux:output -->\n1\n",
+	} {
+		if _, err := parseNotebook(doc); errors.Code(err) != codes.Invalid {
+			t.Errorf("expected invalid error for %q, got %v", doc, err)
+		}
+	}
+}
+
+func TestRenderOutput(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		out    string
+		format string
+		err    error
+		want   string
+	}{
+		{
+			name:   "markdown",
+			out:    "Result: _result\n\nTable: keys: []\n\n| a:int |\n| --- |\n| 1 |\n",
+			format: "markdown",
+			want:   "Result: _result\n\nTable: keys: []\n\n| a:int |\n| --- |\n| 1 |",
+		},
+		{
+			name:   "csv",
+			out:    "#datatype,string,long\r\n,result,table\r\n\r\n",
+			format: "csv",
+			want:   "```csv\n#datatype,string,long\n,result,table\n```",
+		},
+		{
+			name:   "error",
+			format: "markdown",
+			err:    errors.New(codes.Invalid, "undefined identifier y\nat 1:1"),
+			want:   "> **Error:** undefined identifier y\n> at 1:1",
+		},
+		{
+			name:   "empty",
+			format: "markdown",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := renderOutput(tc.out, tc.format, tc.err); got != tc.want {
+				t.Errorf("unexpected output -want/+got:\n%s", cmp.Diff(tc.want, got))
+			}
+		})
+	}
+}
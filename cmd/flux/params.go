@@ -0,0 +1,235 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/influxdata/flux"
+	"github.com/influxdata/flux/ast"
+	"github.com/influxdata/flux/ast/edit"
+	"github.com/influxdata/flux/codes"
+	"github.com/influxdata/flux/internal/errors"
+	"github.com/influxdata/flux/lang"
+	"github.com/influxdata/flux/parser"
+)
+
+var identifierPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// parseNow returns the time to use for now, which is the
+// time given by --now or the current time.
+func parseNow(now string) (time.Time, error) {
+	if now == "" {
+		return time.Now(), nil
+	}
+	t, err := time.Parse(time.RFC3339Nano, now)
+	if err != nil {
+		return time.Time{}, errors.Wrapf(err, codes.Invalid, "invalid value for --now %q, must be an RFC3339 timestamp", now)
+	}
+	return t, nil
+}
+
+// parseParams returns the params record of the script. Each param of the
+// form key=value becomes a field of the record. A nil record is returned
+// when there are no params.
+func parseParams(params []string) (lang.Params, error) {
+	if len(params) == 0 {
+		return nil, nil
+	}
+
+	record := make(lang.Params, len(params))
+	for _, param := range params {
+		key, expr, err := parseKeyValue("--param", param)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := record[key]; ok {
+			return nil, errors.Newf(codes.Invalid, "duplicate param %q", key)
+		}
+		value, err := paramValue(expr)
+		if err != nil {
+			return nil, errors.Wrapf(err, codes.Invalid, "invalid value for --param %q", param)
+		}
+		record[key] = value
+	}
+	return record, nil
+}
+
+// paramValue returns the value of a param for the literal returned by
+// parseParamValue. Regular expressions and durations with months or years
+// have no param value.
+func paramValue(expr ast.Expression) (interface{}, error) {
+	switch e := expr.(type) {
+	case *ast.StringLiteral:
+		return e.Value, nil
+	case *ast.IntegerLiteral:
+		return e.Value, nil
+	case *ast.FloatLiteral:
+		return e.Value, nil
+	case *ast.DateTimeLiteral:
+		return e.Value, nil
+	case *ast.DurationLiteral:
+		for _, d := range e.Values {
+			if d.Unit == ast.MonthUnit || d.Unit == ast.YearUnit {
+				return nil, errors.New(codes.Invalid, "durations with months or years are not supported")
+			}
+		}
+		return ast.DurationFrom(e, time.Time{})
+	case *ast.Identifier:
+		return e.Name == "true", nil
+	case *ast.UnaryExpression:
+		v, err := paramValue(e.Argument)
+		if err != nil {
+			return nil, err
+		}
+		switch v := v.(type) {
+		case int64:
+			return -v, nil
+		case float64:
+			return -v, nil
+		case time.Duration:
+			return -v, nil
+		}
+	case *ast.RegexpLiteral:
+		return nil, errors.New(codes.Invalid, "regular expressions are not supported")
+	}
+	return nil, errors.Newf(codes.Internal, "unexpected param literal %s", expr.Type())
+}
+
+// scriptOption is an option given on the command line.
+type scriptOption struct {
+	// pkg is the name or the path of the package of the option.
+	// It is empty for an option of the script.
+	pkg   string
+	name  string
+	value ast.Expression
+}
+
+var qualifiedOptionPattern = regexp.MustCompile(`^([A-Za-z0-9_/]+)\.([A-Za-z_][A-Za-z0-9_]*)$`)
+
+// parseOptions parses the options of the form name=value or pkg.name=value
+// where pkg is the name a package is imported as by the script or its path.
+func parseOptions(options []string) ([]scriptOption, error) {
+	opts := make([]scriptOption, 0, len(options))
+	for _, option := range options {
+		key, value, ok := strings.Cut(option, "=")
+		if !ok {
+			return nil, errors.Newf(codes.Invalid, "invalid value for --option %q, must be of the form name=value", option)
+		}
+		opt := scriptOption{name: key, value: parseParamValue(value)}
+		if m := qualifiedOptionPattern.FindStringSubmatch(key); m != nil {
+			opt.pkg, opt.name = m[1], m[2]
+		} else if !identifierPattern.MatchString(key) {
+			return nil, errors.Newf(codes.Invalid, "invalid name for --option %q, must be an identifier or a package and an identifier", key)
+		}
+		opts = append(opts, opt)
+	}
+	return opts, nil
+}
+
+// setOptions sets the options in the first file of the script. An option
+// that the script declares has its value replaced so that the value given
+// on the command line is used, other options are added before the first
+// statement of the script. The package of an option that the script does
+// not import is imported by its path.
+func setOptions(pkg *ast.Package, options []scriptOption) {
+	if len(pkg.Files) == 0 {
+		return
+	}
+	file := pkg.Files[0]
+	for _, opt := range options {
+		name := opt.name
+		if opt.pkg != "" {
+			name = importedAs(file, opt.pkg) + "." + opt.name
+		}
+		edit.SetOption(file, name, opt.value)
+	}
+}
+
+// importedAs returns the name the package is imported as in the file.
+// The package is given by that name or by its path and it is imported
+// when the file does not import it yet.
+func importedAs(file *ast.File, pkg string) string {
+	for _, imp := range file.Imports {
+		if name, _ := edit.ImportName(file, imp.Path.Value); name == pkg {
+			return name
+		}
+	}
+	if name, ok := edit.ImportName(file, pkg); ok {
+		return name
+	}
+	file.Imports = append(file.Imports, &ast.ImportDeclaration{
+		Path: &ast.StringLiteral{Value: pkg},
+	})
+	return path.Base(pkg)
+}
+
+// optionRuntime is a flux.Runtime that sets the options given on the
+// command line in the scripts it parses, so they take precedence over
+// the options that the scripts declare.
+type optionRuntime struct {
+	flux.Runtime
+	options []scriptOption
+}
+
+func (rt optionRuntime) Parse(ctx context.Context, src string) (flux.ASTHandle, error) {
+	pkg := parser.ParseSource(src)
+	if ast.Check(pkg) > 0 {
+		// The runtime reports the syntax errors.
+		return rt.Runtime.Parse(ctx, src)
+	}
+	setOptions(pkg, rt.options)
+	bs, err := json.Marshal(pkg)
+	if err != nil {
+		return nil, err
+	}
+	return rt.Runtime.JSONToHandle(bs)
+}
+
+func parseKeyValue(flag, s string) (string, ast.Expression, error) {
+	key, value, ok := strings.Cut(s, "=")
+	if !ok {
+		return "", nil, errors.Newf(codes.Invalid, "invalid value for %s %q, must be of the form key=value", flag, s)
+	}
+	if !identifierPattern.MatchString(key) {
+		return "", nil, errors.Newf(codes.Invalid, "invalid key for %s %q, must be a valid identifier", flag, key)
+	}
+	return key, parseParamValue(value), nil
+}
+
+// parseParamValue returns the Flux literal for the value so that numbers,
+// booleans, durations and times are typed. Any value that is not a single
+// literal, such as telegraf, is a string.
+func parseParamValue(value string) ast.Expression {
+	pkg := parser.ParseSource("x = " + value)
+	if ast.Check(pkg) > 0 || len(pkg.Files) != 1 || len(pkg.Files[0].Body) != 1 {
+		return &ast.StringLiteral{Value: value}
+	}
+	a, ok := pkg.Files[0].Body[0].(*ast.VariableAssignment)
+	if !ok || !isLiteral(a.Init) {
+		return &ast.StringLiteral{Value: value}
+	}
+	return a.Init
+}
+
+func isLiteral(expr ast.Expression) bool {
+	switch e := expr.(type) {
+	case *ast.IntegerLiteral, *ast.FloatLiteral, *ast.DurationLiteral, *ast.DateTimeLiteral,
+		*ast.StringLiteral, *ast.RegexpLiteral:
+		return true
+	case *ast.Identifier:
+		return e.Name == "true" || e.Name == "false"
+	case *ast.UnaryExpression:
+		if e.Operator != ast.SubtractionOperator {
+			return false
+		}
+		switch e.Argument.(type) {
+		case *ast.IntegerLiteral, *ast.FloatLiteral, *ast.DurationLiteral:
+			return true
+		}
+	}
+	return false
+}
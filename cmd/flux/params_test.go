@@ -0,0 +1,224 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/influxdata/flux/ast"
+	"github.com/influxdata/flux/ast/asttest"
+	"github.com/influxdata/flux/ast/edit"
+	"github.com/influxdata/flux/lang"
+	"github.com/influxdata/flux/parser"
+	"github.com/influxdata/flux/runtime"
+)
+
+func TestParseParams(t *testing.T) {
+	params, err := parseParams([]string{
+		"bucket=telegraf",
+		"start=-1h",
+		`name="a b"`,
+		"n=10",
+		"f=1.5",
+		"ok=true",
+		"now=2026-01-01T00:00:00Z",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := lang.Params{
+		"bucket": "telegraf",
+		"start":  -time.Hour,
+		"name":   "a b",
+		"n":      int64(10),
+		"f":      1.5,
+		"ok":     true,
+		"now":    time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+	if !cmp.Equal(want, params) {
+		t.Errorf("unexpected params -want/+got:\n%s", cmp.Diff(want, params))
+	}
+}
+
+func TestParseParams_Empty(t *testing.T) {
+	params, err := parseParams(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if params != nil {
+		t.Errorf("expected no params, got %v", params)
+	}
+}
+
+func TestParseParams_Invalid(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		params []string
+		want   string
+	}{
+		{
+			name:   "missing value",
+			params: []string{"bucket"},
+			want:   `invalid value for --param "bucket", must be of the form key=value`,
+		},
+		{
+			name:   "invalid key",
+			params: []string{"my-bucket=telegraf"},
+			want:   `invalid key for --param "my-bucket", must be a valid identifier`,
+		},
+		{
+			name:   "duplicate",
+			params: []string{"a=1", "a=2"},
+			want:   `duplicate param "a"`,
+		},
+		{
+			name:   "regexp",
+			params: []string{"host=/a.*/"},
+			want:   `invalid value for --param "host=/a.*/": regular expressions are not supported`,
+		},
+		{
+			name:   "months",
+			params: []string{"every=1mo"},
+			want:   `invalid value for --param "every=1mo": durations with months or years are not supported`,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseParams(tt.params)
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if got := err.Error(); got != tt.want {
+				t.Errorf("unexpected error got %q want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseParamValue(t *testing.T) {
+	for _, tt := range []struct {
+		value string
+		want  ast.Expression
+	}{
+		{value: "telegraf", want: &ast.StringLiteral{}},
+		{value: "", want: &ast.StringLiteral{}},
+		{value: "10", want: &ast.IntegerLiteral{}},
+		{value: "-10", want: &ast.UnaryExpression{}},
+		{value: "1.5", want: &ast.FloatLiteral{}},
+		{value: "1h30m", want: &ast.DurationLiteral{}},
+		{value: "2026-01-01T00:00:00Z", want: &ast.DateTimeLiteral{}},
+		{value: "/cpu.*/", want: &ast.RegexpLiteral{}},
+		{value: "false", want: &ast.Identifier{}},
+		{value: "-telegraf", want: &ast.StringLiteral{}},
+	} {
+		got := parseParamValue(tt.value)
+		if got.Type() != tt.want.Type() {
+			t.Errorf("%q: unexpected expression got %s want %s", tt.value, got.Type(), tt.want.Type())
+		}
+	}
+}
+
+func TestSetOptions(t *testing.T) {
+	options, err := parseOptions([]string{
+		"bucket=telegraf",
+		"v.timeRangeStart=-1h",
+		"influxdata/influxdb/monitor.bucket=alerts",
+		"every=1m",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkg := parser.ParseSource(`import v "influxdata/influxdb/v1"
+
+option bucket = "default"
+option v.timeRangeStart = -5m
+
+x = bucket`)
+	if ast.Check(pkg) > 0 {
+		t.Fatal(ast.GetError(pkg))
+	}
+	setOptions(pkg, options)
+
+	file := pkg.Files[0]
+	for _, tt := range []struct {
+		name string
+		want string
+	}{
+		{name: "bucket", want: "telegraf"},
+		{name: "v.timeRangeStart", want: "-1h"},
+		{name: "monitor.bucket", want: "alerts"},
+		{name: "every", want: "1m"},
+	} {
+		expr, err := edit.GetOption(file, tt.name)
+		if err != nil {
+			t.Errorf("option %s: %s", tt.name, err)
+			continue
+		}
+		if want := parseParamValue(tt.want); !cmp.Equal(want, expr, asttest.IgnoreBaseNodeOptions...) {
+			t.Errorf("unexpected value for option %s -want/+got:\n%s", tt.name, cmp.Diff(want, expr, asttest.IgnoreBaseNodeOptions...))
+		}
+	}
+	if name, ok := edit.ImportName(file, "influxdata/influxdb/monitor"); !ok || name != "monitor" {
+		t.Errorf("expected the package of the option to be imported, got %q", name)
+	}
+	// The options declared by the script are replaced
+	// instead of being declared again.
+	if edit.HasDuplicateOptions(file, "bucket") {
+		t.Error("option bucket is declared twice")
+	}
+}
+
+func TestParseOptions_Invalid(t *testing.T) {
+	for _, tt := range []struct {
+		option string
+		want   string
+	}{
+		{
+			option: "bucket",
+			want:   `invalid value for --option "bucket", must be of the form name=value`,
+		},
+		{
+			option: "=1",
+			want:   `invalid name for --option "", must be an identifier or a package and an identifier`,
+		},
+		{
+			option: "v.time-range=1",
+			want:   `invalid name for --option "v.time-range", must be an identifier or a package and an identifier`,
+		},
+	} {
+		_, err := parseOptions([]string{tt.option})
+		if err == nil {
+			t.Errorf("%q: expected error", tt.option)
+			continue
+		}
+		if got := err.Error(); got != tt.want {
+			t.Errorf("%q: unexpected error got %q want %q", tt.option, got, tt.want)
+		}
+	}
+}
+
+func TestOptionRuntime(t *testing.T) {
+	options, err := parseOptions([]string{"bucket=telegraf"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rt := optionRuntime{Runtime: runtime.Default, options: options}
+
+	ctx, span := injectDependencies(context.Background())
+	defer span.Finish()
+
+	script := `import "array"
+
+option bucket = "default"
+
+array.from(rows: [{bucket: bucket}])`
+	var out bytes.Buffer
+	if err := executeTo(ctx, &out, rt, script, "csv", nil, time.Now(), nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := out.String(); !strings.Contains(got, "telegraf") || strings.Contains(got, "default") {
+		t.Errorf("expected the option given on the command line to override the script, got:\n%s", got)
+	}
+}
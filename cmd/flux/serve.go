@@ -0,0 +1,319 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"os/signal"
+	"time"
+	"unicode/utf8"
+
+	"github.com/influxdata/flux"
+	fluxcmd "github.com/influxdata/flux/cmd/flux/cmd"
+	"github.com/influxdata/flux/codes"
+	"github.com/influxdata/flux/csv"
+	"github.com/influxdata/flux/dependency"
+	"github.com/influxdata/flux/fluxinit"
+	"github.com/influxdata/flux/internal/errors"
+	"github.com/influxdata/flux/lang"
+	"github.com/influxdata/flux/memory"
+	"github.com/influxdata/flux/runtime"
+	"github.com/spf13/cobra"
+)
+
+var serveFlags struct {
+	Addr string
+}
+
+// queryPath is the path of the InfluxDB query endpoint served by flux serve.
+const queryPath = "/api/v2/query"
+
+func serveE(cmd *cobra.Command, args []string) error {
+	fluxinit.FluxInit()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	h := &queryHandler{
+		errOut: cmd.OutOrStderr(),
+		withDependencies: func(ctx context.Context) (context.Context, *dependency.Span, error) {
+			ctx, span := injectDependencies(ctx)
+			ctx, err := fluxcmd.WithFeatureFlags(ctx, flags.Features)
+			if err != nil {
+				span.Finish()
+				return nil, nil, err
+			}
+			return ctx, span, nil
+		},
+	}
+	mux := http.NewServeMux()
+	mux.Handle(queryPath, h)
+	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		_, _ = io.WriteString(w, `{"name":"flux","status":"pass"}`)
+	})
+
+	srv := &http.Server{
+		Addr:    serveFlags.Addr,
+		Handler: mux,
+	}
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- srv.ListenAndServe()
+	}()
+	fmt.Fprintf(cmd.OutOrStderr(), "Serving Flux queries on http://%s%s\n", serveFlags.Addr, queryPath)
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	return srv.Shutdown(shutdownCtx)
+}
+
+// queryRequest is the body of a JSON query request.
+// It matches the request body accepted by the InfluxDB /api/v2/query endpoint.
+type queryRequest struct {
+	Query   string          `json:"query"`
+	Type    string          `json:"type"`
+	Dialect queryDialect    `json:"dialect"`
+	Now     time.Time       `json:"now"`
+	Extern  json.RawMessage `json:"extern,omitempty"`
+}
+
+// queryDialect describes how the csv response is formatted.
+type queryDialect struct {
+	Header         *bool    `json:"header"`
+	Delimiter      string   `json:"delimiter"`
+	Annotations    []string `json:"annotations"`
+	CommentPrefix  string   `json:"commentPrefix"`
+	DateTimeFormat string   `json:"dateTimeFormat"`
+}
+
+// decodeQueryRequest reads the query request from the http request.
+// JSON bodies are decoded as a queryRequest and any other content
+// type is treated as the raw Flux source.
+func decodeQueryRequest(r *http.Request) (*queryRequest, error) {
+	req := &queryRequest{}
+	mt, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		mt = ""
+	}
+	if mt == "application/json" {
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			return nil, errors.Wrap(err, codes.Invalid, "failed to decode request body")
+		}
+	} else {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			return nil, errors.Wrap(err, codes.Invalid, "failed to read request body")
+		}
+		req.Query = string(body)
+	}
+	return req, req.validate()
+}
+
+func (req *queryRequest) validate() error {
+	if req.Query == "" {
+		return errors.New(codes.Invalid, "query request body is empty")
+	}
+	if req.Type != "" && req.Type != "flux" {
+		return errors.Newf(codes.Invalid, "unsupported query type %q, only flux is supported", req.Type)
+	}
+	d := req.Dialect
+	if d.Delimiter != "" && utf8.RuneCountInString(d.Delimiter) != 1 {
+		return errors.New(codes.Invalid, "dialect delimiter must be a single character")
+	}
+	if d.CommentPrefix != "" && d.CommentPrefix != "#" {
+		return errors.New(codes.Invalid, `dialect commentPrefix must be "#"`)
+	}
+	switch d.DateTimeFormat {
+	case "", "RFC3339", "RFC3339Nano":
+	default:
+		return errors.Newf(codes.Invalid, "unsupported dialect dateTimeFormat %q", d.DateTimeFormat)
+	}
+	for _, a := range d.Annotations {
+		switch a {
+		case "group", "datatype", "default":
+		default:
+			return errors.Newf(codes.Invalid, "unknown dialect annotation %q", a)
+		}
+	}
+	return nil
+}
+
+// encoderConfig returns the csv encoder configuration for the dialect.
+func (d queryDialect) encoderConfig() csv.ResultEncoderConfig {
+	config := csv.ResultEncoderConfig{
+		Annotations: d.Annotations,
+		Delimiter:   ',',
+	}
+	if d.Delimiter != "" {
+		config.Delimiter, _ = utf8.DecodeRuneInString(d.Delimiter)
+	}
+	if d.Header != nil {
+		config.NoHeader = !*d.Header
+	}
+	if d.DateTimeFormat == "RFC3339" {
+		config.TimeFormat = time.RFC3339
+	}
+	return config
+}
+
+// queryHandler executes Flux queries and streams the results as csv.
+type queryHandler struct {
+	// withDependencies injects the dependencies used to execute
+	// a query into the request context.
+	withDependencies func(ctx context.Context) (context.Context, *dependency.Span, error)
+	// errOut receives the errors that can no longer be
+	// reported in the response.
+	errOut io.Writer
+}
+
+func (h *queryHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeError(w, http.StatusMethodNotAllowed, errors.Newf(codes.Invalid, "method %s not allowed", r.Method))
+		return
+	}
+
+	req, err := decodeQueryRequest(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// The request context is canceled when the client disconnects
+	// which cancels the running query.
+	ctx, span, err := h.withDependencies(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	defer span.Finish()
+
+	c := lang.FluxCompiler{
+		Now:    req.Now,
+		Extern: req.Extern,
+		Query:  req.Query,
+	}
+	prog, err := c.Compile(ctx, runtime.Default)
+	if err != nil {
+		writeError(w, statusCode(err), err)
+		return
+	}
+
+	q, err := prog.Start(ctx, &memory.ResourceAllocator{})
+	if err != nil {
+		writeError(w, statusCode(err), err)
+		return
+	}
+	results := flux.NewResultIteratorFromQuery(q)
+	defer results.Release()
+
+	dialect := csv.Dialect{ResultEncoderConfig: req.Dialect.encoderConfig()}
+	dialect.SetHeaders(w)
+	rw := &responseWriter{ResponseWriter: w}
+	if _, err := dialect.Encoder().Encode(rw, results); err != nil {
+		if rw.written == 0 {
+			// Nothing has been sent yet so the error
+			// can still be reported with a status code.
+			w.Header().Del("Transfer-Encoding")
+			writeError(w, statusCode(err), err)
+			return
+		}
+		// The response has already started and the error has been
+		// encoded into the csv output if possible.
+		fmt.Fprintf(h.errOut, "error encoding query results: %s\n", err)
+	}
+}
+
+// responseWriter counts the bytes written to the response
+// and exposes the Flush method of the underlying writer
+// so results are flushed as they are encoded.
+type responseWriter struct {
+	http.ResponseWriter
+	written int64
+}
+
+func (w *responseWriter) Write(p []byte) (int, error) {
+	n, err := w.ResponseWriter.Write(p)
+	w.written += int64(n)
+	return n, err
+}
+
+func (w *responseWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// writeError writes an error using the InfluxDB error format.
+func writeError(w http.ResponseWriter, status int, err error) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("X-Platform-Error-Code", errorCode(err))
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	}{
+		Code:    errorCode(err),
+		Message: err.Error(),
+	})
+}
+
+// errorCode returns the InfluxDB error code for an error.
+func errorCode(err error) string {
+	switch flux.ErrorCode(err) {
+	case codes.Invalid, codes.FailedPrecondition, codes.OutOfRange:
+		return "invalid"
+	case codes.NotFound:
+		return "not found"
+	case codes.PermissionDenied:
+		return "forbidden"
+	case codes.Unauthenticated:
+		return "unauthorized"
+	case codes.ResourceExhausted:
+		return "too many requests"
+	case codes.Unavailable:
+		return "unavailable"
+	case codes.Unimplemented:
+		return "not implemented"
+	case codes.Canceled:
+		return "canceled"
+	default:
+		return "internal error"
+	}
+}
+
+// statusCode returns the http status code for an error.
+func statusCode(err error) int {
+	switch errorCode(err) {
+	case "invalid":
+		return http.StatusBadRequest
+	case "not found":
+		return http.StatusNotFound
+	case "forbidden":
+		return http.StatusForbidden
+	case "unauthorized":
+		return http.StatusUnauthorized
+	case "too many requests":
+		return http.StatusTooManyRequests
+	case "unavailable":
+		return http.StatusServiceUnavailable
+	case "not implemented":
+		return http.StatusNotImplemented
+	case "canceled":
+		// Client closed request.
+		return 499
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/influxdata/flux/dependency"
+)
+
+func newTestQueryServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	h := &queryHandler{
+		errOut: io.Discard,
+		withDependencies: func(ctx context.Context) (context.Context, *dependency.Span, error) {
+			ctx, span := injectDependencies(ctx)
+			return ctx, span, nil
+		},
+	}
+	server := httptest.NewServer(h)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func Test_Serve(t *testing.T) {
+	const query = `import "array" array.from(rows: [{a: 1}])`
+	jsonBody := func(dialect string) string {
+		q, _ := json.Marshal(query)
+		return `{"query":` + string(q) + `,"dialect":` + dialect + `}`
+	}
+
+	for _, tt := range []struct {
+		name        string
+		method      string
+		contentType string
+		body        string
+		wantStatus  int
+		want        string
+	}{
+		{
+			name:        "raw flux",
+			method:      http.MethodPost,
+			contentType: "application/vnd.flux",
+			body:        query,
+			wantStatus:  http.StatusOK,
+			want:        ",result,table,a\r\n,_result,0,1\r\n\r\n",
+		},
+		{
+			name:        "json dialect",
+			method:      http.MethodPost,
+			contentType: "application/json",
+			body:        jsonBody(`{"header":false,"delimiter":";","annotations":["datatype"]}`),
+			wantStatus:  http.StatusOK,
+			want:        "#datatype;string;long;long\r\n;_result;0;1\r\n\r\n",
+		},
+		{
+			name:        "date time format",
+			method:      http.MethodPost,
+			contentType: "application/json",
+			body:        `{"query":"import \"array\" array.from(rows: [{t: 2018-01-01T00:00:00.5Z}])","dialect":{"dateTimeFormat":"RFC3339"}}`,
+			wantStatus:  http.StatusOK,
+			want:        ",result,table,t\r\n,_result,0,2018-01-01T00:00:00Z\r\n\r\n",
+		},
+		{
+			name:        "invalid dialect",
+			method:      http.MethodPost,
+			contentType: "application/json",
+			body:        jsonBody(`{"annotations":["foo"]}`),
+			wantStatus:  http.StatusBadRequest,
+			want:        `{"code":"invalid","message":"unknown dialect annotation \"foo\""}` + "\n",
+		},
+		{
+			name:        "compile error",
+			method:      http.MethodPost,
+			contentType: "application/vnd.flux",
+			body:        `x = `,
+			wantStatus:  http.StatusBadRequest,
+		},
+		{
+			name:       "method not allowed",
+			method:     http.MethodGet,
+			wantStatus: http.StatusMethodNotAllowed,
+			want:       `{"code":"invalid","message":"method GET not allowed"}` + "\n",
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			server := newTestQueryServer(t)
+			req, err := http.NewRequest(tt.method, server.URL+queryPath, strings.NewReader(tt.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer func() { _ = resp.Body.Close() }()
+
+			if got, want := resp.StatusCode, tt.wantStatus; got != want {
+				t.Fatalf("unexpected status code -want/+got:\n- %d\n+ %d", want, got)
+			}
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.want == "" {
+				return
+			}
+			if got, want := string(body), tt.want; got != want {
+				t.Errorf("unexpected response body -want/+got:\n%s", cmp.Diff(want, got))
+			}
+		})
+	}
+}
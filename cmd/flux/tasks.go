@@ -0,0 +1,416 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/influxdata/flux"
+	"github.com/influxdata/flux/ast"
+	"github.com/influxdata/flux/ast/edit"
+	fluxcmd "github.com/influxdata/flux/cmd/flux/cmd"
+	"github.com/influxdata/flux/codes"
+	"github.com/influxdata/flux/fluxinit"
+	"github.com/influxdata/flux/internal/errors"
+	"github.com/influxdata/flux/lang"
+	"github.com/influxdata/flux/memory"
+	"github.com/influxdata/flux/parser"
+	"github.com/influxdata/flux/runtime"
+	"github.com/influxdata/flux/values"
+	"github.com/spf13/cobra"
+)
+
+var runTasksFlags struct {
+	State   string
+	History string
+	Once    bool
+}
+
+const (
+	// tasksPkgPath is the package that defines tasks.lastSuccess.
+	tasksPkgPath = "influxdata/influxdb/tasks"
+
+	// defaultTasksState is the name of the state file
+	// in the task directory when --state is not set.
+	defaultTasksState = ".flux-tasks.json"
+)
+
+// localTask is a Flux script with a task option.
+// A task is scheduled either every interval or by a cron expression
+// and runs offset after its scheduled time with now set to the
+// scheduled time.
+type localTask struct {
+	Name   string
+	File   *ast.File
+	Every  time.Duration
+	Cron   *cronSchedule
+	Offset time.Duration
+
+	// next is the next scheduled time of the task.
+	next time.Time
+}
+
+// taskState is the state of a task that is kept across runs.
+type taskState struct {
+	LastSuccess time.Time `json:"last_success,omitempty"`
+	LastRun     time.Time `json:"last_run,omitempty"`
+	LastError   string    `json:"last_error,omitempty"`
+}
+
+// taskRun is an entry of the run history.
+type taskRun struct {
+	Task         string        `json:"task"`
+	ScheduledFor time.Time     `json:"scheduled_for"`
+	StartedAt    time.Time     `json:"started_at"`
+	Duration     time.Duration `json:"duration"`
+	Status       string        `json:"status"`
+	Rows         int64         `json:"rows"`
+	Error        string        `json:"error,omitempty"`
+}
+
+func runTasksE(cmd *cobra.Command, args []string) error {
+	dir := args[0]
+	tasks, err := readTasks(dir)
+	if err != nil {
+		return err
+	}
+	if len(tasks) == 0 {
+		return errors.Newf(codes.NotFound, "no Flux scripts with a task option found in %s", dir)
+	}
+
+	statePath := runTasksFlags.State
+	if statePath == "" {
+		statePath = filepath.Join(dir, defaultTasksState)
+	}
+	state, err := readTaskState(statePath)
+	if err != nil {
+		return err
+	}
+
+	var history io.Writer
+	if runTasksFlags.History != "" {
+		f, err := os.OpenFile(runTasksFlags.History, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = f.Close() }()
+		history = f
+	}
+
+	fluxinit.FluxInit()
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	ctx, span := injectDependencies(ctx)
+	defer span.Finish()
+	ctx, err = fluxcmd.WithFeatureFlags(ctx, flags.Features)
+	if err != nil {
+		return err
+	}
+
+	r := &taskRunner{
+		tasks:     tasks,
+		state:     state,
+		statePath: statePath,
+		log:       cmd.OutOrStderr(),
+		history:   history,
+	}
+	if runTasksFlags.Once {
+		return r.runOnce(ctx, time.Now().UTC().Truncate(time.Second))
+	}
+	return r.schedule(ctx, time.Now())
+}
+
+// readTasks parses the Flux scripts in dir and returns those with a task option.
+// Test files are skipped.
+func readTasks(dir string) ([]*localTask, error) {
+	var tasks []*localTask
+	names := make(map[string]string)
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || filepath.Ext(path) != ".flux" || strings.HasSuffix(path, "_test.flux") {
+			return nil
+		}
+		src, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		task, err := parseTask(path, string(src))
+		if err != nil {
+			return err
+		}
+		if task == nil {
+			return nil
+		}
+		if other, ok := names[task.Name]; ok {
+			return errors.Newf(codes.Invalid, "duplicate task name %q in %s and %s", task.Name, other, path)
+		}
+		names[task.Name] = path
+		tasks = append(tasks, task)
+		return nil
+	})
+	return tasks, err
+}
+
+// parseTask reads the task option of the script.
+// A nil task is returned when the script has no task option.
+func parseTask(filename, src string) (*localTask, error) {
+	pkg := parser.ParseSourceWithFileName(src, filename)
+	if ast.Check(pkg) > 0 {
+		return nil, ast.GetError(pkg)
+	}
+	file := pkg.Files[0]
+
+	opt, err := edit.GetOption(file, "task")
+	if err != nil {
+		return nil, nil
+	}
+	obj, ok := opt.(*ast.ObjectExpression)
+	if !ok {
+		return nil, errors.Newf(codes.Invalid, "%s: task option must be a record", filename)
+	}
+
+	task := &localTask{File: file}
+	for _, prop := range obj.Properties {
+		var err error
+		switch key := prop.Key.Key(); key {
+		case "name":
+			task.Name, err = taskString(prop.Value, key)
+		case "every":
+			task.Every, err = taskDuration(prop.Value, key)
+		case "offset":
+			task.Offset, err = taskDuration(prop.Value, key)
+		case "cron":
+			var expr string
+			if expr, err = taskString(prop.Value, key); err == nil {
+				task.Cron, err = parseCron(expr)
+			}
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, codes.Inherit, "%s", filename)
+		}
+	}
+
+	switch {
+	case task.Name == "":
+		return nil, errors.Newf(codes.Invalid, "%s: task option must have a name", filename)
+	case task.Every == 0 && task.Cron == nil:
+		return nil, errors.Newf(codes.Invalid, "%s: task option must have one of every or cron", filename)
+	case task.Every != 0 && task.Cron != nil:
+		return nil, errors.Newf(codes.Invalid, "%s: task option must not have both every and cron", filename)
+	case task.Every < 0:
+		return nil, errors.Newf(codes.Invalid, "%s: task every must be positive", filename)
+	}
+	return task, nil
+}
+
+func taskString(expr ast.Expression, key string) (string, error) {
+	lit, ok := expr.(*ast.StringLiteral)
+	if !ok {
+		return "", errors.Newf(codes.Invalid, "task %s must be a string literal", key)
+	}
+	return lit.Value, nil
+}
+
+func taskDuration(expr ast.Expression, key string) (time.Duration, error) {
+	lit, ok := expr.(*ast.DurationLiteral)
+	if !ok {
+		return 0, errors.Newf(codes.Invalid, "task %s must be a duration literal", key)
+	}
+	d, err := values.FromDurationValues(lit.Values)
+	if err != nil {
+		return 0, err
+	}
+	if !d.NanoOnly() {
+		return 0, errors.Newf(codes.Invalid, "task %s must not use months or years", key)
+	}
+	return d.Duration(), nil
+}
+
+// Next returns the first scheduled time of the task after t.
+// Tasks with an every interval are aligned to multiples of the interval.
+func (t *localTask) Next(after time.Time) time.Time {
+	if t.Cron != nil {
+		return t.Cron.Next(after)
+	}
+	return after.UTC().Truncate(t.Every).Add(t.Every)
+}
+
+// script returns the package that runs the task. When the script imports
+// the tasks package and the task has succeeded before, the lastSuccessTime
+// option is set so tasks.lastSuccess returns the last successful run.
+func (t *localTask) script(lastSuccess time.Time) *ast.Package {
+	file := *t.File
+	if name, ok := edit.ImportName(t.File, tasksPkgPath); ok && !lastSuccess.IsZero() {
+		file.Body = append([]ast.Statement{&ast.OptionStatement{
+			Assignment: &ast.MemberAssignment{
+				Member: &ast.MemberExpression{
+					Object:   &ast.Identifier{Name: name},
+					Property: &ast.Identifier{Name: "lastSuccessTime"},
+				},
+				Init: &ast.DateTimeLiteral{Value: lastSuccess},
+			},
+		}}, t.File.Body...)
+	}
+	return &ast.Package{
+		Package: "main",
+		Files:   []*ast.File{&file},
+	}
+}
+
+type taskRunner struct {
+	tasks     []*localTask
+	state     map[string]*taskState
+	statePath string
+	log       io.Writer
+	history   io.Writer
+}
+
+// schedule runs the tasks as they become due until the context is canceled.
+// A task is due offset after its scheduled time. Tasks run one at a time
+// and scheduled times that were missed while another task ran are caught up.
+func (r *taskRunner) schedule(ctx context.Context, start time.Time) error {
+	for _, t := range r.tasks {
+		t.next = t.Next(start)
+		fmt.Fprintf(r.log, "scheduled task %q for %s\n", t.Name, t.next.Add(t.Offset).Format(time.RFC3339))
+	}
+	for {
+		sort.SliceStable(r.tasks, func(i, j int) bool {
+			return r.tasks[i].next.Add(r.tasks[i].Offset).Before(r.tasks[j].next.Add(r.tasks[j].Offset))
+		})
+		t := r.tasks[0]
+		if t.next.IsZero() {
+			return errors.Newf(codes.Invalid, "task %q is never scheduled", t.Name)
+		}
+
+		timer := time.NewTimer(time.Until(t.next.Add(t.Offset)))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil
+		case <-timer.C:
+		}
+		if err := r.run(ctx, t, t.next); err != nil {
+			return err
+		}
+		t.next = t.Next(t.next)
+	}
+}
+
+// runOnce runs every task once with now set to the given time.
+func (r *taskRunner) runOnce(ctx context.Context, now time.Time) error {
+	for _, t := range r.tasks {
+		if err := r.run(ctx, t, now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// run executes a single run of the task scheduled for the given time and
+// records it in the state and history. A failed run is not an error of
+// the runner, only failing to record it is.
+func (r *taskRunner) run(ctx context.Context, t *localTask, scheduledFor time.Time) error {
+	st, ok := r.state[t.Name]
+	if !ok {
+		st = &taskState{}
+		r.state[t.Name] = st
+	}
+
+	run := taskRun{
+		Task:         t.Name,
+		ScheduledFor: scheduledFor,
+		StartedAt:    time.Now().UTC(),
+		Status:       "success",
+	}
+	rows, err := executeTask(ctx, t.script(st.LastSuccess), scheduledFor)
+	run.Duration = time.Since(run.StartedAt)
+	run.Rows = rows
+
+	st.LastRun = scheduledFor
+	st.LastError = ""
+	if err != nil {
+		run.Status = "failed"
+		run.Error = err.Error()
+		st.LastError = run.Error
+	} else {
+		st.LastSuccess = scheduledFor
+	}
+
+	fmt.Fprintf(r.log, "%s task=%q scheduled_for=%s status=%s duration=%s rows=%d",
+		run.StartedAt.Format(time.RFC3339), run.Task, run.ScheduledFor.Format(time.RFC3339), run.Status, run.Duration, run.Rows)
+	if run.Error != "" {
+		fmt.Fprintf(r.log, " error=%q", run.Error)
+	}
+	fmt.Fprintln(r.log)
+
+	if r.history != nil {
+		if err := json.NewEncoder(r.history).Encode(run); err != nil {
+			return err
+		}
+	}
+	return writeTaskState(r.statePath, r.state)
+}
+
+// executeTask runs the task script and returns the number of rows it produced.
+func executeTask(ctx context.Context, pkg *ast.Package, now time.Time) (int64, error) {
+	jsonAST, err := json.Marshal(pkg)
+	if err != nil {
+		return 0, err
+	}
+	c := lang.ASTCompiler{AST: jsonAST, Now: now}
+	prog, err := c.Compile(ctx, runtime.Default)
+	if err != nil {
+		return 0, err
+	}
+	q, err := prog.Start(ctx, &memory.ResourceAllocator{})
+	if err != nil {
+		return 0, err
+	}
+
+	var rows int64
+	results := flux.NewResultIteratorFromQuery(q)
+	defer results.Release()
+	for results.More() {
+		if err := results.Next().Tables().Do(func(tbl flux.Table) error {
+			return tbl.Do(func(cr flux.ColReader) error {
+				rows += int64(cr.Len())
+				return nil
+			})
+		}); err != nil {
+			return rows, err
+		}
+	}
+	results.Release()
+	return rows, results.Err()
+}
+
+func readTaskState(filename string) (map[string]*taskState, error) {
+	state := make(map[string]*taskState)
+	data, err := os.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return state, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, errors.Wrapf(err, codes.Invalid, "invalid task state file %s", filename)
+	}
+	return state, nil
+}
+
+func writeTaskState(filename string, state map[string]*taskState) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filename, append(data, '\n'), 0644)
+}
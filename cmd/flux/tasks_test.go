@@ -0,0 +1,142 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/flux/ast"
+)
+
+func TestParseTask(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		src     string
+		every   time.Duration
+		offset  time.Duration
+		cron    bool
+		noTask  bool
+		wantErr bool
+	}{
+		{
+			name:   "every",
+			src:    `option task = {name: "t", every: 1h, offset: 10m}` + "\n" + `from(bucket: "b")`,
+			every:  time.Hour,
+			offset: 10 * time.Minute,
+		},
+		{
+			name: "cron",
+			src:  `option task = {name: "t", cron: "0 * * * *"}`,
+			cron: true,
+		},
+		{
+			name:   "no task",
+			src:    `x = 1`,
+			noTask: true,
+		},
+		{
+			name:    "missing name",
+			src:     `option task = {every: 1h}`,
+			wantErr: true,
+		},
+		{
+			name:    "missing schedule",
+			src:     `option task = {name: "t"}`,
+			wantErr: true,
+		},
+		{
+			name:    "every and cron",
+			src:     `option task = {name: "t", every: 1h, cron: "0 * * * *"}`,
+			wantErr: true,
+		},
+		{
+			name:    "every months",
+			src:     `option task = {name: "t", every: 1mo}`,
+			wantErr: true,
+		},
+		{
+			name:    "invalid cron",
+			src:     `option task = {name: "t", cron: "0 * *"}`,
+			wantErr: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			task, err := parseTask("task.flux", tc.src)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tc.noTask {
+				if task != nil {
+					t.Fatalf("unexpected task %q", task.Name)
+				}
+				return
+			}
+			if task.Name != "t" {
+				t.Errorf("unexpected name %q", task.Name)
+			}
+			if task.Every != tc.every {
+				t.Errorf("unexpected every -want/+got:\n\t- %v\n\t+ %v", tc.every, task.Every)
+			}
+			if task.Offset != tc.offset {
+				t.Errorf("unexpected offset -want/+got:\n\t- %v\n\t+ %v", tc.offset, task.Offset)
+			}
+			if (task.Cron != nil) != tc.cron {
+				t.Errorf("unexpected cron %v", task.Cron)
+			}
+		})
+	}
+}
+
+func TestLocalTask_Next(t *testing.T) {
+	task := &localTask{Name: "t", Every: 15 * time.Minute}
+	after := time.Date(2021, 3, 4, 10, 7, 0, 0, time.UTC)
+	if got, want := task.Next(after), time.Date(2021, 3, 4, 10, 15, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("unexpected next time -want/+got:\n\t- %s\n\t+ %s", want, got)
+	}
+}
+
+func TestLocalTask_Script(t *testing.T) {
+	task, err := parseTask("task.flux", `import "influxdata/influxdb/tasks"
+
+option task = {name: "t", every: 1h}
+
+tasks.lastSuccess(orTime: -1h)
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Without a previous success the script is unchanged.
+	if got := task.script(time.Time{}).Files[0].Body; len(got) != len(task.File.Body) {
+		t.Fatalf("unexpected number of statements %d", len(got))
+	}
+
+	lastSuccess := time.Date(2021, 3, 4, 10, 0, 0, 0, time.UTC)
+	body := task.script(lastSuccess).Files[0].Body
+	if len(body) != len(task.File.Body)+1 {
+		t.Fatalf("unexpected number of statements %d", len(body))
+	}
+	opt, ok := body[0].(*ast.OptionStatement)
+	if !ok {
+		t.Fatalf("unexpected statement %T", body[0])
+	}
+	assign, ok := opt.Assignment.(*ast.MemberAssignment)
+	if !ok {
+		t.Fatalf("unexpected assignment %T", opt.Assignment)
+	}
+	if got := assign.Member.Object.(*ast.Identifier).Name + "." + assign.Member.Property.Key(); got != "tasks.lastSuccessTime" {
+		t.Errorf("unexpected option %s", got)
+	}
+	if got := assign.Init.(*ast.DateTimeLiteral).Value; !got.Equal(lastSuccess) {
+		t.Errorf("unexpected last success time %s", got)
+	}
+	// The parsed file is not modified.
+	if len(task.File.Body) != 2 {
+		t.Errorf("task file was modified")
+	}
+}
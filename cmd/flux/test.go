@@ -3,6 +3,8 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"os"
+	"path/filepath"
 
 	"github.com/influxdata/flux"
 	"github.com/influxdata/flux/ast"
@@ -12,30 +14,44 @@ import (
 	"github.com/influxdata/flux/dependency"
 	"github.com/influxdata/flux/execute/executetest"
 	"github.com/influxdata/flux/internal/errors"
+	"github.com/influxdata/flux/interpreter"
 	"github.com/influxdata/flux/lang"
 	"github.com/influxdata/flux/memory"
 	"github.com/influxdata/flux/runtime"
 )
 
 func NewTestExecutor(ctx context.Context) (cmd.TestExecutor, error) {
-	return testExecutor{}, nil
+	return testExecutor{coverage: interpreter.CoverageFromContext(ctx)}, nil
 }
 
-type testExecutor struct{}
+// testExecutor runs each test with its own context. Only the
+// coverage of the setup context is carried over so that the tests
+// are not affected by the feature flags of the command.
+type testExecutor struct {
+	coverage *interpreter.Coverage
+}
 
-func (testExecutor) Run(pkg *ast.Package, fn cmd.TestResultFunc) error {
+func (e testExecutor) Run(pkg *ast.Package, fn cmd.TestResultFunc) error {
 	jsonAST, err := json.Marshal(pkg)
 	if err != nil {
 		return err
 	}
 	c := lang.ASTCompiler{AST: jsonAST}
 
-	ctx, span := dependency.Inject(context.Background(),
+	ctx := context.Background()
+	if e.coverage != nil {
+		ctx = interpreter.WithCoverage(ctx, e.coverage)
+	}
+	ctx, span := dependency.Inject(ctx,
 		executetest.NewTestExecuteDependencies(),
 		testing.FrameworkConfig{},
 	)
 	defer span.Finish()
-	program, err := c.Compile(ctx, runtime.Default)
+	rt, err := testRuntime(pkg)
+	if err != nil {
+		return err
+	}
+	program, err := c.Compile(ctx, rt)
 	if err != nil {
 		return errors.Wrap(err, codes.Invalid, "failed to compile")
 	}
@@ -53,3 +69,14 @@ func (testExecutor) Run(pkg *ast.Package, fn cmd.TestResultFunc) error {
 }
 
 func (testExecutor) Close() error { return nil }
+
+// testRuntime returns the runtime for the test. Tests that are read
+// from the filesystem can import the local packages of their module.
+func testRuntime(pkg *ast.Package) (flux.Runtime, error) {
+	for _, f := range pkg.Files {
+		if fi, err := os.Stat(f.Name); err == nil && fi.Mode().IsRegular() {
+			return scriptRuntime(filepath.Dir(f.Name))
+		}
+	}
+	return runtime.Default, nil
+}
@@ -5,6 +5,8 @@ import (
 	"archive/zip"
 	"bufio"
 	"bytes"
+	"encoding/json"
+	"encoding/xml"
 	"errors"
 	"io"
 	"io/fs"
@@ -227,6 +229,35 @@ func Test_TestCmd(t *testing.T) {
 	}
 }
 
+func Test_TestCmd_LocalPackages(t *testing.T) {
+	dir := t.TempDir()
+	for name, src := range map[string]string{
+		"flux.mod":       "module mycompany\n",
+		"math/math.flux": "package math\n\ndouble = (x) => x * 2\n",
+		"math/math_test.flux": `package math_test
+
+import "testing"
+import "mycompany/math"
+
+testcase double {
+    testing.assertEqualValues(got: math.double(x: 2), want: 4)
+}
+`,
+	} {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := Summary{Found: 1, Passed: 1}
+	if got := runForPath(t, dir, nil); got != want {
+		t.Errorf("unexpected summary got %+v want %+v", got, want)
+	}
+}
+
 func Test_TestCmd_TestName(t *testing.T) {
 	want := Summary{
 		Found:   9,
@@ -421,3 +452,277 @@ func Test_TestCmd_SkipUntagged(t *testing.T) {
 		}
 	}
 }
+
+func Test_TestCmd_ReporterJSON(t *testing.T) {
+	output := filepath.Join(t.TempDir(), "report.json")
+	runForPath(t, "./testdata", errors.New("tests failed"), "--tags", "fail", "--reporter", "json", "--output", output)
+
+	data, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var report struct {
+		Tests     int `json:"tests"`
+		Passed    int `json:"passed"`
+		Failed    int `json:"failed"`
+		Skipped   int `json:"skipped"`
+		TestCases []struct {
+			Package string `json:"package"`
+			Name    string `json:"name"`
+			File    string `json:"file"`
+			Line    int    `json:"line"`
+			Status  string `json:"status"`
+			Failure string `json:"failure"`
+		} `json:"testcases"`
+	}
+	if err := json.Unmarshal(data, &report); err != nil {
+		t.Fatal(err)
+	}
+	if want, got := (Summary{Found: 9, Passed: 3, Failed: 1, Skipped: 5}), (Summary{
+		Found:   int64(report.Tests),
+		Passed:  int64(report.Passed),
+		Failed:  int64(report.Failed),
+		Skipped: int64(report.Skipped),
+	}); want != got {
+		t.Errorf("unexpected summary got %+v want %+v", got, want)
+	}
+
+	var found bool
+	for _, tc := range report.TestCases {
+		if tc.Package != "test" || tc.Name != "fails" {
+			continue
+		}
+		found = true
+		if tc.Status != "fail" {
+			t.Errorf("unexpected status got %q want %q", tc.Status, "fail")
+		}
+		if filepath.Base(tc.File) != "test_test.flux" || tc.Line != 23 {
+			t.Errorf("unexpected location got %s:%d want test_test.flux:23", tc.File, tc.Line)
+		}
+		if tc.Failure == "" {
+			t.Error("expected failure message")
+		}
+	}
+	if !found {
+		t.Error("testcase test.fails not found in report")
+	}
+}
+
+func Test_TestCmd_ReporterJUnit(t *testing.T) {
+	output := filepath.Join(t.TempDir(), "report.xml")
+	runForPath(t, "./testdata", errors.New("tests failed"), "--tags", "fail", "--reporter", "junit", "--output", output)
+
+	data, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var report struct {
+		Tests    int `xml:"tests,attr"`
+		Failures int `xml:"failures,attr"`
+		Skipped  int `xml:"skipped,attr"`
+		Suites   []struct {
+			Name      string `xml:"name,attr"`
+			TestCases []struct {
+				Name    string    `xml:"name,attr"`
+				Failure *struct{} `xml:"failure"`
+			} `xml:"testcase"`
+		} `xml:"testsuite"`
+	}
+	if err := xml.Unmarshal(data, &report); err != nil {
+		t.Fatal(err)
+	}
+	if report.Tests != 9 || report.Failures != 1 || report.Skipped != 5 {
+		t.Errorf("unexpected totals got tests=%d failures=%d skipped=%d want tests=9 failures=1 skipped=5",
+			report.Tests, report.Failures, report.Skipped)
+	}
+	var failed []string
+	for _, suite := range report.Suites {
+		for _, tc := range suite.TestCases {
+			if tc.Failure != nil {
+				failed = append(failed, suite.Name+"."+tc.Name)
+			}
+		}
+	}
+	if len(failed) != 1 || failed[0] != "test.fails" {
+		t.Errorf("unexpected failed testcases got %v want [test.fails]", failed)
+	}
+}
+
+func Test_TestCmd_InvalidReporter(t *testing.T) {
+	runForPath(t, "./testdata", errors.New("unknown test reporter \"xml\", must be one of: junit,json"), "--reporter", "xml")
+}
+
+func Test_TestCmd_Update(t *testing.T) {
+	const src = `package update_test
+
+
+import "array"
+import "csv"
+import "testing"
+
+outData =
+    "
+#datatype,string,long,long
+#group,false,false,false
+#default,_result,,
+,result,table,_value
+,,0,1
+"
+
+testcase double {
+    got = array.from(rows: [{_value: 2}])
+    want = csv.from(csv: outData)
+
+    testing.diff(got, want)
+}
+`
+	dir := t.TempDir()
+	path := filepath.Join(dir, "update_test.flux")
+	if err := os.WriteFile(path, []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	want := Summary{Found: 1, Passed: 1}
+	if got := runForPath(t, dir, nil, "--update"); want != got {
+		t.Errorf("unexpected summary got %+v want %+v", got, want)
+	}
+
+	updated, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(updated), ",,0,2\n") {
+		t.Errorf("expected output was not updated:\n%s", updated)
+	}
+
+	// The updated test passes without updating.
+	if got := runForPath(t, dir, nil); want != got {
+		t.Errorf("unexpected summary got %+v want %+v", got, want)
+	}
+}
+
+func Test_TestCmd_UpdateShared(t *testing.T) {
+	const src = `package update_test
+
+
+import "array"
+import "csv"
+import "testing"
+
+outData =
+    "
+#datatype,string,long,long
+#group,false,false,false
+#default,_result,,
+,result,table,_value
+,,0,1
+"
+
+testcase single {
+    got = array.from(rows: [{_value: 1}])
+    want = csv.from(csv: outData)
+
+    testing.diff(got, want)
+}
+
+testcase double {
+    got = array.from(rows: [{_value: 2}])
+    want = csv.from(csv: outData)
+
+    testing.diff(got, want)
+}
+`
+	dir := t.TempDir()
+	path := filepath.Join(dir, "update_test.flux")
+	if err := os.WriteFile(path, []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// The expected output is read by both testcases
+	// so it is not updated for the failing one.
+	want := Summary{Found: 2, Passed: 1, Failed: 1}
+	if got := runForPath(t, dir, errors.New("tests failed"), "--update"); want != got {
+		t.Errorf("unexpected summary got %+v want %+v", got, want)
+	}
+
+	updated, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(updated) != src {
+		t.Errorf("shared expected output was updated:\n%s", updated)
+	}
+}
+
+func Test_TestCmd_Coverage(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "foo"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		"foo/foo.flux": `package foo
+
+
+builtin double : (v: int) => int
+
+sign = (x) => if x > 0 then 1 else -1
+`,
+		"foo/foo_test.flux": `package foo_test
+
+
+import "array"
+import "testing"
+
+testcase pass {
+    want = array.from(rows: [{_value: 1}])
+
+    testing.diff(got: want, want: want)
+}
+`,
+	}
+	for name, src := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	output := filepath.Join(dir, "coverage.json")
+	want := Summary{Found: 1, Passed: 1}
+	if got := runForPath(t, dir, nil, "--coverage-output", output); want != got {
+		t.Errorf("unexpected summary got %+v want %+v", got, want)
+	}
+
+	data, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var report struct {
+		Packages []struct {
+			Path             string `json:"path"`
+			Functions        int    `json:"functions"`
+			CoveredFunctions int    `json:"covered_functions"`
+			Branches         int    `json:"branches"`
+			Uncovered        []struct {
+				Kind string `json:"kind"`
+				Name string `json:"name"`
+			} `json:"uncovered"`
+		} `json:"packages"`
+	}
+	if err := json.Unmarshal(data, &report); err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Packages) != 1 {
+		t.Fatalf("unexpected number of packages got %d want 1", len(report.Packages))
+	}
+	pkg := report.Packages[0]
+	if pkg.Path != "foo" || pkg.Functions != 2 || pkg.CoveredFunctions != 0 || pkg.Branches != 2 {
+		t.Errorf("unexpected package coverage %+v", pkg)
+	}
+	var uncovered []string
+	for _, item := range pkg.Uncovered {
+		uncovered = append(uncovered, item.Kind+" "+item.Name)
+	}
+	if want := []string{"builtin double", "function sign", "branch then", "branch else"}; strings.Join(want, ",") != strings.Join(uncovered, ",") {
+		t.Errorf("unexpected uncovered symbols got %v want %v", uncovered, want)
+	}
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+	"time"
+
+	fluxcmd "github.com/influxdata/flux/cmd/flux/cmd"
+	"github.com/influxdata/flux/codes"
+	"github.com/influxdata/flux/dependency"
+	"github.com/influxdata/flux/execute/remote"
+	"github.com/influxdata/flux/fluxinit"
+	"github.com/influxdata/flux/internal/errors"
+	"github.com/spf13/cobra"
+)
+
+var workerFlags struct {
+	Addr string
+}
+
+// workerReady is printed by a worker, followed by the URL
+// of its partition endpoint, once it accepts partitions.
+const workerReady = "Executing partitions on "
+
+// workerStartTimeout is how long a spawned worker
+// has to report that it accepts partitions.
+const workerStartTimeout = 30 * time.Second
+
+func workerE(cmd *cobra.Command, args []string) error {
+	fluxinit.FluxInit()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	w := &remote.Worker{
+		WithDependencies: func(ctx context.Context) (context.Context, *dependency.Span, error) {
+			ctx, span := injectDependencies(ctx)
+			ctx, err := fluxcmd.WithFeatureFlags(ctx, flags.Features)
+			if err != nil {
+				span.Finish()
+				return nil, nil, err
+			}
+			return ctx, span, nil
+		},
+	}
+	mux := http.NewServeMux()
+	mux.Handle(remote.PartitionPath, w)
+
+	// Listen before reporting the address so that a port
+	// of zero is reported as the port that was picked.
+	l, err := net.Listen("tcp", workerFlags.Addr)
+	if err != nil {
+		return err
+	}
+	srv := &http.Server{
+		Handler: mux,
+	}
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- srv.Serve(l)
+	}()
+	fmt.Fprintf(cmd.OutOrStderr(), "%shttp://%s%s\n", workerReady, l.Addr(), remote.PartitionPath)
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	return srv.Shutdown(shutdownCtx)
+}
+
+// localWorker is a worker process spawned by spawnWorkers.
+type localWorker struct {
+	cmd    *exec.Cmd
+	exited chan error
+}
+
+// spawnWorkers starts n worker processes of the flux executable
+// that listen on free local ports and returns their URLs.
+// The workers use the configuration and features of this process.
+// Calling stop kills the workers and waits for them to exit.
+func spawnWorkers(n int) (urls []string, stop func(), err error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return nil, nil, errors.Wrap(err, codes.Internal, "cannot find the flux executable to spawn workers")
+	}
+	args := []string{"worker", "--addr", "127.0.0.1:0"}
+	if configFlags.Path != "" {
+		args = append(args, "--config", configFlags.Path)
+	}
+	if configFlags.Profile != "" {
+		args = append(args, "--config-profile", configFlags.Profile)
+	}
+	if flags.Features != "" {
+		args = append(args, "--features", flags.Features)
+	}
+
+	var workers []*localWorker
+	stop = func() {
+		for _, w := range workers {
+			_ = w.cmd.Process.Kill()
+			<-w.exited
+		}
+	}
+	for i := 0; i < n; i++ {
+		ready := make(chan string, 1)
+		w := &localWorker{
+			cmd:    exec.Command(exe, args...),
+			exited: make(chan error, 1),
+		}
+		w.cmd.Stdout = os.Stderr
+		w.cmd.Stderr = &workerOutput{w: os.Stderr, ready: ready}
+		if err := w.cmd.Start(); err != nil {
+			stop()
+			return nil, nil, errors.Wrap(err, codes.Internal, "failed to spawn worker")
+		}
+		go func() {
+			w.exited <- w.cmd.Wait()
+		}()
+		workers = append(workers, w)
+
+		select {
+		case url := <-ready:
+			urls = append(urls, url)
+		case err := <-w.exited:
+			// The worker is gone so it must not be waited for again.
+			workers = workers[:len(workers)-1]
+			stop()
+			return nil, nil, errors.Newf(codes.Unavailable, "worker exited before accepting partitions: %v", err)
+		case <-time.After(workerStartTimeout):
+			stop()
+			return nil, nil, errors.Newf(codes.Unavailable, "worker did not accept partitions within %v", workerStartTimeout)
+		}
+	}
+	return urls, stop, nil
+}
+
+// workerOutput forwards the output of a spawned worker to w.
+// It sends the base URL of the worker to ready, instead of
+// forwarding the line, once the worker reports it.
+type workerOutput struct {
+	w     io.Writer
+	line  []byte
+	ready chan<- string
+}
+
+func (o *workerOutput) Write(p []byte) (int, error) {
+	if o.ready == nil {
+		return o.w.Write(p)
+	}
+	o.line = append(o.line, p...)
+	for {
+		i := bytes.IndexByte(o.line, '\n')
+		if i < 0 {
+			return len(p), nil
+		}
+		line := string(o.line[:i])
+		o.line = o.line[i+1:]
+		if url, ok := strings.CutPrefix(line, workerReady); ok {
+			o.ready <- strings.TrimSuffix(url, remote.PartitionPath)
+			o.ready = nil
+			_, err := o.w.Write(o.line)
+			o.line = nil
+			return len(p), err
+		}
+		if _, err := fmt.Fprintln(o.w, line); err != nil {
+			return len(p), err
+		}
+	}
+}
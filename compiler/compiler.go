@@ -3,8 +3,10 @@ package compiler
 import (
 	"context"
 
+	"github.com/influxdata/flux/ast"
 	"github.com/influxdata/flux/codes"
 	"github.com/influxdata/flux/internal/errors"
+	"github.com/influxdata/flux/interpreter"
 	"github.com/influxdata/flux/semantic"
 	"github.com/influxdata/flux/values"
 )
@@ -62,11 +64,15 @@ func Compile(ctx context.Context, scope Scope, f *semantic.FunctionExpression, i
 		}
 	}
 
-	compiler := &compiler{ctx}
+	compiler := &compiler{
+		ctx:      ctx,
+		coverage: interpreter.CoverageFromContext(ctx),
+	}
 	root, err := compiler.compile(f.Block, subst)
 	if err != nil {
 		return nil, errors.Wrapf(err, codes.Inherit, "cannot compile @ %v", f.Location())
 	}
+	root = compiler.covered(root, f.Location())
 	return compiledFn{
 		root:        root,
 		parentScope: scope,
@@ -408,6 +414,22 @@ func apply(sub semantic.Substitutor, props []semantic.PropertyType, t semantic.M
 
 type compiler struct {
 	ctx context.Context
+	// coverage records the evaluated function bodies and
+	// conditional branches when it is set.
+	coverage *interpreter.Coverage
+}
+
+// covered wraps the evaluator of the node at the source location
+// so that it records its evaluation when coverage is recorded.
+func (compiler *compiler) covered(e Evaluator, loc ast.SourceLocation) Evaluator {
+	if compiler.coverage == nil {
+		return e
+	}
+	return &coverageEvaluator{
+		Evaluator: e,
+		coverage:  compiler.coverage,
+		loc:       loc,
+	}
 }
 
 // compile recursively compiles semantic nodes into evaluators.
@@ -668,6 +690,8 @@ func (compiler *compiler) compile(n semantic.Node, subst semantic.Substitutor) (
 		if err != nil {
 			return nil, err
 		}
+		c = compiler.covered(c, n.Consequent.Location())
+		a = compiler.covered(a, n.Alternate.Location())
 
 		if test.Type().Nature() == semantic.Vector && c.Type().Nature() == semantic.Vector && a.Type().Nature() == semantic.Vector {
 			return &conditionalVectorEvaluator{
@@ -6,6 +6,7 @@ import (
 	"log"
 	"regexp"
 	"strings"
+	"sync/atomic"
 
 	"github.com/influxdata/flux/array"
 	"github.com/influxdata/flux/ast"
@@ -736,6 +737,22 @@ func (e *conditionalEvaluator) Eval(ctx context.Context, scope Scope) (values.Va
 	}
 }
 
+// coverageEvaluator records the first evaluation of a function body
+// or conditional branch in the coverage.
+type coverageEvaluator struct {
+	Evaluator
+	coverage *interpreter.Coverage
+	loc      ast.SourceLocation
+	recorded int32
+}
+
+func (e *coverageEvaluator) Eval(ctx context.Context, scope Scope) (values.Value, error) {
+	if atomic.CompareAndSwapInt32(&e.recorded, 0, 1) {
+		e.coverage.RecordEvaluated(e.loc)
+	}
+	return e.Evaluator.Eval(ctx, scope)
+}
+
 type conditionalVectorEvaluator struct {
 	test       Evaluator
 	consequent Evaluator
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"sort"
+	"strings"
 
 	"github.com/influxdata/flux/semantic"
 	"github.com/influxdata/flux/values"
@@ -18,6 +19,14 @@ type FunctionSuggestion struct {
 // Completer provides methods for suggestions in Flux queries.
 type Completer struct {
 	scope values.Scope
+
+	// TypeOf infers the type of an expression in scope. It is used to
+	// complete the columns of the rows of a table stream piped into a
+	// function. Columns are not completed when it is nil.
+	TypeOf func(expr string) (semantic.MonoType, error)
+
+	// ImportPaths are the paths of the packages that can be imported.
+	ImportPaths []string
 }
 
 // NewCompleter creates a new completer from scope.
@@ -102,3 +111,207 @@ func (c Completer) FunctionSuggestion(name string) (FunctionSuggestion, error) {
 func isFunction(v values.Value) bool {
 	return v.Type().Nature() == semantic.Function
 }
+
+// Suggestion is a completion of the word before the cursor.
+type Suggestion struct {
+	Text        string
+	Description string
+}
+
+// Argument describes a named argument of a function.
+type Argument struct {
+	Name     string
+	Type     string
+	Optional bool
+	Pipe     bool
+}
+
+// Complete returns the suggestions for the word at the end of text.
+// The kind of suggestions depends on the context of the cursor:
+// names in scope, members of a package or record, columns of the
+// rows of the table stream piped into a function, the arguments of
+// the function being called or import paths.
+func (c Completer) Complete(text string) []Suggestion {
+	ctx := ParseContext(text)
+	var s []Suggestion
+	switch ctx.Kind {
+	case NameKind:
+		s = c.nameSuggestions()
+	case MemberKind:
+		s = c.memberSuggestions(ctx)
+	case ArgumentKind:
+		args, err := c.FunctionArguments(ctx.Function)
+		if err != nil {
+			return nil
+		}
+		for _, arg := range args {
+			if arg.Pipe {
+				continue
+			}
+			desc := arg.Type
+			if arg.Optional {
+				desc += " (optional)"
+			}
+			s = append(s, Suggestion{Text: arg.Name, Description: desc})
+		}
+	case ImportKind:
+		for _, path := range c.ImportPaths {
+			s = append(s, Suggestion{Text: path})
+		}
+	}
+	return filterPrefix(s, ctx.Prefix)
+}
+
+func (c Completer) nameSuggestions() []Suggestion {
+	var s []Suggestion
+	for _, name := range c.Names() {
+		if name != "_" && strings.HasPrefix(name, "_") {
+			continue
+		}
+		v, _ := c.scope.Lookup(name)
+		s = append(s, Suggestion{Text: name, Description: v.Type().CanonicalString()})
+	}
+	return s
+}
+
+// memberSuggestions returns the members of a package or record in scope.
+// Any other object is assumed to be the row parameter of the function
+// so the columns of the table stream piped into the call are returned.
+func (c Completer) memberSuggestions(ctx Context) []Suggestion {
+	if v, ok := c.scope.Lookup(ctx.Object); ok {
+		if s, err := c.PackageMembers(ctx.Object); err == nil {
+			return s
+		}
+		if v.Type().Nature() == semantic.Object {
+			return recordSuggestions(v.Type())
+		}
+		return nil
+	}
+	if ctx.Pipe == "" || c.TypeOf == nil {
+		return nil
+	}
+	t, err := c.TypeOf(ctx.Pipe)
+	if err != nil {
+		return nil
+	}
+	s, err := Columns(t)
+	if err != nil {
+		return nil
+	}
+	return s
+}
+
+// PackageMembers returns the exported members of the package with the
+// given name in scope.
+func (c Completer) PackageMembers(name string) ([]Suggestion, error) {
+	v, err := c.Value(name)
+	if err != nil {
+		return nil, err
+	}
+	pkg, ok := v.(values.Package)
+	if !ok {
+		return nil, fmt.Errorf("name ( %s ) is not a package", name)
+	}
+	var s []Suggestion
+	pkg.Range(func(name string, v values.Value) {
+		if strings.HasPrefix(name, "_") {
+			return
+		}
+		s = append(s, Suggestion{Text: name, Description: v.Type().CanonicalString()})
+	})
+	sort.Slice(s, func(i, j int) bool { return s[i].Text < s[j].Text })
+	return s, nil
+}
+
+// FunctionArguments returns the arguments of the named function sorted by name.
+// The name may be a member of a package such as strings.toUpper.
+func (c Completer) FunctionArguments(name string) ([]Argument, error) {
+	v, err := c.lookup(name)
+	if err != nil {
+		return nil, err
+	}
+	if !isFunction(v) {
+		return nil, fmt.Errorf("name ( %s ) is not a function", name)
+	}
+	sorted, err := v.Type().SortedArguments()
+	if err != nil {
+		return nil, err
+	}
+	args := make([]Argument, 0, len(sorted))
+	for _, arg := range sorted {
+		t, err := arg.TypeOf()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, Argument{
+			Name:     string(arg.Name()),
+			Type:     t.CanonicalString(),
+			Optional: arg.Optional(),
+			Pipe:     arg.Pipe(),
+		})
+	}
+	return args, nil
+}
+
+// lookup finds a value in scope or a member of a package in scope.
+func (c Completer) lookup(name string) (values.Value, error) {
+	pkgName, member, ok := strings.Cut(name, ".")
+	if !ok {
+		return c.Value(name)
+	}
+	v, err := c.Value(pkgName)
+	if err != nil {
+		return nil, err
+	}
+	pkg, ok := v.(values.Package)
+	if !ok {
+		return nil, fmt.Errorf("name ( %s ) is not a package", pkgName)
+	}
+	mv, ok := pkg.Get(member)
+	if !ok {
+		return nil, fmt.Errorf("could not find %s in package %s", member, pkgName)
+	}
+	return mv, nil
+}
+
+// Columns returns the columns of the rows of a table stream type or
+// the properties of a record type.
+func Columns(t semantic.MonoType) ([]Suggestion, error) {
+	if t.Nature() == semantic.Stream {
+		elem, err := t.ElemType()
+		if err != nil {
+			return nil, err
+		}
+		t = elem
+	}
+	if t.Nature() != semantic.Object {
+		return nil, fmt.Errorf("type %s does not have columns", t)
+	}
+	return recordSuggestions(t), nil
+}
+
+func recordSuggestions(t semantic.MonoType) []Suggestion {
+	props, err := t.SortedProperties()
+	if err != nil {
+		return nil
+	}
+	s := make([]Suggestion, 0, len(props))
+	for _, p := range props {
+		pt, err := p.TypeOf()
+		if err != nil {
+			continue
+		}
+		s = append(s, Suggestion{Text: semantic.NewSymbol(p.Name()).Name(), Description: pt.CanonicalString()})
+	}
+	return s
+}
+
+func filterPrefix(s []Suggestion, prefix string) []Suggestion {
+	filtered := s[:0]
+	for _, sug := range s {
+		if strings.HasPrefix(sug.Text, prefix) {
+			filtered = append(filtered, sug)
+		}
+	}
+	return filtered
+}
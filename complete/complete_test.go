@@ -5,7 +5,9 @@ import (
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
 	"github.com/influxdata/flux/complete"
+	"github.com/influxdata/flux/interpreter"
 	"github.com/influxdata/flux/semantic"
 	"github.com/influxdata/flux/values"
 )
@@ -99,3 +101,174 @@ func TestFunctionSuggestion(t *testing.T) {
 		t.Error(cmp.Diff(result, expected), "does not match expected suggestion")
 	}
 }
+
+func TestParseContext(t *testing.T) {
+	for _, tc := range []struct {
+		text string
+		want complete.Context
+	}{
+		{
+			text: "fro",
+			want: complete.Context{Kind: complete.NameKind, Prefix: "fro"},
+		},
+		{
+			text: "strings.to",
+			want: complete.Context{Kind: complete.MemberKind, Object: "strings", Prefix: "to"},
+		},
+		{
+			text: "x = strings.",
+			want: complete.Context{Kind: complete.MemberKind, Object: "strings"},
+		},
+		{
+			text: "range(",
+			want: complete.Context{Kind: complete.ArgumentKind, Function: "range"},
+		},
+		{
+			text: "range(start: -1h, st",
+			want: complete.Context{Kind: complete.ArgumentKind, Function: "range", Prefix: "st"},
+		},
+		{
+			text: "range(start: no",
+			want: complete.Context{Kind: complete.NameKind, Function: "range", Prefix: "no"},
+		},
+		{
+			text: `strings.toUpper(v`,
+			want: complete.Context{Kind: complete.ArgumentKind, Function: "strings.toUpper", Prefix: "v"},
+		},
+		{
+			text: `data |> range(start: -1h) |> filter(fn: (r) => r._`,
+			want: complete.Context{
+				Kind:     complete.MemberKind,
+				Object:   "r",
+				Prefix:   "_",
+				Function: "filter",
+				Pipe:     "data |> range(start: -1h)",
+			},
+		},
+		{
+			text: "x = data\n    |> map(fn: (r) => ({r with y: r.",
+			want: complete.Context{
+				Kind:     complete.MemberKind,
+				Object:   "r",
+				Function: "map",
+				Pipe:     "data",
+			},
+		},
+		{
+			text: "y = 1\ndata |> filter(fn: (r) => r.",
+			want: complete.Context{
+				Kind:     complete.MemberKind,
+				Object:   "r",
+				Function: "filter",
+				Pipe:     "data",
+			},
+		},
+		{
+			text: `import "str`,
+			want: complete.Context{Kind: complete.ImportKind, Prefix: "str"},
+		},
+		{
+			text: `x = "str`,
+			want: complete.Context{Kind: complete.NoneKind},
+		},
+		{
+			text: `// a comment`,
+			want: complete.Context{Kind: complete.NoneKind},
+		},
+	} {
+		t.Run(tc.text, func(t *testing.T) {
+			if got := complete.ParseContext(tc.text); !cmp.Equal(tc.want, got) {
+				t.Errorf("unexpected context -want/+got:\n%s", cmp.Diff(tc.want, got))
+			}
+		})
+	}
+}
+
+func TestComplete(t *testing.T) {
+	row := semantic.NewObjectType([]semantic.PropertyType{
+		{Key: []byte("_time"), Value: semantic.BasicTime},
+		{Key: []byte("_value"), Value: semantic.BasicFloat},
+		{Key: []byte("host"), Value: semantic.BasicString},
+	})
+	toUpper := values.NewFunction(
+		"toUpper",
+		semantic.NewFunctionType(semantic.BasicString, []semantic.ArgumentType{
+			{Name: []byte("v"), Type: semantic.BasicString},
+		}),
+		func(context.Context, values.Object) (values.Value, error) {
+			return values.NewString(""), nil
+		},
+		false,
+	)
+	filter := values.NewFunction(
+		"filter",
+		semantic.NewFunctionType(semantic.NewStreamType(row), []semantic.ArgumentType{
+			{Name: []byte("tables"), Type: semantic.NewStreamType(row)},
+			{Name: []byte("fn"), Type: semantic.NewFunctionType(semantic.BasicBool, nil)},
+			{Name: []byte("onEmpty"), Type: semantic.BasicString},
+		}),
+		func(context.Context, values.Object) (values.Value, error) {
+			return nil, nil
+		},
+		false,
+	)
+
+	scope := values.NewScope()
+	scope.Set("strings", interpreter.NewPackageWithValues("strings", "strings", values.NewObjectWithValues(map[string]values.Value{
+		"toUpper":  toUpper,
+		"_private": values.NewInt(0),
+	})))
+	scope.Set("filter", filter)
+	scope.Set("point", values.NewObjectWithValues(map[string]values.Value{
+		"x": values.NewInt(1),
+		"y": values.NewInt(2),
+	}))
+	scope.Set("_hidden", values.NewInt(0))
+
+	c := complete.NewCompleter(scope)
+	c.ImportPaths = []string{"strings", "influxdata/influxdb/tasks", "math"}
+	c.TypeOf = func(expr string) (semantic.MonoType, error) {
+		return semantic.NewStreamType(row), nil
+	}
+
+	texts := func(s []complete.Suggestion) []string {
+		names := make([]string, len(s))
+		for i, sug := range s {
+			names[i] = sug.Text
+		}
+		return names
+	}
+	for _, tc := range []struct {
+		text string
+		want []string
+	}{
+		{text: "fi", want: []string{"filter"}},
+		{text: "", want: []string{"filter", "point", "strings"}},
+		{text: "strings.", want: []string{"toUpper"}},
+		{text: "point.", want: []string{"x", "y"}},
+		{text: "filter(", want: []string{"fn", "onEmpty", "tables"}},
+		{text: "strings.toUpper(", want: []string{"v"}},
+		{text: "data |> filter(fn: (r) => r.", want: []string{"_time", "_value", "host"}},
+		{text: "data |> filter(fn: (r) => r.h", want: []string{"host"}},
+		{text: `import "in`, want: []string{"influxdata/influxdb/tasks"}},
+	} {
+		t.Run(tc.text, func(t *testing.T) {
+			if got := texts(c.Complete(tc.text)); !cmp.Equal(tc.want, got, cmpopts.EquateEmpty()) {
+				t.Errorf("unexpected suggestions -want/+got:\n%s", cmp.Diff(tc.want, got))
+			}
+		})
+	}
+
+	args, err := c.FunctionArguments("filter")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []complete.Argument{
+		{Name: "fn", Type: "() => bool"},
+		{Name: "onEmpty", Type: "string"},
+		{Name: "tables", Type: "stream[{_time: time, _value: float, host: string}]"},
+	}
+	if !cmp.Equal(want, args) {
+		t.Errorf("unexpected arguments -want/+got:\n%s", cmp.Diff(want, args))
+	}
+}
@@ -0,0 +1,186 @@
+package complete
+
+import (
+	"strings"
+)
+
+// Kind is the kind of text being completed at the cursor.
+type Kind int
+
+const (
+	// NoneKind is text that is not completed such as a comment or string.
+	NoneKind Kind = iota
+	// NameKind completes an identifier in scope.
+	NameKind
+	// MemberKind completes the property after a dot. The object is
+	// a package, a record in scope or the row of a function that
+	// is applied to the piped table stream.
+	MemberKind
+	// ArgumentKind completes a named argument of a function call.
+	ArgumentKind
+	// ImportKind completes the path of an import.
+	ImportKind
+)
+
+// Context describes the text before the cursor.
+type Context struct {
+	Kind Kind
+	// Prefix is the partial word before the cursor.
+	Prefix string
+	// Object is the expression before the dot for MemberKind.
+	Object string
+	// Function is the name of the innermost function being called
+	// including its package name such as strings.toUpper.
+	Function string
+	// Pipe is the expression that is piped into the innermost
+	// function call or empty if there is none.
+	Pipe string
+}
+
+// frame is a bracket that is open at the cursor.
+type frame struct {
+	// call is true when the bracket is the argument list of a call.
+	call     bool
+	function string
+	pipe     string
+	// exprStart is the offset where the current expression starts.
+	exprStart int
+}
+
+// ParseContext returns the context of the cursor at the end of text.
+// Text is scanned without parsing it since it is usually incomplete.
+func ParseContext(text string) Context {
+	stack := []*frame{{}}
+	cur := func() *frame { return stack[len(stack)-1] }
+
+	var (
+		// chain is the dotted identifier that ends at chainEnd and
+		// chainPipe is the offset of a |> right before the chain.
+		chain     string
+		chainEnd  = -1
+		chainPipe = -1
+		// lastPipe is the offset of a |> that is the last token.
+		lastPipe = -1
+	)
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case c == '"':
+			start := i
+			for i++; i < len(text) && text[i] != '"'; i++ {
+				if text[i] == '\\' {
+					i++
+				}
+			}
+			if i >= len(text) {
+				if strings.HasSuffix(strings.TrimSpace(text[:start]), "import") {
+					return Context{Kind: ImportKind, Prefix: text[start+1:]}
+				}
+				return Context{Kind: NoneKind}
+			}
+			chain, lastPipe = "", -1
+		case c == '/' && i+1 < len(text) && text[i+1] == '/':
+			for i < len(text) && text[i] != '\n' {
+				i++
+			}
+			if i >= len(text) {
+				return Context{Kind: NoneKind}
+			}
+		case isIdentChar(c):
+			start := i
+			for i+1 < len(text) && isIdentChar(text[i+1]) {
+				i++
+			}
+			word := text[start : i+1]
+			if strings.HasSuffix(chain, ".") && chainEnd == start {
+				chain += word
+			} else {
+				chain, chainPipe = word, lastPipe
+			}
+			chainEnd = i + 1
+			lastPipe = -1
+		case c == '.':
+			if chain != "" && chainEnd == i {
+				chain += "."
+				chainEnd = i + 1
+			} else {
+				chain, lastPipe = "", -1
+			}
+		case c == '(' || c == '[' || c == '{':
+			f := &frame{exprStart: i + 1}
+			if c == '(' && chain != "" && chainEnd == i {
+				f.call = true
+				f.function = chain
+				if chainPipe >= 0 {
+					f.pipe = strings.TrimSpace(text[cur().exprStart:chainPipe])
+				}
+			}
+			stack = append(stack, f)
+			chain, lastPipe = "", -1
+		case c == ')' || c == ']' || c == '}':
+			if len(stack) > 1 {
+				stack = stack[:len(stack)-1]
+			}
+			chain, lastPipe = "", -1
+		case c == '|' && i+1 < len(text) && text[i+1] == '>':
+			lastPipe = i
+			chain = ""
+			i++
+		case c == '=' && i+1 < len(text) && text[i+1] == '>':
+			cur().exprStart = i + 2
+			chain, lastPipe = "", -1
+			i++
+		case c == '=' && i+1 < len(text) && text[i+1] == '=':
+			chain, lastPipe = "", -1
+			i++
+		case c == '=' || c == ',' || c == ':':
+			cur().exprStart = i + 1
+			chain, lastPipe = "", -1
+		case c == '\n' && len(stack) == 1 && lastPipe < 0 && startsStatement(text[i+1:]):
+			cur().exprStart = i + 1
+			chain, lastPipe = "", -1
+		case c == ' ' || c == '\t' || c == '\r' || c == '\n':
+		default:
+			chain, lastPipe = "", -1
+		}
+	}
+
+	ctx := Context{Kind: NameKind}
+	for j := len(stack) - 1; j >= 0; j-- {
+		if stack[j].call {
+			ctx.Function = stack[j].function
+			ctx.Pipe = stack[j].pipe
+			break
+		}
+	}
+
+	end := len(text)
+	if chainEnd == end {
+		if dot := strings.LastIndexByte(chain, '.'); dot >= 0 {
+			ctx.Kind = MemberKind
+			ctx.Object = chain[:dot]
+			ctx.Prefix = chain[dot+1:]
+			return ctx
+		}
+		ctx.Prefix = chain
+	}
+
+	// An argument name follows the open paren or a comma of a call.
+	f := cur()
+	before := strings.TrimRight(text[:end-len(ctx.Prefix)], " \t\r\n")
+	if f.call && len(before) == f.exprStart && (strings.HasSuffix(before, "(") || strings.HasSuffix(before, ",")) {
+		ctx.Kind = ArgumentKind
+	}
+	return ctx
+}
+
+// startsStatement reports whether the line starts a new statement
+// instead of continuing a pipeline from the previous line.
+func startsStatement(line string) bool {
+	line = strings.TrimLeft(line, " \t\r")
+	return line != "" && !strings.HasPrefix(line, "|>") && !strings.HasPrefix(line, "\n")
+}
+
+func isIdentChar(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
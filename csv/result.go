@@ -800,6 +800,10 @@ type ResultEncoderConfig struct {
 	// Delimiter is the character to delimite columns.
 	// It must not be \r, \n, or the Unicode replacement character (0xFFFD).
 	Delimiter rune
+
+	// TimeFormat is the layout used to encode time values.
+	// If it is empty, time.RFC3339Nano is used.
+	TimeFormat string
 }
 
 func (c ResultEncoderConfig) MarshalJSON() ([]byte, error) {
@@ -910,7 +914,10 @@ func (e *ResultEncoder) Encode(w io.Writer, result flux.Result) (int64, error) {
 		for _, c := range tbl.Cols() {
 			cm := colMeta{ColMeta: c}
 			if c.Type == flux.TTime {
-				cm.fmt = time.RFC3339Nano
+				cm.fmt = e.c.TimeFormat
+				if cm.fmt == "" {
+					cm.fmt = time.RFC3339Nano
+				}
 			}
 			cols = append(cols, cm)
 		}
@@ -1685,6 +1685,25 @@ func TestResultEncoder(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:          "time format",
+			encoderConfig: csv.ResultEncoderConfig{TimeFormat: time.RFC3339},
+			encoded: toCRLF(`,result,table,_time,_value
+,_result,0,2018-04-17T00:00:01Z,42
+`),
+			result: &executetest.Result{
+				Nm: "_result",
+				Tbls: []*executetest.Table{{
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "_value", Type: flux.TFloat},
+					},
+					Data: [][]interface{}{
+						{values.ConvertTime(time.Date(2018, 4, 17, 0, 0, 1, 500, time.UTC)), 42.0},
+					},
+				}},
+			},
+		},
 		{
 			name: "table error",
 			result: &executetest.Result{
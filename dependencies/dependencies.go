@@ -28,14 +28,20 @@ func NewDefaultDependencies(defaultInfluxDBHost string) Dependencies {
 	deps := flux.NewDefaultDependencies()
 	deps.Deps.FilesystemService = filesystem.SystemFS
 
+	return NewDependencies(deps, influxdb.Config{
+		Host: defaultInfluxDBHost,
+	})
+}
+
+// NewDependencies creates the dependencies from the flux dependencies
+// and the default configuration used to connect to InfluxDB.
+func NewDependencies(deps flux.Deps, influxdbConfig influxdb.Config) Dependencies {
 	return Dependencies{
 		Deps: deps,
 
 		influxdb: influxdb.Dependency{
 			Provider: &influxdb.HttpProvider{
-				DefaultConfig: influxdb.Config{
-					Host: defaultInfluxDBHost,
-				},
+				DefaultConfig: influxdbConfig,
 			},
 		},
 
@@ -0,0 +1,50 @@
+package filesystem
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/influxdata/flux/codes"
+	"github.com/influxdata/flux/internal/errors"
+)
+
+// NewRootFS returns a Service that proxies requests to the filesystem
+// but only allows opening files within the root directory.
+// Relative paths are resolved relative to the root directory.
+func NewRootFS(root string) Service {
+	return rootFS{root: filepath.Clean(root)}
+}
+
+type rootFS struct {
+	root string
+}
+
+func (fs rootFS) Open(fpath string) (File, error) {
+	if !filepath.IsAbs(fpath) {
+		fpath = filepath.Join(fs.root, fpath)
+	}
+	fpath = filepath.Clean(fpath)
+	if !within(fs.root, fpath) {
+		return nil, errors.Newf(codes.PermissionDenied, "path %q is outside of the filesystem root", fpath)
+	}
+
+	// Resolve symbolic links so a link within the root
+	// cannot be used to read files outside of it.
+	root, err := filepath.EvalSymlinks(fs.root)
+	if err != nil {
+		return nil, err
+	}
+	if resolved, err := filepath.EvalSymlinks(fpath); err == nil && !within(root, resolved) {
+		return nil, errors.Newf(codes.PermissionDenied, "path %q is outside of the filesystem root", fpath)
+	}
+	return SystemFS.Open(fpath)
+}
+
+// within reports if the cleaned path is the root or a descendant of it.
+func within(root, fpath string) bool {
+	rel, err := filepath.Rel(root, fpath)
+	if err != nil {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
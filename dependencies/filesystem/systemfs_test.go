@@ -94,3 +94,45 @@ func TestSystemFS_ReadFile(t *testing.T) {
 		t.Fatalf("unexpected file contents -want/+got:\n\t- %q\n\t+ %q", want, got)
 	}
 }
+
+func TestRootFS_Open(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "data.csv"), []byte("a,b\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	outside := t.TempDir()
+	if err := os.WriteFile(filepath.Join(outside, "secret.txt"), []byte("secret"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Join(outside, "secret.txt"), filepath.Join(root, "link.txt")); err != nil {
+		t.Fatal(err)
+	}
+
+	fs := filesystem.NewRootFS(root)
+	for _, tt := range []struct {
+		name    string
+		path    string
+		wantErr bool
+	}{
+		{name: "relative", path: "data.csv"},
+		{name: "absolute", path: filepath.Join(root, "data.csv")},
+		{name: "parent", path: "../secret.txt", wantErr: true},
+		{name: "outside", path: filepath.Join(outside, "secret.txt"), wantErr: true},
+		{name: "symlink", path: "link.txt", wantErr: true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := fs.Open(tt.path)
+			if tt.wantErr {
+				if err == nil {
+					_ = f.Close()
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			_ = f.Close()
+		})
+	}
+}
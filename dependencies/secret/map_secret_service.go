@@ -0,0 +1,19 @@
+package secret
+
+import (
+	"context"
+
+	"github.com/influxdata/flux/codes"
+	"github.com/influxdata/flux/internal/errors"
+)
+
+func (mss MapSecretService) LoadSecret(ctx context.Context, k string) (string, error) {
+	v, ok := mss[k]
+	if !ok {
+		return "", errors.Newf(codes.NotFound, "secret key %q not found", k)
+	}
+	return v, nil
+}
+
+// Secret service that retrieves secrets from a fixed set of key value pairs.
+type MapSecretService map[string]string
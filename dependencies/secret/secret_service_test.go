@@ -4,6 +4,7 @@ import (
 	"context"
 	"testing"
 
+	"github.com/influxdata/flux/dependencies/secret"
 	"github.com/influxdata/flux/mock"
 )
 
@@ -21,3 +22,18 @@ func TestSecret_Service(t *testing.T) {
 		t.Error("secret service should have errored on key lookup")
 	}
 }
+
+func TestMapSecretService(t *testing.T) {
+	ss := secret.MapSecretService{"key": "val"}
+	val, err := ss.LoadSecret(context.Background(), "key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if val != "val" {
+		t.Error("secret service returned wrong value")
+	}
+
+	if _, err = ss.LoadSecret(context.Background(), "k"); err == nil {
+		t.Error("secret service should have errored on key lookup")
+	}
+}
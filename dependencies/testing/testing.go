@@ -96,3 +96,20 @@ func (want results) Check(got results) error {
 	}
 	return nil
 }
+
+// InvokedPlannerRules returns the number of times each planner rule
+// was invoked as recorded by the testing dependencies.
+//
+// This returns an error if testing dependencies have not been configured.
+func InvokedPlannerRules(ctx context.Context) (map[string]int, error) {
+	tf, err := getTestingFramework(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rules := make(map[string]int, len(tf.got.plannerRules))
+	for name, n := range tf.got.plannerRules {
+		rules[name] = n
+	}
+	return rules, nil
+}
@@ -3,6 +3,8 @@ package testing
 import (
 	"context"
 	"testing"
+
+	"github.com/google/go-cmp/cmp"
 )
 
 func MustExpectPlannerRule(ctx context.Context, name string, n int) {
@@ -103,3 +105,22 @@ func TestNoTestingFramework_ExpectPlannerRule(t *testing.T) {
 		t.Errorf("unexpected error: %s", err)
 	}
 }
+
+func TestInvokedPlannerRules(t *testing.T) {
+	ctx := Inject(context.Background())
+	MarkInvokedPlannerRule(ctx, "A")
+	MarkInvokedPlannerRule(ctx, "A")
+	MarkInvokedPlannerRule(ctx, "B")
+
+	got, err := InvokedPlannerRules(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]int{"A": 2, "B": 1}; !cmp.Equal(want, got) {
+		t.Errorf("unexpected invoked planner rules -want/+got:\n%s", cmp.Diff(want, got))
+	}
+
+	if _, err := InvokedPlannerRules(context.Background()); err == nil {
+		t.Error("expected error")
+	}
+}
@@ -1,6 +1,8 @@
 package execute
 
 import (
+	"sync/atomic"
+
 	"github.com/influxdata/flux/memory"
 )
 
@@ -220,3 +222,65 @@ func (a *Allocator) GrowTimes(slice []Time, n int) []Time {
 	a.account(diff, timeSize)
 	return s
 }
+
+// operatorAllocator tracks the memory used by a single operator.
+// Allocations are passed through to the query allocator so query
+// wide accounting and limits continue to apply.
+type operatorAllocator struct {
+	// Variables accessed with atomic operations should be at
+	// the beginning of the struct to ensure byte alignment is correct.
+	bytesAllocated int64
+	maxAllocated   int64
+
+	memory.Allocator
+}
+
+func newOperatorAllocator(mem memory.Allocator) *operatorAllocator {
+	return &operatorAllocator{Allocator: mem}
+}
+
+func (a *operatorAllocator) Allocate(size int) []byte {
+	b := a.Allocator.Allocate(size)
+	a.count(size)
+	return b
+}
+
+func (a *operatorAllocator) Reallocate(size int, b []byte) []byte {
+	sizediff := size - cap(b)
+	b = a.Allocator.Reallocate(size, b)
+	a.count(sizediff)
+	return b
+}
+
+func (a *operatorAllocator) Free(b []byte) {
+	size := len(b)
+	a.Allocator.Free(b)
+	a.count(-size)
+}
+
+func (a *operatorAllocator) Account(size int) error {
+	if err := a.Allocator.Account(size); err != nil {
+		return err
+	}
+	a.count(size)
+	return nil
+}
+
+// MaxAllocated reports the maximum amount of memory allocated
+// by the operator at any point.
+func (a *operatorAllocator) MaxAllocated() int64 {
+	return atomic.LoadInt64(&a.maxAllocated)
+}
+
+func (a *operatorAllocator) count(size int) {
+	if size == 0 {
+		return
+	}
+	n := atomic.AddInt64(&a.bytesAllocated, int64(size))
+	for {
+		max := atomic.LoadInt64(&a.maxAllocated)
+		if n <= max || atomic.CompareAndSwapInt64(&a.maxAllocated, max, n) {
+			return
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package execute
+
+import (
+	"testing"
+
+	"github.com/influxdata/flux/memory"
+)
+
+func TestOperatorAllocator(t *testing.T) {
+	query := &memory.ResourceAllocator{}
+	mem := newOperatorAllocator(query)
+
+	b := mem.Allocate(64)
+	if err := mem.Account(32); err != nil {
+		t.Fatal(err)
+	}
+	b = mem.Reallocate(128, b)
+	if err := mem.Account(-32); err != nil {
+		t.Fatal(err)
+	}
+	mem.Free(b)
+
+	if got, want := mem.MaxAllocated(), int64(160); got != want {
+		t.Errorf("unexpected operator max allocated -want/+got:\n\t- %d\n\t+ %d", want, got)
+	}
+	// Allocations must still be recorded by the query allocator.
+	if got, want := query.MaxAllocated(), mem.MaxAllocated(); got != want {
+		t.Errorf("unexpected query max allocated -want/+got:\n\t- %d\n\t+ %d", want, got)
+	}
+	if got := query.Allocated(); got != 0 {
+		t.Errorf("expected all query memory to be released, got %d", got)
+	}
+}
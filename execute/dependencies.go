@@ -16,11 +16,18 @@ import (
 
 type key int
 
-const executionDependenciesKey key = iota
+const (
+	executionDependenciesKey key = iota
+	executionTimeLimitKey
+)
 
 type ExecutionOptions struct {
 	OperatorProfiler *OperatorProfiler
 	Profilers        []Profiler
+	Limits           ResourceLimits
+	// Partitions executes the partitions of parallel sub-plans
+	// when it is set, usually on worker processes.
+	Partitions PartitionExecutor
 }
 
 // ExecutionDependencies represents the dependencies that a function call
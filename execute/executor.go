@@ -67,6 +67,20 @@ type executionState struct {
 	sources []Source
 	statsCh chan flux.Statistics
 
+	// profileMemory is set when the operator profiler is enabled.
+	// Each operator then records the memory it allocates.
+	profileMemory bool
+	// sourceAllocs holds the allocator for each source
+	// when memory is being profiled.
+	sourceAllocs []*operatorAllocator
+
+	limits ResourceLimits
+
+	// partition is set when a single partition of a parallel
+	// sub-plan is executed. Only the copy of each parallel node
+	// that belongs to the partition is created.
+	partition *ParallelOpts
+
 	transports []AsyncTransport
 
 	dispatcher *poolDispatcher
@@ -74,7 +88,11 @@ type executionState struct {
 }
 
 func (e *executor) Execute(ctx context.Context, p *plan.Spec, a memory.Allocator) (map[string]flux.Result, <-chan flux.Statistics, error) {
-	es, err := e.createExecutionState(ctx, p, a)
+	return e.execute(ctx, p, a, nil)
+}
+
+func (e *executor) execute(ctx context.Context, p *plan.Spec, a memory.Allocator, partition *ParallelOpts) (map[string]flux.Result, <-chan flux.Statistics, error) {
+	es, err := e.createExecutionState(ctx, p, a, partition)
 	if err != nil {
 		return nil, nil, errors.Wrap(err, codes.Inherit, "failed to initialize execute state")
 	}
@@ -82,7 +100,7 @@ func (e *executor) Execute(ctx context.Context, p *plan.Spec, a memory.Allocator
 	return es.results, es.statsCh, nil
 }
 
-func (e *executor) createExecutionState(ctx context.Context, p *plan.Spec, a memory.Allocator) (*executionState, error) {
+func (e *executor) createExecutionState(ctx context.Context, p *plan.Spec, a memory.Allocator, partition *ParallelOpts) (*executionState, error) {
 	ctx, cancel := context.WithCancel(ctx)
 	es := &executionState{
 		p:         p,
@@ -94,6 +112,16 @@ func (e *executor) createExecutionState(ctx context.Context, p *plan.Spec, a mem
 		// TODO(nathanielc): Have the planner specify the dispatcher throughput
 		dispatcher: newPoolDispatcher(10, e.logger),
 		logger:     e.logger,
+		partition:  partition,
+	}
+	if a != nil && HaveExecutionDependencies(ctx) {
+		if opts := GetExecutionDependencies(ctx).ExecutionOptions; opts != nil {
+			es.profileMemory = opts.OperatorProfiler != nil
+			es.limits = opts.Limits
+			if opts.Partitions != nil && partition == nil {
+				distributePlan(p, opts.Partitions, e.logger)
+			}
+		}
 	}
 	v := &createExecutionNodeVisitor{
 		es:    es,
@@ -188,9 +216,17 @@ func (v *createExecutionNodeVisitor) Visit(node plan.Node) error {
 	// 3. Merge instantiation. There is a single copy of the node, but multiple copies of the
 	//    predecessors. These copies merge into the node.
 
-	copies := 1
+	copies, factor, group := 1, 1, 0
 	if attr := plan.GetOutputAttribute(ppn, plan.ParallelRunKey); attr != nil {
 		copies = attr.(plan.ParallelRunAttribute).Factor
+		factor = copies
+		if p := v.es.partition; p != nil {
+			// Only the copy that belongs to the partition is executed.
+			if p.Factor != factor {
+				return errors.Newf(codes.Invalid, "cannot execute partition %d of %d: node %q has a parallel factor of %d", p.Group, p.Factor, node.ID(), factor)
+			}
+			copies, group = 1, p.Group
+		}
 	}
 
 	isParallelMerge := false
@@ -207,7 +243,10 @@ func (v *createExecutionNodeVisitor) Visit(node plan.Node) error {
 			es:            v.es,
 			parents:       make([]DatasetID, len(node.Predecessors())*predCopies),
 			streamContext: streamContext,
-			parallelOpts:  ParallelOpts{Group: i, Factor: copies},
+			parallelOpts:  ParallelOpts{Group: group + i, Factor: factor},
+		}
+		if v.es.profileMemory {
+			ec[i].mem = newOperatorAllocator(v.es.alloc)
 		}
 
 		for pi, pred := range nonYieldPredecessors(node) {
@@ -237,6 +276,7 @@ func (v *createExecutionNodeVisitor) Visit(node plan.Node) error {
 
 			source.SetLabel(string(node.ID()))
 			v.es.sources = append(v.es.sources, source)
+			v.es.sourceAllocs = append(v.es.sourceAllocs, ec[i].mem)
 			v.nodes[node][i] = source
 		}
 	} else {
@@ -283,7 +323,10 @@ func (v *createExecutionNodeVisitor) Visit(node plan.Node) error {
 				for j := 0; j < predCopies; j++ {
 					// Either i == 0 && j == 0: we are either iterating i, or we are iterating j.
 					executionNode := v.nodes[p][i+j]
-					transport := newConsecutiveTransport(v.es.ctx, v.es.dispatcher, tr, node, v.es.logger, v.es.alloc)
+					transport := newConsecutiveTransport(v.es.ctx, v.es.dispatcher, tr, node, v.es.logger, ec[i].Allocator())
+					if max := v.es.limits.MaxGroupKeys; max > 0 {
+						transport.keyLimit = newGroupKeyLimit(max, string(p.ID()))
+					}
 					v.es.transports = append(v.es.transports, transport)
 					executionNode.AddTransformation(transport)
 				}
@@ -326,6 +369,13 @@ func (v *createExecutionNodeVisitor) generateResult(resultName string, node plan
 		return errors.Newf(codes.Invalid, "tried to produce more than one result with the name %q", resultName)
 	}
 	r := newResult(resultName)
+	if limits := v.es.limits; limits.MaxTablesPerResult > 0 || limits.MaxRowsPerResult > 0 || limits.MaxBytesPerResult > 0 {
+		r.limits = &resultLimits{
+			limits: limits,
+			name:   resultName,
+			op:     string(skipYields(node).ID()),
+		}
+	}
 	v.es.results[resultName] = r
 	v.nodes[skipYields(node)][idx].AddTransformation(r)
 	return nil
@@ -490,6 +540,7 @@ func (es *executionState) chooseDefaultResources(ctx context.Context, p *plan.Sp
 }
 
 func (es *executionState) abort(err error) {
+	err = ExecutionTimeLimitError(es.ctx, err)
 	for _, r := range es.results {
 		r.(*result).abort(err)
 	}
@@ -510,9 +561,9 @@ func (es *executionState) do() {
 	}
 
 	stats.Metadata = make(metadata.Metadata)
-	for _, src := range es.sources {
+	for i, src := range es.sources {
 		wg.Add(1)
-		go func(src Source) {
+		go func(src Source, mem *operatorAllocator) {
 			ctx := es.ctx
 			opName := reflect.TypeOf(src).String()
 
@@ -534,6 +585,9 @@ func (es *executionState) do() {
 			defer es.recover()
 			src.Run(ctx)
 			profileSpan.Finish()
+			if mem != nil {
+				profile.MaxAllocated = mem.MaxAllocated()
+			}
 
 			updateStats(func(stats *flux.Statistics) {
 				stats.Profiles = append(stats.Profiles, profile)
@@ -541,7 +595,7 @@ func (es *executionState) do() {
 					stats.Metadata.AddAll(mdn.Metadata())
 				}
 			})
-		}(src)
+		}(src, es.sourceAllocs[i])
 	}
 
 	wg.Add(1)
@@ -597,6 +651,9 @@ type executionContext struct {
 	parents       []DatasetID
 	streamContext streamContext
 	parallelOpts  ParallelOpts
+
+	// mem is the allocator for this operator when memory is profiled.
+	mem *operatorAllocator
 }
 
 func resolveTime(qt flux.Time, now time.Time) Time {
@@ -616,6 +673,9 @@ func (ec executionContext) StreamContext() StreamContext {
 }
 
 func (ec executionContext) Allocator() memory.Allocator {
+	if ec.mem != nil {
+		return ec.mem
+	}
 	return ec.es.alloc
 }
 
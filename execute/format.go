@@ -46,6 +46,10 @@ type FormatOptions struct {
 	RepeatHeaderCount int
 
 	NullRepresentation string
+
+	// Markdown writes the table as a Markdown pipe table
+	// instead of aligned columns.
+	Markdown bool
 }
 
 func DefaultFormatOptions() *FormatOptions {
@@ -132,6 +136,11 @@ func (f *Formatter) WriteTo(out io.Writer) (int64, error) {
 		return w.n, w.err
 	}
 
+	if f.opts.Markdown {
+		f.writeMarkdown(w)
+		return w.n, w.err
+	}
+
 	// Write rows
 	r := 0
 	w.err = f.tbl.Do(func(cr flux.ColReader) error {
@@ -229,6 +238,41 @@ func (f *Formatter) writeHeaderSeparator(w *writeToHelper) {
 	w.write(eol)
 }
 
+// writeMarkdown writes the rows as a Markdown pipe table. Columns
+// are not padded and pipes within values are escaped.
+func (f *Formatter) writeMarkdown(w *writeToHelper) {
+	w.write(eol)
+	w.write([]byte("|"))
+	for _, c := range f.cols.cols {
+		w.write([]byte(" " + escapeMarkdown(c.Label) + ":" + c.Type.String() + " |"))
+	}
+	w.write(eol)
+	w.write([]byte("|"))
+	for range f.cols.cols {
+		w.write([]byte(" --- |"))
+	}
+	w.write(eol)
+
+	w.err = f.tbl.Do(func(cr flux.ColReader) error {
+		l := cr.Len()
+		for i := 0; i < l; i++ {
+			w.write([]byte("|"))
+			for oj, c := range f.cols.cols {
+				j := f.cols.Idx(oj)
+				w.write([]byte(" " + escapeMarkdown(string(f.valueBuf(i, j, c.Type, cr))) + " |"))
+			}
+			w.write(eol)
+		}
+		return w.err
+	})
+}
+
+// escapeMarkdown escapes the characters that end a cell of a pipe table.
+func escapeMarkdown(s string) string {
+	s = strings.ReplaceAll(s, "|", "\\|")
+	return strings.ReplaceAll(s, "\n", " ")
+}
+
 func (f *Formatter) valueBuf(i, j int, typ flux.ColType, cr flux.ColReader) []byte {
 	buf := []byte(f.opts.NullRepresentation)
 	switch typ {
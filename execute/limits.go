@@ -0,0 +1,183 @@
+package execute
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/influxdata/flux"
+	"github.com/influxdata/flux/codes"
+	"github.com/influxdata/flux/internal/errors"
+)
+
+// ResourceLimits are the limits on the resources used by a query.
+// A limit that is zero is not enforced. Each limit that is exceeded
+// fails the query with a codes.ResourceExhausted error.
+type ResourceLimits struct {
+	// MaxTablesPerResult is the maximum number of tables in a result.
+	MaxTablesPerResult int64
+	// MaxRowsPerResult is the maximum number of rows in a result.
+	MaxRowsPerResult int64
+	// MaxBytesPerResult is the maximum size in bytes of the column values in a result.
+	MaxBytesPerResult int64
+	// MaxGroupKeys is the maximum number of distinct group keys
+	// that any operation may produce.
+	MaxGroupKeys int64
+	// MaxExecutionTime is the maximum time the query may take, including
+	// the evaluation of its script. It is enforced by the deadline of the
+	// context returned by WithExecutionTimeLimit, which the program that
+	// starts the query sets once for all the executions it runs.
+	MaxExecutionTime time.Duration
+}
+
+// IsZero reports whether no limits are set.
+func (l ResourceLimits) IsZero() bool {
+	return l == ResourceLimits{}
+}
+
+// resultLimits enforces the limits on the tables of a result.
+// The result's tables may be read concurrently so the counts are atomic.
+type resultLimits struct {
+	limits ResourceLimits
+	name   string
+	// op is the label of the operation that produces the result.
+	op string
+
+	tables int64
+	rows   int64
+	bytes  int64
+}
+
+func (rl *resultLimits) errorf(limit int64, unit string) error {
+	return errors.Newf(codes.ResourceExhausted, "result %q exceeded the limit of %d %s, produced by %s", rl.name, limit, unit, rl.op)
+}
+
+// addTable counts a table of the result.
+func (rl *resultLimits) addTable() error {
+	if max := rl.limits.MaxTablesPerResult; max > 0 && atomic.AddInt64(&rl.tables, 1) > max {
+		return rl.errorf(max, "tables")
+	}
+	return nil
+}
+
+// addColumns counts the rows and bytes of a column reader of the result.
+func (rl *resultLimits) addColumns(cr flux.ColReader) error {
+	if max := rl.limits.MaxRowsPerResult; max > 0 && atomic.AddInt64(&rl.rows, int64(cr.Len())) > max {
+		return rl.errorf(max, "rows")
+	}
+	if max := rl.limits.MaxBytesPerResult; max > 0 && atomic.AddInt64(&rl.bytes, columnBytes(cr)) > max {
+		return rl.errorf(max, "bytes")
+	}
+	return nil
+}
+
+// columnBytes returns the size of the values in the column reader.
+// Numbers and times count eight bytes, booleans one byte and strings their length.
+func columnBytes(cr flux.ColReader) int64 {
+	var n int64
+	for j, c := range cr.Cols() {
+		switch c.Type {
+		case flux.TBool:
+			n += int64(cr.Len())
+		case flux.TString:
+			vs := cr.Strings(j)
+			for i := 0; i < vs.Len(); i++ {
+				n += int64(vs.ValueLen(i))
+			}
+		default:
+			n += 8 * int64(cr.Len())
+		}
+	}
+	return n
+}
+
+// limitedTable is a table of a result that counts
+// the rows and bytes read from it against the limits.
+type limitedTable struct {
+	flux.Table
+	limits *resultLimits
+}
+
+func (t *limitedTable) Do(f func(flux.ColReader) error) error {
+	return t.Table.Do(func(cr flux.ColReader) error {
+		if err := t.limits.addColumns(cr); err != nil {
+			return err
+		}
+		return f(cr)
+	})
+}
+
+// groupKeyLimit counts the distinct group keys
+// that an operation sends to a transport.
+type groupKeyLimit struct {
+	max  int64
+	op   string
+	keys *RandomAccessGroupLookup
+	n    int64
+}
+
+func newGroupKeyLimit(max int64, op string) *groupKeyLimit {
+	return &groupKeyLimit{
+		max:  max,
+		op:   op,
+		keys: NewRandomAccessGroupLookup(),
+	}
+}
+
+// add counts the group key of the message if it has one.
+func (l *groupKeyLimit) add(m Message) error {
+	var key flux.GroupKey
+	switch m := m.(type) {
+	case ProcessMsg:
+		key = m.Table().Key()
+	case ProcessChunkMsg:
+		key = m.TableChunk().Key()
+	default:
+		return nil
+	}
+	if _, ok := l.keys.Lookup(key); ok {
+		return nil
+	}
+	l.keys.Set(key, nil)
+	if l.n++; l.n > l.max {
+		return errors.Newf(codes.ResourceExhausted, "%s exceeded the limit of %d group keys", l.op, l.max)
+	}
+	return nil
+}
+
+// executionTimeLimit is the deadline of a query with an execution time limit.
+type executionTimeLimit struct {
+	d        time.Duration
+	deadline time.Time
+}
+
+// WithExecutionTimeLimit returns a context whose deadline is the
+// execution time limit d from now. The limit is not changed when
+// the context already has one, so nested executions share the limit
+// of the query. The context is only canceled when d is not positive.
+func WithExecutionTimeLimit(ctx context.Context, d time.Duration) (context.Context, context.CancelFunc) {
+	if d <= 0 || ctx.Value(executionTimeLimitKey) != nil {
+		return context.WithCancel(ctx)
+	}
+	deadline := time.Now().Add(d)
+	ctx = context.WithValue(ctx, executionTimeLimitKey, executionTimeLimit{d: d, deadline: deadline})
+	return context.WithDeadline(ctx, deadline)
+}
+
+// ExecutionTimeLimitError returns the error for the execution time
+// limit when the context is done because the limit passed and err
+// otherwise. A context that is done because of an earlier deadline
+// set by the caller keeps its error.
+func ExecutionTimeLimitError(ctx context.Context, err error) error {
+	if ctx.Err() != context.DeadlineExceeded {
+		return err
+	}
+	limit, ok := ctx.Value(executionTimeLimitKey).(executionTimeLimit)
+	if !ok {
+		return err
+	}
+	if deadline, _ := ctx.Deadline(); !deadline.Equal(limit.deadline) {
+		return err
+	}
+	return errors.Newf(codes.ResourceExhausted, "query exceeded the execution time limit of %v", limit.d)
+}
@@ -0,0 +1,170 @@
+package execute_test
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/influxdata/flux"
+	"github.com/influxdata/flux/codes"
+	"github.com/influxdata/flux/dependency"
+	"github.com/influxdata/flux/execute"
+	"github.com/influxdata/flux/execute/executetest"
+	"github.com/influxdata/flux/internal/errors"
+	"github.com/influxdata/flux/plan"
+	"github.com/influxdata/flux/plan/plantest"
+	"github.com/influxdata/flux/stdlib/universe"
+	"go.uber.org/zap/zaptest"
+)
+
+// limitTables returns n tables with distinct group keys and two rows each.
+func limitTables(n int) []*executetest.Table {
+	tables := make([]*executetest.Table, n)
+	for i := range tables {
+		tag := fmt.Sprintf("t%d", i)
+		tables[i] = &executetest.Table{
+			KeyCols: []string{"tag"},
+			ColMeta: []flux.ColMeta{
+				{Label: "tag", Type: flux.TString},
+				{Label: "_time", Type: flux.TTime},
+				{Label: "_value", Type: flux.TFloat},
+			},
+			Data: [][]interface{}{
+				{tag, execute.Time(0), 1.0},
+				{tag, execute.Time(1), 2.0},
+			},
+		}
+	}
+	return tables
+}
+
+func TestExecutor_ResourceLimits(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		limits  execute.ResourceLimits
+		tables  int
+		wantErr string
+	}{
+		{
+			name:   "within limits",
+			limits: execute.ResourceLimits{MaxTablesPerResult: 3, MaxRowsPerResult: 6, MaxBytesPerResult: 1000, MaxGroupKeys: 3},
+			tables: 3,
+		},
+		{
+			name:    "tables",
+			limits:  execute.ResourceLimits{MaxTablesPerResult: 2},
+			tables:  3,
+			wantErr: `result "_result" exceeded the limit of 2 tables, produced by limit`,
+		},
+		{
+			name:    "rows",
+			limits:  execute.ResourceLimits{MaxRowsPerResult: 5},
+			tables:  3,
+			wantErr: `result "_result" exceeded the limit of 5 rows, produced by limit`,
+		},
+		{
+			// Each row has a two byte tag, a time and a float.
+			name:    "bytes",
+			limits:  execute.ResourceLimits{MaxBytesPerResult: 50},
+			tables:  3,
+			wantErr: `result "_result" exceeded the limit of 50 bytes, produced by limit`,
+		},
+		{
+			name:    "group keys",
+			limits:  execute.ResourceLimits{MaxGroupKeys: 2},
+			tables:  3,
+			wantErr: "from-test exceeded the limit of 2 group keys",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			spec := &plantest.PlanSpec{
+				Nodes: []plan.Node{
+					plan.CreatePhysicalNode("from-test", executetest.NewFromProcedureSpec(limitTables(tc.tables))),
+					plan.CreatePhysicalNode("limit", &universe.LimitProcedureSpec{N: 10}),
+					plan.CreatePhysicalNode("yield", executetest.NewYieldProcedureSpec("_result")),
+				},
+				Edges: [][2]int{{0, 1}, {1, 2}},
+				Resources: flux.ResourceManagement{
+					ConcurrencyQuota: 1,
+					MemoryBytesQuota: math.MaxInt64,
+				},
+				Now: time.Now(),
+			}
+
+			ctx, deps := dependency.Inject(context.Background(), executetest.NewTestExecuteDependencies())
+			defer deps.Finish()
+			execDeps := execute.DefaultExecutionDependencies()
+			execDeps.ExecutionOptions.Limits = tc.limits
+			ctx = execDeps.Inject(ctx)
+
+			exe := execute.NewExecutor(zaptest.NewLogger(t))
+			results, _, err := exe.Execute(ctx, plantest.CreatePlanSpec(spec), executetest.UnlimitedAllocator)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, r := range results {
+				if err = r.Tables().Do(func(tbl flux.Table) error {
+					_, err := executetest.ConvertTable(tbl)
+					return err
+				}); err != nil {
+					break
+				}
+			}
+
+			if tc.wantErr == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("expected error %q", tc.wantErr)
+			}
+			if got := errors.Code(err); got != codes.ResourceExhausted {
+				t.Errorf("unexpected code %v", got)
+			}
+			if !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("expected error containing %q, got %q", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestExecutionTimeLimit(t *testing.T) {
+	errCanceled := errors.New(codes.Canceled, "canceled")
+
+	// A nested limit keeps the deadline of the query.
+	ctx, cancel := execute.WithExecutionTimeLimit(context.Background(), time.Millisecond)
+	defer cancel()
+	deadline, _ := ctx.Deadline()
+	nested, cancelNested := execute.WithExecutionTimeLimit(ctx, time.Hour)
+	defer cancelNested()
+	if got, ok := nested.Deadline(); !ok || !got.Equal(deadline) {
+		t.Errorf("unexpected nested deadline %v, want %v", got, deadline)
+	}
+
+	if err := execute.ExecutionTimeLimitError(ctx, errCanceled); err != errCanceled {
+		t.Errorf("unexpected error before the deadline %v", err)
+	}
+	<-nested.Done()
+	err := execute.ExecutionTimeLimitError(nested, errCanceled)
+	if got := errors.Code(err); got != codes.ResourceExhausted {
+		t.Errorf("unexpected code %v", got)
+	}
+	if want := "query exceeded the execution time limit of 1ms"; err.Error() != want {
+		t.Errorf("unexpected error %q, want %q", err, want)
+	}
+
+	// An earlier deadline of the caller is not the limit.
+	parent, cancelParent := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancelParent()
+	ctx, cancel = execute.WithExecutionTimeLimit(parent, time.Hour)
+	defer cancel()
+	<-ctx.Done()
+	if err := execute.ExecutionTimeLimitError(ctx, errCanceled); err != errCanceled {
+		t.Errorf("unexpected error for the caller's deadline %v", err)
+	}
+}
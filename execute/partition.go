@@ -0,0 +1,201 @@
+package execute
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/influxdata/flux"
+	"github.com/influxdata/flux/codes"
+	"github.com/influxdata/flux/internal/errors"
+	"github.com/influxdata/flux/memory"
+	"github.com/influxdata/flux/plan"
+	"go.uber.org/zap"
+)
+
+// PartitionRequest asks for the execution of one partition of a parallel sub-plan.
+type PartitionRequest struct {
+	// Plan is the sub-plan encoded with plan.MarshalSpec.
+	// Its single root produces the tables of the partition.
+	Plan json.RawMessage `json:"plan"`
+	// Group is the partition to execute, from zero to Factor - 1.
+	Group int `json:"group"`
+	// Factor is the number of partitions of the sub-plan.
+	Factor int `json:"factor"`
+}
+
+// PartitionExecutor executes the partitions of parallel sub-plans,
+// usually in other processes.
+type PartitionExecutor interface {
+	// ExecutePartition executes the partition and calls f with each
+	// table that it produces. The table must be consumed before f returns.
+	// Canceling the context must stop the execution of the partition.
+	ExecutePartition(ctx context.Context, req *PartitionRequest, f func(flux.Table) error) error
+	// Factor returns the number of partitions that parallel sources
+	// are split into, usually the number of workers.
+	Factor() int
+}
+
+// PartitionFactor returns the number of partitions that parallel
+// sources are split into by the partition executor of the execution
+// options of the context. It returns zero when there is no partition
+// executor, in which case sources are not split.
+func PartitionFactor(ctx context.Context) int {
+	if !HaveExecutionDependencies(ctx) {
+		return 0
+	}
+	opts := GetExecutionDependencies(ctx).ExecutionOptions
+	if opts == nil || opts.Partitions == nil {
+		return 0
+	}
+	return opts.Partitions.Factor()
+}
+
+// ExecutePartition executes a single partition of a parallel sub-plan
+// in this process. It is used by workers to serve partition requests.
+// Only the copy of each parallel node that belongs to the partition is
+// created, so parallel sources read only their share of the data.
+// It calls f with each table of the partition and returns once the
+// execution is complete. The table must be consumed before f returns.
+func ExecutePartition(ctx context.Context, logger *zap.Logger, req *PartitionRequest, alloc memory.Allocator, f func(flux.Table) error) error {
+	if req.Factor <= 0 || req.Group < 0 || req.Group >= req.Factor {
+		return errors.Newf(codes.Invalid, "invalid partition %d of %d", req.Group, req.Factor)
+	}
+	p, err := plan.UnmarshalSpec(req.Plan)
+	if err != nil {
+		return err
+	}
+	if len(p.Roots) != 1 {
+		return errors.Newf(codes.Invalid, "partition plan must have a single root, found %d", len(p.Roots))
+	}
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	e := &executor{logger: logger}
+	results, statsCh, err := e.execute(ctx, p, alloc, &ParallelOpts{Group: req.Group, Factor: req.Factor})
+	if err != nil {
+		return err
+	}
+	for _, r := range results {
+		err = r.Tables().Do(f)
+	}
+	// Stop the execution if the tables were not all read
+	// and wait for it to finish before returning.
+	cancel()
+	for range statsCh {
+	}
+	return err
+}
+
+const remotePartitionKind = "remotePartition"
+
+// remotePartitionProcedureSpec is a parallel source that executes
+// a parallel sub-plan with a partition executor. Each copy of the
+// source executes the partition of its parallel group.
+type remotePartitionProcedureSpec struct {
+	plan.DefaultCost
+	Plan     json.RawMessage
+	Factor   int
+	executor PartitionExecutor
+}
+
+func (s *remotePartitionProcedureSpec) Kind() plan.ProcedureKind {
+	return remotePartitionKind
+}
+
+func (s *remotePartitionProcedureSpec) Copy() plan.ProcedureSpec {
+	ns := *s
+	return &ns
+}
+
+func (s *remotePartitionProcedureSpec) OutputAttributes() plan.PhysicalAttributes {
+	return plan.PhysicalAttributes{
+		plan.ParallelRunKey: plan.ParallelRunAttribute{Factor: s.Factor},
+	}
+}
+
+func init() {
+	RegisterSource(remotePartitionKind, createRemotePartitionSource)
+}
+
+func createRemotePartitionSource(spec plan.ProcedureSpec, id DatasetID, a Administration) (Source, error) {
+	s, ok := spec.(*remotePartitionProcedureSpec)
+	if !ok {
+		return nil, errors.Newf(codes.Internal, "invalid spec type %T", spec)
+	}
+	return CreateSourceFromIterator(&remotePartition{
+		spec:  s,
+		group: a.ParallelOpts().Group,
+	}, id)
+}
+
+// remotePartition reads the tables of one partition from the partition executor.
+type remotePartition struct {
+	spec  *remotePartitionProcedureSpec
+	group int
+}
+
+func (p *remotePartition) Do(ctx context.Context, f func(flux.Table) error) error {
+	req := &PartitionRequest{
+		Plan:   p.spec.Plan,
+		Group:  p.group,
+		Factor: p.spec.Factor,
+	}
+	if err := p.spec.executor.ExecutePartition(ctx, req, f); err != nil {
+		return errors.Wrapf(err, codes.Inherit, "partition %d of %d", p.group, p.spec.Factor)
+	}
+	return nil
+}
+
+// distributePlan replaces the sub-plan below each parallel merge
+// with a source that executes the partitions of the sub-plan with
+// the partition executor. Sub-plans that cannot be serialized or
+// that have side effects are executed locally.
+func distributePlan(p *plan.Spec, pe PartitionExecutor, logger *zap.Logger) {
+	var merges []plan.Node
+	_ = p.TopDownWalk(func(node plan.Node) error {
+		if plan.GetOutputAttribute(node, plan.ParallelMergeKey) != nil {
+			merges = append(merges, node)
+		}
+		return nil
+	})
+
+	for _, merge := range merges {
+		if len(merge.Predecessors()) != 1 {
+			continue
+		}
+		pred := merge.Predecessors()[0]
+		if plan.HasSideEffect(pred.ProcedureSpec()) {
+			continue
+		}
+		factor := plan.GetOutputAttribute(merge, plan.ParallelMergeKey).(plan.ParallelMergeAttribute).Factor
+
+		sub := plan.NewPlanSpec()
+		sub.Roots[pred] = struct{}{}
+		sub.Resources = p.Resources
+		sub.Now = p.Now
+		data, err := plan.MarshalSpec(sub)
+		if err != nil {
+			logger.Debug("Executing parallel sub-plan locally",
+				zap.String("node", string(pred.ID())),
+				zap.Error(err),
+			)
+			continue
+		}
+
+		// The source takes the place of the root of the sub-plan
+		// so results and errors refer to the same node.
+		remote := plan.CreatePhysicalNode(pred.ID(), &remotePartitionProcedureSpec{
+			Plan:     data,
+			Factor:   factor,
+			executor: pe,
+		})
+		remote.SetBounds(pred.Bounds())
+		merge.ClearPredecessors()
+		merge.AddPredecessors(remote)
+		remote.AddSuccessors(merge)
+	}
+}
@@ -119,6 +119,10 @@ func (o *OperatorProfiler) getTableBuilder(stats flux.Statistics, alloc memory.A
 			Label: "MeanDuration",
 			Type:  flux.TFloat,
 		},
+		{
+			Label: "MaxAllocated",
+			Type:  flux.TInt,
+		},
 	}
 	for _, col := range colMeta {
 		if _, err := b.AddCol(col); err != nil {
@@ -135,6 +139,7 @@ func (o *OperatorProfiler) getTableBuilder(stats flux.Statistics, alloc memory.A
 		b.AppendInt(5, profile.Max)
 		b.AppendInt(6, profile.Sum)
 		b.AppendFloat(7, profile.Mean)
+		b.AppendInt(8, profile.MaxAllocated)
 	}
 	return b, nil
 }
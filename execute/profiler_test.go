@@ -42,22 +42,22 @@ func TestOperatorProfiler_GetResult(t *testing.T) {
 	// Build the "want" table.
 	var wantStr bytes.Buffer
 	wantStr.WriteString(`
-#datatype,string,long,string,string,string,long,long,long,long,double
-#group,false,false,true,false,false,false,false,false,false,false
-#default,_profiler,,,,,,,,,
-,result,table,_measurement,Type,Label,Count,MinDuration,MaxDuration,DurationSum,MeanDuration
+#datatype,string,long,string,string,string,long,long,long,long,double,long
+#group,false,false,true,false,false,false,false,false,false,false,false
+#default,_profiler,,,,,,,,,,
+,result,table,_measurement,Type,Label,Count,MinDuration,MaxDuration,DurationSum,MeanDuration,MaxAllocated
 `)
-	fmt.Fprintf(&wantStr, ",,0,profiler/operator,%s,%s,%d,%d,%d,%d,%f\n",
-		"type0", "lab0", 4, 1000, 1606, 5212, 1303.0,
+	fmt.Fprintf(&wantStr, ",,0,profiler/operator,%s,%s,%d,%d,%d,%d,%f,%d\n",
+		"type0", "lab0", 4, 1000, 1606, 5212, 1303.0, 0,
 	)
-	fmt.Fprintf(&wantStr, ",,0,profiler/operator,%s,%s,%d,%d,%d,%d,%f\n",
-		"type1", "lab0", 4, 1101, 1707, 5616, 1404.0,
+	fmt.Fprintf(&wantStr, ",,0,profiler/operator,%s,%s,%d,%d,%d,%d,%f,%d\n",
+		"type1", "lab0", 4, 1101, 1707, 5616, 1404.0, 0,
 	)
-	fmt.Fprintf(&wantStr, ",,0,profiler/operator,%s,%s,%d,%d,%d,%d,%f\n",
-		"type0", "lab1", 4, 1808, 2414, 8444, 2111.0,
+	fmt.Fprintf(&wantStr, ",,0,profiler/operator,%s,%s,%d,%d,%d,%d,%f,%d\n",
+		"type0", "lab1", 4, 1808, 2414, 8444, 2111.0, 1024,
 	)
-	fmt.Fprintf(&wantStr, ",,0,profiler/operator,%s,%s,%d,%d,%d,%d,%f\n",
-		"type1", "lab1", 4, 1909, 2515, 8848, 2212.0,
+	fmt.Fprintf(&wantStr, ",,0,profiler/operator,%s,%s,%d,%d,%d,%d,%f,%d\n",
+		"type1", "lab1", 4, 1909, 2515, 8848, 2212.0, 0,
 	)
 	count := 16
 
@@ -79,6 +79,8 @@ func TestOperatorProfiler_GetResult(t *testing.T) {
 		span.FinishWithTime(time.Date(2020, 10, 14, 12, 30, 0, 1000+offset, time.UTC))
 	}
 
+	stats.Profiles[1].MaxAllocated = 1024
+
 	// Write profiles for the various different transports.
 	for i := 0; i < count; i++ {
 		profile := &stats.Profiles[i%2*2+i/8]
@@ -0,0 +1,111 @@
+package remote
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/influxdata/flux"
+	"github.com/influxdata/flux/codes"
+	"github.com/influxdata/flux/execute"
+	"github.com/influxdata/flux/internal/errors"
+	"github.com/influxdata/flux/memory"
+)
+
+// Client executes the partitions of parallel sub-plans on workers.
+// Each partition is sent to the worker at the index of its group modulo
+// the number of workers, so the partitions of a sub-plan are spread
+// evenly across the workers.
+type Client struct {
+	urls   []string
+	client *http.Client
+}
+
+var _ execute.PartitionExecutor = (*Client)(nil)
+
+// NewClient creates a client for the workers at the base URLs.
+// If client is nil, http.DefaultClient is used.
+func NewClient(urls []string, client *http.Client) *Client {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &Client{
+		urls:   urls,
+		client: client,
+	}
+}
+
+// Factor returns the number of workers so that each
+// worker executes one partition of a parallel source.
+func (c *Client) Factor() int {
+	return len(c.urls)
+}
+
+// ExecutePartition sends the partition to its worker and calls f with
+// each table of the response. An error reported by the worker keeps its code.
+func (c *Client) ExecutePartition(ctx context.Context, req *execute.PartitionRequest, f func(flux.Table) error) error {
+	if len(c.urls) == 0 {
+		return errors.New(codes.Invalid, "no workers to execute partitions")
+	}
+	url := strings.TrimSuffix(c.urls[req.Group%len(c.urls)], "/") + PartitionPath
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		return errors.Wrap(err, codes.Internal, "failed to encode partition request")
+	}
+	hreq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return errors.Wrapf(err, codes.Invalid, "invalid worker url %q", url)
+	}
+	hreq.Header.Set("Content-Type", "application/json")
+	hreq.Header.Set("Accept", ContentType)
+
+	resp, err := c.client.Do(hreq)
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return errors.Wrapf(err, codes.Unavailable, "failed to reach worker %s", url)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return decodeError(resp)
+	}
+
+	mem := memory.GetAllocator(ctx)
+	if mem == nil {
+		mem = memory.DefaultAllocator
+	}
+	if err := decodeTables(resp.Body, mem, f); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return err
+	}
+	// The trailers are only available once the body has been read.
+	if msg := resp.Trailer.Get(ErrorTrailer); msg != "" {
+		var code codes.Code
+		if err := code.UnmarshalText([]byte(resp.Trailer.Get(ErrorCodeTrailer))); err != nil {
+			code = codes.Unknown
+		}
+		return errors.New(code, msg)
+	}
+	return nil
+}
+
+// decodeError returns the error of a failed partition response.
+func decodeError(resp *http.Response) error {
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return errors.Wrapf(err, codes.Unavailable, "worker responded with status %d", resp.StatusCode)
+	}
+	var ej errorJSON
+	if err := json.Unmarshal(body, &ej); err != nil || ej.Message == "" {
+		return errors.Newf(codes.Unavailable, "worker responded with status %d: %s", resp.StatusCode, bytes.TrimSpace(body))
+	}
+	return errors.New(ej.Code, ej.Message)
+}
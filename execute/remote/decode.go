@@ -0,0 +1,152 @@
+package remote
+
+import (
+	"io"
+	"strconv"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	arrowarray "github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/ipc"
+	"github.com/influxdata/flux"
+	"github.com/influxdata/flux/array"
+	fluxarrow "github.com/influxdata/flux/arrow"
+	"github.com/influxdata/flux/codes"
+	"github.com/influxdata/flux/execute"
+	"github.com/influxdata/flux/execute/table"
+	"github.com/influxdata/flux/internal/errors"
+	"github.com/influxdata/flux/memory"
+	"github.com/influxdata/flux/values"
+)
+
+// decodeTables reads the tables written by a fluxarrow.IPCMultiResultEncoder
+// and calls f with each of them until r is exhausted. Each table is
+// read completely before f is called.
+func decodeTables(r io.Reader, mem memory.Allocator, f func(flux.Table) error) error {
+	for {
+		rdr, err := ipc.NewReader(r, ipc.WithAllocator(mem))
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return errors.Wrap(err, codes.Internal, "failed to decode table")
+		}
+		tbl, err := decodeTable(rdr)
+		rdr.Release()
+		if err != nil {
+			return err
+		}
+		if err := f(tbl); err != nil {
+			return err
+		}
+	}
+}
+
+// decodeTable reads the table of a single Arrow IPC stream.
+func decodeTable(rdr *ipc.Reader) (flux.Table, error) {
+	cols, key, err := decodeSchema(rdr)
+	if err != nil {
+		return nil, err
+	}
+
+	var buffers []flux.ColReader
+	release := func() {
+		for _, buf := range buffers {
+			buf.Release()
+		}
+	}
+	for rdr.Next() {
+		rec := rdr.Record()
+		vs := make([]array.Array, len(cols))
+		for j, c := range cols {
+			data := rec.Column(j).Data()
+			if c.Type == flux.TTime {
+				// Reinterpret the timestamp buffers as an int64 array.
+				data = arrowarray.NewData(array.IntType, data.Len(), data.Buffers(), nil, data.NullN(), data.Offset())
+				vs[j] = array.MakeFromData(data)
+				data.Release()
+				continue
+			}
+			vs[j] = array.MakeFromData(data)
+		}
+		buffers = append(buffers, &fluxarrow.TableBuffer{
+			GroupKey: key,
+			Columns:  cols,
+			Values:   vs,
+		})
+	}
+	if err := rdr.Err(); err != nil && !errors.Is(err, io.EOF) {
+		release()
+		return nil, errors.Wrap(err, codes.Internal, "failed to decode table")
+	}
+	return &table.BufferedTable{
+		GroupKey: key,
+		Columns:  cols,
+		Buffers:  buffers,
+	}, nil
+}
+
+// decodeSchema returns the columns and group key described by the
+// schema of the stream.
+func decodeSchema(rdr *ipc.Reader) ([]flux.ColMeta, flux.GroupKey, error) {
+	schema := rdr.Schema()
+	cols := make([]flux.ColMeta, schema.NumFields())
+	var (
+		keyCols   []flux.ColMeta
+		keyValues []values.Value
+	)
+	for j, field := range schema.Fields() {
+		text, _ := field.Metadata.GetValue(fluxarrow.TypeMetadataKey)
+		typ, ok := colType(text)
+		if !ok {
+			return nil, nil, errors.Newf(codes.Internal, "failed to decode table: column %q has unknown type %q", field.Name, text)
+		}
+		cols[j] = flux.ColMeta{Label: field.Name, Type: typ}
+		if group, _ := field.Metadata.GetValue(fluxarrow.GroupMetadataKey); group != "true" {
+			continue
+		}
+		v, err := keyValue(field.Metadata, typ)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, codes.Internal, "failed to decode table: group key value of column %q", field.Name)
+		}
+		keyCols = append(keyCols, cols[j])
+		keyValues = append(keyValues, v)
+	}
+	return cols, execute.NewGroupKey(keyCols, keyValues), nil
+}
+
+func colType(text string) (flux.ColType, bool) {
+	for _, typ := range []flux.ColType{flux.TBool, flux.TInt, flux.TUInt, flux.TFloat, flux.TString, flux.TTime} {
+		if typ.String() == text {
+			return typ, true
+		}
+	}
+	return flux.TInvalid, false
+}
+
+// keyValue parses the group key value of a column from its
+// field metadata. The value is null when it is absent.
+func keyValue(md arrow.Metadata, typ flux.ColType) (values.Value, error) {
+	text, ok := md.GetValue(fluxarrow.KeyValueMetadataKey)
+	if !ok {
+		return values.NewNull(flux.SemanticType(typ)), nil
+	}
+	switch typ {
+	case flux.TBool:
+		v, err := strconv.ParseBool(text)
+		return values.NewBool(v), err
+	case flux.TInt:
+		v, err := strconv.ParseInt(text, 10, 64)
+		return values.NewInt(v), err
+	case flux.TUInt:
+		v, err := strconv.ParseUint(text, 10, 64)
+		return values.NewUInt(v), err
+	case flux.TFloat:
+		v, err := strconv.ParseFloat(text, 64)
+		return values.NewFloat(v), err
+	case flux.TString:
+		return values.NewString(text), nil
+	default:
+		v, err := strconv.ParseInt(text, 10, 64)
+		return values.NewTime(values.Time(v)), err
+	}
+}
@@ -0,0 +1,72 @@
+// Package remote executes the partitions of parallel sub-plans on
+// worker processes over HTTP.
+//
+// A Worker serves partition requests by executing the partition and
+// streaming its tables back as Arrow IPC streams. A Client implements
+// execute.PartitionExecutor by sending each partition to a worker and
+// decoding the tables of the response.
+package remote
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/influxdata/flux/codes"
+	"github.com/influxdata/flux/internal/errors"
+)
+
+// PartitionPath is the path of the partition endpoint served by a worker.
+const PartitionPath = "/api/v2/partition"
+
+// ContentType is the content type of the tables streamed by a worker.
+const ContentType = "application/vnd.apache.arrow.stream"
+
+// Trailers of a partition response that report an error which occurred
+// after the worker started streaming tables.
+const (
+	// ErrorTrailer holds the error message.
+	ErrorTrailer = "Flux-Error"
+	// ErrorCodeTrailer holds the code of the error.
+	ErrorCodeTrailer = "Flux-Error-Code"
+)
+
+// errorJSON is the body of a partition response that failed
+// before any table was sent.
+type errorJSON struct {
+	Code    codes.Code `json:"code"`
+	Message string     `json:"message"`
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(errorJSON{
+		Code:    errors.Code(err),
+		Message: err.Error(),
+	})
+}
+
+// statusCode returns the http status code for an error.
+func statusCode(err error) int {
+	switch errors.Code(err) {
+	case codes.Invalid, codes.FailedPrecondition, codes.OutOfRange:
+		return http.StatusBadRequest
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	case codes.Unimplemented:
+		return http.StatusNotImplemented
+	case codes.Canceled:
+		// Client closed request.
+		return 499
+	default:
+		return http.StatusInternalServerError
+	}
+}
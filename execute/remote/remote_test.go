@@ -0,0 +1,328 @@
+package remote_test
+
+import (
+	"context"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/influxdata/flux"
+	"github.com/influxdata/flux/codes"
+	"github.com/influxdata/flux/dependency"
+	"github.com/influxdata/flux/execute"
+	"github.com/influxdata/flux/execute/executetest"
+	"github.com/influxdata/flux/execute/remote"
+	"github.com/influxdata/flux/internal/errors"
+	"github.com/influxdata/flux/plan"
+	"github.com/influxdata/flux/plan/plantest"
+	"github.com/influxdata/flux/stdlib/universe"
+	"go.uber.org/zap/zaptest"
+)
+
+const partitionTestKind = "remote-partition-test"
+
+// partitionTestProcedureSpec is a serializable parallel source.
+// Each partition produces a table with a row keyed by the partition
+// and an empty table.
+type partitionTestProcedureSpec struct {
+	plan.DefaultCost
+	Factor int
+	// Fail makes the second partition fail after its first table.
+	Fail bool
+	// Block makes every partition wait until it is canceled.
+	Block bool
+}
+
+func (s *partitionTestProcedureSpec) Kind() plan.ProcedureKind {
+	return partitionTestKind
+}
+
+func (s *partitionTestProcedureSpec) Copy() plan.ProcedureSpec {
+	ns := *s
+	return &ns
+}
+
+func (s *partitionTestProcedureSpec) OutputAttributes() plan.PhysicalAttributes {
+	return plan.PhysicalAttributes{
+		plan.ParallelRunKey: plan.ParallelRunAttribute{Factor: s.Factor},
+	}
+}
+
+// started and canceled receive the group of each blocked partition
+// when it starts and when it is canceled.
+var started, canceled chan int
+
+func init() {
+	execute.RegisterSource(partitionTestKind, func(spec plan.ProcedureSpec, id execute.DatasetID, a execute.Administration) (execute.Source, error) {
+		return execute.CreateSourceFromIterator(&partitionTestIterator{
+			spec:  spec.(*partitionTestProcedureSpec),
+			group: a.ParallelOpts().Group,
+		}, id)
+	})
+	plan.RegisterJSONCodec[partitionTestProcedureSpec](partitionTestKind)
+}
+
+type partitionTestIterator struct {
+	spec  *partitionTestProcedureSpec
+	group int
+}
+
+func (it *partitionTestIterator) Do(ctx context.Context, f func(flux.Table) error) error {
+	if it.spec.Block {
+		started <- it.group
+		<-ctx.Done()
+		canceled <- it.group
+		return ctx.Err()
+	}
+	for _, tbl := range partitionTables(it.group) {
+		if err := f(tbl); err != nil {
+			return err
+		}
+		if it.spec.Fail && it.group == 1 {
+			return errors.New(codes.FailedPrecondition, "source failed")
+		}
+	}
+	return nil
+}
+
+func partitionTables(group int) []*executetest.Table {
+	cols := []flux.ColMeta{
+		{Label: "_time", Type: flux.TTime},
+		{Label: "g", Type: flux.TInt},
+		{Label: "host", Type: flux.TString},
+		{Label: "_value", Type: flux.TFloat},
+	}
+	return []*executetest.Table{
+		{
+			KeyCols: []string{"g", "host"},
+			ColMeta: cols,
+			Data: [][]interface{}{
+				{execute.Time(1), int64(group), "a", float64(group)},
+				{execute.Time(2), int64(group), "a", nil},
+			},
+		},
+		{
+			KeyCols:   []string{"g", "host"},
+			KeyValues: []interface{}{int64(group), nil},
+			ColMeta:   cols,
+		},
+	}
+}
+
+// newWorkers starts n workers and returns their urls
+// and the number of requests each one served.
+func newWorkers(t *testing.T, n int) ([]string, []*int32) {
+	t.Helper()
+	urls := make([]string, n)
+	counts := make([]*int32, n)
+	for i := range urls {
+		count := new(int32)
+		w := &remote.Worker{Logger: zaptest.NewLogger(t)}
+		mux := http.NewServeMux()
+		mux.HandleFunc(remote.PartitionPath, func(rw http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(count, 1)
+			w.ServeHTTP(rw, r)
+		})
+		server := httptest.NewServer(mux)
+		t.Cleanup(server.Close)
+		urls[i], counts[i] = server.URL, count
+	}
+	return urls, counts
+}
+
+// executeOnWorkers starts the execution of a plan that merges the partitions
+// of the source on the workers.
+func executeOnWorkers(ctx context.Context, t *testing.T, urls []string, source *partitionTestProcedureSpec) (map[string]flux.Result, error) {
+	t.Helper()
+	spec := &plantest.PlanSpec{
+		Nodes: []plan.Node{
+			plan.CreatePhysicalNode("source", source),
+			plan.CreatePhysicalNode("merge", &universe.PartitionMergeProcedureSpec{Factor: source.Factor}),
+			plan.CreatePhysicalNode("yield", executetest.NewYieldProcedureSpec("_result")),
+		},
+		Edges: [][2]int{{0, 1}, {1, 2}},
+		Resources: flux.ResourceManagement{
+			ConcurrencyQuota: source.Factor,
+			MemoryBytesQuota: math.MaxInt64,
+		},
+		Now: time.Now(),
+	}
+
+	ctx, deps := dependency.Inject(ctx, executetest.NewTestExecuteDependencies())
+	t.Cleanup(deps.Finish)
+	execDeps := execute.DefaultExecutionDependencies()
+	execDeps.ExecutionOptions.Partitions = remote.NewClient(urls, nil)
+	ctx = execDeps.Inject(ctx)
+
+	exe := execute.NewExecutor(zaptest.NewLogger(t))
+	results, _, err := exe.Execute(ctx, plantest.CreatePlanSpec(spec), executetest.UnlimitedAllocator)
+	return results, err
+}
+
+func readResults(results map[string]flux.Result) ([]*executetest.Table, error) {
+	var got []*executetest.Table
+	for _, r := range results {
+		if err := r.Tables().Do(func(tbl flux.Table) error {
+			t, err := executetest.ConvertTable(tbl)
+			if err != nil {
+				return err
+			}
+			got = append(got, t)
+			return nil
+		}); err != nil {
+			return got, err
+		}
+	}
+	return got, nil
+}
+
+func TestClient_ExecutePartition(t *testing.T) {
+	urls, counts := newWorkers(t, 2)
+	results, err := executeOnWorkers(context.Background(), t, urls, &partitionTestProcedureSpec{Factor: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := readResults(results)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var want []*executetest.Table
+	for group := 0; group < 4; group++ {
+		want = append(want, partitionTables(group)...)
+	}
+	executetest.NormalizeTables(got)
+	executetest.NormalizeTables(want)
+	if !cmp.Equal(want, got) {
+		t.Errorf("unexpected tables -want/+got:\n%s", cmp.Diff(want, got))
+	}
+
+	// The partitions are spread evenly across the workers.
+	for i, count := range counts {
+		if n := atomic.LoadInt32(count); n != 2 {
+			t.Errorf("worker %d executed %d partitions, want 2", i, n)
+		}
+	}
+}
+
+func TestClient_ExecutePartition_Error(t *testing.T) {
+	urls, _ := newWorkers(t, 2)
+	results, err := executeOnWorkers(context.Background(), t, urls, &partitionTestProcedureSpec{Factor: 2, Fail: true})
+	if err == nil {
+		_, err = readResults(results)
+	}
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	if got, want := errors.Code(err), codes.FailedPrecondition; got != want {
+		t.Errorf("unexpected code %v, want %v", got, want)
+	}
+	for _, want := range []string{"partition 1 of 2", "source failed"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected error containing %q, got %q", want, err)
+		}
+	}
+}
+
+func TestClient_ExecutePartition_Cancel(t *testing.T) {
+	started, canceled = make(chan int, 2), make(chan int, 2)
+	urls, _ := newWorkers(t, 2)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	results, err := executeOnWorkers(ctx, t, urls, &partitionTestProcedureSpec{Factor: 2, Block: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := readResults(results)
+		errCh <- err
+	}()
+
+	timeout := time.After(10 * time.Second)
+	for i := 0; i < 2; i++ {
+		select {
+		case <-started:
+		case <-timeout:
+			t.Fatal("timed out waiting for the partitions to start")
+		}
+	}
+
+	// Canceling the query cancels the partitions on the workers.
+	cancel()
+	for i := 0; i < 2; i++ {
+		select {
+		case <-canceled:
+		case <-timeout:
+			t.Fatal("timed out waiting for the partitions to be canceled")
+		}
+	}
+	select {
+	case <-errCh:
+	case <-timeout:
+		t.Fatal("timed out waiting for the query to finish")
+	}
+}
+
+func TestWorker_Errors(t *testing.T) {
+	server := httptest.NewServer(&remote.Worker{})
+	defer server.Close()
+
+	for _, tc := range []struct {
+		name   string
+		method string
+		body   string
+		status int
+		want   string
+	}{
+		{
+			name:   "method not allowed",
+			method: http.MethodGet,
+			status: http.StatusMethodNotAllowed,
+			want:   `{"code":"invalid","message":"method GET not allowed"}` + "\n",
+		},
+		{
+			name:   "invalid request",
+			method: http.MethodPost,
+			body:   `{`,
+			status: http.StatusBadRequest,
+			want:   `{"code":"invalid","message":"failed to decode partition request: unexpected EOF"}` + "\n",
+		},
+		{
+			name:   "invalid partition",
+			method: http.MethodPost,
+			body:   `{"plan":{},"group":2,"factor":2}`,
+			status: http.StatusBadRequest,
+			want:   `{"code":"invalid","message":"invalid partition 2 of 2"}` + "\n",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := http.NewRequest(tc.method, server.URL, strings.NewReader(tc.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer func() { _ = resp.Body.Close() }()
+			if resp.StatusCode != tc.status {
+				t.Errorf("unexpected status %d, want %d", resp.StatusCode, tc.status)
+			}
+			var body strings.Builder
+			if _, err := io.Copy(&body, resp.Body); err != nil {
+				t.Fatal(err)
+			}
+			if body.String() != tc.want {
+				t.Errorf("unexpected body %q, want %q", body.String(), tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,139 @@
+package remote
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/influxdata/flux"
+	"github.com/influxdata/flux/arrow"
+	"github.com/influxdata/flux/codes"
+	"github.com/influxdata/flux/dependency"
+	"github.com/influxdata/flux/execute"
+	"github.com/influxdata/flux/internal/errors"
+	"github.com/influxdata/flux/memory"
+	"github.com/influxdata/flux/plan"
+	"go.uber.org/zap"
+)
+
+// Worker is an http.Handler that executes the partitions of parallel
+// sub-plans sent by a Client.
+//
+// The body of a request is a JSON execute.PartitionRequest. The tables
+// of the partition are streamed back as they are produced in the format
+// of arrow.IPCMultiResultEncoder. An error that occurs before the first
+// table is sent is returned with an error status and a JSON body and
+// an error that occurs later is returned in the ErrorTrailer and
+// ErrorCodeTrailer trailers. The execution of the partition is canceled
+// when the client disconnects.
+type Worker struct {
+	// WithDependencies injects the dependencies used to execute
+	// a partition into the request context. It may be nil.
+	WithDependencies func(ctx context.Context) (context.Context, *dependency.Span, error)
+	// Logger is used by the executor. It may be nil.
+	Logger *zap.Logger
+}
+
+func (w *Worker) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		rw.Header().Set("Allow", http.MethodPost)
+		writeError(rw, http.StatusMethodNotAllowed, errors.Newf(codes.Invalid, "method %s not allowed", r.Method))
+		return
+	}
+
+	var req execute.PartitionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(rw, http.StatusBadRequest, errors.Wrap(err, codes.Invalid, "failed to decode partition request"))
+		return
+	}
+
+	ctx := r.Context()
+	if w.WithDependencies != nil {
+		var (
+			span *dependency.Span
+			err  error
+		)
+		ctx, span, err = w.WithDependencies(ctx)
+		if err != nil {
+			writeError(rw, http.StatusInternalServerError, err)
+			return
+		}
+		defer span.Finish()
+	}
+
+	rw.Header().Set("Content-Type", ContentType)
+	rw.Header().Set("Trailer", ErrorTrailer+", "+ErrorCodeTrailer)
+	out := &responseWriter{ResponseWriter: rw}
+	if err := w.execute(ctx, &req, out); err != nil {
+		if out.written == 0 {
+			// Nothing has been sent yet so the error
+			// can still be reported with a status code.
+			rw.Header().Del("Trailer")
+			writeError(rw, statusCode(err), err)
+			return
+		}
+		rw.Header().Set(ErrorTrailer, err.Error())
+		rw.Header().Set(ErrorCodeTrailer, errors.Code(err).String())
+	}
+}
+
+// execute executes the partition and encodes its tables to out,
+// flushing the output after each table.
+func (w *Worker) execute(ctx context.Context, req *execute.PartitionRequest, out *responseWriter) error {
+	logger := w.Logger
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	mem := &memory.ResourceAllocator{}
+	tables := partitionTables(func(f func(flux.Table) error) error {
+		return execute.ExecutePartition(ctx, logger, req, mem, func(tbl flux.Table) error {
+			if err := f(tbl); err != nil {
+				return err
+			}
+			out.Flush()
+			return nil
+		})
+	})
+	results := flux.NewSliceResultIterator([]flux.Result{partitionResult{tables: tables}})
+	_, err := arrow.NewIPCMultiResultEncoder(mem).Encode(out, results)
+	return err
+}
+
+// partitionResult is the single result of a partition.
+type partitionResult struct {
+	tables flux.TableIterator
+}
+
+func (r partitionResult) Name() string {
+	return plan.DefaultYieldName
+}
+
+func (r partitionResult) Tables() flux.TableIterator {
+	return r.tables
+}
+
+// partitionTables executes the partition when its tables are read.
+type partitionTables func(f func(flux.Table) error) error
+
+func (fn partitionTables) Do(f func(flux.Table) error) error {
+	return fn(f)
+}
+
+// responseWriter counts the bytes written to the response
+// and flushes the underlying writer on request.
+type responseWriter struct {
+	http.ResponseWriter
+	written int64
+}
+
+func (w *responseWriter) Write(p []byte) (int, error) {
+	n, err := w.ResponseWriter.Write(p)
+	w.written += int64(n)
+	return n, err
+}
+
+func (w *responseWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
@@ -17,6 +17,9 @@ type result struct {
 
 	abortErr chan error
 	aborted  chan struct{}
+
+	// limits are the limits on the tables of the result, if any.
+	limits *resultLimits
 }
 
 type resultMessage struct {
@@ -43,6 +46,13 @@ func (s *result) RetractTable(DatasetID, flux.GroupKey) error {
 }
 
 func (s *result) Process(id DatasetID, tbl flux.Table) error {
+	if s.limits != nil {
+		if err := s.limits.addTable(); err != nil {
+			tbl.Done()
+			return err
+		}
+		tbl = &limitedTable{Table: tbl, limits: s.limits}
+	}
 	select {
 	case s.tables <- resultMessage{
 		table: tbl,
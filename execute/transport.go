@@ -51,6 +51,7 @@ type consecutiveTransport struct {
 	logger     *zap.Logger
 
 	t        Transport
+	mem      memory.Allocator
 	messages MessageQueue
 	stack    []interpreter.StackEntry
 	profile  flux.TransportProfile
@@ -65,6 +66,9 @@ type consecutiveTransport struct {
 
 	initSpanOnce sync.Once
 	span         opentracing.Span
+
+	// keyLimit limits the group keys sent to the transport, if set.
+	keyLimit *groupKeyLimit
 }
 
 func newConsecutiveTransport(ctx context.Context, dispatcher Dispatcher, t Transformation, n plan.Node, logger *zap.Logger, mem memory.Allocator) *consecutiveTransport {
@@ -73,6 +77,7 @@ func newConsecutiveTransport(ctx context.Context, dispatcher Dispatcher, t Trans
 		dispatcher: dispatcher,
 		logger:     logger,
 		t:          WrapTransformationInTransport(t, mem),
+		mem:        mem,
 		// TODO(nathanielc): Have planner specify message queue initial buffer size.
 		messages: newMessageQueue(64),
 		profile: flux.TransportProfile{
@@ -127,7 +132,11 @@ func (t *consecutiveTransport) Finished() <-chan struct{} {
 }
 
 func (t *consecutiveTransport) TransportProfile() flux.TransportProfile {
-	return t.profile
+	profile := t.profile
+	if mem, ok := t.mem.(*operatorAllocator); ok {
+		profile.MaxAllocated = mem.MaxAllocated()
+	}
+	return profile
 }
 
 func (t *consecutiveTransport) RetractTable(id DatasetID, key flux.GroupKey) error {
@@ -294,6 +303,12 @@ func (t *consecutiveTransport) processMessage(m Message) (finished bool, err err
 	span := t.profile.StartSpan()
 	defer span.Finish()
 
+	if t.keyLimit != nil {
+		if err := t.keyLimit.add(m); err != nil {
+			m.Ack()
+			return false, err
+		}
+	}
 	if err := t.t.ProcessMessage(m); err != nil {
 		return false, err
 	}
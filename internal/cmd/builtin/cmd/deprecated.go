@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"bufio"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/dave/jennifer/jen"
+	"github.com/spf13/cobra"
+)
+
+// deprecatedCmd represents the deprecated command
+var deprecatedCmd = &cobra.Command{
+	Use:   "deprecated",
+	Short: "Generate a Go source file listing deprecated Flux functions",
+	Long: `This utility reads the documentation metadata of the Flux packages and
+	generates a Go source file that maps each deprecated package and package
+	member to the version in which it was deprecated.`,
+	RunE: deprecated,
+}
+
+var (
+	deprecatedGoPkg,
+	deprecatedRootDir,
+	deprecatedOutput string
+)
+
+func init() {
+	rootCmd.AddCommand(deprecatedCmd)
+	deprecatedCmd.Flags().StringVar(&deprecatedGoPkg, "go-pkg", "", "The fully qualified Go package name of the generated file.")
+	deprecatedCmd.Flags().StringVar(&deprecatedRootDir, "root-dir", ".", "The root level directory for all Flux packages.")
+	deprecatedCmd.Flags().StringVar(&deprecatedOutput, "output", "deprecated.gen.go", "The path of the generated file.")
+}
+
+var (
+	deprecatedMetadata = regexp.MustCompile(`^//\s*deprecated:\s*(\S+)`)
+	builtinStatement   = regexp.MustCompile(`^builtin\s+([A-Za-z_][A-Za-z0-9_]*)`)
+	variableStatement  = regexp.MustCompile(`^([A-Za-z_][A-Za-z0-9_]*)\s*=`)
+	packageClause      = regexp.MustCompile(`^package\s+`)
+)
+
+func deprecated(cmd *cobra.Command, args []string) error {
+	entries := make(map[string]string)
+	err := walkDirs(deprecatedRootDir, func(dir string) error {
+		if isInternal(dir) || filepath.Base(dir) == "testdata" {
+			return nil
+		}
+		files, err := os.ReadDir(dir)
+		if err != nil {
+			return err
+		}
+		pkgPath, err := filepath.Rel(deprecatedRootDir, dir)
+		if err != nil {
+			return err
+		}
+		pkgPath = filepath.ToSlash(pkgPath)
+		for _, f := range files {
+			if f.IsDir() || filepath.Ext(f.Name()) != ".flux" || strings.HasSuffix(f.Name(), "_test.flux") {
+				continue
+			}
+			if err := readDeprecated(filepath.Join(dir, f.Name()), pkgPath, entries); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	f := jen.NewFile(path.Base(deprecatedGoPkg))
+	f.HeaderComment(`// DO NOT EDIT: This file is autogenerated via the builtin command.`)
+	f.Comment("deprecated maps deprecated Flux packages and package members to the")
+	f.Comment("version of Flux in which they were deprecated. Packages are keyed by")
+	f.Comment("their import path and members by the import path and name joined with a dot.")
+	f.Var().Id("deprecated").Op("=").Map(jen.String()).String().Values(jen.DictFunc(func(d jen.Dict) {
+		for k, v := range entries {
+			d[jen.Lit(k)] = jen.Lit(v)
+		}
+	}))
+	return f.Save(deprecatedOutput)
+}
+
+// readDeprecated records the deprecated package or members declared in
+// a Flux source file. The deprecated metadata is part of the doc comment
+// directly preceding the package clause or statement it applies to.
+func readDeprecated(fpath, pkgPath string, entries map[string]string) error {
+	file, err := os.Open(fpath)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = file.Close() }()
+
+	var version string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "//") {
+			if m := deprecatedMetadata.FindStringSubmatch(line); m != nil {
+				version = m[1]
+			}
+			continue
+		}
+		if version != "" {
+			if packageClause.MatchString(line) {
+				entries[pkgPath] = version
+			} else if m := builtinStatement.FindStringSubmatch(line); m != nil {
+				entries[pkgPath+"."+m[1]] = version
+			} else if m := variableStatement.FindStringSubmatch(line); m != nil {
+				entries[pkgPath+"."+m[1]] = version
+			}
+		}
+		version = ""
+	}
+	return scanner.Err()
+}
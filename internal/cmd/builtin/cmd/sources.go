@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/dave/jennifer/jen"
+	"github.com/spf13/cobra"
+)
+
+// sourcesCmd represents the sources command
+var sourcesCmd = &cobra.Command{
+	Use:   "sources",
+	Short: "Generate a Go source file with the Flux sources of the packages",
+	Long: `This utility reads the Flux source files of the packages, excluding the
+	test files, and generates a Go source file that maps the path of each file
+	to its contents so the sources can be documented without the source tree.`,
+	RunE: sources,
+}
+
+var (
+	sourcesGoPkg,
+	sourcesRootDir,
+	sourcesOutput string
+)
+
+func init() {
+	rootCmd.AddCommand(sourcesCmd)
+	sourcesCmd.Flags().StringVar(&sourcesGoPkg, "go-pkg", "", "The fully qualified Go package name of the generated file.")
+	sourcesCmd.Flags().StringVar(&sourcesRootDir, "root-dir", ".", "The root level directory for all Flux packages.")
+	sourcesCmd.Flags().StringVar(&sourcesOutput, "output", "sources.gen.go", "The path of the generated file.")
+}
+
+func sources(cmd *cobra.Command, args []string) error {
+	entries := make(map[string]string)
+	err := walkDirs(sourcesRootDir, func(dir string) error {
+		if filepath.Base(dir) == "testdata" {
+			return nil
+		}
+		files, err := os.ReadDir(dir)
+		if err != nil {
+			return err
+		}
+		for _, f := range files {
+			if f.IsDir() || filepath.Ext(f.Name()) != ".flux" || strings.HasSuffix(f.Name(), "_test.flux") {
+				continue
+			}
+			fpath := filepath.Join(dir, f.Name())
+			name, err := filepath.Rel(sourcesRootDir, fpath)
+			if err != nil {
+				return err
+			}
+			src, err := os.ReadFile(fpath)
+			if err != nil {
+				return err
+			}
+			entries[filepath.ToSlash(name)] = string(src)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	f := jen.NewFile(path.Base(sourcesGoPkg))
+	f.HeaderComment(`// DO NOT EDIT: This file is autogenerated via the builtin command.`)
+	f.Comment("sources maps the path of each Flux source file of the packages,")
+	f.Comment("relative to the root directory, to the contents of the file.")
+	f.Var().Id("sources").Op("=").Map(jen.String()).String().Values(jen.DictFunc(func(d jen.Dict) {
+		for k, v := range entries {
+			d[jen.Lit(k)] = jen.Lit(v)
+		}
+	}))
+	return f.Save(sourcesOutput)
+}
@@ -0,0 +1,23 @@
+// Package stdlibsource holds the Flux source files of the standard library
+// so programs can document it without a copy of the Flux source tree.
+// The sources are compiled into every program that imports the package
+// so only the commands that document the standard library import it.
+package stdlibsource
+
+import (
+	"io/fs"
+	"testing/fstest"
+)
+
+//go:generate go run github.com/influxdata/flux/internal/cmd/builtin sources --go-pkg github.com/influxdata/flux/internal/stdlibsource --root-dir ../../stdlib --output sources.gen.go
+
+// FS returns the Flux source files of the standard library with their
+// doc comments. The test files are not included. The import path of a
+// package is the directory of its files.
+func FS() fs.FS {
+	fsys := make(fstest.MapFS, len(sources))
+	for name, src := range sources {
+		fsys[name] = &fstest.MapFile{Data: []byte(src), Mode: 0o444}
+	}
+	return fsys
+}
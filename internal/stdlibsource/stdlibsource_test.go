@@ -0,0 +1,28 @@
+package stdlibsource_test
+
+import (
+	"io/fs"
+	"strings"
+	"testing"
+
+	"github.com/influxdata/flux/internal/stdlibsource"
+)
+
+func TestFS(t *testing.T) {
+	fsys := stdlibsource.FS()
+	if _, err := fs.Stat(fsys, "universe/universe.flux"); err != nil {
+		t.Errorf("expected the universe sources: %v", err)
+	}
+	err := fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if strings.HasSuffix(name, "_test.flux") {
+			t.Errorf("unexpected test file %s", name)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
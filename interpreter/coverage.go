@@ -0,0 +1,110 @@
+package interpreter
+
+import (
+	"context"
+	"sync"
+
+	"github.com/influxdata/flux/ast"
+	"github.com/influxdata/flux/semantic"
+	"github.com/influxdata/flux/values"
+)
+
+// Coverage records the builtin functions, user defined functions
+// and conditional branches that are evaluated by the interpreter.
+// It is safe to record coverage from multiple queries concurrently.
+type Coverage struct {
+	mu        sync.Mutex
+	builtins  map[string]struct{}
+	locations map[coverageLocation]struct{}
+}
+
+// coverageLocation identifies a node by the start of its source location.
+type coverageLocation struct {
+	file         string
+	line, column int
+}
+
+// NewCoverage creates an empty Coverage.
+func NewCoverage() *Coverage {
+	return &Coverage{
+		builtins:  make(map[string]struct{}),
+		locations: make(map[coverageLocation]struct{}),
+	}
+}
+
+// WithCoverage returns a context that records coverage of any
+// Flux program that is evaluated with it.
+func WithCoverage(ctx context.Context, cov *Coverage) context.Context {
+	return context.WithValue(ctx, coverageKey, cov)
+}
+
+// CoverageFromContext returns the coverage recorded for
+// the context or nil if coverage is not recorded.
+func CoverageFromContext(ctx context.Context) *Coverage {
+	cov, _ := ctx.Value(coverageKey).(*Coverage)
+	return cov
+}
+
+// CalledBuiltin reports if the builtin function name in the package
+// with the given import path was called.
+func (c *Coverage) CalledBuiltin(pkgPath, name string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, ok := c.builtins[pkgPath+"."+name]
+	return ok
+}
+
+// Evaluated reports if the function body or conditional branch that
+// starts at the source location was evaluated.
+func (c *Coverage) Evaluated(loc ast.SourceLocation) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, ok := c.locations[newCoverageLocation(loc)]
+	return ok
+}
+
+func (c *Coverage) recordBuiltin(pkgPath, name string) {
+	c.mu.Lock()
+	c.builtins[pkgPath+"."+name] = struct{}{}
+	c.mu.Unlock()
+}
+
+// RecordEvaluated records that the function body or conditional
+// branch that starts at the source location was evaluated.
+func (c *Coverage) RecordEvaluated(loc ast.SourceLocation) {
+	c.mu.Lock()
+	c.locations[newCoverageLocation(loc)] = struct{}{}
+	c.mu.Unlock()
+}
+
+func newCoverageLocation(loc ast.SourceLocation) coverageLocation {
+	return coverageLocation{
+		file:   loc.File,
+		line:   loc.Start.Line,
+		column: loc.Start.Column,
+	}
+}
+
+// builtinName returns the package path and name of the function
+// called by the call expression when it refers to a package member.
+func builtinName(call *semantic.CallExpression, scope values.Scope) (pkgPath, name string, ok bool) {
+	switch callee := call.Callee.(type) {
+	case *semantic.IdentifierExpression:
+		if callee.Name.Package != "" {
+			return callee.Name.Package, callee.Name.LocalName, true
+		}
+	case *semantic.MemberExpression:
+		id, ok := callee.Object.(*semantic.IdentifierExpression)
+		if !ok {
+			return "", "", false
+		}
+		v, ok := scope.Lookup(id.Name.Name())
+		if !ok {
+			return "", "", false
+		}
+		if pkg, ok := v.(*Package); ok {
+			return pkg.Path(), callee.Property.Name(), true
+		}
+	}
+	return "", "", false
+}
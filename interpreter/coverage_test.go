@@ -0,0 +1,53 @@
+package interpreter_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/influxdata/flux/ast"
+	"github.com/influxdata/flux/dependencies/dependenciestest"
+	"github.com/influxdata/flux/dependency"
+	"github.com/influxdata/flux/interpreter"
+	"github.com/influxdata/flux/runtime"
+)
+
+func TestCoverage(t *testing.T) {
+	src := `import "array"
+import "strings"
+sign = (x) => if x > 0 then "positive" else "negative"
+unused = () => 0
+sign(x: 1)
+strings.toUpper(v: "a")
+array.map(arr: [1], fn: (x) => if x > 0 then x else -x)`
+	ctx, deps := dependency.Inject(context.Background(), dependenciestest.Default())
+	defer deps.Finish()
+
+	cov := interpreter.NewCoverage()
+	ctx = interpreter.WithCoverage(ctx, cov)
+	if _, _, err := runtime.Eval(ctx, src); err != nil {
+		t.Fatal(err)
+	}
+
+	loc := func(line, column int) ast.SourceLocation {
+		return ast.SourceLocation{Start: ast.Position{Line: line, Column: column}}
+	}
+	for _, tc := range []struct {
+		name string
+		got  bool
+		want bool
+	}{
+		{name: "called builtin", got: cov.CalledBuiltin("strings", "toUpper"), want: true},
+		{name: "uncalled builtin", got: cov.CalledBuiltin("strings", "toLower"), want: false},
+		{name: "called function", got: cov.Evaluated(loc(3, 8)), want: true},
+		{name: "uncalled function", got: cov.Evaluated(loc(4, 10)), want: false},
+		{name: "evaluated branch", got: cov.Evaluated(loc(3, 29)), want: true},
+		{name: "unevaluated branch", got: cov.Evaluated(loc(3, 45)), want: false},
+		{name: "compiled function", got: cov.Evaluated(loc(7, 25)), want: true},
+		{name: "compiled branch", got: cov.Evaluated(loc(7, 46)), want: true},
+		{name: "uncompiled branch", got: cov.Evaluated(loc(7, 53)), want: false},
+	} {
+		if tc.got != tc.want {
+			t.Errorf("%s: got %v want %v", tc.name, tc.got, tc.want)
+		}
+	}
+}
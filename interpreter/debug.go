@@ -0,0 +1,235 @@
+package interpreter
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/influxdata/flux/ast"
+	"github.com/influxdata/flux/semantic"
+	"github.com/influxdata/flux/values"
+)
+
+// DebugAction tells the interpreter how to continue
+// evaluation after it paused.
+type DebugAction int
+
+const (
+	// Continue evaluates until the next breakpoint.
+	Continue DebugAction = iota
+	// StepOver pauses at the next statement of the current
+	// function or of its caller when the function returns.
+	StepOver
+	// StepInto pauses at the next statement including the
+	// statements of the functions that are called.
+	StepInto
+	// StepOut pauses at the next statement after the
+	// current function returns.
+	StepOut
+)
+
+// PauseReason is the reason the interpreter paused.
+type PauseReason int
+
+const (
+	// PauseEntry is a pause before the first statement.
+	PauseEntry PauseReason = iota
+	// PauseBreakpoint is a pause at a breakpoint.
+	PauseBreakpoint
+	// PauseStep is a pause after a step.
+	PauseStep
+)
+
+func (r PauseReason) String() string {
+	switch r {
+	case PauseEntry:
+		return "entry"
+	case PauseBreakpoint:
+		return "breakpoint"
+	default:
+		return "step"
+	}
+}
+
+// DebugFrame describes the statement the interpreter paused at.
+// It is only valid until the handler returns.
+type DebugFrame struct {
+	Reason    PauseReason
+	Statement semantic.Statement
+	// Scope is the scope the statement is evaluated in.
+	Scope values.Scope
+	// Stack is the call stack with the innermost call first.
+	Stack []StackEntry
+}
+
+// Location returns the source location of the statement.
+func (f *DebugFrame) Location() ast.SourceLocation {
+	return f.Statement.Location()
+}
+
+// DebugVariable is a variable that is in scope at a statement.
+type DebugVariable struct {
+	Name  string
+	Value values.Value
+}
+
+// Variables returns the variables in scope at the statement
+// sorted by name. Variables of the prelude are not included.
+func (f *DebugFrame) Variables() []DebugVariable {
+	var vars []DebugVariable
+	seen := make(map[string]bool)
+	for s := f.Scope; s != nil && s.Pop() != nil; s = s.Pop() {
+		s.LocalRange(func(k string, v values.Value) {
+			if !seen[k] {
+				seen[k] = true
+				vars = append(vars, DebugVariable{Name: k, Value: v})
+			}
+		})
+	}
+	sort.Slice(vars, func(i, j int) bool {
+		return vars[i].Name < vars[j].Name
+	})
+	return vars
+}
+
+// DebugHandler is called when the interpreter pauses.
+// Evaluation is blocked until Paused returns. Returning an
+// error stops the evaluation with the error.
+type DebugHandler interface {
+	Paused(ctx context.Context, frame *DebugFrame) (DebugAction, error)
+}
+
+// Debugger pauses the evaluation of a Flux program before
+// statements at breakpoints and steps through its statements.
+// Statements of row functions that are compiled to run during
+// query execution, such as the fn of map, are not paused at.
+type Debugger struct {
+	handler DebugHandler
+
+	mu          sync.Mutex
+	breakpoints map[string]map[int]bool
+	action      DebugAction
+	// depth is the call depth of the statement
+	// that the last step started from.
+	depth int
+	entry bool
+}
+
+// NewDebugger creates a Debugger that calls the handler when it pauses.
+func NewDebugger(h DebugHandler) *Debugger {
+	return &Debugger{
+		handler:     h,
+		breakpoints: make(map[string]map[int]bool),
+	}
+}
+
+// WithDebugger returns a context that debugs any
+// Flux program that is evaluated with it.
+func WithDebugger(ctx context.Context, d *Debugger) context.Context {
+	return context.WithValue(ctx, debuggerKey, d)
+}
+
+func debuggerFromContext(ctx context.Context) *Debugger {
+	d, _ := ctx.Value(debuggerKey).(*Debugger)
+	return d
+}
+
+// StopOnEntry pauses before the first statement is evaluated.
+func (d *Debugger) StopOnEntry() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.action, d.entry = StepInto, true
+}
+
+// SetBreakpoint pauses before any statement that starts on the line
+// of the file. The file is the name of the source file, which is
+// empty for a script that was not parsed from a named file.
+func (d *Debugger) SetBreakpoint(file string, line int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.breakpoints[file] == nil {
+		d.breakpoints[file] = make(map[int]bool)
+	}
+	d.breakpoints[file][line] = true
+}
+
+// ClearBreakpoint removes the breakpoint on the line of the file.
+func (d *Debugger) ClearBreakpoint(file string, line int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.breakpoints[file], line)
+}
+
+// SetBreakpoints replaces the breakpoints of the file.
+func (d *Debugger) SetBreakpoints(file string, lines []int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.breakpoints[file] = make(map[int]bool, len(lines))
+	for _, line := range lines {
+		d.breakpoints[file][line] = true
+	}
+}
+
+// Breakpoints returns the sorted lines with breakpoints in the file.
+func (d *Debugger) Breakpoints(file string) []int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	lines := make([]int, 0, len(d.breakpoints[file]))
+	for line := range d.breakpoints[file] {
+		lines = append(lines, line)
+	}
+	sort.Ints(lines)
+	return lines
+}
+
+// before is called before the statement is evaluated
+// and pauses when the statement should be stopped at.
+func (d *Debugger) before(ctx context.Context, stmt semantic.Statement, scope values.Scope) error {
+	loc := stmt.Location()
+	depth := callDepth(ctx)
+
+	d.mu.Lock()
+	var (
+		pause  bool
+		reason = PauseStep
+	)
+	switch d.action {
+	case StepInto:
+		pause = true
+	case StepOver:
+		pause = depth <= d.depth
+	case StepOut:
+		pause = depth < d.depth
+	}
+	if d.entry {
+		reason, d.entry = PauseEntry, false
+	} else if d.breakpoints[loc.File][loc.Start.Line] {
+		pause, reason = true, PauseBreakpoint
+	}
+	d.mu.Unlock()
+	if !pause {
+		return nil
+	}
+
+	action, err := d.handler.Paused(ctx, &DebugFrame{
+		Reason:    reason,
+		Statement: stmt,
+		Scope:     scope,
+		Stack:     Stack(ctx),
+	})
+	if err != nil {
+		return err
+	}
+	d.mu.Lock()
+	d.action, d.depth = action, depth
+	d.mu.Unlock()
+	return nil
+}
+
+// callDepth returns the number of calls on the call stack.
+func callDepth(ctx context.Context) int {
+	if e, ok := ctx.Value(callStackKey).(*stackElement); ok {
+		return e.depth + 1
+	}
+	return 0
+}
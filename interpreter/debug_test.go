@@ -0,0 +1,167 @@
+package interpreter_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/influxdata/flux/codes"
+	"github.com/influxdata/flux/dependencies/dependenciestest"
+	"github.com/influxdata/flux/dependency"
+	"github.com/influxdata/flux/internal/errors"
+	"github.com/influxdata/flux/interpreter"
+	"github.com/influxdata/flux/runtime"
+)
+
+// debugScript has a function so stepping into
+// and over calls can be tested.
+const debugScript = `add = (a, b) => {
+    c = a + b
+    return c
+}
+x = add(a: 1, b: 2)
+y = x * 2
+z = add(a: x, b: y)
+`
+
+// stepper records each pause and continues with the next action.
+type stepper struct {
+	actions []interpreter.DebugAction
+	pauses  []pause
+}
+
+type pause struct {
+	Line   int
+	Reason string
+	Depth  int
+}
+
+func (s *stepper) Paused(ctx context.Context, frame *interpreter.DebugFrame) (interpreter.DebugAction, error) {
+	s.pauses = append(s.pauses, pause{
+		Line:   frame.Location().Start.Line,
+		Reason: frame.Reason.String(),
+		Depth:  len(frame.Stack),
+	})
+	if len(s.actions) == 0 {
+		return interpreter.Continue, nil
+	}
+	action := s.actions[0]
+	s.actions = s.actions[1:]
+	return action, nil
+}
+
+func TestDebugger(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		entry       bool
+		breakpoints []int
+		actions     []interpreter.DebugAction
+		want        []pause
+	}{
+		{
+			name:        "breakpoints",
+			breakpoints: []int{2, 6},
+			want: []pause{
+				{Line: 2, Reason: "breakpoint", Depth: 1},
+				{Line: 6, Reason: "breakpoint"},
+				{Line: 2, Reason: "breakpoint", Depth: 1},
+			},
+		},
+		{
+			name:    "step over",
+			entry:   true,
+			actions: []interpreter.DebugAction{interpreter.StepOver, interpreter.StepOver, interpreter.StepOver},
+			want: []pause{
+				{Line: 1, Reason: "entry"},
+				{Line: 5, Reason: "step"},
+				{Line: 6, Reason: "step"},
+				{Line: 7, Reason: "step"},
+			},
+		},
+		{
+			name:    "step into and out",
+			entry:   true,
+			actions: []interpreter.DebugAction{interpreter.StepOver, interpreter.StepInto, interpreter.StepInto, interpreter.StepOut},
+			want: []pause{
+				{Line: 1, Reason: "entry"},
+				{Line: 5, Reason: "step"},
+				{Line: 2, Reason: "step", Depth: 1},
+				{Line: 3, Reason: "step", Depth: 1},
+				{Line: 6, Reason: "step"},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, deps := dependency.Inject(context.Background(), dependenciestest.Default())
+			defer deps.Finish()
+
+			s := &stepper{actions: tc.actions}
+			d := interpreter.NewDebugger(s)
+			if tc.entry {
+				d.StopOnEntry()
+			}
+			d.SetBreakpoints("", tc.breakpoints)
+			ctx = interpreter.WithDebugger(ctx, d)
+			if _, _, err := runtime.Eval(ctx, debugScript); err != nil {
+				t.Fatal(err)
+			}
+			if !cmp.Equal(tc.want, s.pauses) {
+				t.Errorf("unexpected pauses -want/+got:\n%s", cmp.Diff(tc.want, s.pauses))
+			}
+		})
+	}
+}
+
+type inspector struct {
+	vars map[string]string
+}
+
+func (i *inspector) Paused(ctx context.Context, frame *interpreter.DebugFrame) (interpreter.DebugAction, error) {
+	for _, v := range frame.Variables() {
+		i.vars[v.Name] = v.Value.Type().String()
+	}
+	return interpreter.Continue, nil
+}
+
+func TestDebugger_Variables(t *testing.T) {
+	ctx, deps := dependency.Inject(context.Background(), dependenciestest.Default())
+	defer deps.Finish()
+
+	i := &inspector{vars: make(map[string]string)}
+	d := interpreter.NewDebugger(i)
+	d.SetBreakpoint("", 3)
+	ctx = interpreter.WithDebugger(ctx, d)
+	if _, _, err := runtime.Eval(ctx, debugScript); err != nil {
+		t.Fatal(err)
+	}
+
+	// The breakpoint in the function body sees the parameters,
+	// the local variable and the variables of the package but
+	// not those of the prelude.
+	for _, name := range []string{"a", "b", "c", "add", "x"} {
+		if _, ok := i.vars[name]; !ok {
+			t.Errorf("missing variable %s", name)
+		}
+	}
+	if _, ok := i.vars["from"]; ok {
+		t.Error("unexpected prelude variable from")
+	}
+}
+
+type quitter struct{}
+
+func (quitter) Paused(ctx context.Context, frame *interpreter.DebugFrame) (interpreter.DebugAction, error) {
+	return 0, errors.New(codes.Canceled, "quit")
+}
+
+func TestDebugger_Stop(t *testing.T) {
+	ctx, deps := dependency.Inject(context.Background(), dependenciestest.Default())
+	defer deps.Finish()
+
+	d := interpreter.NewDebugger(quitter{})
+	d.StopOnEntry()
+	ctx = interpreter.WithDebugger(ctx, d)
+	if _, _, err := runtime.Eval(ctx, debugScript); errors.Code(err) != codes.Canceled {
+		t.Fatalf("expected canceled error, got %v", err)
+	}
+}
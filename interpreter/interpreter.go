@@ -44,6 +44,8 @@ type Interpreter struct {
 	sideEffects    []SideEffect // a list of the side effects occurred during the last call to `Eval`.
 	pkgName        string
 	execOptsConfig ExecOptsConfig
+	// coverage records the evaluated code when it is set.
+	coverage *Coverage
 }
 
 func NewInterpreter(pkg *Package, eoc ExecOptsConfig) *Interpreter {
@@ -60,6 +62,12 @@ func NewInterpreter(pkg *Package, eoc ExecOptsConfig) *Interpreter {
 	}
 }
 
+// SetCoverage records the functions and conditional branches
+// that are evaluated by the interpreter in cov.
+func (itrp *Interpreter) SetCoverage(cov *Coverage) {
+	itrp.coverage = cov
+}
+
 func (itrp *Interpreter) PackageName() string {
 	return itrp.pkgName
 }
@@ -187,6 +195,11 @@ func (itrp *Interpreter) doImport(ctx context.Context, dec *semantic.ImportDecla
 
 // doStatement returns the resolved value of a top-level statement
 func (itrp *Interpreter) doStatement(ctx context.Context, stmt semantic.Statement, scope values.Scope) (values.Value, error) {
+	if d := debuggerFromContext(ctx); d != nil {
+		if err := d.before(ctx, stmt, scope); err != nil {
+			return nil, err
+		}
+	}
 	scope.SetReturn(values.InvalidValue)
 	switch s := stmt.(type) {
 	case *semantic.OptionStatement:
@@ -569,10 +582,14 @@ func (itrp *Interpreter) doExpression(ctx context.Context, expr semantic.Express
 		if t.Type().Nature() != semantic.Bool {
 			return nil, errors.New(codes.Invalid, "conditional test expression is not a boolean value")
 		}
+		branch := e.Alternate
 		if t.Bool() {
-			return itrp.doExpression(ctx, e.Consequent, scope)
+			branch = e.Consequent
+		}
+		if itrp.coverage != nil {
+			itrp.coverage.RecordEvaluated(branch.Location())
 		}
-		return itrp.doExpression(ctx, e.Alternate, scope)
+		return itrp.doExpression(ctx, branch, scope)
 	case *semantic.FunctionExpression:
 		// In the case of builtin functions this function value is shared across all query requests
 		// and as such must NOT be a pointer value.
@@ -794,6 +811,10 @@ func (itrp *Interpreter) doCall(ctx context.Context, call *semantic.CallExpressi
 	if af, ok := f.(function); ok {
 		af.itrp = itrp
 		f = af
+	} else if itrp.coverage != nil {
+		if pkgPath, name, ok := builtinName(call, scope); ok {
+			itrp.coverage.recordBuiltin(pkgPath, name)
+		}
 	}
 
 	// Call the function. We attach source location information
@@ -981,7 +1002,10 @@ func (f function) Call(ctx context.Context, args values.Object) (values.Value, e
 func (f function) doCall(ctx context.Context, args Arguments) (values.Value, error) {
 	if f.itrp == nil {
 		// Create an new interpreter
-		f.itrp = &Interpreter{}
+		f.itrp = &Interpreter{coverage: CoverageFromContext(ctx)}
+	}
+	if f.itrp.coverage != nil {
+		f.itrp.coverage.RecordEvaluated(f.e.Location())
 	}
 
 	blockScope := f.scope.Nest(nil)
@@ -1779,6 +1803,8 @@ type contextKey int
 
 const (
 	callStackKey contextKey = iota
+	coverageKey
+	debuggerKey
 )
 
 // StackEntry describes a single entry in the call stack.
@@ -0,0 +1,358 @@
+// Package json contains the JSON and newline delimited JSON result encoders.
+package json
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/influxdata/flux"
+	"github.com/influxdata/flux/execute"
+	"github.com/influxdata/flux/iocounter"
+	"github.com/influxdata/flux/values"
+)
+
+const (
+	resultLabel = "result"
+	tableLabel  = "table"
+	errorLabel  = "error"
+)
+
+// ResultEncoder encodes a single result as one JSON document.
+//
+// The document contains the name of the result and each table
+// with its group key, typed column schema and row data:
+//
+//	{"result":"_result","tables":[{"table":0,"groupKey":{"host":"A"},
+//	  "columns":[{"label":"host","type":"string","group":true}, ...],
+//	  "data":[["A", ...], ...]}]}
+//
+// Time values are encoded as RFC3339 strings with nanosecond precision
+// and non-finite floats are encoded as the strings "NaN", "+Inf" and "-Inf".
+type ResultEncoder struct{}
+
+// NewResultEncoder creates a new JSON result encoder.
+func NewResultEncoder() *ResultEncoder {
+	return &ResultEncoder{}
+}
+
+func (e *ResultEncoder) Encode(w io.Writer, result flux.Result) (int64, error) {
+	wc := &iocounter.Writer{Writer: w}
+	// The document header is only written with the first table
+	// so an error before any table is read leaves the writer untouched.
+	header := []byte(`{"` + resultLabel + `":`)
+	header = appendString(header, result.Name())
+	header = append(header, `,"tables":[`...)
+
+	tableID := 0
+	buf := make([]byte, 0, 1024)
+	if err := result.Tables().Do(func(tbl flux.Table) error {
+		buf = buf[:0]
+		if tableID == 0 {
+			buf = append(buf, header...)
+		} else {
+			buf = append(buf, ',')
+		}
+		buf = append(buf, `{"`+tableLabel+`":`...)
+		buf = strconv.AppendInt(buf, int64(tableID), 10)
+		buf = append(buf, `,"groupKey":`...)
+		buf = appendGroupKey(buf, tbl.Key())
+		buf = append(buf, `,"columns":`...)
+		buf = appendColumns(buf, tbl.Cols(), tbl.Key())
+		buf = append(buf, `,"data":[`...)
+
+		first := true
+		if err := tbl.Do(func(cr flux.ColReader) error {
+			for i, l := 0, cr.Len(); i < l; i++ {
+				if !first {
+					buf = append(buf, ',')
+				}
+				first = false
+				buf = append(buf, '[')
+				for j, c := range cr.Cols() {
+					if j > 0 {
+						buf = append(buf, ',')
+					}
+					var err error
+					if buf, err = appendValueFrom(buf, i, j, c, cr); err != nil {
+						return wrapEncodingError(err)
+					}
+				}
+				buf = append(buf, ']')
+			}
+			_, err := wc.Write(buf)
+			buf = buf[:0]
+			return wrapEncodingError(err)
+		}); err != nil {
+			return err
+		}
+		buf = append(buf, "]}"...)
+		tableID++
+		_, err := wc.Write(buf)
+		return wrapEncodingError(err)
+	}); err != nil {
+		return wc.Count(), err
+	}
+	if tableID == 0 {
+		buf = append(header, "]}"...)
+	} else {
+		buf = append(buf[:0], "]}"...)
+	}
+	_, err := wc.Write(buf)
+	return wc.Count(), wrapEncodingError(err)
+}
+
+// EncodeError encodes an error as a JSON document with a single error field.
+func (e *ResultEncoder) EncodeError(w io.Writer, err error) error {
+	return encodeError(w, err)
+}
+
+// NewMultiResultEncoder creates a MultiResultEncoder that writes
+// one JSON document per result with each document terminated by a newline.
+func NewMultiResultEncoder() flux.MultiResultEncoder {
+	return &flux.DelimitedMultiResultEncoder{
+		Delimiter: []byte("\n"),
+		Encoder:   NewResultEncoder(),
+	}
+}
+
+// NDJSONResultEncoder encodes a result as newline delimited JSON
+// with one JSON object per row.
+//
+// Each object contains the result name, the table index
+// and a field for each column of the table:
+//
+//	{"result":"_result","table":0,"host":"A","_value":42}
+//
+// A column whose label collides with the result or table field is
+// prefixed with underscores until its field name is unique,
+// so a column named table is written as "_table".
+type NDJSONResultEncoder struct{}
+
+// NewNDJSONResultEncoder creates a new newline delimited JSON result encoder.
+func NewNDJSONResultEncoder() *NDJSONResultEncoder {
+	return &NDJSONResultEncoder{}
+}
+
+func (e *NDJSONResultEncoder) Encode(w io.Writer, result flux.Result) (int64, error) {
+	wc := &iocounter.Writer{Writer: w}
+	prefix := []byte(`{"` + resultLabel + `":`)
+	prefix = appendString(prefix, result.Name())
+	prefix = append(prefix, `,"`+tableLabel+`":`...)
+
+	tableID := 0
+	buf := make([]byte, 0, 1024)
+	err := result.Tables().Do(func(tbl flux.Table) error {
+		cols := tbl.Cols()
+		labels := make([][]byte, len(cols))
+		for j, key := range ndjsonKeys(cols) {
+			labels[j] = append(appendString(nil, key), ':')
+		}
+		if err := tbl.Do(func(cr flux.ColReader) error {
+			buf = buf[:0]
+			for i, l := 0, cr.Len(); i < l; i++ {
+				buf = append(buf, prefix...)
+				buf = strconv.AppendInt(buf, int64(tableID), 10)
+				for j, c := range cols {
+					buf = append(buf, ',')
+					buf = append(buf, labels[j]...)
+					var err error
+					if buf, err = appendValueFrom(buf, i, j, c, cr); err != nil {
+						return wrapEncodingError(err)
+					}
+				}
+				buf = append(buf, "}\n"...)
+			}
+			_, err := wc.Write(buf)
+			return wrapEncodingError(err)
+		}); err != nil {
+			return err
+		}
+		tableID++
+		return nil
+	})
+	return wc.Count(), err
+}
+
+// ndjsonKeys returns the field name of each column in a row object.
+// Labels that collide with the result and table fields are prefixed
+// with underscores until they do not collide with any other field.
+func ndjsonKeys(cols []flux.ColMeta) []string {
+	used := map[string]bool{resultLabel: true, tableLabel: true}
+	for _, c := range cols {
+		if c.Label != resultLabel && c.Label != tableLabel {
+			used[c.Label] = true
+		}
+	}
+	keys := make([]string, len(cols))
+	for j, c := range cols {
+		key := c.Label
+		if key == resultLabel || key == tableLabel {
+			for used[key] {
+				key = "_" + key
+			}
+			used[key] = true
+		}
+		keys[j] = key
+	}
+	return keys
+}
+
+// EncodeError encodes an error as a single line with an error field.
+func (e *NDJSONResultEncoder) EncodeError(w io.Writer, err error) error {
+	return encodeError(w, err)
+}
+
+// NewNDJSONMultiResultEncoder creates a MultiResultEncoder that writes
+// the rows of every result as newline delimited JSON.
+func NewNDJSONMultiResultEncoder() flux.MultiResultEncoder {
+	return &flux.DelimitedMultiResultEncoder{
+		Delimiter: []byte{},
+		Encoder:   NewNDJSONResultEncoder(),
+	}
+}
+
+type jsonEncoderError struct {
+	err error
+}
+
+func (e *jsonEncoderError) Error() string {
+	return fmt.Sprintf("json encoder error: %s", e.err.Error())
+}
+
+func (e *jsonEncoderError) IsEncoderError() bool {
+	return true
+}
+
+func (e *jsonEncoderError) Unwrap() error {
+	return e.err
+}
+
+func wrapEncodingError(err error) error {
+	if err == nil {
+		return err
+	}
+	if _, ok := err.(*jsonEncoderError); ok {
+		return err
+	}
+	return &jsonEncoderError{err: err}
+}
+
+func encodeError(w io.Writer, err error) error {
+	buf := []byte(`{"` + errorLabel + `":`)
+	buf = appendString(buf, err.Error())
+	buf = append(buf, "}\n"...)
+	_, werr := w.Write(buf)
+	return werr
+}
+
+func appendColumns(buf []byte, cols []flux.ColMeta, key flux.GroupKey) []byte {
+	buf = append(buf, '[')
+	for j, c := range cols {
+		if j > 0 {
+			buf = append(buf, ',')
+		}
+		buf = append(buf, `{"label":`...)
+		buf = appendString(buf, c.Label)
+		buf = append(buf, `,"type":`...)
+		buf = appendString(buf, c.Type.String())
+		buf = append(buf, `,"group":`...)
+		buf = strconv.AppendBool(buf, key.HasCol(c.Label))
+		buf = append(buf, '}')
+	}
+	return append(buf, ']')
+}
+
+func appendGroupKey(buf []byte, key flux.GroupKey) []byte {
+	buf = append(buf, '{')
+	for j, c := range key.Cols() {
+		if j > 0 {
+			buf = append(buf, ',')
+		}
+		buf = appendString(buf, c.Label)
+		buf = append(buf, ':')
+		buf = appendValue(buf, key.Value(j), c.Type)
+	}
+	return append(buf, '}')
+}
+
+func appendValue(buf []byte, v values.Value, typ flux.ColType) []byte {
+	if v.IsNull() {
+		return append(buf, "null"...)
+	}
+	switch typ {
+	case flux.TBool:
+		return strconv.AppendBool(buf, v.Bool())
+	case flux.TInt:
+		return strconv.AppendInt(buf, v.Int(), 10)
+	case flux.TUInt:
+		return strconv.AppendUint(buf, v.UInt(), 10)
+	case flux.TFloat:
+		return appendFloat(buf, v.Float())
+	case flux.TString:
+		return appendString(buf, v.Str())
+	case flux.TTime:
+		return appendTime(buf, v.Time())
+	default:
+		return append(buf, "null"...)
+	}
+}
+
+func appendValueFrom(buf []byte, i, j int, c flux.ColMeta, cr flux.ColReader) ([]byte, error) {
+	switch c.Type {
+	case flux.TBool:
+		if vs := cr.Bools(j); vs.IsValid(i) {
+			return strconv.AppendBool(buf, vs.Value(i)), nil
+		}
+	case flux.TInt:
+		if vs := cr.Ints(j); vs.IsValid(i) {
+			return strconv.AppendInt(buf, vs.Value(i), 10), nil
+		}
+	case flux.TUInt:
+		if vs := cr.UInts(j); vs.IsValid(i) {
+			return strconv.AppendUint(buf, vs.Value(i), 10), nil
+		}
+	case flux.TFloat:
+		if vs := cr.Floats(j); vs.IsValid(i) {
+			return appendFloat(buf, vs.Value(i)), nil
+		}
+	case flux.TString:
+		if vs := cr.Strings(j); vs.IsValid(i) {
+			return appendString(buf, vs.Value(i)), nil
+		}
+	case flux.TTime:
+		if vs := cr.Times(j); vs.IsValid(i) {
+			return appendTime(buf, execute.Time(vs.Value(i))), nil
+		}
+	default:
+		return buf, fmt.Errorf("unknown type %v", c.Type)
+	}
+	return append(buf, "null"...), nil
+}
+
+func appendFloat(buf []byte, f float64) []byte {
+	switch {
+	case math.IsNaN(f):
+		return append(buf, `"NaN"`...)
+	case math.IsInf(f, 1):
+		return append(buf, `"+Inf"`...)
+	case math.IsInf(f, -1):
+		return append(buf, `"-Inf"`...)
+	}
+	return strconv.AppendFloat(buf, f, 'f', -1, 64)
+}
+
+func appendTime(buf []byte, t execute.Time) []byte {
+	buf = append(buf, '"')
+	buf = t.Time().AppendFormat(buf, time.RFC3339Nano)
+	return append(buf, '"')
+}
+
+func appendString(buf []byte, s string) []byte {
+	// Marshaling a string cannot fail.
+	b, _ := json.Marshal(s)
+	return append(buf, b...)
+}
@@ -0,0 +1,102 @@
+package json_test
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/influxdata/flux"
+	"github.com/influxdata/flux/execute/executetest"
+	"github.com/influxdata/flux/json"
+	"github.com/influxdata/flux/values"
+)
+
+func testResults() []flux.Result {
+	return []flux.Result{&executetest.Result{
+		Nm: "_result",
+		Tbls: []*executetest.Table{{
+			KeyCols: []string{"_measurement", "host"},
+			ColMeta: []flux.ColMeta{
+				{Label: "_time", Type: flux.TTime},
+				{Label: "_measurement", Type: flux.TString},
+				{Label: "host", Type: flux.TString},
+				{Label: "_value", Type: flux.TFloat},
+				{Label: "ok", Type: flux.TBool},
+			},
+			Data: [][]interface{}{
+				{values.ConvertTime(time.Date(2018, 4, 17, 0, 0, 0, 0, time.UTC)), "cpu", "A", 42.0, true},
+				{values.ConvertTime(time.Date(2018, 4, 17, 0, 0, 1, 0, time.UTC)), "cpu", "A", math.NaN(), nil},
+			},
+		}},
+	}}
+}
+
+func TestMultiResultEncoder(t *testing.T) {
+	testCases := []struct {
+		name    string
+		encoder flux.MultiResultEncoder
+		results flux.ResultIterator
+		encoded string
+	}{
+		{
+			name:    "json",
+			encoder: json.NewMultiResultEncoder(),
+			results: flux.NewSliceResultIterator(testResults()),
+			encoded: `{"result":"_result","tables":[{"table":0,"groupKey":{"_measurement":"cpu","host":"A"},"columns":[{"label":"_time","type":"time","group":false},{"label":"_measurement","type":"string","group":true},{"label":"host","type":"string","group":true},{"label":"_value","type":"float","group":false},{"label":"ok","type":"bool","group":false}],"data":[["2018-04-17T00:00:00Z","cpu","A",42,true],["2018-04-17T00:00:01Z","cpu","A","NaN",null]]}]}
+`,
+		},
+		{
+			name:    "ndjson",
+			encoder: json.NewNDJSONMultiResultEncoder(),
+			results: flux.NewSliceResultIterator(testResults()),
+			encoded: `{"result":"_result","table":0,"_time":"2018-04-17T00:00:00Z","_measurement":"cpu","host":"A","_value":42,"ok":true}
+{"result":"_result","table":0,"_time":"2018-04-17T00:00:01Z","_measurement":"cpu","host":"A","_value":"NaN","ok":null}
+`,
+		},
+		{
+			name:    "ndjson colliding labels",
+			encoder: json.NewNDJSONMultiResultEncoder(),
+			results: flux.NewSliceResultIterator([]flux.Result{&executetest.Result{
+				Nm: "_result",
+				Tbls: []*executetest.Table{{
+					ColMeta: []flux.ColMeta{
+						{Label: "table", Type: flux.TInt},
+						{Label: "_table", Type: flux.TInt},
+						{Label: "result", Type: flux.TString},
+					},
+					Data: [][]interface{}{
+						{int64(1), int64(2), "x"},
+					},
+				}},
+			}}),
+			encoded: `{"result":"_result","table":0,"__table":1,"_table":2,"_result":"x"}
+`,
+		},
+		{
+			name:    "json error",
+			encoder: json.NewMultiResultEncoder(),
+			results: flux.NewSliceResultIterator([]flux.Result{
+				testResults()[0],
+				&executetest.Result{Nm: "failed", Err: errors.New("expected error")},
+			}),
+			encoded: `{"result":"_result","tables":[{"table":0,"groupKey":{"_measurement":"cpu","host":"A"},"columns":[{"label":"_time","type":"time","group":false},{"label":"_measurement","type":"string","group":true},{"label":"host","type":"string","group":true},{"label":"_value","type":"float","group":false},{"label":"ok","type":"bool","group":false}],"data":[["2018-04-17T00:00:00Z","cpu","A",42,true],["2018-04-17T00:00:01Z","cpu","A","NaN",null]]}]}
+{"error":"expected error"}
+`,
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if _, err := tc.encoder.Encode(&buf, tc.results); err != nil {
+				t.Fatal(err)
+			}
+			if got, want := buf.String(), tc.encoded; got != want {
+				t.Fatalf("unexpected encoding -want/+got:\n%s", cmp.Diff(want, got))
+			}
+		})
+	}
+}
@@ -0,0 +1,468 @@
+package lang
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode"
+
+	"github.com/influxdata/flux"
+	"github.com/influxdata/flux/codes"
+	"github.com/influxdata/flux/internal/errors"
+	"github.com/influxdata/flux/interpreter"
+	"github.com/influxdata/flux/libflux/go/libflux"
+	"github.com/influxdata/flux/semantic"
+	"github.com/influxdata/flux/values"
+)
+
+// semanticEvaluator is implemented by runtimes that can analyze
+// a package and evaluate the analysis any number of times.
+type semanticEvaluator interface {
+	Analyze(ctx context.Context, astPkg flux.ASTHandle) (*semantic.Package, error)
+	EvalSemantic(ctx context.Context, semPkg *semantic.Package, es interpreter.ExecOptsConfig, opts ...flux.ScopeMutator) ([]interpreter.SideEffect, values.Scope, error)
+}
+
+// ProgramCache is a least recently used cache of analyzed programs
+// that is safe for concurrent use.
+//
+// A program compiled through the cache reuses the semantic package
+// analyzed for an earlier program with the same script, the same
+// externs, params of the same types and the same feature flags.
+// Only now and the values of the params are bound when it starts,
+// so parsing and type inference happen once per cached program.
+// Scripts that differ only in trailing whitespace share a program.
+// The other compile options, such as the profilers, the planner
+// options, the resource limits and the partition executor, do not
+// change the analysis and are applied to each program.
+type ProgramCache struct {
+	size int
+
+	mu      sync.Mutex
+	order   *list.List
+	entries map[[sha256.Size]byte]*list.Element
+
+	hits      int64
+	misses    int64
+	evictions int64
+}
+
+// ProgramCacheStats are the statistics of a ProgramCache.
+type ProgramCacheStats struct {
+	// Hits is the number of programs that reused a cached analysis.
+	Hits int64
+	// Misses is the number of programs that were analyzed.
+	Misses int64
+	// Evictions is the number of analyses removed to make room for others.
+	Evictions int64
+	// Len is the number of cached analyses.
+	Len int
+}
+
+// NewProgramCache returns a cache that holds at most size programs.
+// A cache with a size less than one caches nothing.
+func NewProgramCache(size int) *ProgramCache {
+	return &ProgramCache{
+		size:    size,
+		order:   list.New(),
+		entries: make(map[[sha256.Size]byte]*list.Element),
+	}
+}
+
+// programSource is the part of a compiler that is analyzed.
+type programSource struct {
+	kind   flux.CompilerType
+	query  string
+	ast    json.RawMessage
+	extern json.RawMessage
+	// optExtern is the package of the extern
+	// set with WithExtern, if any.
+	optExtern json.RawMessage
+	params    Params
+	now       time.Time
+}
+
+// programEntry is the analysis of a program in the cache.
+type programEntry struct {
+	key  [sha256.Size]byte
+	once sync.Once
+	pkg  *semantic.Package
+	err  error
+}
+
+// Compile compiles the program for the compiler with the options using
+// the cache. Flux and AST compilers are cached when the runtime can
+// evaluate analyzed packages and any other compiler is compiled
+// directly. The options of a plan compiler are applied to its program
+// and those of other compilers are ignored. Errors in the script are
+// reported when the program starts.
+func (c *ProgramCache) Compile(ctx context.Context, compiler flux.Compiler, rt flux.Runtime, opts ...CompileOption) (flux.Program, error) {
+	var (
+		src     programSource
+		compile func() (flux.Program, error)
+	)
+	switch fc := compiler.(type) {
+	case FluxCompiler:
+		src = programSource{kind: FluxCompilerType, query: fc.Query, extern: fc.Extern, params: fc.Params, now: fc.Now}
+		compile = func() (flux.Program, error) { return fc.compile(ctx, rt, opts...) }
+	case *FluxCompiler:
+		src = programSource{kind: FluxCompilerType, query: fc.Query, extern: fc.Extern, params: fc.Params, now: fc.Now}
+		compile = func() (flux.Program, error) { return fc.compile(ctx, rt, opts...) }
+	case ASTCompiler:
+		src = programSource{kind: ASTCompilerType, ast: fc.AST, extern: fc.Extern, params: fc.Params, now: fc.Now}
+		compile = func() (flux.Program, error) { return fc.compile(ctx, rt, opts...) }
+	case *ASTCompiler:
+		src = programSource{kind: ASTCompilerType, ast: fc.AST, extern: fc.Extern, params: fc.Params, now: fc.Now}
+		compile = func() (flux.Program, error) { return fc.compile(ctx, rt, opts...) }
+	default:
+		program, err := compiler.Compile(ctx, rt)
+		if err != nil {
+			return nil, err
+		}
+		if p, ok := program.(*Program); ok {
+			o := applyOptions(opts...)
+			p.opts, p.limits = o, o.limits
+		}
+		return program, nil
+	}
+	eval, ok := rt.(semanticEvaluator)
+	if !ok {
+		atomic.AddInt64(&c.misses, 1)
+		return compile()
+	}
+	var params values.Value
+	if len(src.params) > 0 {
+		params, ok = paramValue(reflect.ValueOf(map[string]interface{}(src.params)))
+		if !ok {
+			// The compiler reports the invalid params.
+			atomic.AddInt64(&c.misses, 1)
+			return compile()
+		}
+	}
+	o := applyOptions(opts...)
+	if o.extern != nil {
+		// The extern is part of the analysis, so it is keyed by
+		// its JSON, and it is merged into the package to analyze.
+		bs, err := json.Marshal(o.extern)
+		if err != nil {
+			atomic.AddInt64(&c.misses, 1)
+			return compile()
+		}
+		src.optExtern, o.extern = bs, nil
+	}
+	key, err := src.key(ctx, params)
+	if err != nil {
+		atomic.AddInt64(&c.misses, 1)
+		return compile()
+	}
+
+	now := src.now
+	if src.kind == ASTCompilerType && now.IsZero() {
+		now = time.Now()
+	}
+	return &AstProgram{
+		Program: &Program{
+			Runtime: rt,
+			opts:    o,
+			limits:  o.limits,
+		},
+		Now: now,
+		cached: &cachedProgram{
+			cache:     c,
+			entry:     c.entry(key),
+			src:       src,
+			params:    params,
+			evaluator: eval,
+		},
+	}, nil
+}
+
+// Stats returns the statistics of the cache.
+func (c *ProgramCache) Stats() ProgramCacheStats {
+	c.mu.Lock()
+	n := c.order.Len()
+	c.mu.Unlock()
+	return ProgramCacheStats{
+		Hits:      atomic.LoadInt64(&c.hits),
+		Misses:    atomic.LoadInt64(&c.misses),
+		Evictions: atomic.LoadInt64(&c.evictions),
+		Len:       n,
+	}
+}
+
+// entry returns the entry for the key, adding it when it is missing.
+func (c *ProgramCache) entry(key [sha256.Size]byte) *programEntry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.entries[key]; ok {
+		c.order.MoveToFront(e)
+		atomic.AddInt64(&c.hits, 1)
+		return e.Value.(*programEntry)
+	}
+	atomic.AddInt64(&c.misses, 1)
+	entry := &programEntry{key: key}
+	if c.size < 1 {
+		return entry
+	}
+	c.entries[key] = c.order.PushFront(entry)
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*programEntry).key)
+		atomic.AddInt64(&c.evictions, 1)
+	}
+	return entry
+}
+
+// remove removes the entry so the program is analyzed again.
+func (c *ProgramCache) remove(entry *programEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.entries[entry.key]; ok && e.Value == entry {
+		c.order.Remove(e)
+		delete(c.entries, entry.key)
+	}
+}
+
+// key returns the cache key of the source.
+func (src programSource) key(ctx context.Context, params values.Value) ([sha256.Size]byte, error) {
+	var buf bytes.Buffer
+	write := func(s string) {
+		buf.WriteString(s)
+		buf.WriteByte(0)
+	}
+	write(string(src.kind))
+	write(strings.TrimRightFunc(src.query, unicode.IsSpace))
+	if err := writeCompactJSON(&buf, src.ast); err != nil {
+		return [sha256.Size]byte{}, err
+	}
+	buf.WriteByte(0)
+	if IsNonNullJSON(src.extern) {
+		if err := writeCompactJSON(&buf, src.extern); err != nil {
+			return [sha256.Size]byte{}, err
+		}
+	}
+	buf.WriteByte(0)
+	if err := writeCompactJSON(&buf, src.optExtern); err != nil {
+		return [sha256.Size]byte{}, err
+	}
+	buf.WriteByte(0)
+	if params != nil {
+		write(params.Type().String())
+	} else {
+		write("")
+	}
+	write(strings.Join(libflux.NewOptions(ctx).Features, ","))
+	return sha256.Sum256(buf.Bytes()), nil
+}
+
+func writeCompactJSON(buf *bytes.Buffer, data json.RawMessage) error {
+	if len(data) == 0 {
+		return nil
+	}
+	return json.Compact(buf, data)
+}
+
+// handle returns the package to analyze. The params
+// are assigned in the first file of the package.
+func (src programSource) handle(ctx context.Context, rt flux.Runtime) (flux.ASTHandle, error) {
+	var hdl flux.ASTHandle
+	if src.kind == FluxCompilerType {
+		h, err := rt.Parse(ctx, src.query)
+		if err != nil {
+			return nil, err
+		}
+		hdl = h
+	} else {
+		h, err := rt.JSONToHandle(src.ast)
+		if err != nil {
+			return nil, err
+		}
+		if err := h.GetError(libflux.NewOptions(ctx)); err != nil {
+			return nil, err
+		}
+		hdl = h
+	}
+
+	var optExtern, extern flux.ASTHandle
+	if len(src.optExtern) > 0 {
+		h, err := rt.JSONToHandle(src.optExtern)
+		if err != nil {
+			return nil, err
+		}
+		optExtern = h
+	}
+	if IsNonNullJSON(src.extern) {
+		h, err := rt.JSONToHandle(wrapFileJSONInPkg(src.extern))
+		if err != nil {
+			return nil, errors.Wrap(err, codes.Inherit, "extern json parse error")
+		}
+		extern = h
+	}
+	pkg, err := withParams(rt, nil, src.params)
+	if err != nil {
+		return nil, err
+	}
+	for _, h := range []flux.ASTHandle{optExtern, extern, hdl} {
+		if h == nil {
+			continue
+		}
+		if pkg == nil {
+			pkg = h
+		} else if err := rt.MergePackages(pkg, h); err != nil {
+			return nil, err
+		}
+	}
+	return pkg, nil
+}
+
+// analyze returns the semantic package of the source without
+// the assignment of the params, which are bound when it is evaluated.
+func (src programSource) analyze(ctx context.Context, rt flux.Runtime, eval semanticEvaluator) (*semantic.Package, error) {
+	hdl, err := src.handle(ctx, rt)
+	if err != nil {
+		return nil, err
+	}
+	semPkg, err := eval.Analyze(ctx, hdl)
+	if err != nil {
+		return nil, err
+	}
+	if len(src.params) == 0 {
+		return semPkg, nil
+	}
+	if len(semPkg.Files) == 0 || !isParamsFile(semPkg.Files[0]) {
+		return nil, errors.New(codes.Internal, "analyzed package does not start with the params")
+	}
+	pkg := *semPkg
+	pkg.Files = semPkg.Files[1:]
+	return &pkg, nil
+}
+
+func isParamsFile(f *semantic.File) bool {
+	if len(f.Body) != 1 {
+		return false
+	}
+	a, ok := f.Body[0].(*semantic.NativeVariableAssignment)
+	return ok && a.Identifier.Name.Name() == ParamsIdentifier
+}
+
+// cachedProgram evaluates the cached analysis of a program.
+type cachedProgram struct {
+	cache     *ProgramCache
+	entry     *programEntry
+	src       programSource
+	params    values.Value
+	evaluator semanticEvaluator
+}
+
+func (cp *cachedProgram) eval(ctx context.Context, rt flux.Runtime, opts ...flux.ScopeMutator) ([]interpreter.SideEffect, values.Scope, error) {
+	e := cp.entry
+	e.once.Do(func() {
+		e.pkg, e.err = cp.src.analyze(ctx, rt, cp.evaluator)
+		if e.err != nil {
+			cp.cache.remove(e)
+		}
+	})
+	if e.err != nil {
+		return nil, nil, e.err
+	}
+	if cp.params != nil {
+		opts = append(opts, func(r flux.Runtime, scope values.Scope) {
+			scope.Set(ParamsIdentifier, cp.params)
+		})
+	}
+	return cp.evaluator.EvalSemantic(ctx, e.pkg, &ExecOptsConfig{}, opts...)
+}
+
+// paramValue returns the value of a param. It reports false for
+// values that are invalid or whose type depends on the analysis,
+// such as empty arrays, and these programs are not cached.
+func paramValue(v reflect.Value) (values.Value, bool) {
+	if v.Kind() == reflect.Interface || v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil, false
+		}
+		return paramValue(v.Elem())
+	}
+
+	switch v.Type() {
+	case timeType:
+		return values.NewTime(values.ConvertTime(v.Interface().(time.Time))), true
+	case durationType:
+		return values.NewDuration(values.ConvertDurationNsecs(time.Duration(v.Int()))), true
+	case numberType:
+		n := v.Interface().(json.Number)
+		if i, err := n.Int64(); err == nil {
+			return values.NewInt(i), true
+		}
+		f, err := n.Float64()
+		if err != nil {
+			return nil, false
+		}
+		return values.NewFloat(f), true
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return values.NewString(v.String()), true
+	case reflect.Bool:
+		return values.NewBool(v.Bool()), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return values.NewInt(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return values.NewUInt(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		f := v.Float()
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return nil, false
+		}
+		return values.NewFloat(f), true
+	case reflect.Slice, reflect.Array:
+		if v.Len() == 0 {
+			return nil, false
+		}
+		elements := make([]values.Value, v.Len())
+		for i := range elements {
+			elem, ok := paramValue(v.Index(i))
+			if !ok || (i > 0 && elem.Type().String() != elements[0].Type().String()) {
+				return nil, false
+			}
+			elements[i] = elem
+		}
+		return values.NewArrayWithBacking(semantic.NewArrayType(elements[0].Type()), elements), true
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return nil, false
+		}
+		keys := make([]string, 0, v.Len())
+		for _, k := range v.MapKeys() {
+			keys = append(keys, k.String())
+		}
+		sort.Strings(keys)
+		ok := true
+		obj, err := values.BuildObjectWithSize(len(keys), func(set values.ObjectSetter) error {
+			for _, k := range keys {
+				value, valid := paramValue(v.MapIndex(reflect.ValueOf(k).Convert(v.Type().Key())))
+				if !valid {
+					ok = false
+					return nil
+				}
+				set(k, value)
+			}
+			return nil
+		})
+		if err != nil || !ok {
+			return nil, false
+		}
+		return obj, true
+	}
+	return nil, false
+}
@@ -0,0 +1,218 @@
+package lang_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/andreyvit/diff"
+	"github.com/influxdata/flux"
+	"github.com/influxdata/flux/codes"
+	fcsv "github.com/influxdata/flux/csv"
+	"github.com/influxdata/flux/execute"
+	"github.com/influxdata/flux/internal/errors"
+	"github.com/influxdata/flux/lang"
+	"github.com/influxdata/flux/memory"
+	"github.com/influxdata/flux/runtime"
+)
+
+func TestProgramCache(t *testing.T) {
+	cache := lang.NewProgramCache(10)
+	query := `import "array"
+array.from(rows: [{s: params.s, v: params.n * 2}])`
+
+	for i, tc := range []struct {
+		params lang.Params
+		want   string
+	}{
+		{
+			params: lang.Params{"n": 1, "s": "a"},
+			want:   ",,0,a,2",
+		},
+		{
+			params: lang.Params{"n": 21, "s": "b"},
+			want:   ",,0,b,42",
+		},
+		{
+			// Trailing whitespace does not change the script.
+			params: lang.Params{"n": 5, "s": "c"},
+			want:   ",,0,c,10",
+		},
+	} {
+		q := query
+		if i == 2 {
+			q += "\n\n"
+		}
+		c := lang.FluxCompiler{Query: q, Params: tc.params, Now: time.Now()}
+		program, err := cache.Compile(context.Background(), c, runtime.Default)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := runProgram(t, program)
+		want := toCRLF(`#datatype,string,long,string,long
+#group,false,false,false,false
+#default,_result,,,
+,result,table,s,v
+` + tc.want + `
+
+`)
+		if got != want {
+			t.Errorf("%d: unexpected output -want/+got:\n%s", i, diff.LineDiff(want, got))
+		}
+	}
+
+	if got, want := cache.Stats(), (lang.ProgramCacheStats{Hits: 2, Misses: 1, Len: 1}); got != want {
+		t.Errorf("unexpected stats %+v, want %+v", got, want)
+	}
+
+	// Params of another type are analyzed again.
+	c := lang.FluxCompiler{Query: query, Params: lang.Params{"n": 1.5, "s": "a"}, Now: time.Now()}
+	program, err := cache.Compile(context.Background(), c, runtime.Default)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := program.Start(context.Background(), &memory.ResourceAllocator{}); err == nil {
+		t.Error("expected a type error for a float param")
+	}
+	if got, want := cache.Stats(), (lang.ProgramCacheStats{Hits: 2, Misses: 2, Len: 1}); got != want {
+		t.Errorf("unexpected stats %+v, want %+v", got, want)
+	}
+}
+
+func TestProgramCache_Evict(t *testing.T) {
+	cache := lang.NewProgramCache(2)
+	for _, q := range []string{"a = 1", "b = 1", "a = 1", "c = 1", "b = 1"} {
+		if _, err := cache.Compile(context.Background(), lang.FluxCompiler{Query: q}, runtime.Default); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// b is evicted by c because a was used more recently.
+	want := lang.ProgramCacheStats{Hits: 1, Misses: 4, Evictions: 2, Len: 2}
+	if got := cache.Stats(); got != want {
+		t.Errorf("unexpected stats %+v, want %+v", got, want)
+	}
+}
+
+func TestProgramCache_Error(t *testing.T) {
+	cache := lang.NewProgramCache(2)
+	c := lang.FluxCompiler{Query: `x = 1 +`, Now: time.Now()}
+	for i := 0; i < 2; i++ {
+		program, err := cache.Compile(context.Background(), c, runtime.Default)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = program.Start(context.Background(), &memory.ResourceAllocator{})
+		if err == nil {
+			t.Error("expected an error")
+		}
+	}
+	// Failed analyses are not kept.
+	if got, want := cache.Stats(), (lang.ProgramCacheStats{Misses: 2}); got != want {
+		t.Errorf("unexpected stats %+v, want %+v", got, want)
+	}
+}
+
+func TestProgramCache_Parallel(t *testing.T) {
+	cache := lang.NewProgramCache(10)
+	query := `import "array"
+array.from(rows: [{v: params.n * 2}])`
+
+	// The programs share the analysis and are started concurrently.
+	const n = 16
+	errs := make([]error, n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			c := lang.FluxCompiler{Query: query, Params: lang.Params{"n": i}, Now: time.Now()}
+			program, err := cache.Compile(context.Background(), c, runtime.Default)
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			qry, err := program.Start(context.Background(), &memory.ResourceAllocator{})
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			results := flux.NewResultIteratorFromQuery(qry)
+			defer results.Release()
+
+			var b strings.Builder
+			enc := fcsv.NewMultiResultEncoder(fcsv.DefaultEncoderConfig())
+			if _, err := enc.Encode(&b, results); err != nil {
+				errs[i] = err
+				return
+			}
+			if want := fmt.Sprintf(",,0,%d\r\n", i*2); !strings.Contains(b.String(), want) {
+				errs[i] = fmt.Errorf("expected %q in output:\n%s", want, b.String())
+			}
+		}(i)
+	}
+	wg.Wait()
+	for i, err := range errs {
+		if err != nil {
+			t.Errorf("%d: %s", i, err)
+		}
+	}
+	if got, want := cache.Stats(), (lang.ProgramCacheStats{Hits: n - 1, Misses: 1, Len: 1}); got != want {
+		t.Errorf("unexpected stats %+v, want %+v", got, want)
+	}
+}
+
+func TestProgramCache_Options(t *testing.T) {
+	cache := lang.NewProgramCache(10)
+	query := `import "array"
+array.from(rows: [{v: x}, {v: x + 1}])`
+	compile := func(extern string, opts ...lang.CompileOption) flux.Program {
+		t.Helper()
+		hdl, err := runtime.Parse(context.Background(), extern)
+		if err != nil {
+			t.Fatal(err)
+		}
+		opts = append(opts, lang.WithExtern(hdl))
+		program, err := cache.Compile(context.Background(), lang.FluxCompiler{Query: query, Now: time.Now()}, runtime.Default, opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return program
+	}
+
+	// The extern is part of the analysis.
+	for _, x := range []int{1, 2, 1} {
+		got := runProgram(t, compile(fmt.Sprintf("x = %d", x)))
+		if want := fmt.Sprintf(",,0,%d\r\n,,0,%d\r\n", x, x+1); !strings.Contains(got, want) {
+			t.Errorf("expected %q in output:\n%s", want, got)
+		}
+	}
+	if got, want := cache.Stats(), (lang.ProgramCacheStats{Hits: 1, Misses: 2, Len: 2}); got != want {
+		t.Errorf("unexpected stats %+v, want %+v", got, want)
+	}
+
+	// The resource limits apply to the program that shares the analysis.
+	program := compile("x = 1", lang.WithResourceLimits(execute.ResourceLimits{MaxRowsPerResult: 1}))
+	qry, err := program.Start(context.Background(), &memory.ResourceAllocator{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	results := flux.NewResultIteratorFromQuery(qry)
+	defer results.Release()
+	for err == nil && results.More() {
+		err = results.Next().Tables().Do(func(flux.Table) error { return nil })
+	}
+	if err == nil {
+		err = results.Err()
+	}
+	if err == nil {
+		t.Error("expected the row limit to be exceeded")
+	} else if got := errors.Code(err); got != codes.ResourceExhausted {
+		t.Errorf("unexpected code %v for error %q", got, err)
+	}
+	if got, want := cache.Stats(), (lang.ProgramCacheStats{Hits: 2, Misses: 2, Len: 2}); got != want {
+		t.Errorf("unexpected stats %+v, want %+v", got, want)
+	}
+}
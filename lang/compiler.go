@@ -28,6 +28,7 @@ import (
 const (
 	FluxCompilerType = "flux"
 	ASTCompilerType  = "ast"
+	PlanCompilerType = "plan"
 )
 
 // AddCompilerMappings adds the Flux specific compiler mappings.
@@ -44,6 +45,11 @@ func AddCompilerMappings(mappings flux.CompilerMappings) error {
 	}); err != nil {
 		return err
 	}
+	if err := mappings.Add(PlanCompilerType, func() flux.Compiler {
+		return new(PlanCompiler)
+	}); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -53,6 +59,19 @@ type CompileOption func(*compileOptions)
 type compileOptions struct {
 	extern flux.ASTHandle
 
+	// profilers are the names of the profilers enabled
+	// for the program in addition to any set by the
+	// profiler.enabledProfilers option.
+	profilers []string
+
+	// partitions executes the partitions of parallel
+	// sub-plans when the program is started.
+	partitions execute.PartitionExecutor
+
+	// limits are the limits on the resources
+	// used by the program when it is started.
+	limits execute.ResourceLimits
+
 	planOptions struct {
 		logical  []plan.LogicalOption
 		physical []plan.PhysicalOption
@@ -75,6 +94,31 @@ func WithExtern(extern flux.ASTHandle) CompileOption {
 	}
 }
 
+// WithProfilers enables the named profilers when the program is started.
+// This is equivalent to setting the profiler.enabledProfilers option
+// and the option, when present in the script, takes precedence.
+func WithProfilers(names ...string) CompileOption {
+	return func(o *compileOptions) {
+		o.profilers = append(o.profilers, names...)
+	}
+}
+
+// WithPartitionExecutor executes the partitions of the parallel sub-plans
+// of the program with pe, usually on worker processes, when it is started.
+func WithPartitionExecutor(pe execute.PartitionExecutor) CompileOption {
+	return func(o *compileOptions) {
+		o.partitions = pe
+	}
+}
+
+// WithResourceLimits sets the limits on the resources used by the program
+// when it is started. This is equivalent to calling SetResourceLimits.
+func WithResourceLimits(limits execute.ResourceLimits) CompileOption {
+	return func(o *compileOptions) {
+		o.limits = limits
+	}
+}
+
 func defaultOptions() *compileOptions {
 	o := new(compileOptions)
 	return o
@@ -89,7 +133,8 @@ func applyOptions(opts ...CompileOption) *compileOptions {
 }
 
 // NOTE: compileOptions can be used only when invoking Compile* functions.
-// They can't be used when unmarshaling a Compiler and invoking its Compile method.
+// They can't be used when unmarshaling a Compiler and invoking its Compile method,
+// use FluxCompiler.CompileWithOptions or ProgramCache.Compile instead.
 
 // Compile evaluates a Flux script producing a flux.Program.
 // now parameter must be non-zero, that is the default now time should be set before compiling.
@@ -104,10 +149,12 @@ func Compile(ctx context.Context, q string, runtime flux.Runtime, now time.Time,
 // CompileAST evaluates a Flux handle to an AST and produces a flux.Program.
 // now parameter must be non-zero, that is the default now time should be set before compiling.
 func CompileAST(astPkg flux.ASTHandle, runtime flux.Runtime, now time.Time, opts ...CompileOption) *AstProgram {
+	o := applyOptions(opts...)
 	return &AstProgram{
 		Program: &Program{
 			Runtime: runtime,
-			opts:    applyOptions(opts...),
+			opts:    o,
+			limits:  o.limits,
 		},
 		Ast: astPkg,
 		Now: now,
@@ -129,6 +176,7 @@ func CompileTableObject(ctx context.Context, to *flux.TableObject, now time.Time
 	return &Program{
 		opts:     o,
 		PlanSpec: ps,
+		limits:   o.limits,
 	}, nil
 }
 
@@ -156,10 +204,25 @@ func buildPlan(ctx context.Context, spec *operation.Spec, opts *compileOptions)
 	return ps, nil
 }
 
+// buildLogicalPlan creates the logical plan for the spec
+// without applying the physical planner.
+func buildLogicalPlan(ctx context.Context, spec *operation.Spec, opts *compileOptions) (*plan.Spec, error) {
+	s, _ := opentracing.StartSpanFromContext(ctx, "plan")
+	defer s.Finish()
+
+	lp := plan.NewLogicalPlanner(opts.planOptions.logical...)
+	ip, err := lp.CreateInitialPlan(spec)
+	if err != nil {
+		return nil, err
+	}
+	return lp.Plan(ctx, ip)
+}
+
 // FluxCompiler compiles a Flux script into a spec.
 type FluxCompiler struct {
 	Now    time.Time
 	Extern json.RawMessage `json:"extern,omitempty"`
+	Params Params          `json:"params,omitempty"`
 	Query  string          `json:"query"`
 }
 
@@ -180,17 +243,53 @@ func IsNonNullJSON(bs json.RawMessage) bool {
 }
 
 func (c FluxCompiler) Compile(ctx context.Context, runtime flux.Runtime) (flux.Program, error) {
+	return c.compile(ctx, runtime)
+}
+
+// CompileWithOptions compiles the script with the options. The extern
+// and the params of the compiler are merged after any extern in the options.
+func (c FluxCompiler) CompileWithOptions(ctx context.Context, runtime flux.Runtime, opts ...CompileOption) (flux.Program, error) {
+	return c.compile(ctx, runtime, opts...)
+}
+
+// compile compiles the script with the options. The extern
+// of the compiler is merged after any extern in the options.
+func (c FluxCompiler) compile(ctx context.Context, runtime flux.Runtime, opts ...CompileOption) (flux.Program, error) {
 	query := c.Query
 
 	// Ignore context, it will be provided upon Program Start.
+	var hdl flux.ASTHandle
 	if IsNonNullJSON(c.Extern) {
-		hdl, err := runtime.JSONToHandle(wrapFileJSONInPkg(c.Extern))
+		var err error
+		hdl, err = runtime.JSONToHandle(wrapFileJSONInPkg(c.Extern))
 		if err != nil {
 			return nil, errors.Wrap(err, codes.Inherit, "extern json parse error")
 		}
-		return Compile(ctx, query, runtime, c.Now, WithExtern(hdl))
 	}
-	return Compile(ctx, query, runtime, c.Now)
+	hdl, err := withParams(runtime, hdl, c.Params)
+	if err != nil {
+		return nil, err
+	}
+	if hdl != nil {
+		opt, err := withCompilerExtern(runtime, hdl, opts)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, opt)
+	}
+	return Compile(ctx, query, runtime, c.Now, opts...)
+}
+
+// withCompilerExtern returns the option that sets the extern of
+// a compiler, which is merged into the extern of the options.
+func withCompilerExtern(runtime flux.Runtime, extern flux.ASTHandle, opts []CompileOption) (CompileOption, error) {
+	if o := applyOptions(opts...); o.extern != nil {
+		if err := runtime.MergePackages(o.extern, extern); err != nil {
+			return nil, err
+		}
+		extern = o.extern
+	}
+	return WithExtern(extern), nil
 }
 
 func (c FluxCompiler) CompilerType() flux.CompilerType {
@@ -200,11 +299,18 @@ func (c FluxCompiler) CompilerType() flux.CompilerType {
 // ASTCompiler implements Compiler by producing a Program from an AST.
 type ASTCompiler struct {
 	Extern json.RawMessage `json:"extern,omitempty"`
+	Params Params          `json:"params,omitempty"`
 	AST    json.RawMessage `json:"ast"`
 	Now    time.Time
 }
 
 func (c ASTCompiler) Compile(ctx context.Context, runtime flux.Runtime) (flux.Program, error) {
+	return c.compile(ctx, runtime)
+}
+
+// compile compiles the AST with the options. The extern
+// of the compiler is merged after any extern in the options.
+func (c ASTCompiler) compile(ctx context.Context, runtime flux.Runtime, opts ...CompileOption) (flux.Program, error) {
 	now := c.Now
 	if now.IsZero() {
 		now = time.Now()
@@ -218,14 +324,25 @@ func (c ASTCompiler) Compile(ctx context.Context, runtime flux.Runtime) (flux.Pr
 	}
 
 	// Ignore context, it will be provided upon Program Start.
+	var extHdl flux.ASTHandle
 	if IsNonNullJSON(c.Extern) {
-		extHdl, err := runtime.JSONToHandle(wrapFileJSONInPkg(c.Extern))
+		extHdl, err = runtime.JSONToHandle(wrapFileJSONInPkg(c.Extern))
+		if err != nil {
+			return nil, err
+		}
+	}
+	extHdl, err = withParams(runtime, extHdl, c.Params)
+	if err != nil {
+		return nil, err
+	}
+	if extHdl != nil {
+		opt, err := withCompilerExtern(runtime, extHdl, opts)
 		if err != nil {
 			return nil, err
 		}
-		return CompileAST(hdl, runtime, now, WithExtern(extHdl)), nil
+		opts = append(opts, opt)
 	}
-	return CompileAST(hdl, runtime, now), nil
+	return CompileAST(hdl, runtime, now, opts...), nil
 }
 
 func (ASTCompiler) CompilerType() flux.CompilerType {
@@ -249,6 +366,29 @@ func (*TableObjectCompiler) CompilerType() flux.CompilerType {
 	panic("TableObjectCompiler is not associated with a CompilerType")
 }
 
+// PlanCompiler implements Compiler by decoding a physical plan
+// that was serialized with plan.MarshalSpec. The plan is executed
+// as it is, so every node must already be physical and planned.
+type PlanCompiler struct {
+	Plan json.RawMessage `json:"plan"`
+}
+
+func (c PlanCompiler) Compile(ctx context.Context, runtime flux.Runtime) (flux.Program, error) {
+	ps, err := plan.UnmarshalSpec(c.Plan)
+	if err != nil {
+		return nil, err
+	}
+	return &Program{
+		PlanSpec: ps,
+		Runtime:  runtime,
+		opts:     defaultOptions(),
+	}, nil
+}
+
+func (PlanCompiler) CompilerType() flux.CompilerType {
+	return PlanCompilerType
+}
+
 type LoggingProgram interface {
 	SetLogger(logger *zap.Logger)
 }
@@ -260,15 +400,24 @@ type Program struct {
 	PlanSpec *plan.Spec
 	Runtime  flux.Runtime
 
-	opts *compileOptions
+	opts   *compileOptions
+	limits execute.ResourceLimits
 }
 
 func (p *Program) SetLogger(logger *zap.Logger) {
 	p.Logger = logger
 }
 
+// SetResourceLimits sets the limits on the resources used by the program
+// when it starts, including any queries started while it is evaluated.
+// The execution time limit starts with the program and covers its
+// evaluation as well as all of its executions.
+func (p *Program) SetResourceLimits(limits execute.ResourceLimits) {
+	p.limits = limits
+}
+
 func (p *Program) Start(ctx context.Context, alloc memory.Allocator) (flux.Query, error) {
-	ctx, cancel := context.WithCancel(ctx)
+	ctx, cancel := execute.WithExecutionTimeLimit(ctx, p.limits.MaxExecutionTime)
 
 	// This span gets closed by the query when it is done.
 	var s opentracing.Span
@@ -283,6 +432,25 @@ func (p *Program) Start(ctx context.Context, alloc memory.Allocator) (flux.Query
 	}
 
 	ctx = memory.WithAllocator(ctx, resourceAlloc)
+	needsDeps := !p.limits.IsZero() || (p.opts != nil && p.opts.partitions != nil)
+	if needsDeps && !execute.HaveExecutionDependencies(ctx) {
+		// A program that is not started by an AstProgram has no
+		// execution dependencies to hold its execution options.
+		now := p.PlanSpec.Now
+		ctx = execute.NewExecutionDependencies(resourceAlloc, &now, p.Logger).Inject(ctx)
+	}
+	if !p.limits.IsZero() {
+		var eoc ExecOptsConfig
+		if err := eoc.ConfigureResourceLimits(ctx, p.limits); err != nil {
+			s.Finish()
+			cancel()
+			return nil, err
+		}
+	}
+	if p.opts != nil && p.opts.partitions != nil {
+		var eoc ExecOptsConfig
+		eoc.ConfigurePartitions(ctx, p.opts.partitions)
+	}
 
 	q := &query{
 		ctx:     ctx,
@@ -307,7 +475,8 @@ func (p *Program) Start(ctx context.Context, alloc memory.Allocator) (flux.Query
 	resultMap, statsCh, err := e.Execute(ctx, p.PlanSpec, q.alloc)
 	if err != nil {
 		s.Finish()
-		return nil, err
+		cancel()
+		return nil, execute.ExecutionTimeLimitError(ctx, err)
 	}
 
 	// There was no error so send the results downstream.
@@ -329,7 +498,7 @@ func (p *Program) processResults(ctx context.Context, q *query, resultMap map[st
 		select {
 		case q.results <- res:
 		case <-ctx.Done():
-			q.err = ctx.Err()
+			q.err = execute.ExecutionTimeLimitError(ctx, ctx.Err())
 			return
 		}
 	}
@@ -354,6 +523,8 @@ type AstProgram struct {
 	// The operator profiler that is profiling this query, if any.
 	// Note this operator profiler is also cached in the Profilers array.
 	tfProfiler *execute.OperatorProfiler
+	// The analysis of the program in a ProgramCache, if any.
+	cached *cachedProgram
 }
 
 // Prepare the Ast for semantic analysis
@@ -418,6 +589,27 @@ func (eoc *ExecOptsConfig) ConfigureProfiler(ctx context.Context, profilerNames
 	}
 }
 
+// ConfigureResourceLimits sets the limits on the resources
+// used by the executions started with the context. It fails
+// when the context has no execution dependencies to hold them.
+func (eoc *ExecOptsConfig) ConfigureResourceLimits(ctx context.Context, limits execute.ResourceLimits) error {
+	if !execute.HaveExecutionDependencies(ctx) {
+		return errors.New(codes.Internal, "resource limits require execution dependencies")
+	}
+	deps := execute.GetExecutionDependencies(ctx)
+	deps.ExecutionOptions.Limits = limits
+	return nil
+}
+
+// ConfigurePartitions sets the executor of the partitions of parallel
+// sub-plans used by the executions started with the context.
+func (eoc *ExecOptsConfig) ConfigurePartitions(ctx context.Context, pe execute.PartitionExecutor) {
+	if execute.HaveExecutionDependencies(ctx) {
+		deps := execute.GetExecutionDependencies(ctx)
+		deps.ExecutionOptions.Partitions = pe
+	}
+}
+
 func (eoc *ExecOptsConfig) ConfigureNow(ctx context.Context, now time.Time) {
 	// Stash in the execution dependencies. The deps use a pointer and we
 	// overwrite the dest of the pointer. Overwritng the pointer would have no
@@ -443,7 +635,7 @@ func (p *AstProgram) getSpec(ctx context.Context, alloc memory.Allocator) (*oper
 	// the runtime and flux code in so many places. We should evaluate how
 	// now is used and see if we can improve how now interacts with the system.
 	var nowOpt values.Value
-	sideEffects, scope, err := p.Runtime.Eval(cctx, ast, &ExecOptsConfig{},
+	opts := []flux.ScopeMutator{
 		flux.SetNowOption(p.Now),
 		func(r flux.Runtime, scope values.Scope) {
 			nowOpt, _ = scope.Lookup(interpreter.NowOption)
@@ -451,7 +643,17 @@ func (p *AstProgram) getSpec(ctx context.Context, alloc memory.Allocator) (*oper
 				panic("now must be an option")
 			}
 		},
+	}
+	var (
+		sideEffects []interpreter.SideEffect
+		scope       values.Scope
+		err         error
 	)
+	if p.cached != nil {
+		sideEffects, scope, err = p.cached.eval(cctx, p.Runtime, opts...)
+	} else {
+		sideEffects, scope, err = p.Runtime.Eval(cctx, ast, &ExecOptsConfig{}, opts...)
+	}
 	if err != nil {
 		return nil, nil, err
 	}
@@ -472,6 +674,20 @@ func (p *AstProgram) getSpec(ctx context.Context, alloc memory.Allocator) (*oper
 }
 
 func (p *AstProgram) Start(ctx context.Context, alloc memory.Allocator) (flux.Query, error) {
+	// The execution time limit covers the evaluation of the
+	// script and every execution started by the program.
+	ctx, cancel := execute.WithExecutionTimeLimit(ctx, p.limits.MaxExecutionTime)
+	q, err := p.start(ctx, alloc)
+	if err != nil {
+		err = execute.ExecutionTimeLimitError(ctx, err)
+		cancel()
+		return nil, err
+	}
+	q.cancel = cancel
+	return q, nil
+}
+
+func (p *AstProgram) start(ctx context.Context, alloc memory.Allocator) (*spanQuery, error) {
 	// The program must inject execution dependencies to make it available to
 	// function calls during the evaluation phase (see `tableFind`).
 	deps := execute.NewExecutionDependencies(alloc, &p.Now, p.Logger)
@@ -479,6 +695,20 @@ func (p *AstProgram) Start(ctx context.Context, alloc memory.Allocator) (flux.Qu
 	ctx, span := dependency.Inject(ctx, deps)
 	nextPlanNodeID := new(int)
 	ctx = context.WithValue(ctx, plan.NextPlanNodeIDKey, nextPlanNodeID)
+	if p.opts != nil && len(p.opts.profilers) > 0 {
+		var eoc ExecOptsConfig
+		eoc.ConfigureProfiler(ctx, p.opts.profilers)
+	}
+	if !p.limits.IsZero() {
+		var eoc ExecOptsConfig
+		if err := eoc.ConfigureResourceLimits(ctx, p.limits); err != nil {
+			return nil, err
+		}
+	}
+	if p.opts != nil && p.opts.partitions != nil {
+		var eoc ExecOptsConfig
+		eoc.ConfigurePartitions(ctx, p.opts.partitions)
+	}
 
 	// Evaluation.
 	sp, scope, err := p.getSpec(ctx, alloc)
@@ -516,6 +746,54 @@ func (p *AstProgram) Start(ctx context.Context, alloc memory.Allocator) (flux.Qu
 	}, nil
 }
 
+// LogicalPlan evaluates the program and returns the logical plan
+// for the query without applying physical planning or executing it.
+func (p *AstProgram) LogicalPlan(ctx context.Context, alloc memory.Allocator) (*plan.Spec, error) {
+	return p.plan(ctx, alloc, buildLogicalPlan)
+}
+
+// PhysicalPlan evaluates the program and returns the physical plan
+// that would be executed by Start without executing it.
+func (p *AstProgram) PhysicalPlan(ctx context.Context, alloc memory.Allocator) (*plan.Spec, error) {
+	return p.plan(ctx, alloc, buildPlan)
+}
+
+func (p *AstProgram) plan(ctx context.Context, alloc memory.Allocator, build func(context.Context, *operation.Spec, *compileOptions) (*plan.Spec, error)) (*plan.Spec, error) {
+	// Evaluation may call functions that need the execution
+	// dependencies in the same way as Start.
+	deps := execute.NewExecutionDependencies(alloc, &p.Now, p.Logger)
+	ctx, span := dependency.Inject(ctx, deps)
+	defer span.Finish()
+	ctx, cancel := execute.WithExecutionTimeLimit(ctx, p.limits.MaxExecutionTime)
+	defer cancel()
+	ctx = context.WithValue(ctx, plan.NextPlanNodeIDKey, new(int))
+	if !p.limits.IsZero() {
+		var eoc ExecOptsConfig
+		if err := eoc.ConfigureResourceLimits(ctx, p.limits); err != nil {
+			return nil, err
+		}
+	}
+	// The partition executor decides whether sources are
+	// split into partitions by the physical planner.
+	if p.opts != nil && p.opts.partitions != nil {
+		var eoc ExecOptsConfig
+		eoc.ConfigurePartitions(ctx, p.opts.partitions)
+	}
+
+	sp, scope, err := p.getSpec(ctx, alloc)
+	if err != nil {
+		return nil, execute.ExecutionTimeLimitError(ctx, err)
+	}
+	if err := p.updateOpts(scope); err != nil {
+		return nil, errors.Wrap(err, codes.Inherit, "error in reading options while planning program")
+	}
+	ps, err := build(ctx, sp, p.opts)
+	if err != nil {
+		return nil, errors.Wrap(err, codes.Inherit, "error in building plan")
+	}
+	return ps, nil
+}
+
 func (p *AstProgram) updateProfilers(ctx context.Context, scope values.Scope) error {
 	if execute.HaveExecutionDependencies(ctx) {
 		deps := execute.GetExecutionDependencies(ctx)
@@ -548,10 +826,15 @@ type spanQuery struct {
 	span     *dependency.Span
 	stats    flux.Statistics
 	metadata *metadata.SyncMetadata
+	// cancel releases the execution time limit of the query.
+	cancel context.CancelFunc
 }
 
 func (q *spanQuery) Done() {
 	q.Query.Done()
+	if q.cancel != nil {
+		q.cancel()
+	}
 	q.stats.Metadata = make(metadata.Metadata)
 	q.metadata.ReadView(func(meta metadata.Metadata) {
 		q.stats.Metadata.AddAll(meta)
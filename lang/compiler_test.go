@@ -5,8 +5,12 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
 	"regexp"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -14,12 +18,15 @@ import (
 	"github.com/google/go-cmp/cmp"
 	"github.com/influxdata/flux"
 	"github.com/influxdata/flux/ast"
+	"github.com/influxdata/flux/codes"
 	fcsv "github.com/influxdata/flux/csv"
 	"github.com/influxdata/flux/dependencies/dependenciestest"
 	"github.com/influxdata/flux/dependency"
 	"github.com/influxdata/flux/execute"
 	"github.com/influxdata/flux/execute/executetest"
+	"github.com/influxdata/flux/execute/remote"
 	_ "github.com/influxdata/flux/fluxinit/static"
+	"github.com/influxdata/flux/internal/errors"
 	"github.com/influxdata/flux/lang"
 	"github.com/influxdata/flux/memory"
 	"github.com/influxdata/flux/mock"
@@ -526,6 +533,94 @@ func TestCompileOptions(t *testing.T) {
 	}
 }
 
+func TestAstProgram_Plan(t *testing.T) {
+	ctx, deps := dependency.Inject(context.Background(), executetest.NewTestExecuteDependencies())
+	defer deps.Finish()
+
+	src := `import "csv"
+			csv.from(csv: "foo,bar")
+				|> range(start: 2017-10-10T00:00:00Z)
+				|> count()`
+
+	now := parser.MustParseTime("2018-10-10T00:00:00Z").Value
+
+	opt := lang.WithLogPlanOpts(plan.OnlyLogicalRules(removeCount{}))
+
+	program, err := lang.Compile(ctx, src, runtime.Default, now, opt)
+	if err != nil {
+		t.Fatalf("failed to compile script: %v", err)
+	}
+
+	lp, err := program.LogicalPlan(ctx, &memory.ResourceAllocator{})
+	if err != nil {
+		t.Fatalf("failed to build logical plan: %v", err)
+	}
+	wantLogical := plantest.CreatePlanSpec(&plantest.PlanSpec{
+		Nodes: []plan.Node{
+			plan.CreateLogicalNode("fromCSV0", &csv.FromCSVProcedureSpec{}),
+			plan.CreateLogicalNode("range1", &universe.RangeProcedureSpec{}),
+		},
+		Edges: [][2]int{
+			{0, 1},
+		},
+		Now: now,
+	})
+	if err := plantest.ComparePlansShallow(wantLogical, lp); err != nil {
+		t.Fatalf("unexpected logical plan: %v", err)
+	}
+
+	pp, err := program.PhysicalPlan(ctx, &memory.ResourceAllocator{})
+	if err != nil {
+		t.Fatalf("failed to build physical plan: %v", err)
+	}
+	wantPhysical := plantest.CreatePlanSpec(&plantest.PlanSpec{
+		Nodes: []plan.Node{
+			&plan.PhysicalPlanNode{Spec: &csv.FromCSVProcedureSpec{}},
+			&plan.PhysicalPlanNode{Spec: &universe.RangeProcedureSpec{}},
+		},
+		Edges: [][2]int{
+			{0, 1},
+		},
+		Now: now,
+	})
+	if err := plantest.ComparePlansShallow(wantPhysical, pp); err != nil {
+		t.Fatalf("unexpected physical plan: %v", err)
+	}
+}
+
+func TestCompileOptions_WithProfilers(t *testing.T) {
+	ctx, deps := dependency.Inject(context.Background(), executetest.NewTestExecuteDependencies())
+	defer deps.Finish()
+
+	src := `import "array" array.from(rows: [{a: 1}, {a: 2}])`
+	now := parser.MustParseTime("2018-10-10T00:00:00Z").Value
+
+	program, err := lang.Compile(ctx, src, runtime.Default, now, lang.WithProfilers("query", "operator"))
+	if err != nil {
+		t.Fatalf("failed to compile script: %v", err)
+	}
+	q, err := program.Start(ctx, &memory.ResourceAllocator{})
+	if err != nil {
+		t.Fatalf("failed to start program: %v", err)
+	}
+	for res := range q.Results() {
+		if err := res.Tables().Do(func(flux.Table) error { return nil }); err != nil {
+			t.Fatal(err)
+		}
+	}
+	q.Done()
+	if err := q.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := len(program.Profilers), 2; got != want {
+		t.Fatalf("unexpected number of profilers -want/+got:\n\t- %d\n\t+ %d", want, got)
+	}
+	if len(q.Statistics().Profiles) == 0 {
+		t.Fatal("expected operator profiles in the query statistics")
+	}
+}
+
 type removeCount struct{}
 
 func (rule removeCount) Name() string {
@@ -1043,6 +1138,204 @@ csv.from(csv: data)
 	compareTableObjectWithTables(t, filterTO, wantFilter)
 }
 
+func TestPlanCompiler(t *testing.T) {
+	dataRaw := `#datatype,string,long,dateTime:RFC3339,long,string
+#group,false,false,false,false,true
+#default,_result,,,,
+,result,table,_time,_value,host
+,,0,2018-05-22T19:53:26Z,1,a
+,,0,2018-05-22T19:53:36Z,2,a
+,,1,2018-05-22T19:53:26Z,3,b
+,,1,2018-05-22T19:53:36Z,4,b
+`
+	limitedDataRaw := `#datatype,string,long,dateTime:RFC3339,long,string
+#group,false,false,false,false,true
+#default,_result,,,,
+,result,table,_time,_value,host
+,,0,2018-05-22T19:53:26Z,1,a
+,,1,2018-05-22T19:53:26Z,3,b
+`
+
+	ps := plantest.CreatePlanSpec(&plantest.PlanSpec{
+		Nodes: []plan.Node{
+			plan.CreatePhysicalNode("fromCSV", &csv.FromCSVProcedureSpec{CSV: dataRaw}),
+			plan.CreatePhysicalNode("limit", &universe.LimitProcedureSpec{N: 1}),
+			plan.CreatePhysicalNode("yield", &universe.YieldProcedureSpec{Name: "_result"}),
+		},
+		Edges: [][2]int{{0, 1}, {1, 2}},
+		Resources: flux.ResourceManagement{
+			ConcurrencyQuota: 1,
+			MemoryBytesQuota: math.MaxInt64,
+		},
+		Now: time.Now(),
+	})
+	data, err := plan.MarshalSpec(ps)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The serialized plan is sent as a compiler request.
+	mappings := make(flux.CompilerMappings)
+	if err := lang.AddCompilerMappings(mappings); err != nil {
+		t.Fatal(err)
+	}
+	request, err := json.Marshal(lang.PlanCompiler{Plan: data})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := mappings[lang.PlanCompilerType]()
+	if err := json.Unmarshal(request, c); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, deps := dependency.Inject(context.Background(), executetest.NewTestExecuteDependencies())
+	defer deps.Finish()
+	program, err := c.Compile(ctx, runtime.Default)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q, err := program.Start(ctx, &memory.ResourceAllocator{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := <-q.Results()
+	got := getTablesFromResultOrFail(t, result)
+	q.Done()
+	if err := q.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := getTablesFromRawOrFail(t, limitedDataRaw); !cmp.Equal(want, got) {
+		t.Errorf("unexpected result -want/+got:\n%s", cmp.Diff(want, got))
+	}
+}
+
+func TestProgram_ResourceLimitsWithoutExecutionDependencies(t *testing.T) {
+	dataRaw := `#datatype,string,long,dateTime:RFC3339,long,string
+#group,false,false,false,false,true
+#default,_result,,,,
+,result,table,_time,_value,host
+,,0,2018-05-22T19:53:26Z,1,a
+,,0,2018-05-22T19:53:36Z,2,a
+`
+	ps := plantest.CreatePlanSpec(&plantest.PlanSpec{
+		Nodes: []plan.Node{
+			plan.CreatePhysicalNode("fromCSV", &csv.FromCSVProcedureSpec{CSV: dataRaw}),
+			plan.CreatePhysicalNode("yield", &universe.YieldProcedureSpec{Name: "_result"}),
+		},
+		Edges: [][2]int{{0, 1}},
+		Resources: flux.ResourceManagement{
+			ConcurrencyQuota: 1,
+			MemoryBytesQuota: math.MaxInt64,
+		},
+		Now: time.Now(),
+	})
+	data, err := plan.MarshalSpec(ps)
+	if err != nil {
+		t.Fatal(err)
+	}
+	program, err := lang.PlanCompiler{Plan: data}.Compile(context.Background(), runtime.Default)
+	if err != nil {
+		t.Fatal(err)
+	}
+	program.(*lang.Program).SetResourceLimits(execute.ResourceLimits{MaxRowsPerResult: 1})
+
+	// The context has no execution dependencies
+	// so the program injects them for its limits.
+	ctx, deps := dependency.Inject(context.Background(), executetest.NewTestExecuteDependencies())
+	defer deps.Finish()
+	q, err := program.Start(ctx, &memory.ResourceAllocator{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for result := range q.Results() {
+		if err = result.Tables().Do(func(flux.Table) error { return nil }); err != nil {
+			break
+		}
+	}
+	q.Done()
+	if err == nil {
+		err = q.Err()
+	}
+	if err == nil {
+		t.Fatal("expected the row limit to be exceeded")
+	}
+	if got := errors.Code(err); got != codes.ResourceExhausted {
+		t.Errorf("unexpected code %v for error %q", got, err)
+	}
+}
+
+func TestCompile_PartitionExecutor(t *testing.T) {
+	script := `import "csv"
+
+data = "
+#datatype,string,long,string,long
+#group,false,false,true,false
+#default,_result,,,
+,result,table,host,_value
+,,0,a,1
+,,0,a,2
+,,1,b,3
+
+#datatype,string,long,string,long
+#group,false,false,true,false
+#default,_result,,,
+,result,table,host,_value
+,,2,c,4
+,,2,c,5
+,,3,d,6
+"
+
+csv.from(csv: data)
+	|> filter(fn: (r) => r._value > 1)
+	|> sum()
+	|> group()
+	|> sort(columns: ["host"])`
+
+	// Each worker counts the partitions that it executes.
+	var requests int32
+	var urls []string
+	for i := 0; i < 2; i++ {
+		w := &remote.Worker{}
+		server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&requests, 1)
+			w.ServeHTTP(rw, r)
+		}))
+		defer server.Close()
+		urls = append(urls, server.URL)
+	}
+
+	run := func(opts ...lang.CompileOption) string {
+		t.Helper()
+		program, err := lang.Compile(context.Background(), script, runtime.Default, time.Now(), opts...)
+		if err != nil {
+			t.Fatalf("failed to compile AST: %v", err)
+		}
+		return runProgram(t, program)
+	}
+
+	want := toCRLF(`#datatype,string,long,string,long
+#group,false,false,false,false
+#default,_result,,,
+,result,table,host,_value
+,,0,a,2
+,,0,b,3
+,,0,c,9
+,,0,d,6
+
+`)
+	if got := run(); got != want {
+		t.Fatalf("unexpected output without workers -want/+got:\n%s", diff.LineDiff(want, got))
+	}
+	if got := run(lang.WithPartitionExecutor(remote.NewClient(urls, nil))); got != want {
+		t.Errorf("unexpected output with workers -want/+got:\n%s", diff.LineDiff(want, got))
+	}
+	// The source is split into a partition for each worker.
+	if got := atomic.LoadInt32(&requests); got != 2 {
+		t.Errorf("expected the workers to execute 2 partitions, got %d", got)
+	}
+}
+
 func compareTableObjectWithTables(t *testing.T, to *flux.TableObject, want []*executetest.Table) {
 	t.Helper()
 
@@ -0,0 +1,346 @@
+package lang
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/influxdata/flux"
+	"github.com/influxdata/flux/ast"
+	"github.com/influxdata/flux/codes"
+	"github.com/influxdata/flux/internal/errors"
+)
+
+// ParamsIdentifier is the name of the record
+// that holds the params of a compiler.
+const ParamsIdentifier = "params"
+
+// Params are the values of the params record that a script references
+// as params.<name>. Each value is converted to a Flux literal so it is
+// never interpolated into the script and the analyzer checks the script's
+// use of params against the types of the values.
+//
+// Strings, booleans, numbers, time.Time and time.Duration values are
+// supported along with slices of them as arrays and maps with string
+// keys as records. Numbers decoded from JSON without a fraction or
+// exponent are integers and all other numbers are floats. Times and
+// durations are encoded to JSON with their type, as in
+// {"$type": "time", "$value": "2021-01-01T00:00:00Z"} and
+// {"$type": "duration", "$value": "5m0s"}, so a compiler decoded
+// from JSON sees the same values as the one that was encoded.
+// A record with a $type field is encoded as
+// {"$type": "record", "$value": {...}} so it is never mistaken
+// for the encoding of a time or a duration.
+type Params map[string]interface{}
+
+// typedParam is the JSON encoding of a param
+// whose type is not a JSON type.
+type typedParam struct {
+	Type  string      `json:"$type"`
+	Value interface{} `json:"$value"`
+}
+
+const (
+	paramTypeKey  = "$type"
+	paramValueKey = "$value"
+
+	timeParamType     = "time"
+	durationParamType = "duration"
+	recordParamType   = "record"
+)
+
+// MarshalJSON encodes the params with the type of times and durations.
+func (p Params) MarshalJSON() ([]byte, error) {
+	if p == nil {
+		return []byte("null"), nil
+	}
+	m := make(map[string]interface{}, len(p))
+	for k, v := range p {
+		m[k] = encodeParam(reflect.ValueOf(v))
+	}
+	return json.Marshal(m)
+}
+
+// encodeParam replaces the times and durations in the value
+// with their typed encoding.
+func encodeParam(v reflect.Value) interface{} {
+	if !v.IsValid() {
+		return nil
+	}
+	if v.Kind() == reflect.Interface || v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil
+		}
+		return encodeParam(v.Elem())
+	}
+
+	switch v.Type() {
+	case timeType:
+		return typedParam{Type: timeParamType, Value: v.Interface().(time.Time).Format(time.RFC3339Nano)}
+	case durationType:
+		return typedParam{Type: durationParamType, Value: time.Duration(v.Int()).String()}
+	case numberType:
+		return v.Interface()
+	}
+
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return v.Interface()
+		}
+		l := make([]interface{}, v.Len())
+		for i := range l {
+			l[i] = encodeParam(v.Index(i))
+		}
+		return l
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			// Params are validated when they are compiled.
+			return v.Interface()
+		}
+		m := make(map[string]interface{}, v.Len())
+		for _, k := range v.MapKeys() {
+			m[k.String()] = encodeParam(v.MapIndex(k))
+		}
+		if _, ok := m[paramTypeKey]; ok {
+			return typedParam{Type: recordParamType, Value: m}
+		}
+		return m
+	}
+	return v.Interface()
+}
+
+// UnmarshalJSON decodes the params keeping integers distinct from floats
+// and decoding the typed encoding of times and durations.
+func (p *Params) UnmarshalJSON(data []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var m map[string]interface{}
+	if err := dec.Decode(&m); err != nil {
+		return err
+	}
+	for k, v := range m {
+		v, err := decodeParam(v, k)
+		if err != nil {
+			return err
+		}
+		m[k] = v
+	}
+	*p = m
+	return nil
+}
+
+// decodeParam replaces the typed encoding of times and durations in
+// the decoded value with the values they encode. The name of the param
+// is only used to report errors.
+func decodeParam(v interface{}, name string) (interface{}, error) {
+	switch v := v.(type) {
+	case []interface{}:
+		for i, elem := range v {
+			elem, err := decodeParam(elem, name)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = elem
+		}
+		return v, nil
+	case map[string]interface{}:
+		if _, ok := v[paramTypeKey]; ok {
+			return decodeTypedParam(v, name)
+		}
+		return decodeParamRecord(v, name)
+	}
+	return v, nil
+}
+
+// decodeParamRecord decodes the values of the fields of a record.
+func decodeParamRecord(m map[string]interface{}, name string) (interface{}, error) {
+	for k, elem := range m {
+		elem, err := decodeParam(elem, name+"."+k)
+		if err != nil {
+			return nil, err
+		}
+		m[k] = elem
+	}
+	return m, nil
+}
+
+// decodeTypedParam decodes the typed encoding of a param.
+func decodeTypedParam(m map[string]interface{}, name string) (interface{}, error) {
+	typ, ok := m[paramTypeKey].(string)
+	value, hasValue := m[paramValueKey]
+	if !ok || !hasValue || len(m) != 2 {
+		return nil, errors.Newf(codes.Invalid, "param %s must have exactly the fields %s and %s", name, paramTypeKey, paramValueKey)
+	}
+	switch typ {
+	case timeParamType:
+		s, _ := value.(string)
+		t, err := time.Parse(time.RFC3339Nano, s)
+		if err != nil {
+			return nil, errors.Wrapf(err, codes.Invalid, "param %s is not a valid time", name)
+		}
+		return t, nil
+	case durationParamType:
+		s, _ := value.(string)
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return nil, errors.Wrapf(err, codes.Invalid, "param %s is not a valid duration", name)
+		}
+		return d, nil
+	case recordParamType:
+		record, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, errors.Newf(codes.Invalid, "param %s is not a valid record", name)
+		}
+		return decodeParamRecord(record, name)
+	}
+	return nil, errors.Newf(codes.Invalid, "param %s has unknown type %q", name, typ)
+}
+
+// file returns a file that assigns the params record.
+func (p Params) file() (*ast.File, error) {
+	record, err := paramRecord(reflect.ValueOf(map[string]interface{}(p)), ParamsIdentifier)
+	if err != nil {
+		return nil, err
+	}
+	return &ast.File{
+		Name: ParamsIdentifier,
+		Body: []ast.Statement{&ast.VariableAssignment{
+			ID:   &ast.Identifier{Name: ParamsIdentifier},
+			Init: record,
+		}},
+	}, nil
+}
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	durationType = reflect.TypeOf(time.Duration(0))
+	numberType   = reflect.TypeOf(json.Number(""))
+)
+
+// paramLiteral returns the Flux literal for the value of the param
+// with the name, which is only used to report errors.
+func paramLiteral(v reflect.Value, name string) (ast.Expression, error) {
+	if v.Kind() == reflect.Interface || v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil, errors.Newf(codes.Invalid, "param %s is null, Flux has no null literal", name)
+		}
+		return paramLiteral(v.Elem(), name)
+	}
+
+	switch v.Type() {
+	case timeType:
+		return &ast.DateTimeLiteral{Value: v.Interface().(time.Time)}, nil
+	case durationType:
+		d := v.Int()
+		if d < 0 {
+			return &ast.UnaryExpression{
+				Operator: ast.SubtractionOperator,
+				Argument: &ast.DurationLiteral{Values: []ast.Duration{{Magnitude: -d, Unit: ast.NanosecondUnit}}},
+			}, nil
+		}
+		return &ast.DurationLiteral{Values: []ast.Duration{{Magnitude: d, Unit: ast.NanosecondUnit}}}, nil
+	case numberType:
+		n := v.Interface().(json.Number)
+		if i, err := n.Int64(); err == nil {
+			return &ast.IntegerLiteral{Value: i}, nil
+		}
+		f, err := n.Float64()
+		if err != nil {
+			return nil, errors.Wrapf(err, codes.Invalid, "param %s is not a valid number", name)
+		}
+		return &ast.FloatLiteral{Value: f}, nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return &ast.StringLiteral{Value: v.String()}, nil
+	case reflect.Bool:
+		return &ast.BooleanLiteral{Value: v.Bool()}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return &ast.IntegerLiteral{Value: v.Int()}, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &ast.UnsignedIntegerLiteral{Value: v.Uint()}, nil
+	case reflect.Float32, reflect.Float64:
+		f := v.Float()
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return nil, errors.Newf(codes.Invalid, "param %s is not a finite number", name)
+		}
+		return &ast.FloatLiteral{Value: f}, nil
+	case reflect.Slice, reflect.Array:
+		array := &ast.ArrayExpression{}
+		for i := 0; i < v.Len(); i++ {
+			elem, err := paramLiteral(v.Index(i), name)
+			if err != nil {
+				return nil, err
+			}
+			array.Elements = append(array.Elements, elem)
+		}
+		return array, nil
+	case reflect.Map:
+		return paramRecord(v, name)
+	}
+	return nil, errors.Newf(codes.Invalid, "param %s has unsupported type %s", name, v.Type())
+}
+
+// paramRecord returns a record with the entries of the map sorted by key.
+func paramRecord(v reflect.Value, name string) (ast.Expression, error) {
+	if v.Type().Key().Kind() != reflect.String {
+		return nil, errors.Newf(codes.Invalid, "param %s must be a map with string keys", name)
+	}
+	keys := make([]string, 0, v.Len())
+	for _, k := range v.MapKeys() {
+		keys = append(keys, k.String())
+	}
+	sort.Strings(keys)
+
+	record := &ast.ObjectExpression{}
+	for _, k := range keys {
+		propName := k
+		if name != ParamsIdentifier {
+			propName = name + "." + k
+		}
+		value, err := paramLiteral(v.MapIndex(reflect.ValueOf(k).Convert(v.Type().Key())), propName)
+		if err != nil {
+			return nil, err
+		}
+		record.Properties = append(record.Properties, &ast.Property{
+			Key:   &ast.StringLiteral{Value: k},
+			Value: value,
+		})
+	}
+	return record, nil
+}
+
+// withParams returns the extern with the file that assigns the params
+// record merged into it. The extern may be nil.
+func withParams(runtime flux.Runtime, extern flux.ASTHandle, params Params) (flux.ASTHandle, error) {
+	if len(params) == 0 {
+		return extern, nil
+	}
+	file, err := params.file()
+	if err != nil {
+		return nil, err
+	}
+	bs, err := json.Marshal(&ast.Package{
+		Package: "main",
+		Files:   []*ast.File{file},
+	})
+	if err != nil {
+		return nil, err
+	}
+	hdl, err := runtime.JSONToHandle(bs)
+	if err != nil {
+		return nil, err
+	}
+	if extern == nil {
+		return hdl, nil
+	}
+	if err := runtime.MergePackages(extern, hdl); err != nil {
+		return nil, err
+	}
+	return extern, nil
+}
@@ -0,0 +1,295 @@
+package lang_test
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/andreyvit/diff"
+	"github.com/google/go-cmp/cmp"
+	"github.com/influxdata/flux"
+	"github.com/influxdata/flux/codes"
+	fcsv "github.com/influxdata/flux/csv"
+	"github.com/influxdata/flux/internal/errors"
+	"github.com/influxdata/flux/lang"
+	"github.com/influxdata/flux/memory"
+	"github.com/influxdata/flux/parser"
+	"github.com/influxdata/flux/runtime"
+)
+
+// runParams runs the compiler and returns its results encoded as CSV.
+func runParams(t *testing.T, c flux.Compiler) string {
+	t.Helper()
+	program, err := c.Compile(context.Background(), runtime.Default)
+	if err != nil {
+		t.Fatalf("unexpected compile error: %s", err)
+	}
+	return runProgram(t, program)
+}
+
+// runProgram runs the program and returns its results encoded as CSV.
+func runProgram(t *testing.T, program flux.Program) string {
+	t.Helper()
+	qry, err := program.Start(context.Background(), &memory.ResourceAllocator{})
+	if err != nil {
+		t.Fatalf("unexpected program error: %s", err)
+	}
+	results := flux.NewResultIteratorFromQuery(qry)
+	defer results.Release()
+
+	var b strings.Builder
+	enc := fcsv.NewMultiResultEncoder(fcsv.DefaultEncoderConfig())
+	if _, err := enc.Encode(&b, results); err != nil {
+		t.Fatalf("unexpected encode error: %s", err)
+	}
+	return b.String()
+}
+
+func TestParams(t *testing.T) {
+	query := `import "array"
+array.from(rows: [
+	{v: string(v: params.n + 1)},
+	{v: params.s},
+	{v: string(v: params.start)},
+	{v: string(v: params.every)},
+	{v: params.tags[1]},
+	{v: params.tag["host name"]},
+])`
+	params := lang.Params{
+		"n":     41,
+		"s":     `") |> drop() //`,
+		"start": time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC),
+		"every": 5 * time.Minute,
+		"tags":  []string{"a", "b"},
+		"tag":   map[string]string{"host name": "server"},
+	}
+	want := toCRLF(`#datatype,string,long,string
+#group,false,false,false
+#default,_result,,
+,result,table,v
+,,0,42
+,,0,""") |> drop() //"
+,,0,2021-01-01T00:00:00.000000000Z
+,,0,5m
+,,0,b
+,,0,server
+
+`)
+
+	hdl, err := parser.ParseToHandle(context.Background(), []byte(query))
+	if err != nil {
+		t.Fatal(err)
+	}
+	astJSON, err := parser.HandleToJSON(hdl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, c := range map[string]flux.Compiler{
+		"flux": lang.FluxCompiler{Query: query, Params: params, Now: time.Now()},
+		"ast":  lang.ASTCompiler{AST: astJSON, Params: params},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if got := runParams(t, c); got != want {
+				t.Errorf("unexpected output -want/+got:\n%s", diff.LineDiff(want, got))
+			}
+		})
+	}
+}
+
+func TestParams_TypeError(t *testing.T) {
+	c := lang.FluxCompiler{
+		Query:  `import "array" array.from(rows: [{v: params.n + 1}])`,
+		Params: lang.Params{"n": "41"},
+		Now:    time.Now(),
+	}
+	program, err := c.Compile(context.Background(), runtime.Default)
+	if err != nil {
+		t.Fatalf("unexpected compile error: %s", err)
+	}
+	if _, err := program.Start(context.Background(), &memory.ResourceAllocator{}); err == nil {
+		t.Fatal("expected a type error")
+	} else if !strings.Contains(err.Error(), "string") {
+		t.Errorf("unexpected error %q", err)
+	}
+}
+
+func TestParams_Invalid(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		params lang.Params
+		want   string
+	}{
+		{
+			name:   "null",
+			params: lang.Params{"a": nil},
+			want:   "param a is null, Flux has no null literal",
+		},
+		{
+			name:   "nested null",
+			params: lang.Params{"a": map[string]interface{}{"b": nil}},
+			want:   "param a.b is null, Flux has no null literal",
+		},
+		{
+			name:   "not finite",
+			params: lang.Params{"a": []float64{1, math.Inf(1)}},
+			want:   "param a is not a finite number",
+		},
+		{
+			name:   "unsupported",
+			params: lang.Params{"a": struct{}{}},
+			want:   "param a has unsupported type struct {}",
+		},
+		{
+			name:   "map keys",
+			params: lang.Params{"a": map[int]string{1: "b"}},
+			want:   "param a must be a map with string keys",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			c := lang.FluxCompiler{Query: `params`, Params: tc.params, Now: time.Now()}
+			_, err := c.Compile(context.Background(), runtime.Default)
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if got := errors.Code(err); got != codes.Invalid {
+				t.Errorf("unexpected code %v", got)
+			}
+			if err.Error() != tc.want {
+				t.Errorf("unexpected error %q, want %q", err, tc.want)
+			}
+		})
+	}
+}
+
+func TestParams_JSON(t *testing.T) {
+	mappings := make(flux.CompilerMappings)
+	if err := lang.AddCompilerMappings(mappings); err != nil {
+		t.Fatal(err)
+	}
+	data := []byte(`{"query":"x = params.n * 2 + int(v: params.f)","params":{"n":21,"f":1.5,"l":[1,2]}}`)
+	c := mappings[lang.FluxCompilerType]()
+	if err := json.Unmarshal(data, c); err != nil {
+		t.Fatal(err)
+	}
+	want := lang.Params{
+		"n": json.Number("21"),
+		"f": json.Number("1.5"),
+		"l": []interface{}{json.Number("1"), json.Number("2")},
+	}
+	if got := c.(*lang.FluxCompiler).Params; !cmp.Equal(want, got) {
+		t.Errorf("unexpected params -want/+got:\n%s", cmp.Diff(want, got))
+	}
+
+	// The params survive a round trip through JSON.
+	bs, err := json.Marshal(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c2 := mappings[lang.FluxCompilerType]()
+	if err := json.Unmarshal(bs, c2); err != nil {
+		t.Fatal(err)
+	}
+	if got := c2.(*lang.FluxCompiler).Params; !cmp.Equal(want, got) {
+		t.Errorf("unexpected params after round trip -want/+got:\n%s", cmp.Diff(want, got))
+	}
+}
+
+func TestParams_JSONTimes(t *testing.T) {
+	c := lang.FluxCompiler{
+		Query: `import "array"
+array.from(rows: [
+	{_time: 2021-01-01T00:00:00Z, _value: 1},
+	{_time: 2021-01-01T00:10:00Z, _value: 2},
+	{_time: 2021-01-01T00:20:00Z, _value: 3},
+])
+	|> range(start: params.start, stop: params.stop)
+	|> aggregateWindow(every: params.every, fn: sum, createEmpty: false, timeSrc: "_start")
+	|> keep(columns: ["_time", "_value"])`,
+		Params: lang.Params{
+			"start": time.Date(2021, 1, 1, 0, 5, 0, 0, time.UTC),
+			"stop":  time.Date(2021, 1, 1, 1, 0, 0, 0, time.UTC),
+			"every": time.Hour,
+		},
+		Now: time.Date(2021, 1, 2, 0, 0, 0, 0, time.UTC),
+	}
+	bs, err := json.Marshal(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"$type":"duration","$value":"1h0m0s"}`; !strings.Contains(string(bs), want) {
+		t.Errorf("expected %s in %s", want, bs)
+	}
+
+	mappings := make(flux.CompilerMappings)
+	if err := lang.AddCompilerMappings(mappings); err != nil {
+		t.Fatal(err)
+	}
+	c2 := mappings[lang.FluxCompilerType]()
+	if err := json.Unmarshal(bs, c2); err != nil {
+		t.Fatal(err)
+	}
+	if got := c2.(*lang.FluxCompiler).Params; !cmp.Equal(c.Params, got) {
+		t.Fatalf("unexpected params after round trip -want/+got:\n%s", cmp.Diff(c.Params, got))
+	}
+
+	want := toCRLF(`#datatype,string,long,dateTime:RFC3339,long
+#group,false,false,false,false
+#default,_result,,,
+,result,table,_time,_value
+,,0,2021-01-01T00:05:00Z,5
+
+`)
+	if got := runParams(t, c2); got != want {
+		t.Errorf("unexpected output -want/+got:\n%s", diff.LineDiff(want, got))
+	}
+}
+
+func TestParams_JSONInvalidTime(t *testing.T) {
+	var p lang.Params
+	err := json.Unmarshal([]byte(`{"a":[{"$type":"time","$value":"yesterday"}]}`), &p)
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	if got := errors.Code(err); got != codes.Invalid {
+		t.Errorf("unexpected code %v", got)
+	}
+
+	// Records with a $type field must be typed params.
+	err = json.Unmarshal([]byte(`{"a":{"$type":"host","$value":"a"}}`), &p)
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	if got := errors.Code(err); got != codes.Invalid {
+		t.Errorf("unexpected code %v", got)
+	}
+}
+
+func TestParams_JSONRecords(t *testing.T) {
+	// Records with the fields of a typed param
+	// survive a round trip through JSON.
+	want := lang.Params{
+		"a": map[string]interface{}{
+			"type":  "time",
+			"value": "2021-01-01T00:00:00Z",
+		},
+		"b": map[string]interface{}{
+			"$type":  "time",
+			"$value": "2021-01-01T00:00:00Z",
+			"every":  time.Hour,
+		},
+	}
+	bs, err := json.Marshal(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got lang.Params
+	if err := json.Unmarshal(bs, &got); err != nil {
+		t.Fatal(err)
+	}
+	if !cmp.Equal(want, got) {
+		t.Errorf("unexpected params after round trip -want/+got:\n%s", cmp.Diff(want, got))
+	}
+}
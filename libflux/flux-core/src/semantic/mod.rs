@@ -526,6 +526,7 @@ pub struct Analyzer<'env, I: import::Importer> {
     env: env::Environment<'env>,
     importer: I,
     config: AnalyzerConfig,
+    warnings: Errors<Warning>,
 }
 
 /// Features used in the flux compiler
@@ -568,6 +569,7 @@ impl<'env, I: import::Importer> Analyzer<'env, I> {
             env,
             importer,
             config,
+            warnings: Errors::new(),
         }
     }
     /// Create an analyzer with the given environment and importer using default configuration.
@@ -613,6 +615,7 @@ impl<'env, I: import::Importer> Analyzer<'env, I> {
         ast_pkg: &ast::Package,
         sub: &mut sub::Substitution,
     ) -> SalvageResult<(PackageExports, nodes::Package), FileErrors> {
+        self.warnings = Errors::new();
         let mut errors = Errors::new();
 
         if let Err(err) = ast::check::check(ast::walk::Node::Package(ast_pkg)) {
@@ -711,9 +714,18 @@ impl<'env, I: import::Importer> Analyzer<'env, I> {
             log::debug!("{}", err);
         }
 
+        self.warnings = warnings;
+
         Ok((env, sem_pkg))
     }
 
+    /// Returns the warnings found by the last successful analysis.
+    /// Warnings are only collected for the enabled features, for example
+    /// `Feature::UnusedSymbolWarnings`.
+    pub fn take_warnings(&mut self) -> Errors<Warning> {
+        std::mem::take(&mut self.warnings)
+    }
+
     /// Drop returns ownership of the environment and importer.
     pub fn drop(self) -> (env::Environment<'env>, I) {
         (self.env, self.importer)
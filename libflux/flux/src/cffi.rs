@@ -1,9 +1,11 @@
 use std::{
     any::Any,
+    collections::BTreeMap,
     ffi::*,
     mem,
     os::raw::c_char,
     panic::{catch_unwind, resume_unwind},
+    sync::Arc,
 };
 
 use anyhow::anyhow;
@@ -24,7 +26,7 @@ use fluxcore::{
         types::{BoundTvar, MonoType},
         Analyzer, AnalyzerConfig, Feature, PackageExports,
     },
-    Database, Flux,
+    Database, Flux, FluxBase,
 };
 
 use crate::{
@@ -460,8 +462,8 @@ fn new_stateful_analyzer(options: Options) -> Result<StatefulAnalyzer> {
         None => return Err(anyhow!("missing stdlib imports").into()),
     };
 
-    let db = if options.features.contains(&Feature::SalsaDatabase) {
-        Some(new_db()?)
+    let db = if options.features.contains(&Feature::SalsaDatabase) || !options.sources.is_empty() {
+        Some(new_db(&options.sources)?)
     } else {
         None
     };
@@ -471,6 +473,7 @@ fn new_stateful_analyzer(options: Options) -> Result<StatefulAnalyzer> {
         imports,
         db,
         options,
+        warnings: Vec::new(),
     })
 }
 
@@ -481,21 +484,27 @@ pub struct StatefulAnalyzer {
     imports: &'static Packages,
     db: Option<Database>,
     options: Options,
+    warnings: Vec<semantic::Warning>,
 }
 
 impl StatefulAnalyzer {
     fn analyze(&mut self, ast_pkg: &ast::Package) -> Result<fluxcore::semantic::nodes::Package> {
-        let Options { features } = self.options.clone();
+        let Options { features, .. } = self.options.clone();
 
         let env = Environment::from(&self.env);
 
+        self.warnings.clear();
         let result = if let Some(db) = &self.db {
             let mut db = db as &dyn Flux;
             let mut analyzer = Analyzer::new(env, &mut db, AnalyzerConfig { features });
-            analyzer.analyze_ast(ast_pkg)
+            let result = analyzer.analyze_ast(ast_pkg);
+            self.warnings.extend(analyzer.take_warnings());
+            result
         } else {
             let mut analyzer = Analyzer::new(env, &mut self.imports, AnalyzerConfig { features });
-            analyzer.analyze_ast(ast_pkg)
+            let result = analyzer.analyze_ast(ast_pkg);
+            self.warnings.extend(analyzer.take_warnings());
+            result
         };
         let (mut env, sem_pkg) = match result {
             Ok(r) => r,
@@ -514,7 +523,11 @@ impl StatefulAnalyzer {
 
                 // A failure should have already happened if any of these
                 // imports would have failed.
-                if let Ok(typ) = self.imports.import(path) {
+                let typ = match &self.db {
+                    Some(db) => (db as &dyn Flux).import(path),
+                    None => self.imports.import(path),
+                };
+                if let Ok(typ) = typ {
                     env.add(dec.import_symbol.clone(), typ);
                 }
             }
@@ -522,6 +535,18 @@ impl StatefulAnalyzer {
         self.env.copy_bindings_from(&env);
         Ok(sem_pkg)
     }
+
+    /// Sets the source of a file of a package that is not part of the standard library. The
+    /// packages are compiled by a database, which is created if the analyzer does not have one.
+    fn set_source(&mut self, path: String, source: &str) -> Result<()> {
+        if self.db.is_none() {
+            self.db = Some(new_db(&self.options.sources)?);
+        }
+        if let Some(db) = &mut self.db {
+            db.set_source(path, Arc::from(source));
+        }
+        Ok(())
+    }
 }
 
 /// Create a new semantic analyzer.
@@ -597,6 +622,64 @@ pub unsafe extern "C" fn flux_analyze_with(
     .unwrap_or_else(|err| Some(err.into()))
 }
 
+/// flux_analyzer_set_source sets the source of a file of a package that is not part of the
+/// standard library so later calls to flux_analyze_with can import the package. The path is
+/// the path of the file under the import path of its package.
+///
+/// # Safety
+///
+/// Ths function is unsafe because it dereferences raw pointers.
+#[no_mangle]
+pub unsafe extern "C" fn flux_analyzer_set_source(
+    analyzer: *mut Result<StatefulAnalyzer>,
+    cpath: *const c_char,
+    csrc: *const c_char,
+) -> Option<Box<ErrorHandle>> {
+    catch_unwind(|| {
+        let analyzer = match &mut *analyzer {
+            Ok(a) => a,
+            Err(_) => return Some(Error::from(anyhow!("invalid analyzer")).into()),
+        };
+        let path = String::from_utf8_lossy(CStr::from_ptr(cpath).to_bytes()).into_owned();
+        let src = String::from_utf8_lossy(CStr::from_ptr(csrc).to_bytes());
+        analyzer.set_source(path, &src).err().map(Into::into)
+    })
+    .unwrap_or_else(|err| Some(err.into()))
+}
+
+/// flux_analyzer_warnings populates the supplied buffer with the warnings found by
+/// the last successful call to flux_analyze_with, one warning per line.
+/// Warnings are only reported for the features enabled in the analyzer options.
+/// The data of the buffer is null when there are no warnings.
+///
+/// # Safety
+///
+/// Ths function is unsafe because it dereferences raw pointers.
+#[no_mangle]
+pub unsafe extern "C" fn flux_analyzer_warnings(
+    analyzer: *const Result<StatefulAnalyzer>,
+    buf: *mut flux_buffer_t,
+) {
+    let analyzer = &*analyzer;
+    let data = match analyzer {
+        Ok(a) => a
+            .warnings
+            .iter()
+            .map(|warn| format!("warning {}: {}\n", warn.location, warn.error))
+            .collect::<String>()
+            .into_bytes(),
+        Err(_) => Vec::new(),
+    };
+    let buf = &mut *buf; // Unsafe
+    if data.is_empty() {
+        buf.len = 0;
+        buf.data = std::ptr::null();
+        return;
+    }
+    buf.len = data.len();
+    buf.data = Box::into_raw(data.into_boxed_slice()) as *mut u8;
+}
+
 /// Compilation options. Deserialized from json when called via the C API
 #[derive(Clone, Default, Debug)]
 #[cfg_attr(feature = "serde", derive(serde::Deserialize))]
@@ -604,6 +687,11 @@ pub struct Options {
     /// Features used in the flux compiler
     #[serde(default)]
     pub features: Vec<Feature>,
+
+    /// Sources of the packages that are not part of the standard library keyed by the path
+    /// of each file under the import path of its package
+    #[serde(default)]
+    pub sources: BTreeMap<String, String>,
 }
 
 impl Options {
@@ -632,10 +720,10 @@ impl Options {
 /// that has been type-inferred.  This function is aware of the standard library
 /// and prelude.
 pub fn analyze(ast_pkg: &ast::Package, options: Options) -> SalvageResult<Package, Error> {
-    let Options { features } = options;
+    let Options { features, sources } = options;
 
-    if features.contains(&Feature::SalsaDatabase) {
-        let mut analyzer = new_semantic_salsa_analyzer(AnalyzerConfig { features })?;
+    if features.contains(&Feature::SalsaDatabase) || !sources.is_empty() {
+        let mut analyzer = new_semantic_salsa_analyzer(AnalyzerConfig { features }, &sources)?;
         let (_, sem_pkg) = analyzer
             .analyze_ast(ast_pkg)
             .map_err(|salvage| salvage.err_into().map(|(_, sem_pkg)| sem_pkg))?;
@@ -815,7 +903,8 @@ mod tests {
     }
 
     fn find_var_type_from_source(source: &str, var_name: &str) -> Result<MonoType> {
-        let mut analyzer = new_semantic_salsa_analyzer(AnalyzerConfig::default())?;
+        let mut analyzer =
+            new_semantic_salsa_analyzer(AnalyzerConfig::default(), &Default::default())?;
         let pkg = match analyzer.analyze_source("".into(), "".into(), source) {
             Ok((_, pkg)) => pkg,
             Err(err) => match err.value {
@@ -981,7 +1070,8 @@ from(bucket: v.bucket)
 
     #[test]
     fn deserialize_and_infer() {
-        let mut analyzer = new_semantic_salsa_analyzer(AnalyzerConfig::default()).unwrap();
+        let mut analyzer =
+            new_semantic_salsa_analyzer(AnalyzerConfig::default(), &Default::default()).unwrap();
 
         let src = r#"
             x = from(bucket: "b")
@@ -1016,7 +1106,8 @@ from(bucket: v.bucket)
 
     #[test]
     fn infer_union() {
-        let mut analyzer = new_semantic_salsa_analyzer(AnalyzerConfig::default()).unwrap();
+        let mut analyzer =
+            new_semantic_salsa_analyzer(AnalyzerConfig::default(), &Default::default()).unwrap();
 
         let src = r#"
             a = from(bucket: "b")
@@ -1098,9 +1189,92 @@ from(bucket: v.bucket)
         }
     }
 
+    #[test]
+    fn stateful_analyzer_warnings() {
+        let mut analyzer = new_stateful_analyzer(Options {
+            features: vec![Feature::UnusedSymbolWarnings],
+            ..Options::default()
+        });
+        let src = r#"
+f = () => {
+    y = 1
+    return 2
+}"#;
+        let ast: ast::Package = fluxcore::parser::parse_string("".to_string(), src).into();
+        analyzer.as_mut().unwrap().analyze(&ast).unwrap();
+
+        let mut buf = flux_buffer_t {
+            data: std::ptr::null(),
+            len: 0,
+        };
+        // Safety: both pointers are valid
+        unsafe { flux_analyzer_warnings(&analyzer, &mut buf) };
+        // Safety: the buffer was populated above
+        let data =
+            unsafe { Box::from_raw(std::slice::from_raw_parts_mut(buf.data as *mut u8, buf.len)) };
+        expect_test::expect![[r#"
+            warning @3:5-3:6: symbol y is never used
+        "#]]
+        .assert_eq(std::str::from_utf8(&data).unwrap());
+    }
+
+    #[test]
+    fn analyze_with_sources() {
+        let sources: BTreeMap<_, _> = [
+            (
+                "mycompany/math/math.flux".to_string(),
+                "package math\n\ndouble = (x) => x * 2\n".to_string(),
+            ),
+            (
+                "mycompany/alerts/alerts.flux".to_string(),
+                "package alerts\n\nimport \"mycompany/math\"\n\noption threshold = math.double(x: 21)\n"
+                    .to_string(),
+            ),
+        ]
+        .into_iter()
+        .collect();
+        let analyze_source = |src: &str| {
+            let ast: ast::Package = fluxcore::parser::parse_string("".to_string(), src).into();
+            analyze(
+                &ast,
+                Options {
+                    sources: sources.clone(),
+                    ..Options::default()
+                },
+            )
+        };
+
+        analyze_source("import \"mycompany/alerts\"\n\nx = alerts.threshold + 1\n").unwrap();
+
+        // The types of the local packages are inferred.
+        assert!(
+            analyze_source("import \"mycompany/alerts\"\n\nx = alerts.threshold + \"a\"\n")
+                .is_err()
+        );
+    }
+
+    #[test]
+    fn stateful_analyzer_set_source() {
+        let mut analyzer = new_stateful_analyzer(Options::default());
+        let analyzer = analyzer.as_mut().unwrap();
+        analyzer
+            .set_source(
+                "mycompany/math/math.flux".to_string(),
+                "package math\n\ndouble = (x) => x * 2\n",
+            )
+            .unwrap();
+
+        // The imports of a previous snippet can be used by later snippets.
+        for src in [r#"import "mycompany/math""#, "x = math.double(x: 1.0)"] {
+            let ast: ast::Package = fluxcore::parser::parse_string("".to_string(), src).into();
+            analyzer.analyze(&ast).unwrap();
+        }
+    }
+
     #[test]
     fn prelude_symbols_retain_their_package() {
-        let mut analyzer = new_semantic_salsa_analyzer(AnalyzerConfig::default()).unwrap();
+        let mut analyzer =
+            new_semantic_salsa_analyzer(AnalyzerConfig::default(), &Default::default()).unwrap();
 
         let src = r#"
             derivative
@@ -2,7 +2,10 @@
 
 //! This module provides the public facing API for Flux's Go runtime, including formatting,
 //! parsing, and standard library analysis.
-use std::sync::{Arc, LazyLock};
+use std::{
+    collections::BTreeMap,
+    sync::{Arc, LazyLock},
+};
 
 use anyhow::anyhow;
 use fluxcore::semantic::env::Environment;
@@ -81,15 +84,21 @@ pub fn new_semantic_analyzer(
     Ok(Analyzer::new(Environment::from(env), importer, config))
 }
 
-fn new_semantic_salsa_analyzer(config: AnalyzerConfig) -> Result<Analyzer<'static, Database>> {
+fn new_semantic_salsa_analyzer(
+    config: AnalyzerConfig,
+    sources: &BTreeMap<String, String>,
+) -> Result<Analyzer<'static, Database>> {
     let env = PRELUDE.as_ref().ok_or_else(|| anyhow!("missing prelude"))?;
 
-    let db = new_db()?;
+    let db = new_db(sources)?;
 
     Ok(Analyzer::new(Environment::from(&**env), db, config))
 }
 
-fn new_db() -> Result<Database> {
+/// Creates a database with the standard library and the sources of the packages that are not
+/// part of it. The sources are keyed by the path of each file under its import path, for example
+/// `mycompany/alerts/alerts.flux`.
+fn new_db(sources: &BTreeMap<String, String>) -> Result<Database> {
     let mut db = fluxcore::Database::default();
 
     let imports = IMPORTS
@@ -97,5 +106,9 @@ fn new_db() -> Result<Database> {
         .ok_or_else(|| anyhow!("missing stdlib imports"))?;
     db.set_precompiled_packages(Some(imports));
 
+    for (path, source) in sources {
+        db.set_source(path.clone(), Arc::from(source.as_str()));
+    }
+
     Ok(db)
 }
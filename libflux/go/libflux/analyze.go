@@ -10,6 +10,7 @@ import (
 	"path"
 	"path/filepath"
 	"runtime"
+	"strings"
 	"unsafe"
 
 	flatbuffers "github.com/google/flatbuffers/go"
@@ -21,10 +22,7 @@ import (
 )
 
 func SemanticPackages() (map[string]*semantic.Package, error) {
-	var buf C.struct_flux_buffer_t
-	C.flux_semantic_packages(&buf)
-
-	data := C.GoBytes(unsafe.Pointer(buf.data), C.int(buf.len))
+	data := SemanticPackagesData()
 
 	packages := fbsemantic.GetRootAsPackageList(data, 0)
 
@@ -49,8 +47,20 @@ func SemanticPackages() (map[string]*semantic.Package, error) {
 	return m, nil
 }
 
+// SemanticPackagesData returns the semantic packages of the standard
+// library serialized as a flatbuffers PackageList.
+func SemanticPackagesData() []byte {
+	var buf C.struct_flux_buffer_t
+	C.flux_semantic_packages(&buf)
+	return C.GoBytes(unsafe.Pointer(buf.data), C.int(buf.len))
+}
+
 type Options struct {
 	Features []string `json:"features,omitempty"`
+	// Sources are the sources of the packages that are not part of the
+	// standard library keyed by the path of each file under the import
+	// path of its package, for example mycompany/alerts/alerts.flux.
+	Sources map[string]string `json:"sources,omitempty"`
 }
 
 func NewOptions(ctx context.Context) Options {
@@ -256,6 +266,42 @@ func (p *Analyzer) Analyze(src string, astPkg *ASTPkg) (*SemanticPkg, *FluxError
 	return pkg, nil
 }
 
+// SetSource sets the source of a file of a package that is not part of
+// the standard library so later calls to Analyze can import the package.
+// The path is the path of the file under the import path of its package.
+func (p *Analyzer) SetSource(path, src string) error {
+	cpath := C.CString(path)
+	defer C.free(unsafe.Pointer(cpath))
+	csrc := C.CString(src)
+	defer C.free(unsafe.Pointer(csrc))
+
+	err := C.flux_analyzer_set_source(p.ptr, cpath, csrc)
+	runtime.KeepAlive(p)
+	if err != nil {
+		defer C.flux_free_error(err)
+		cstr := C.flux_error_str(err)
+		str := C.GoString(cstr)
+		return errors.New(codes.Internal, str)
+	}
+	return nil
+}
+
+// Warnings returns the warnings found by the last successful call to Analyze.
+// Warnings are only reported for the features enabled in the analyzer options,
+// for example the unusedSymbolWarnings feature.
+func (p *Analyzer) Warnings() []string {
+	var buf C.struct_flux_buffer_t
+	C.flux_analyzer_warnings(p.ptr, &buf)
+	runtime.KeepAlive(p)
+	if buf.data == nil {
+		return nil
+	}
+	defer C.flux_free_bytes(buf.data)
+
+	data := C.GoBytes(unsafe.Pointer(buf.data), C.int(buf.len))
+	return strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+}
+
 // Free frees the memory allocated by Rust for the semantic graph.
 func (p *Analyzer) Free() {
 	if p.ptr != nil {
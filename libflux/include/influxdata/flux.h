@@ -105,6 +105,19 @@ void flux_free_stateful_analyzer(struct flux_stateful_analyzer_t *);
 // a semantic graph for that snippet.
 struct flux_error_t *flux_analyze_with(struct flux_stateful_analyzer_t *, const char * src, struct flux_ast_pkg_t *, struct flux_semantic_pkg_t **);
 
+// flux_analyzer_set_source sets the source of a file of a package that is not part of the
+// standard library so later calls to flux_analyze_with can import the package. The path is
+// the path of the file under the import path of its package, for example mycompany/alerts/alerts.flux.
+// Any non-null error must be freed by calling flux_free_error.
+struct flux_error_t *flux_analyzer_set_source(struct flux_stateful_analyzer_t *, const char * path, const char * src);
+
+// flux_analyzer_warnings populates the buffer with the warnings found by the last
+// successful call to flux_analyze_with, one warning per line. Warnings are only
+// reported for the features enabled in the analyzer options.
+// If there are no warnings the data of the buffer is null, otherwise it is the caller's
+// responsibility to free it with flux_free_bytes().
+void flux_analyzer_warnings(struct flux_stateful_analyzer_t *, struct flux_buffer_t *);
+
 // flux_analyze analyzes the given AST and will populate the second pointer argument with
 // a pointer to the resulting semantic graph.
 // It is the caller's responsibility to free the resulting semantic graph with a call to flux_free_semantic_pkg().
@@ -0,0 +1,41 @@
+// DO NOT EDIT: This file is autogenerated via the builtin command.
+
+package lint
+
+// deprecated maps deprecated Flux packages and package members to the
+// version of Flux in which they were deprecated. Packages are keyed by
+// their import path and members by the import path and name joined with a dot.
+var deprecated = map[string]string{
+	"date/boundaries":                             "0.177.1",
+	"date/boundaries.friday":                      "0.177.1",
+	"date/boundaries.monday":                      "0.177.1",
+	"date/boundaries.month":                       "0.177.1",
+	"date/boundaries.saturday":                    "0.177.1",
+	"date/boundaries.sunday":                      "0.177.1",
+	"date/boundaries.thursday":                    "0.177.1",
+	"date/boundaries.tuesday":                     "0.177.1",
+	"date/boundaries.wednesday":                   "0.177.1",
+	"date/boundaries.week":                        "0.177.1",
+	"date/boundaries.yesterday":                   "0.177.1",
+	"experimental.addDuration":                    "0.162.0",
+	"experimental.join":                           "0.172.0",
+	"experimental.subDuration":                    "0.162.0",
+	"experimental.to":                             "0.174.0",
+	"experimental/array.concat":                   "0.173.0",
+	"experimental/array.filter":                   "0.173.0",
+	"experimental/array.from":                     "0.103.0",
+	"experimental/array.map":                      "0.173.0",
+	"experimental/bitwise":                        "0.173.0",
+	"experimental/csv":                            "0.173.0",
+	"experimental/http":                           "0.173.0",
+	"experimental/http/requests":                  "0.173.0",
+	"influxdata/influxdb/v1.fieldKeys":            "0.88.0",
+	"influxdata/influxdb/v1.fieldsAsCols":         "0.88.0",
+	"influxdata/influxdb/v1.measurementFieldKeys": "0.88.0",
+	"influxdata/influxdb/v1.measurementTagKeys":   "0.88.0",
+	"influxdata/influxdb/v1.measurementTagValues": "0.88.0",
+	"influxdata/influxdb/v1.measurements":         "0.88.0",
+	"influxdata/influxdb/v1.tagKeys":              "0.88.0",
+	"influxdata/influxdb/v1.tagValues":            "0.88.0",
+	"universe.join":                               "0.172.0",
+}
@@ -0,0 +1,3 @@
+package lint
+
+//go:generate go run github.com/influxdata/flux/internal/cmd/builtin deprecated --go-pkg github.com/influxdata/flux/lint --root-dir ../stdlib --output deprecated.gen.go
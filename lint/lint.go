@@ -0,0 +1,235 @@
+// Package lint provides static analysis of Flux scripts.
+//
+// Check reports syntax and type errors found by the libflux analyzer
+// along with warnings for common mistakes that are still valid Flux.
+package lint
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/influxdata/flux/ast"
+	"github.com/influxdata/flux/internal/feature"
+	"github.com/influxdata/flux/libflux/go/libflux"
+	"github.com/influxdata/flux/parser"
+	"github.com/influxdata/flux/semantic"
+)
+
+// Severity is the severity of a Diagnostic.
+type Severity string
+
+const (
+	// Error is the severity of a diagnostic that prevents
+	// the script from being executed.
+	Error Severity = "error"
+	// Warning is the severity of a diagnostic that reports
+	// a likely mistake in a valid script.
+	Warning Severity = "warning"
+)
+
+// Code identifies the kind of problem reported by a Diagnostic.
+type Code string
+
+const (
+	SyntaxError  Code = "syntax-error"
+	TypeError    Code = "type-error"
+	UnusedSymbol Code = "unused-symbol"
+	Deprecated   Code = "deprecated"
+	MissingRange Code = "missing-range"
+)
+
+// Diagnostic is a single problem found in a Flux script.
+type Diagnostic struct {
+	File      string   `json:"file"`
+	Line      int      `json:"line"`
+	Column    int      `json:"column"`
+	EndLine   int      `json:"endLine"`
+	EndColumn int      `json:"endColumn"`
+	Severity  Severity `json:"severity"`
+	Code      Code     `json:"code"`
+	Message   string   `json:"message"`
+}
+
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%s:%d:%d: %s: %s (%s)", d.File, d.Line, d.Column, d.Severity, d.Message, d.Code)
+}
+
+// HasErrors reports if any of the diagnostics has the Error severity.
+func HasErrors(diags []Diagnostic) bool {
+	for _, d := range diags {
+		if d.Severity == Error {
+			return true
+		}
+	}
+	return false
+}
+
+// Check analyzes the Flux source in the named file and returns
+// the diagnostics sorted by their position in the file.
+// The returned error is only set when the analysis itself fails.
+func Check(ctx context.Context, filename, src string) ([]Diagnostic, error) {
+	diags := checkSyntax(filename, src)
+	if len(diags) > 0 {
+		// Type errors for a script that does not parse
+		// only repeat the syntax errors.
+		return diags, nil
+	}
+
+	pkg, diags, err := analyze(ctx, filename, src)
+	if err != nil || pkg == nil {
+		return diags, err
+	}
+
+	diags = append(diags, deprecatedUses(filename, pkg)...)
+	diags = append(diags, missingRanges(filename, pkg)...)
+	sort.SliceStable(diags, func(i, j int) bool {
+		if diags[i].Line != diags[j].Line {
+			return diags[i].Line < diags[j].Line
+		}
+		return diags[i].Column < diags[j].Column
+	})
+	return diags, nil
+}
+
+func checkSyntax(filename, src string) []Diagnostic {
+	var diags []Diagnostic
+	ast.Walk(ast.CreateVisitor(func(node ast.Node) {
+		for _, err := range node.Errs() {
+			d := newDiagnostic(filename, node.Location(), Error, SyntaxError, err.Msg)
+			diags = append(diags, d)
+		}
+	}), parser.ParseSource(src))
+	return diags
+}
+
+// analyze runs the libflux analyzer over the source. Errors reported by the
+// analyzer are returned as diagnostics along with a nil package. Warnings
+// are returned as diagnostics along with the package, they are only
+// reported for the enabled analyzer features, for example unused symbols
+// are reported when the unusedSymbolWarnings feature is enabled.
+func analyze(ctx context.Context, filename, src string) (*semantic.Package, []Diagnostic, error) {
+	analyzer, err := libflux.NewAnalyzerWithOptions(analyzerOptions(ctx))
+	if err != nil {
+		return nil, nil, err
+	}
+	defer analyzer.Free()
+
+	semPkg, ferr := analyzer.AnalyzeString(src)
+	if ferr != nil {
+		defer ferr.Free()
+		return nil, parseAnalyzerErrors(filename, ferr.GoError().Error()), nil
+	}
+	defer semPkg.Free()
+	diags := parseAnalyzerWarnings(filename, analyzer.Warnings())
+
+	bs, err := semPkg.MarshalFB()
+	if err != nil {
+		return nil, nil, err
+	}
+	pkg, err := semantic.DeserializeFromFlatBuffer(bs)
+	if err != nil {
+		return nil, nil, err
+	}
+	return pkg, diags, nil
+}
+
+// analyzerOptions returns the analyzer options for the feature flags in the
+// context. Pretty errors are always disabled because they cannot be parsed.
+func analyzerOptions(ctx context.Context) libflux.Options {
+	opts := libflux.NewOptions(ctx)
+	features := opts.Features[:0]
+	for _, f := range opts.Features {
+		if f != feature.PrettyError().Key() {
+			features = append(features, f)
+		}
+	}
+	opts.Features = features
+	return opts
+}
+
+// locatedError matches the first line of an error reported by the analyzer,
+// for example "error @2:17-2:18: undefined identifier y".
+var locatedError = regexp.MustCompile(`^(?:type )?error \S*@(\d+):(\d+)-(\d+):(\d+): (.*)$`)
+
+// locatedWarning matches a warning reported by the analyzer,
+// for example "warning @4:5-4:6: symbol y is never used".
+var locatedWarning = regexp.MustCompile(`^warning \S*@(\d+):(\d+)-(\d+):(\d+): (.*)$`)
+
+// parseAnalyzerWarnings converts the warnings of the analyzer to diagnostics.
+// The analyzer only reports unused symbols as warnings.
+func parseAnalyzerWarnings(filename string, warnings []string) []Diagnostic {
+	var diags []Diagnostic
+	for _, w := range warnings {
+		m := locatedWarning.FindStringSubmatch(w)
+		if m == nil {
+			continue
+		}
+		d := Diagnostic{
+			File:     filename,
+			Severity: Warning,
+			Code:     UnusedSymbol,
+			Message:  m[5],
+		}
+		d.Line, _ = strconv.Atoi(m[1])
+		d.Column, _ = strconv.Atoi(m[2])
+		d.EndLine, _ = strconv.Atoi(m[3])
+		d.EndColumn, _ = strconv.Atoi(m[4])
+		diags = append(diags, d)
+	}
+	return diags
+}
+
+func parseAnalyzerErrors(filename, msg string) []Diagnostic {
+	var diags []Diagnostic
+	for _, line := range strings.Split(msg, "\n") {
+		m := locatedError.FindStringSubmatch(line)
+		if m == nil {
+			// Messages may span multiple lines. Attach any
+			// continuation lines to the previous error.
+			if line = strings.TrimSpace(line); line != "" && len(diags) > 0 {
+				diags[len(diags)-1].Message += "\n" + line
+			}
+			continue
+		}
+		d := Diagnostic{
+			File:     filename,
+			Severity: Error,
+			Code:     TypeError,
+			Message:  m[5],
+		}
+		d.Line, _ = strconv.Atoi(m[1])
+		d.Column, _ = strconv.Atoi(m[2])
+		d.EndLine, _ = strconv.Atoi(m[3])
+		d.EndColumn, _ = strconv.Atoi(m[4])
+		diags = append(diags, d)
+	}
+	if len(diags) == 0 {
+		// The error has no location so report it at the start of the file.
+		diags = append(diags, Diagnostic{
+			File:     filename,
+			Line:     1,
+			Column:   1,
+			Severity: Error,
+			Code:     TypeError,
+			Message:  msg,
+		})
+	}
+	return diags
+}
+
+func newDiagnostic(filename string, loc ast.SourceLocation, severity Severity, code Code, msg string) Diagnostic {
+	return Diagnostic{
+		File:      filename,
+		Line:      loc.Start.Line,
+		Column:    loc.Start.Column,
+		EndLine:   loc.End.Line,
+		EndColumn: loc.End.Column,
+		Severity:  severity,
+		Code:      code,
+		Message:   msg,
+	}
+}
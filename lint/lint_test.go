@@ -0,0 +1,152 @@
+package lint_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/influxdata/flux/dependencies/feature"
+	"github.com/influxdata/flux/execute/executetest"
+	"github.com/influxdata/flux/lint"
+)
+
+func TestCheck(t *testing.T) {
+	// diagnostic holds the fields of a lint.Diagnostic that
+	// do not depend on the exact wording of the message.
+	type diagnostic struct {
+		Line     int
+		Severity lint.Severity
+		Code     lint.Code
+	}
+
+	for _, tt := range []struct {
+		name  string
+		src   string
+		flags executetest.TestFlagger
+		want  []diagnostic
+	}{
+		{
+			name: "valid",
+			src: `import "array"
+
+array.from(rows: [{a: 1}])`,
+		},
+		{
+			name: "syntax error",
+			src:  `x = `,
+			want: []diagnostic{
+				{Line: 1, Severity: lint.Error, Code: lint.SyntaxError},
+			},
+		},
+		{
+			name: "type error",
+			src: `a = 1
+b = a + "x"`,
+			want: []diagnostic{
+				{Line: 2, Severity: lint.Error, Code: lint.TypeError},
+			},
+		},
+		{
+			name: "unused symbols",
+			src: `import "strings"
+
+f = () => {
+    y = 1
+
+    return 2
+}`,
+			flags: executetest.TestFlagger{"unusedSymbolWarnings": true},
+			want: []diagnostic{
+				{Line: 1, Severity: lint.Warning, Code: lint.UnusedSymbol},
+				{Line: 4, Severity: lint.Warning, Code: lint.UnusedSymbol},
+			},
+		},
+		{
+			name: "unused symbols disabled",
+			src: `import "strings"
+
+f = () => {
+    y = 1
+
+    return 2
+}`,
+		},
+		{
+			name: "deprecated",
+			src: `import "experimental"
+
+experimental.addDuration(d: 1h, to: 2020-01-01T00:00:00Z)`,
+			want: []diagnostic{
+				{Line: 3, Severity: lint.Warning, Code: lint.Deprecated},
+			},
+		},
+		{
+			name: "missing range",
+			src: `from(bucket: "telegraf")
+    |> filter(fn: (r) => r._measurement == "cpu")`,
+			want: []diagnostic{
+				{Line: 1, Severity: lint.Warning, Code: lint.MissingRange},
+			},
+		},
+		{
+			name: "range",
+			src: `from(bucket: "telegraf")
+    |> range(start: -1h)
+    |> filter(fn: (r) => r._measurement == "cpu")`,
+		},
+		{
+			name: "shadowed in another block",
+			src: `import "experimental"
+
+f = (from, experimental) => from + experimental
+
+from(bucket: "telegraf")
+    |> filter(fn: (r) => r._measurement == "cpu")
+    |> experimental.to(bucket: "cpu")`,
+			want: []diagnostic{
+				{Line: 5, Severity: lint.Warning, Code: lint.MissingRange},
+				{Line: 7, Severity: lint.Warning, Code: lint.Deprecated},
+			},
+		},
+		{
+			name: "shadowed",
+			src: `f = () => {
+    from = (bucket) => bucket
+
+    return from(bucket: "telegraf")
+}`,
+		},
+		{
+			name: "from assigned to variable",
+			src: `data = from(bucket: "telegraf")
+
+data |> range(start: -1h)`,
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.flags != nil {
+				ctx = feature.Dependency{Flagger: tt.flags}.Inject(ctx)
+			}
+			diags, err := lint.Check(ctx, "test.flux", tt.src)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got []diagnostic
+			for _, d := range diags {
+				if d.File != "test.flux" {
+					t.Errorf("unexpected file name %q in diagnostic %v", d.File, d)
+				}
+				got = append(got, diagnostic{Line: d.Line, Severity: d.Severity, Code: d.Code})
+			}
+			if !cmp.Equal(tt.want, got) {
+				t.Errorf("unexpected diagnostics -want/+got:\n%s", cmp.Diff(tt.want, got))
+			}
+			if want, got := len(tt.want) > 0 && tt.want[0].Severity == lint.Error, lint.HasErrors(diags); want != got {
+				t.Errorf("unexpected HasErrors result: want %v, got %v", want, got)
+			}
+		})
+	}
+}
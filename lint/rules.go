@@ -0,0 +1,207 @@
+package lint
+
+import (
+	"fmt"
+	"path"
+
+	"github.com/influxdata/flux/semantic"
+)
+
+const (
+	universePkg = "universe"
+	influxdbPkg = "influxdata/influxdb"
+)
+
+// scope records the names that are imported by a file or defined in
+// a block so references to package members can be resolved.
+type scope struct {
+	parent  *scope
+	imports map[string]string
+	defined map[string]bool
+}
+
+func (s *scope) isDefined(name string) bool {
+	for ; s != nil; s = s.parent {
+		if s.defined[name] {
+			return true
+		}
+	}
+	return false
+}
+
+// resolve returns the package path and member name referenced by
+// an identifier or member expression. Identifiers that are not defined
+// in an enclosing block are resolved to the prelude.
+func (s *scope) resolve(expr semantic.Expression) (pkgPath, name string, ok bool) {
+	switch e := expr.(type) {
+	case *semantic.IdentifierExpression:
+		if e.Name.Package != "" {
+			return e.Name.Package, e.Name.LocalName, true
+		}
+		if s.isDefined(e.Name.LocalName) {
+			return "", "", false
+		}
+		if e.Name.LocalName == "from" {
+			// The prelude from function is defined in the influxdb package.
+			return influxdbPkg, e.Name.LocalName, true
+		}
+		return universePkg, e.Name.LocalName, true
+	case *semantic.MemberExpression:
+		id, ok := e.Object.(*semantic.IdentifierExpression)
+		if !ok || s.isDefined(id.Name.LocalName) {
+			return "", "", false
+		}
+		pkgPath, ok := s.imports[id.Name.LocalName]
+		if !ok {
+			return "", "", false
+		}
+		return pkgPath, e.Property.LocalName, true
+	}
+	return "", "", false
+}
+
+// walkScopes calls fn with each node of the file and the scope it
+// is evaluated in. A variable is in scope from the end of its assignment
+// to the end of the enclosing block and a function parameter is in scope
+// in the body of its function.
+func walkScopes(file *semantic.File, fn func(s *scope, node semantic.Node)) {
+	s := &scope{
+		imports: make(map[string]string),
+		defined: make(map[string]bool),
+	}
+	for _, imp := range file.Imports {
+		s.imports[importName(imp)] = imp.Path.Value
+	}
+	semantic.Walk(semantic.NewScopedVisitor(&scopeVisitor{s: s, fn: fn}), file)
+}
+
+type scopeVisitor struct {
+	s  *scope
+	fn func(s *scope, node semantic.Node)
+}
+
+func (v *scopeVisitor) Nest() semantic.NestingVisitor {
+	return &scopeVisitor{
+		s: &scope{
+			parent:  v.s,
+			imports: v.s.imports,
+			defined: make(map[string]bool),
+		},
+		fn: v.fn,
+	}
+}
+
+func (v *scopeVisitor) Visit(node semantic.Node) semantic.Visitor {
+	if p, ok := node.(*semantic.FunctionParameter); ok {
+		v.s.defined[p.Key.Name.LocalName] = true
+	}
+	v.fn(v.s, node)
+	return v
+}
+
+func (v *scopeVisitor) Done(node semantic.Node) {
+	if n, ok := node.(*semantic.NativeVariableAssignment); ok {
+		v.s.defined[n.Identifier.Name.LocalName] = true
+	}
+}
+
+func importName(imp *semantic.ImportDeclaration) string {
+	if imp.As != nil {
+		return imp.As.Name.LocalName
+	}
+	return path.Base(imp.Path.Value)
+}
+
+// deprecatedUses reports imports of deprecated packages and
+// references to deprecated package members.
+func deprecatedUses(filename string, pkg *semantic.Package) []Diagnostic {
+	var diags []Diagnostic
+	for _, file := range pkg.Files {
+		for _, imp := range file.Imports {
+			if version, ok := deprecated[imp.Path.Value]; ok {
+				diags = append(diags, newDiagnostic(filename, imp.Location(), Warning, Deprecated,
+					fmt.Sprintf("package %q is deprecated since Flux %s", imp.Path.Value, version)))
+			}
+		}
+
+		walkScopes(file, func(s *scope, node semantic.Node) {
+			var expr semantic.Expression
+			switch n := node.(type) {
+			case *semantic.IdentifierExpression:
+				expr = n
+			case *semantic.MemberExpression:
+				expr = n
+			default:
+				return
+			}
+			pkgPath, name, ok := s.resolve(expr)
+			if !ok {
+				return
+			}
+			if version, ok := deprecated[pkgPath+"."+name]; ok {
+				diags = append(diags, newDiagnostic(filename, expr.Location(), Warning, Deprecated,
+					fmt.Sprintf("%s.%s is deprecated since Flux %s", path.Base(pkgPath), name, version)))
+			}
+		})
+	}
+	return diags
+}
+
+// missingRanges reports pipelines that read from InfluxDB with from()
+// without restricting the time range with range(). A from() call that
+// is only assigned to a variable is not reported since the pipeline
+// may be continued elsewhere.
+func missingRanges(filename string, pkg *semantic.Package) []Diagnostic {
+	var diags []Diagnostic
+	for _, file := range pkg.Files {
+		piped := make(map[*semantic.CallExpression]bool)
+		statements := make(map[*semantic.CallExpression]bool)
+		// callees records the package member called by each call
+		// in the scope of the call.
+		callees := make(map[*semantic.CallExpression]string)
+		var calls []*semantic.CallExpression
+		walkScopes(file, func(s *scope, node semantic.Node) {
+			switch n := node.(type) {
+			case *semantic.CallExpression:
+				calls = append(calls, n)
+				if pipe, ok := n.Pipe.(*semantic.CallExpression); ok {
+					piped[pipe] = true
+				}
+				if pkgPath, name, ok := s.resolve(n.Callee); ok {
+					callees[n] = pkgPath + "." + name
+				}
+			case *semantic.ExpressionStatement:
+				if call, ok := n.Expression.(*semantic.CallExpression); ok {
+					statements[call] = true
+				}
+			}
+		})
+
+		isCall := func(call *semantic.CallExpression, pkgPath, name string) bool {
+			return callees[call] == pkgPath+"."+name
+		}
+		for _, call := range calls {
+			if piped[call] {
+				// Only consider each pipeline once from its last call.
+				continue
+			}
+			head, hasRange, length := call, false, 0
+			for c := call; c != nil; length++ {
+				head = c
+				if isCall(c, universePkg, "range") {
+					hasRange = true
+				}
+				c, _ = c.Pipe.(*semantic.CallExpression)
+			}
+			if hasRange || !isCall(head, influxdbPkg, "from") {
+				continue
+			}
+			if length == 1 && !statements[call] {
+				continue
+			}
+			diags = append(diags, newDiagnostic(filename, head.Location(), Warning, MissingRange,
+				"from() must be followed by range() to limit the time range of the query"))
+		}
+	}
+	return diags
+}
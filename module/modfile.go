@@ -0,0 +1,124 @@
+// Package module resolves imports of Flux packages that are stored on the
+// filesystem instead of being registered with the runtime.
+//
+// A module is a directory tree of packages with a flux.mod file at its root.
+// The file names the module and may list additional directories to search
+// for packages:
+//
+//	// Helper libraries shared by our tasks.
+//	module mycompany
+//	path ../shared
+//
+// The package in the alerts directory of the module is imported as
+// mycompany/alerts. Packages in a search path are imported by their
+// directory relative to the search path.
+//
+// The sources of the local packages imported by a program are given to
+// the analyzer so it infers their types, and each local package is
+// evaluated into its own package when the program imports it, like the
+// packages of the standard library. Local packages may declare options
+// but not builtins.
+package module
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/influxdata/flux/codes"
+	"github.com/influxdata/flux/internal/errors"
+)
+
+// ModFilename is the name of the file that marks the root of a module.
+const ModFilename = "flux.mod"
+
+// PathEnv is the environment variable with a list of directories that
+// are searched for packages, separated by the OS path list separator.
+const PathEnv = "FLUXPATH"
+
+// ModFile is a parsed flux.mod file.
+type ModFile struct {
+	// Dir is the root directory of the module.
+	Dir string
+	// Module is the import path prefix of the packages in the module.
+	Module string
+	// Paths are the search paths listed in the file
+	// made absolute relative to the module root.
+	Paths []string
+}
+
+// ParseModFile parses the contents of the flux.mod file in dir.
+func ParseModFile(dir string, data []byte) (*ModFile, error) {
+	mod := &ModFile{Dir: dir}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; scanner.Scan(); n++ {
+		line := scanner.Text()
+		if i := strings.Index(line, "//"); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 2 {
+			return nil, errors.Newf(codes.Invalid, "%s:%d: expected a directive and one argument", ModFilename, n)
+		}
+		switch fields[0] {
+		case "module":
+			if mod.Module != "" {
+				return nil, errors.Newf(codes.Invalid, "%s:%d: module is declared more than once", ModFilename, n)
+			}
+			mod.Module = strings.Trim(fields[1], "/")
+		case "path":
+			p := filepath.FromSlash(fields[1])
+			if !filepath.IsAbs(p) {
+				p = filepath.Join(dir, p)
+			}
+			mod.Paths = append(mod.Paths, p)
+		default:
+			return nil, errors.Newf(codes.Invalid, "%s:%d: unknown directive %q", ModFilename, n, fields[0])
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if mod.Module == "" {
+		return nil, errors.Newf(codes.Invalid, "%s in %s does not declare a module", ModFilename, dir)
+	}
+	return mod, nil
+}
+
+// FindModFile reads the flux.mod file in dir or its nearest parent
+// that has one. It returns nil if there is no flux.mod file.
+func FindModFile(dir string) (*ModFile, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		data, err := os.ReadFile(filepath.Join(dir, ModFilename))
+		if err == nil {
+			return ParseModFile(dir, data)
+		} else if !os.IsNotExist(err) {
+			return nil, err
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return nil, nil
+		}
+		dir = parent
+	}
+}
+
+// SearchPaths returns the directories listed in $FLUXPATH.
+func SearchPaths() []string {
+	var paths []string
+	for _, p := range filepath.SplitList(os.Getenv(PathEnv)) {
+		if p != "" {
+			paths = append(paths, p)
+		}
+	}
+	return paths
+}
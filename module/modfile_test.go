@@ -0,0 +1,87 @@
+package module_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/influxdata/flux/module"
+)
+
+func TestParseModFile(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		data    string
+		want    *module.ModFile
+		wantErr string
+	}{
+		{
+			name: "module",
+			data: "module mycompany\n",
+			want: &module.ModFile{Dir: "/src", Module: "mycompany"},
+		},
+		{
+			name: "paths and comments",
+			data: "// Shared helpers.\nmodule mycompany/tasks // the tasks\n\npath ../shared\npath /opt/flux\n",
+			want: &module.ModFile{
+				Dir:    "/src",
+				Module: "mycompany/tasks",
+				Paths:  []string{"/shared", "/opt/flux"},
+			},
+		},
+		{
+			name:    "missing module",
+			data:    "path lib\n",
+			wantErr: "flux.mod in /src does not declare a module",
+		},
+		{
+			name:    "unknown directive",
+			data:    "module a\nrequire b\n",
+			wantErr: `flux.mod:2: unknown directive "require"`,
+		},
+		{
+			name:    "missing argument",
+			data:    "module\n",
+			wantErr: "flux.mod:1: expected a directive and one argument",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := module.ParseModFile("/src", []byte(tc.data))
+			if tc.wantErr != "" {
+				if err == nil || err.Error() != tc.wantErr {
+					t.Fatalf("expected error %q, got %v", tc.wantErr, err)
+				}
+				return
+			} else if err != nil {
+				t.Fatal(err)
+			}
+			if !cmp.Equal(tc.want, got) {
+				t.Errorf("unexpected mod file -want/+got:\n%s", cmp.Diff(tc.want, got))
+			}
+		})
+	}
+}
+
+func TestFindModFile(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, module.ModFilename), []byte("module mycompany\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	sub := filepath.Join(dir, "tasks", "hourly")
+	if err := os.MkdirAll(sub, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	mod, err := module.FindModFile(sub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mod == nil || mod.Dir != dir || mod.Module != "mycompany" {
+		t.Errorf("unexpected mod file %+v", mod)
+	}
+
+	if mod, err := module.FindModFile(t.TempDir()); err != nil || mod != nil {
+		t.Errorf("expected no mod file, got %+v %v", mod, err)
+	}
+}
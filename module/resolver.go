@@ -0,0 +1,269 @@
+package module
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/influxdata/flux/ast"
+	"github.com/influxdata/flux/codes"
+	"github.com/influxdata/flux/internal/errors"
+	"github.com/influxdata/flux/libflux/go/libflux"
+	"github.com/influxdata/flux/parser"
+	"github.com/influxdata/flux/runtime"
+	"github.com/influxdata/flux/semantic"
+)
+
+// Resolver finds the local packages imported by Flux programs. Packages
+// are parsed and analyzed once and cached by import path so a Resolver
+// should not be reused after the package sources change.
+type Resolver struct {
+	mod   *ModFile
+	paths []string
+	// isStdlib reports whether an import path
+	// is a package of the standard library.
+	isStdlib func(pkgpath string) bool
+
+	mu   sync.Mutex
+	pkgs map[string]*localPackage
+}
+
+// NewResolver creates a Resolver for the packages of the module and the
+// packages in the search paths. The module may be nil. Packages of the
+// standard library are found before those of the module, which are
+// found before those of the search paths.
+func NewResolver(mod *ModFile, paths []string) *Resolver {
+	r := &Resolver{
+		mod:      mod,
+		isStdlib: runtime.StdlibPackageExists,
+		pkgs:     make(map[string]*localPackage),
+	}
+	if mod != nil {
+		r.paths = append(r.paths, mod.Paths...)
+	}
+	r.paths = append(r.paths, paths...)
+	return r
+}
+
+// localPackage is a local package that was parsed.
+type localPackage struct {
+	path string
+	// files are the names of the files of the package
+	// and sources are their contents.
+	files   []string
+	sources []string
+	// deps are the local packages imported by the package.
+	deps []*localPackage
+
+	// The package is analyzed once it is imported by a program.
+	once   sync.Once
+	semPkg *semantic.Package
+	err    error
+}
+
+// Locate returns the directory of the package with the import path.
+// It returns false if the path is not a local package.
+func (r *Resolver) Locate(pkgpath string) (string, bool) {
+	if r.isStdlib(pkgpath) {
+		return "", false
+	}
+	if r.mod != nil && (pkgpath == r.mod.Module || strings.HasPrefix(pkgpath, r.mod.Module+"/")) {
+		rel := strings.TrimPrefix(strings.TrimPrefix(pkgpath, r.mod.Module), "/")
+		return filepath.Join(r.mod.Dir, filepath.FromSlash(rel)), true
+	}
+	for _, dir := range r.paths {
+		dir = filepath.Join(dir, filepath.FromSlash(pkgpath))
+		if files, _ := fluxFiles(dir); len(files) > 0 {
+			return dir, true
+		}
+	}
+	return "", false
+}
+
+// Sources returns the sources of the local packages imported by the
+// package and the local packages they import, as expected by the
+// analyzer in libflux.Options. It returns nil if the package does not
+// import a local package.
+func (r *Resolver) Sources(pkg *ast.Package) (map[string]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var sources map[string]string
+	seen := make(map[*localPackage]bool)
+	for _, file := range pkg.Files {
+		for _, imp := range file.Imports {
+			if _, ok := r.Locate(imp.Path.Value); !ok {
+				continue
+			}
+			lp, err := r.load(imp.Path.Value, imp, nil)
+			if err != nil {
+				return nil, err
+			}
+			if sources == nil {
+				sources = make(map[string]string)
+			}
+			lp.addSources(sources, seen)
+		}
+	}
+	return sources, nil
+}
+
+// addSources adds the sources of the package and its dependencies.
+func (lp *localPackage) addSources(sources map[string]string, seen map[*localPackage]bool) {
+	if seen[lp] {
+		return
+	}
+	seen[lp] = true
+	for i, file := range lp.files {
+		sources[lp.path+"/"+filepath.Base(file)] = lp.sources[i]
+	}
+	for _, dep := range lp.deps {
+		dep.addSources(sources, seen)
+	}
+}
+
+// load parses the local package with the import path. The declaration
+// that imports the package may be nil. The stack is the import paths of
+// the packages being loaded and detects cycles.
+func (r *Resolver) load(pkgpath string, imp *ast.ImportDeclaration, stack []string) (*localPackage, error) {
+	for i, p := range stack {
+		if p == pkgpath {
+			cycle := append(append([]string(nil), stack[i:]...), pkgpath)
+			return nil, errors.Newf(codes.Invalid, "import cycle: %s", strings.Join(cycle, " -> "))
+		}
+	}
+	if lp, ok := r.pkgs[pkgpath]; ok {
+		return lp, nil
+	}
+
+	dir, _ := r.Locate(pkgpath)
+	files, err := fluxFiles(dir)
+	if err != nil {
+		return nil, err
+	} else if len(files) == 0 {
+		if imp != nil {
+			return nil, errors.Newf(codes.NotFound, "%s: local package %q not found in %s", imp.Location(), pkgpath, dir)
+		}
+		return nil, errors.Newf(codes.NotFound, "local package %q not found in %s", pkgpath, dir)
+	}
+
+	lp := &localPackage{path: pkgpath, files: files}
+	stack = append(stack, pkgpath)
+	for _, file := range files {
+		src, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		astPkg := parser.ParseSourceWithFileName(string(src), file)
+		if ast.Check(astPkg) > 0 {
+			return nil, errors.Wrapf(ast.GetError(astPkg), codes.Invalid, "failed to parse %s", file)
+		}
+		f := astPkg.Files[0]
+		for _, stmt := range f.Body {
+			// Builtins are implemented in Go and
+			// can only be registered with the runtime.
+			if s, ok := stmt.(*ast.BuiltinStatement); ok {
+				return nil, errors.Newf(codes.Unimplemented, "%s: local package %q may not declare builtins", s.Location(), pkgpath)
+			}
+		}
+		for _, imp := range f.Imports {
+			if _, ok := r.Locate(imp.Path.Value); !ok {
+				continue
+			}
+			dep, err := r.load(imp.Path.Value, imp, stack)
+			if err != nil {
+				return nil, err
+			}
+			lp.deps = append(lp.deps, dep)
+		}
+		lp.sources = append(lp.sources, string(src))
+	}
+	r.pkgs[pkgpath] = lp
+	return lp, nil
+}
+
+// analyze returns the semantic graph of the local package. The package
+// is analyzed once with the feature flags of the first context.
+func (r *Resolver) analyze(ctx context.Context, lp *localPackage) (*semantic.Package, error) {
+	lp.once.Do(func() {
+		lp.semPkg, lp.err = r.analyzePackage(ctx, lp)
+	})
+	return lp.semPkg, lp.err
+}
+
+func (r *Resolver) analyzePackage(ctx context.Context, lp *localPackage) (*semantic.Package, error) {
+	// The dependencies are analyzed first so their errors
+	// are reported with the files that cause them.
+	sources := make(map[string]string)
+	seen := map[*localPackage]bool{lp: true}
+	for _, dep := range lp.deps {
+		if _, err := r.analyze(ctx, dep); err != nil {
+			return nil, err
+		}
+		dep.addSources(sources, seen)
+	}
+
+	hdl := libflux.Parse(lp.files[0], lp.sources[0])
+	for i := 1; i < len(lp.files); i++ {
+		if err := libflux.MergePackages(hdl, libflux.Parse(lp.files[i], lp.sources[i])); err != nil {
+			return nil, err
+		}
+	}
+	options := libflux.NewOptions(ctx)
+	if len(sources) > 0 {
+		options.Sources = sources
+	}
+	semPkg, err := runtime.AnalyzePackageWithOptions(hdl, options)
+	if err != nil {
+		return nil, errors.Wrapf(err, codes.Inherit, "failed to analyze local package %q", lp.path)
+	}
+	return semPkg, nil
+}
+
+// Loader returns a loader of the local packages for the runtime. The
+// packages are analyzed with the feature flags of the context.
+func (r *Resolver) Loader(ctx context.Context) runtime.PackageLoader {
+	return loader{ctx: ctx, r: r}
+}
+
+type loader struct {
+	ctx context.Context
+	r   *Resolver
+}
+
+func (l loader) LoadPackage(pkgpath string) (*semantic.Package, bool, error) {
+	if _, ok := l.r.Locate(pkgpath); !ok {
+		return nil, false, nil
+	}
+	l.r.mu.Lock()
+	lp, err := l.r.load(pkgpath, nil, nil)
+	l.r.mu.Unlock()
+	if err != nil {
+		return nil, false, err
+	}
+	semPkg, err := l.r.analyze(l.ctx, lp)
+	if err != nil {
+		return nil, false, err
+	}
+	return semPkg, true, nil
+}
+
+// fluxFiles returns the sorted Flux files of the package in dir
+// without its test files.
+func fluxFiles(dir string) ([]string, error) {
+	matches, err := filepath.Glob(filepath.Join(dir, "*.flux"))
+	if err != nil {
+		return nil, err
+	}
+	files := matches[:0]
+	for _, f := range matches {
+		if !strings.HasSuffix(f, "_test.flux") {
+			files = append(files, f)
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
@@ -0,0 +1,241 @@
+package module_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/influxdata/flux/codes"
+	"github.com/influxdata/flux/dependencies/dependenciestest"
+	"github.com/influxdata/flux/dependency"
+	_ "github.com/influxdata/flux/fluxinit/static"
+	"github.com/influxdata/flux/internal/errors"
+	"github.com/influxdata/flux/module"
+	"github.com/influxdata/flux/parser"
+	"github.com/influxdata/flux/runtime"
+	"github.com/influxdata/flux/values"
+)
+
+// writeFiles writes the files to dir, creating directories as needed.
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, src := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestResolver(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"flux.mod": "module mycompany\n",
+		"math/math.flux": `package math
+
+import "strings"
+
+option factor = 2
+
+double = (x) => x * factor
+greet = (name) => strings.toUpper(v: "hi " + name)
+`,
+		"math/math_test.flux": `package math_test
+
+this is not parsed
+`,
+		"alerts/alerts.flux": `package alerts
+
+import "mycompany/math"
+
+threshold = math.double(x: 21)
+over = (v) => v > threshold
+// Parameters shadow the members of the package.
+shadow = (threshold) => threshold + 1
+record = {threshold, limit: threshold * 2}
+`,
+	})
+	mod, err := module.FindModFile(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rt := module.NewRuntime(runtime.Default, module.NewResolver(mod, nil))
+
+	script := `
+import "mycompany/alerts"
+import m "mycompany/math"
+
+option m.factor = 3
+
+double = (x) => x
+a = alerts.threshold
+b = alerts.over(v: 50)
+c = m.greet(name: "bob")
+d = double(x: 3)
+e = alerts.shadow(threshold: 1)
+f = alerts.record.limit
+g = m.double(x: 1)
+`
+	ctx, deps := dependency.Inject(context.Background(), dependenciestest.Default())
+	defer deps.Finish()
+	h, err := rt.Parse(ctx, script)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, scope, err := rt.Eval(ctx, h, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, want := range map[string]values.Value{
+		// The package is evaluated before its option is set.
+		"a": values.NewInt(42),
+		"b": values.NewBool(true),
+		"c": values.NewString("HI BOB"),
+		"d": values.NewInt(3),
+		"e": values.NewInt(2),
+		"f": values.NewInt(84),
+		"g": values.NewInt(3),
+	} {
+		got, ok := scope.Lookup(name)
+		if !ok {
+			t.Errorf("missing variable %s", name)
+		} else if !want.Equal(got) {
+			t.Errorf("%s: got %v, want %v", name, got, want)
+		}
+	}
+	// The imports of the local packages are not in scope.
+	if _, ok := scope.Lookup("strings"); ok {
+		t.Error("unexpected strings package in scope")
+	}
+}
+
+func TestResolver_Sources(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"a/a.flux": "package a\nimport \"mycompany/b\"\nx = b.y\n",
+		"b/b.flux": "package b\nimport \"strings\"\ny = strings.toUpper(v: \"b\")\n",
+	})
+	r := module.NewResolver(&module.ModFile{Dir: dir, Module: "mycompany"}, nil)
+
+	got, err := r.Sources(parser.ParseSource(`import "strings"
+x = strings.toUpper(v: "a")`))
+	if err != nil {
+		t.Fatal(err)
+	} else if got != nil {
+		t.Errorf("expected no sources, got %v", got)
+	}
+
+	got, err = r.Sources(parser.ParseSource(`import "mycompany/a"`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"mycompany/a/a.flux": "package a\nimport \"mycompany/b\"\nx = b.y\n",
+		"mycompany/b/b.flux": "package b\nimport \"strings\"\ny = strings.toUpper(v: \"b\")\n",
+	}
+	if !cmp.Equal(want, got) {
+		t.Errorf("unexpected sources -want/+got:\n%s", cmp.Diff(want, got))
+	}
+}
+
+func TestResolver_Errors(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		files  map[string]string
+		script string
+		code   codes.Code
+		want   string
+	}{
+		{
+			name: "cycle",
+			files: map[string]string{
+				"a/a.flux": "package a\nimport \"mycompany/b\"\nx = b.y\n",
+				"b/b.flux": "package b\nimport \"mycompany/a\"\ny = a.x\n",
+			},
+			script: `import "mycompany/a"` + "\nz = a.x",
+			code:   codes.Invalid,
+			want:   "import cycle: mycompany/a -> mycompany/b -> mycompany/a",
+		},
+		{
+			name:   "not found",
+			script: `import "mycompany/missing"`,
+			code:   codes.NotFound,
+			want:   `local package "mycompany/missing" not found`,
+		},
+		{
+			name: "undeclared member",
+			files: map[string]string{
+				"a/a.flux": "package a\nx = 1\n",
+			},
+			script: `import "mycompany/a"` + "\nz = a.y",
+			code:   codes.Invalid,
+			want:   "y",
+		},
+		{
+			name: "type error",
+			files: map[string]string{
+				"a/a.flux": "package a\nx = 1 + \"a\"\n",
+			},
+			script: `import "mycompany/a"` + "\nz = a.x",
+			code:   codes.Invalid,
+			want:   filepath.Join("a", "a.flux") + "@2:",
+		},
+		{
+			name: "builtins",
+			files: map[string]string{
+				"a/a.flux": "package a\nbuiltin x : int\n",
+			},
+			script: `import "mycompany/a"`,
+			code:   codes.Unimplemented,
+			want:   `local package "mycompany/a" may not declare builtins`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFiles(t, dir, tc.files)
+			rt := module.NewRuntime(runtime.Default, module.NewResolver(&module.ModFile{Dir: dir, Module: "mycompany"}, nil))
+
+			ctx, deps := dependency.Inject(context.Background(), dependenciestest.Default())
+			defer deps.Finish()
+			h, err := rt.Parse(ctx, tc.script)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, _, err = rt.Eval(ctx, h, nil)
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if got := errors.Code(err); got != tc.code {
+				t.Errorf("unexpected code %v, want %v", got, tc.code)
+			}
+			if !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("expected error containing %q, got %q", tc.want, err)
+			}
+		})
+	}
+}
+
+func TestResolver_SearchPaths(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"shared/util/util.flux": "package util\nanswer = 42\n",
+	})
+	r := module.NewResolver(nil, []string{dir})
+	if got, ok := r.Locate("shared/util"); !ok || got != filepath.Join(dir, "shared", "util") {
+		t.Errorf("unexpected location %q %v", got, ok)
+	}
+	// The standard library is found before the search paths.
+	if _, ok := r.Locate("strings"); ok {
+		t.Error("expected strings to be a standard library package")
+	}
+	if _, ok := r.Locate("shared/missing"); ok {
+		t.Error("expected shared/missing to not be found")
+	}
+}
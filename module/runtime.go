@@ -0,0 +1,91 @@
+package module
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/influxdata/flux"
+	"github.com/influxdata/flux/ast"
+	"github.com/influxdata/flux/codes"
+	"github.com/influxdata/flux/internal/errors"
+	"github.com/influxdata/flux/interpreter"
+	"github.com/influxdata/flux/libflux/go/libflux"
+	"github.com/influxdata/flux/runtime"
+	"github.com/influxdata/flux/semantic"
+	"github.com/influxdata/flux/values"
+)
+
+// Runtime is a flux.Runtime that imports the local packages found by
+// its Resolver in addition to the packages of the standard library.
+type Runtime struct {
+	flux.Runtime
+	Resolver *Resolver
+}
+
+// NewRuntime returns a runtime that imports the local packages
+// found by r. The runtime rt must be able to evaluate a semantic
+// package with a loader, as the default runtime does.
+func NewRuntime(rt flux.Runtime, r *Resolver) *Runtime {
+	return &Runtime{Runtime: rt, Resolver: r}
+}
+
+// loaderEvaluator is implemented by runtimes that can import packages
+// that are not part of the standard library.
+type loaderEvaluator interface {
+	EvalSemanticWithLoader(ctx context.Context, semPkg *semantic.Package, loader runtime.PackageLoader, es interpreter.ExecOptsConfig, opts ...flux.ScopeMutator) ([]interpreter.SideEffect, values.Scope, error)
+}
+
+// Eval analyzes the package with the local packages it imports and evaluates it.
+func (rt *Runtime) Eval(ctx context.Context, astPkg flux.ASTHandle, es interpreter.ExecOptsConfig, opts ...flux.ScopeMutator) ([]interpreter.SideEffect, values.Scope, error) {
+	semPkg, err := rt.Analyze(ctx, astPkg)
+	if err != nil {
+		return nil, nil, err
+	}
+	return rt.EvalSemantic(ctx, semPkg, es, opts...)
+}
+
+// Analyze analyzes the package with the types of the local packages it imports.
+func (rt *Runtime) Analyze(ctx context.Context, astPkg flux.ASTHandle) (*semantic.Package, error) {
+	hdl, ok := astPkg.(*libflux.ASTPkg)
+	if !ok {
+		return nil, errors.Newf(codes.Internal, "unexpected AST handle %T", astPkg)
+	}
+	bs, err := hdl.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	var pkg ast.Package
+	if err := json.Unmarshal(bs, &pkg); err != nil {
+		return nil, err
+	}
+	sources, err := rt.Resolver.Sources(&pkg)
+	if err != nil {
+		return nil, err
+	} else if len(sources) == 0 {
+		return runtime.AnalyzePackage(ctx, astPkg)
+	}
+
+	// The local packages are analyzed before the program
+	// so their errors are reported with their own files.
+	loader := rt.Resolver.Loader(ctx)
+	for _, file := range pkg.Files {
+		for _, imp := range file.Imports {
+			if _, _, err := loader.LoadPackage(imp.Path.Value); err != nil {
+				return nil, err
+			}
+		}
+	}
+	options := libflux.NewOptions(ctx)
+	options.Sources = sources
+	return runtime.AnalyzePackageWithOptions(astPkg, options)
+}
+
+// EvalSemantic evaluates the analyzed package and
+// the local packages it imports.
+func (rt *Runtime) EvalSemantic(ctx context.Context, semPkg *semantic.Package, es interpreter.ExecOptsConfig, opts ...flux.ScopeMutator) ([]interpreter.SideEffect, values.Scope, error) {
+	ev, ok := rt.Runtime.(loaderEvaluator)
+	if !ok {
+		return nil, nil, errors.Newf(codes.Unimplemented, "runtime %T cannot import local packages", rt.Runtime)
+	}
+	return ev.EvalSemanticWithLoader(ctx, semPkg, rt.Resolver.Loader(ctx), es, opts...)
+}
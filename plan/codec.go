@@ -0,0 +1,353 @@
+package plan
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/influxdata/flux"
+	"github.com/influxdata/flux/codes"
+	"github.com/influxdata/flux/internal/errors"
+	"github.com/influxdata/flux/interpreter"
+)
+
+// ProcedureSpecCodec encodes and decodes the physical procedure specs of a single kind.
+type ProcedureSpecCodec interface {
+	Encode(spec PhysicalProcedureSpec) (json.RawMessage, error)
+	Decode(data json.RawMessage) (PhysicalProcedureSpec, error)
+}
+
+// JSONCodec returns a codec that encodes a procedure spec with encoding/json.
+// The new function returns the empty spec that data is decoded into.
+// It is suitable for any spec whose exported fields fully describe it.
+func JSONCodec(new func() PhysicalProcedureSpec) ProcedureSpecCodec {
+	return jsonCodec{new: new}
+}
+
+type jsonCodec struct {
+	new func() PhysicalProcedureSpec
+}
+
+func (c jsonCodec) Encode(spec PhysicalProcedureSpec) (json.RawMessage, error) {
+	return json.Marshal(spec)
+}
+
+func (c jsonCodec) Decode(data json.RawMessage) (PhysicalProcedureSpec, error) {
+	spec := c.new()
+	if err := json.Unmarshal(data, spec); err != nil {
+		return nil, err
+	}
+	return spec, nil
+}
+
+var procedureSpecCodecs = make(map[ProcedureKind]ProcedureSpecCodec)
+
+// RegisterProcedureSpecCodec registers the codec for procedure specs of the specified kind.
+// A plan can only be serialized if every one of its nodes has a codec.
+// Specs that carry Flux functions, such as those of filter and map, have
+// no codec because a function and its scope cannot be serialized, so a
+// plan that contains them fails with codes.Unimplemented.
+// The call panics if the kind is not unique.
+func RegisterProcedureSpecCodec(k ProcedureKind, c ProcedureSpecCodec) {
+	if procedureSpecCodecs[k] != nil {
+		panic(fmt.Errorf("duplicate registration for procedure spec codec %v", k))
+	}
+	procedureSpecCodecs[k] = c
+}
+
+// ProcedureSpecCodecKinds returns the sorted kinds that have a registered codec.
+func ProcedureSpecCodecKinds() []ProcedureKind {
+	kinds := make([]ProcedureKind, 0, len(procedureSpecCodecs))
+	for k := range procedureSpecCodecs {
+		kinds = append(kinds, k)
+	}
+	sort.Slice(kinds, func(i, j int) bool {
+		return kinds[i] < kinds[j]
+	})
+	return kinds
+}
+
+// RegisterJSONCodec registers a JSON codec for the procedure specs of the
+// specified kind, which are decoded into new values of type T.
+// The call panics if the kind is not unique.
+func RegisterJSONCodec[T any, PT interface {
+	*T
+	PhysicalProcedureSpec
+}](k ProcedureKind) {
+	RegisterProcedureSpecCodec(k, JSONCodec(func() PhysicalProcedureSpec {
+		return PT(new(T))
+	}))
+}
+
+func init() {
+	RegisterJSONCodec[GeneratedYieldProcedureSpec](generatedYieldKind)
+}
+
+// specJSON is the wire format of a physical plan.
+type specJSON struct {
+	Now       time.Time               `json:"now"`
+	Resources flux.ResourceManagement `json:"resources"`
+	// Nodes are ordered so that every node follows its predecessors.
+	Nodes []nodeJSON `json:"nodes"`
+	Roots []NodeID   `json:"roots"`
+}
+
+type nodeJSON struct {
+	ID           NodeID                   `json:"id"`
+	Kind         ProcedureKind            `json:"kind"`
+	Spec         json.RawMessage          `json:"spec"`
+	Predecessors []NodeID                 `json:"predecessors,omitempty"`
+	Successors   []NodeID                 `json:"successors,omitempty"`
+	Bounds       *Bounds                  `json:"bounds,omitempty"`
+	Trigger      *triggerJSON             `json:"trigger,omitempty"`
+	Source       []interpreter.StackEntry `json:"source,omitempty"`
+}
+
+// triggerJSON is the wire format of a trigger spec.
+// Only the fields of the trigger's kind are set.
+type triggerJSON struct {
+	Kind            TriggerKind    `json:"kind"`
+	AllowedLateness *flux.Duration `json:"allowedLateness,omitempty"`
+	Duration        *flux.Duration `json:"duration,omitempty"`
+	Count           int            `json:"count,omitempty"`
+	Trigger         *triggerJSON   `json:"trigger,omitempty"`
+	Main            *triggerJSON   `json:"main,omitempty"`
+	Finally         *triggerJSON   `json:"finally,omitempty"`
+}
+
+// MarshalSpec encodes a physical plan as JSON.
+// Every node must be a physical node whose kind has a registered codec.
+// The plan is made of the roots and their predecessors. Edges to
+// successors outside of the plan are not encoded, so a sub-plan can
+// be encoded by choosing its roots.
+func MarshalSpec(spec *Spec) ([]byte, error) {
+	sj := specJSON{
+		Now:       spec.Now,
+		Resources: spec.Resources,
+	}
+	for root := range spec.Roots {
+		sj.Roots = append(sj.Roots, root.ID())
+	}
+	sort.Slice(sj.Roots, func(i, j int) bool {
+		return sj.Roots[i] < sj.Roots[j]
+	})
+
+	var nodes []Node
+	inPlan := make(map[Node]bool)
+	if err := spec.BottomUpWalk(func(node Node) error {
+		nodes = append(nodes, node)
+		inPlan[node] = true
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	for _, node := range nodes {
+		nj, err := encodeNode(node, inPlan)
+		if err != nil {
+			return nil, err
+		}
+		sj.Nodes = append(sj.Nodes, nj)
+	}
+	return json.Marshal(sj)
+}
+
+func encodeNode(node Node, inPlan map[Node]bool) (nodeJSON, error) {
+	ppn, ok := node.(*PhysicalPlanNode)
+	if !ok {
+		return nodeJSON{}, errors.Newf(codes.Invalid, "cannot serialize plan node %q: not a physical node", node.ID())
+	}
+	codec := procedureSpecCodecs[ppn.Kind()]
+	if codec == nil {
+		return nodeJSON{}, errors.Newf(codes.Unimplemented, "cannot serialize plan node %q: no codec for procedure kind %v", node.ID(), ppn.Kind())
+	}
+	data, err := codec.Encode(ppn.Spec)
+	if err != nil {
+		return nodeJSON{}, errors.Wrapf(err, codes.Inherit, "cannot serialize plan node %q", node.ID())
+	}
+	trigger, err := encodeTrigger(ppn.TriggerSpec)
+	if err != nil {
+		return nodeJSON{}, errors.Wrapf(err, codes.Internal, "cannot serialize plan node %q", node.ID())
+	}
+	var successors []Node
+	for _, succ := range ppn.Successors() {
+		if inPlan[succ] {
+			successors = append(successors, succ)
+		}
+	}
+	return nodeJSON{
+		ID:           ppn.ID(),
+		Kind:         ppn.Kind(),
+		Spec:         data,
+		Predecessors: nodeIDs(ppn.Predecessors()),
+		Successors:   nodeIDs(successors),
+		Bounds:       ppn.Bounds(),
+		Trigger:      trigger,
+		Source:       ppn.Source,
+	}, nil
+}
+
+func nodeIDs(nodes []Node) []NodeID {
+	if len(nodes) == 0 {
+		return nil
+	}
+	ids := make([]NodeID, len(nodes))
+	for i, n := range nodes {
+		ids[i] = n.ID()
+	}
+	return ids
+}
+
+func encodeTrigger(t TriggerSpec) (*triggerJSON, error) {
+	if t == nil {
+		return nil, nil
+	}
+	tj := &triggerJSON{Kind: t.Kind()}
+	var err error
+	switch t := t.(type) {
+	case NarrowTransformationTriggerSpec:
+	case AfterWatermarkTriggerSpec:
+		tj.AllowedLateness = &t.AllowedLateness
+	case RepeatedTriggerSpec:
+		tj.Trigger, err = encodeTrigger(t.Trigger)
+	case AfterProcessingTimeTriggerSpec:
+		tj.Duration = &t.Duration
+	case AfterAtLeastCountTriggerSpec:
+		tj.Count = t.Count
+	case OrFinallyTriggerSpec:
+		if tj.Main, err = encodeTrigger(t.Main); err != nil {
+			return nil, err
+		}
+		tj.Finally, err = encodeTrigger(t.Finally)
+	default:
+		return nil, errors.Newf(codes.Unimplemented, "cannot serialize trigger spec %T", t)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return tj, nil
+}
+
+// UnmarshalSpec decodes a physical plan encoded by MarshalSpec.
+func UnmarshalSpec(data []byte) (*Spec, error) {
+	var sj specJSON
+	if err := json.Unmarshal(data, &sj); err != nil {
+		return nil, errors.Wrap(err, codes.Invalid, "cannot deserialize plan")
+	}
+
+	nodes := make(map[NodeID]*PhysicalPlanNode, len(sj.Nodes))
+	for _, nj := range sj.Nodes {
+		if _, ok := nodes[nj.ID]; ok {
+			return nil, errors.Newf(codes.Invalid, "cannot deserialize plan: duplicate node %q", nj.ID)
+		}
+		node, err := decodeNode(nj)
+		if err != nil {
+			return nil, err
+		}
+		nodes[nj.ID] = node
+	}
+
+	lookup := func(id NodeID) (Node, error) {
+		n, ok := nodes[id]
+		if !ok {
+			return nil, errors.Newf(codes.Invalid, "cannot deserialize plan: unknown node %q", id)
+		}
+		return n, nil
+	}
+	for _, nj := range sj.Nodes {
+		node := nodes[nj.ID]
+		for _, id := range nj.Predecessors {
+			pred, err := lookup(id)
+			if err != nil {
+				return nil, err
+			}
+			node.AddPredecessors(pred)
+		}
+		for _, id := range nj.Successors {
+			succ, err := lookup(id)
+			if err != nil {
+				return nil, err
+			}
+			node.AddSuccessors(succ)
+		}
+	}
+
+	spec := NewPlanSpec()
+	spec.Now = sj.Now
+	spec.Resources = sj.Resources
+	for _, id := range sj.Roots {
+		root, err := lookup(id)
+		if err != nil {
+			return nil, err
+		}
+		spec.Roots[root] = struct{}{}
+	}
+	if err := spec.CheckIntegrity(); err != nil {
+		return nil, errors.Wrap(err, codes.Invalid, "cannot deserialize plan")
+	}
+	return spec, nil
+}
+
+func decodeNode(nj nodeJSON) (*PhysicalPlanNode, error) {
+	codec := procedureSpecCodecs[nj.Kind]
+	if codec == nil {
+		return nil, errors.Newf(codes.Unimplemented, "cannot deserialize plan node %q: no codec for procedure kind %v", nj.ID, nj.Kind)
+	}
+	spec, err := codec.Decode(nj.Spec)
+	if err != nil {
+		return nil, errors.Wrapf(err, codes.Invalid, "cannot deserialize plan node %q", nj.ID)
+	}
+	trigger, err := decodeTrigger(nj.Trigger)
+	if err != nil {
+		return nil, errors.Wrapf(err, codes.Invalid, "cannot deserialize plan node %q", nj.ID)
+	}
+	node := CreatePhysicalNode(nj.ID, spec)
+	node.Source = nj.Source
+	node.TriggerSpec = trigger
+	if nj.Bounds != nil {
+		node.SetBounds(nj.Bounds)
+	}
+	return node, nil
+}
+
+func decodeTrigger(tj *triggerJSON) (TriggerSpec, error) {
+	if tj == nil {
+		return nil, nil
+	}
+	switch tj.Kind {
+	case NarrowTransformation:
+		return NarrowTransformationTriggerSpec{}, nil
+	case AfterWatermark:
+		var t AfterWatermarkTriggerSpec
+		if tj.AllowedLateness != nil {
+			t.AllowedLateness = *tj.AllowedLateness
+		}
+		return t, nil
+	case Repeated:
+		trigger, err := decodeTrigger(tj.Trigger)
+		if err != nil {
+			return nil, err
+		}
+		return RepeatedTriggerSpec{Trigger: trigger}, nil
+	case AfterProcessingTime:
+		var t AfterProcessingTimeTriggerSpec
+		if tj.Duration != nil {
+			t.Duration = *tj.Duration
+		}
+		return t, nil
+	case AfterAtLeastCount:
+		return AfterAtLeastCountTriggerSpec{Count: tj.Count}, nil
+	case OrFinally:
+		main, err := decodeTrigger(tj.Main)
+		if err != nil {
+			return nil, err
+		}
+		finally, err := decodeTrigger(tj.Finally)
+		if err != nil {
+			return nil, err
+		}
+		return OrFinallyTriggerSpec{Main: main, Finally: finally}, nil
+	default:
+		return nil, errors.Newf(codes.Invalid, "unknown trigger kind %d", tj.Kind)
+	}
+}
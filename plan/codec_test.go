@@ -0,0 +1,253 @@
+package plan_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/influxdata/flux"
+	"github.com/influxdata/flux/ast"
+	"github.com/influxdata/flux/codes"
+	"github.com/influxdata/flux/internal/errors"
+	"github.com/influxdata/flux/interpreter"
+	"github.com/influxdata/flux/plan"
+)
+
+const codecKind = "codecTest"
+
+type codecProcedureSpec struct {
+	plan.DefaultCost
+	N       int
+	Columns []string
+}
+
+func (s *codecProcedureSpec) Kind() plan.ProcedureKind {
+	return codecKind
+}
+
+func (s *codecProcedureSpec) Copy() plan.ProcedureSpec {
+	ns := *s
+	return &ns
+}
+
+func init() {
+	plan.RegisterJSONCodec[codecProcedureSpec](codecKind)
+}
+
+func TestMarshalSpec(t *testing.T) {
+	a := plan.CreatePhysicalNode("a", &codecProcedureSpec{N: 1, Columns: []string{"_value"}})
+	b := plan.CreatePhysicalNode("b", &codecProcedureSpec{N: 2})
+	join := plan.CreatePhysicalNode("join", &codecProcedureSpec{N: 3})
+	yield := plan.CreatePhysicalNode("yield", &plan.GeneratedYieldProcedureSpec{Name: "_result"})
+	other := plan.CreatePhysicalNode("other", &plan.GeneratedYieldProcedureSpec{Name: "other"})
+
+	// The order of the predecessors of the join is preserved.
+	join.AddPredecessors(b, a)
+	b.AddSuccessors(join)
+	a.AddSuccessors(join, other)
+	yield.AddPredecessors(join)
+	join.AddSuccessors(yield)
+	other.AddPredecessors(a)
+
+	a.SetBounds(&plan.Bounds{Start: 10, Stop: 20})
+	a.Source = []interpreter.StackEntry{{
+		FunctionName: "from",
+		Location: ast.SourceLocation{
+			Start: ast.Position{Line: 1, Column: 1},
+			End:   ast.Position{Line: 1, Column: 10},
+		},
+	}}
+	a.TriggerSpec = plan.NarrowTransformationTriggerSpec{}
+	b.TriggerSpec = plan.AfterWatermarkTriggerSpec{AllowedLateness: flux.ConvertDuration(time.Minute)}
+	join.TriggerSpec = plan.OrFinallyTriggerSpec{
+		Main: plan.RepeatedTriggerSpec{
+			Trigger: plan.AfterProcessingTimeTriggerSpec{Duration: flux.ConvertDuration(5 * time.Second)},
+		},
+		Finally: plan.AfterAtLeastCountTriggerSpec{Count: 10},
+	}
+
+	want := plan.NewPlanSpec()
+	want.Roots[yield] = struct{}{}
+	want.Roots[other] = struct{}{}
+	want.Resources = flux.ResourceManagement{ConcurrencyQuota: 2, MemoryBytesQuota: 1024}
+	want.Now = time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	data, err := plan.MarshalSpec(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := plan.UnmarshalSpec(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !want.Now.Equal(got.Now) {
+		t.Errorf("unexpected now %v, want %v", got.Now, want.Now)
+	}
+	if !cmp.Equal(want.Resources, got.Resources) {
+		t.Errorf("unexpected resources -want/+got:\n%s", cmp.Diff(want.Resources, got.Resources))
+	}
+	if diff := cmp.Diff(describePlan(t, want), describePlan(t, got)); diff != "" {
+		t.Errorf("unexpected plan -want/+got:\n%s", diff)
+	}
+
+	// Encoding is deterministic.
+	again, err := plan.MarshalSpec(got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != string(again) {
+		t.Errorf("unexpected encoding of the decoded plan:\n%s\nwant:\n%s", again, data)
+	}
+}
+
+// codecNode is the comparable description of a plan node.
+type codecNode struct {
+	Spec         plan.ProcedureSpec
+	Predecessors []plan.NodeID
+	Successors   []plan.NodeID
+	Bounds       *plan.Bounds
+	Trigger      plan.TriggerSpec
+	Source       []interpreter.StackEntry
+	Root         bool
+}
+
+func describePlan(t *testing.T, spec *plan.Spec) map[plan.NodeID]codecNode {
+	t.Helper()
+	ids := func(nodes []plan.Node) []plan.NodeID {
+		var ids []plan.NodeID
+		for _, n := range nodes {
+			ids = append(ids, n.ID())
+		}
+		return ids
+	}
+	nodes := make(map[plan.NodeID]codecNode)
+	if err := spec.BottomUpWalk(func(node plan.Node) error {
+		ppn := node.(*plan.PhysicalPlanNode)
+		_, root := spec.Roots[node]
+		nodes[node.ID()] = codecNode{
+			Spec:         ppn.Spec,
+			Predecessors: ids(ppn.Predecessors()),
+			Successors:   ids(ppn.Successors()),
+			Bounds:       ppn.Bounds(),
+			Trigger:      ppn.TriggerSpec,
+			Source:       ppn.Source,
+			Root:         root,
+		}
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	return nodes
+}
+
+func TestMarshalSpec_SubPlan(t *testing.T) {
+	a := plan.CreatePhysicalNode("a", &codecProcedureSpec{N: 1})
+	b := plan.CreatePhysicalNode("b", &codecProcedureSpec{N: 2})
+	yield := plan.CreatePhysicalNode("yield", &plan.GeneratedYieldProcedureSpec{Name: "_result"})
+	a.AddSuccessors(b)
+	b.AddPredecessors(a)
+	b.AddSuccessors(yield)
+	yield.AddPredecessors(b)
+
+	// The sub-plan ends at b so the edge to the yield is not encoded.
+	sub := plan.NewPlanSpec()
+	sub.Roots[b] = struct{}{}
+	data, err := plan.MarshalSpec(sub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := plan.UnmarshalSpec(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[plan.NodeID]codecNode{
+		"a": {Spec: &codecProcedureSpec{N: 1}, Successors: []plan.NodeID{"b"}},
+		"b": {Spec: &codecProcedureSpec{N: 2}, Predecessors: []plan.NodeID{"a"}, Root: true},
+	}
+	if diff := cmp.Diff(want, describePlan(t, got)); diff != "" {
+		t.Errorf("unexpected plan -want/+got:\n%s", diff)
+	}
+}
+
+func TestMarshalSpec_Errors(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		node plan.Node
+		code codes.Code
+		want string
+	}{
+		{
+			name: "logical node",
+			node: plan.CreateLogicalNode("a", &codecProcedureSpec{}),
+			code: codes.Invalid,
+			want: `cannot serialize plan node "a": not a physical node`,
+		},
+		{
+			name: "no codec",
+			node: plan.CreatePhysicalNode("a", triggerAwareProcedureSpec{}),
+			code: codes.Unimplemented,
+			want: `cannot serialize plan node "a": no codec for procedure kind TriggerAwareProcedure`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			spec := plan.NewPlanSpec()
+			spec.Roots[tc.node] = struct{}{}
+			_, err := plan.MarshalSpec(spec)
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if got := errors.Code(err); got != tc.code {
+				t.Errorf("unexpected code %v, want %v", got, tc.code)
+			}
+			if err.Error() != tc.want {
+				t.Errorf("unexpected error %q, want %q", err, tc.want)
+			}
+		})
+	}
+}
+
+func TestUnmarshalSpec_Errors(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		data string
+		want string
+	}{
+		{
+			name: "no codec",
+			data: `{"nodes":[{"id":"a","kind":"unknown","spec":{}}],"roots":["a"]}`,
+			want: `no codec for procedure kind unknown`,
+		},
+		{
+			name: "duplicate node",
+			data: `{"nodes":[{"id":"a","kind":"codecTest","spec":{}},{"id":"a","kind":"codecTest","spec":{}}],"roots":["a"]}`,
+			want: `duplicate node "a"`,
+		},
+		{
+			name: "unknown node",
+			data: `{"nodes":[{"id":"a","kind":"codecTest","spec":{},"predecessors":["b"]}],"roots":["a"]}`,
+			want: `unknown node "b"`,
+		},
+		{
+			name: "asymmetric edge",
+			data: `{"nodes":[{"id":"a","kind":"codecTest","spec":{}},{"id":"b","kind":"codecTest","spec":{},"predecessors":["a"]}],"roots":["b"]}`,
+			want: `cannot deserialize plan`,
+		},
+		{
+			name: "invalid spec",
+			data: `{"nodes":[{"id":"a","kind":"codecTest","spec":{"N":"x"}}],"roots":["a"]}`,
+			want: `cannot deserialize plan node "a"`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := plan.UnmarshalSpec([]byte(tc.data))
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("unexpected error %q, want %q", err, tc.want)
+			}
+		})
+	}
+}
@@ -30,6 +30,26 @@ func WithDetails() FormatOption {
 	}
 }
 
+// WithLabels returns a FormatOption that renders each node with a DOT label
+// that includes the node details instead of writing the details as comments.
+// This makes the details visible when the plan is rendered with Graphviz.
+// It is only useful in combination with WithDetails().
+func WithLabels() FormatOption {
+	return func(f *formatter) {
+		f.withLabels = true
+	}
+}
+
+// WithText returns a FormatOption that renders the plan as indented text
+// instead of a DOT document. Each node is listed after its predecessors
+// along with the IDs of the nodes it reads from, and node details are
+// indented beneath it when WithDetails() is also set.
+func WithText() FormatOption {
+	return func(f *formatter) {
+		f.asText = true
+	}
+}
+
 // Detailer provides an optional interface that ProcedureSpecs can implement.
 // Implementors of this interface will have their details appear in the
 // formatted output for a plan if the WithDetails() option is set.
@@ -39,6 +59,8 @@ type Detailer interface {
 
 type formatter struct {
 	withDetails bool
+	withLabels  bool
+	asText      bool
 	p           *Spec
 }
 
@@ -48,6 +70,22 @@ func formatAsDOT(id NodeID) string {
 	return fmt.Sprintf("%q", id)
 }
 
+// formatDOTLabel produces a quoted DOT label from the given lines.
+func formatDOTLabel(lines []string) string {
+	var sb strings.Builder
+	sb.WriteByte('"')
+	for i, line := range lines {
+		if i > 0 {
+			sb.WriteString(`\n`)
+		}
+		line = strings.ReplaceAll(line, `\`, `\\`)
+		line = strings.ReplaceAll(line, `"`, `\"`)
+		sb.WriteString(line)
+	}
+	sb.WriteByte('"')
+	return sb.String()
+}
+
 func (f formatter) Format(fs fmt.State, c rune) {
 	// Panicking while producing debug output is frustrating, so catch any panics and
 	// continue if that happens.
@@ -58,30 +96,22 @@ func (f formatter) Format(fs fmt.State, c rune) {
 		}
 	}()
 
+	if f.asText {
+		f.formatText(fs)
+		return
+	}
+
 	_, _ = fmt.Fprintf(fs, "digraph {\n")
 	var edges []string
 	_ = f.p.BottomUpWalk(func(pn Node) error {
-		_, _ = fmt.Fprintf(fs, "  %v\n", formatAsDOT(pn.ID()))
-		if f.withDetails {
-			details := ""
-			if d, ok := pn.ProcedureSpec().(Detailer); ok {
-				details += d.PlanDetails() + "\n"
-			}
-
-			if ppn, ok := pn.(*PhysicalPlanNode); ok {
-				for _, attr := range ppn.outputAttrs() {
-					if d, ok := attr.(Detailer); ok {
-						details += d.PlanDetails() + "\n"
-					}
-				}
-			}
-
-			lines := strings.Split(strings.TrimSpace(details), "\n")
-
+		lines := f.details(pn)
+		if f.withLabels && len(lines) > 0 {
+			label := append([]string{string(pn.ID())}, lines...)
+			_, _ = fmt.Fprintf(fs, "  %v [label=%s]\n", formatAsDOT(pn.ID()), formatDOTLabel(label))
+		} else {
+			_, _ = fmt.Fprintf(fs, "  %v\n", formatAsDOT(pn.ID()))
 			for _, line := range lines {
-				if len(line) > 0 {
-					_, _ = fmt.Fprintf(fs, "  // %s\n", line)
-				}
+				_, _ = fmt.Fprintf(fs, "  // %s\n", line)
 			}
 		}
 		for _, pred := range pn.Predecessors() {
@@ -96,3 +126,52 @@ func (f formatter) Format(fs fmt.State, c rune) {
 	}
 	_, _ = fmt.Fprintf(fs, "}\n")
 }
+
+// formatText writes one line per node with the IDs of its predecessors,
+// followed by the node details indented beneath it.
+func (f formatter) formatText(fs fmt.State) {
+	_ = f.p.BottomUpWalk(func(pn Node) error {
+		_, _ = fmt.Fprintf(fs, "%s", pn.ID())
+		if preds := pn.Predecessors(); len(preds) > 0 {
+			ids := make([]string, len(preds))
+			for i, pred := range preds {
+				ids[i] = string(pred.ID())
+			}
+			_, _ = fmt.Fprintf(fs, " <- %s", strings.Join(ids, ", "))
+		}
+		_, _ = fmt.Fprintf(fs, "\n")
+		for _, line := range f.details(pn) {
+			_, _ = fmt.Fprintf(fs, "    %s\n", line)
+		}
+		return nil
+	})
+}
+
+// details returns the non-empty lines of the node details
+// or nil if the WithDetails() option is not set.
+func (f formatter) details(pn Node) []string {
+	if !f.withDetails {
+		return nil
+	}
+
+	details := ""
+	if d, ok := pn.ProcedureSpec().(Detailer); ok {
+		details += d.PlanDetails() + "\n"
+	}
+
+	if ppn, ok := pn.(*PhysicalPlanNode); ok {
+		for _, attr := range ppn.outputAttrs() {
+			if d, ok := attr.(Detailer); ok {
+				details += d.PlanDetails() + "\n"
+			}
+		}
+	}
+
+	var lines []string
+	for _, line := range strings.Split(strings.TrimSpace(details), "\n") {
+		if len(line) > 0 {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
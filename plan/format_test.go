@@ -105,3 +105,66 @@ func TestFormatted(t *testing.T) {
 		})
 	}
 }
+
+func TestFormatted_WithLabels(t *testing.T) {
+	ps := plantest.CreatePlanSpec(&plantest.PlanSpec{
+		Nodes: []plan.Node{
+			plantest.CreatePhysicalNode("source", spec.MockProcedureSpec{}),
+			plantest.CreatePhysicalNode("merge", spec.MockProcedureSpec{
+				OutputAttributesFn: func() plan.PhysicalAttributes {
+					return plan.PhysicalAttributes{plan.ParallelMergeKey: plan.ParallelMergeAttribute{Factor: 8}}
+				},
+				PlanDetailsFn: func() string {
+					return `"quoted" details`
+				},
+			}),
+		},
+		Edges: [][2]int{
+			{0, 1},
+		},
+	})
+
+	want := `digraph {
+  "source"
+  "merge" [label="merge\n\"quoted\" details\nParallelMergeFactor: 8"]
+
+  "source" -> "merge"
+}
+`
+	got := fmt.Sprintf("%v", plan.Formatted(ps, plan.WithDetails(), plan.WithLabels()))
+	if want != got {
+		t.Fatalf("unexpected output: -want/+got:\n%v", diff.LineDiff(want, got))
+	}
+}
+
+func TestFormatted_WithText(t *testing.T) {
+	ps := plantest.CreatePlanSpec(&plantest.PlanSpec{
+		Nodes: []plan.Node{
+			plantest.CreatePhysicalNode("source0", spec.MockProcedureSpec{}),
+			plantest.CreatePhysicalNode("source1", spec.MockProcedureSpec{}),
+			plantest.CreatePhysicalNode("join", spec.MockProcedureSpec{
+				OutputAttributesFn: func() plan.PhysicalAttributes {
+					return plan.PhysicalAttributes{plan.ParallelMergeKey: plan.ParallelMergeAttribute{Factor: 8}}
+				},
+				PlanDetailsFn: func() string {
+					return "*** spec details ***"
+				},
+			}),
+		},
+		Edges: [][2]int{
+			{0, 2},
+			{1, 2},
+		},
+	})
+
+	want := `source0
+source1
+join <- source0, source1
+    *** spec details ***
+    ParallelMergeFactor: 8
+`
+	got := fmt.Sprintf("%v", plan.Formatted(ps, plan.WithDetails(), plan.WithText()))
+	if want != got {
+		t.Fatalf("unexpected output: -want/+got:\n%v", diff.LineDiff(want, got))
+	}
+}
@@ -15,6 +15,10 @@ import (
 type heuristicPlanner struct {
 	rules         map[ProcedureKind][]Rule
 	disabledRules map[string]bool
+
+	// invokedRules, when set, counts the number of times
+	// each rule changed the plan.
+	invokedRules map[string]int
 }
 
 func newHeuristicPlanner() *heuristicPlanner {
@@ -43,7 +47,12 @@ func (p *heuristicPlanner) clearRules() {
 	p.rules = make(map[ProcedureKind][]Rule)
 }
 
-func applyRule(ctx context.Context, spec *Spec, rule Rule, node Node) (Node, bool, error) {
+// recordRules counts rule invocations in the given map.
+func (p *heuristicPlanner) recordRules(rules map[string]int) {
+	p.invokedRules = rules
+}
+
+func (p *heuristicPlanner) applyRule(ctx context.Context, spec *Spec, rule Rule, node Node) (Node, bool, error) {
 	newNode, changed, err := rule.Rewrite(ctx, node)
 	if err != nil {
 		return nil, false, err
@@ -56,6 +65,9 @@ func applyRule(ctx context.Context, spec *Spec, rule Rule, node Node) (Node, boo
 			)
 		}
 		testing.MarkInvokedPlannerRule(ctx, rule.Name())
+		if p.invokedRules != nil {
+			p.invokedRules[rule.Name()]++
+		}
 		if err := updateSuccessors(spec, node, newNode); err != nil {
 			return node, false, errors.Wrap(
 				err,
@@ -79,7 +91,7 @@ func (p *heuristicPlanner) matchRules(ctx context.Context, spec *Spec, node Node
 			continue
 		}
 		if rule.Pattern().Match(node) {
-			newNode, changed, err := applyRule(ctx, spec, rule, node)
+			newNode, changed, err := p.applyRule(ctx, spec, rule, node)
 			if err != nil {
 				return nil, false, err
 			}
@@ -94,7 +106,7 @@ func (p *heuristicPlanner) matchRules(ctx context.Context, spec *Spec, node Node
 			continue
 		}
 		if rule.Pattern().Match(node) {
-			newNode, changed, err := applyRule(ctx, spec, rule, node)
+			newNode, changed, err := p.applyRule(ctx, spec, rule, node)
 			if err != nil {
 				return nil, false, err
 			}
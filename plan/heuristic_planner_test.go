@@ -324,3 +324,52 @@ func checkMutation(selector func(plan.Node) bool, wantSeenNodes []plan.NodeID) f
 		require.True(t, diff == "", "found difference between -want/+got nodes:\n%v", diff)
 	}
 }
+
+func TestHeuristicPlanner_RecordRules(t *testing.T) {
+	//   0 -> 1 -> 2
+	ps := plantest.PlanSpec{
+		Nodes: []plan.Node{
+			plantest.CreatePhysicalMockNode("0"),
+			plantest.CreatePhysicalMockNode("1"),
+			plantest.CreatePhysicalMockNode("2"),
+		},
+		Edges: [][2]int{
+			{0, 1},
+			{1, 2},
+		},
+	}
+
+	t.Run("logical", func(t *testing.T) {
+		rules := make(map[string]int)
+		thePlanner := plan.NewLogicalPlanner(
+			plan.OnlyLogicalRules(&plantest.SimpleRule{ReturnChanged: true}),
+			plan.RecordLogicalRules(rules),
+		)
+		_, err := thePlanner.Plan(context.Background(), plantest.CreatePlanSpec(&ps))
+		require.NoError(t, err)
+		require.Equal(t, map[string]int{"simple": 3}, rules)
+	})
+
+	t.Run("physical", func(t *testing.T) {
+		rules := make(map[string]int)
+		thePlanner := plan.NewPhysicalPlanner(
+			plan.OnlyPhysicalRules(&plantest.SimpleRule{ReturnChanged: true}),
+			plan.RecordPhysicalRules(rules),
+			plan.DisableValidation(),
+		)
+		_, err := thePlanner.Plan(context.Background(), plantest.CreatePlanSpec(&ps))
+		require.NoError(t, err)
+		require.Equal(t, map[string]int{"simple": 3}, rules)
+	})
+
+	t.Run("unchanged", func(t *testing.T) {
+		rules := make(map[string]int)
+		thePlanner := plan.NewLogicalPlanner(
+			plan.OnlyLogicalRules(&plantest.SimpleRule{}),
+			plan.RecordLogicalRules(rules),
+		)
+		_, err := thePlanner.Plan(context.Background(), plantest.CreatePlanSpec(&ps))
+		require.NoError(t, err)
+		require.Empty(t, rules)
+	})
+}
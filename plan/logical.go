@@ -85,6 +85,14 @@ func RemoveLogicalRules(rules ...string) LogicalOption {
 	})
 }
 
+// RecordLogicalRules produces a logical plan option that counts the number
+// of times each rule changes the plan in the given map.
+func RecordLogicalRules(rules map[string]int) LogicalOption {
+	return logicalOption(func(lp *logicalPlanner) {
+		lp.recordRules(rules)
+	})
+}
+
 // DisableIntegrityChecks disables integrity checks in the logical planner.
 func DisableIntegrityChecks() LogicalOption {
 	return logicalOption(func(lp *logicalPlanner) {
@@ -175,6 +175,15 @@ func RemovePhysicalRules(rules ...string) PhysicalOption {
 	})
 }
 
+// RecordPhysicalRules produces a physical plan option that counts the number
+// of times each physical or parallel rule changes the plan in the given map.
+func RecordPhysicalRules(rules map[string]int) PhysicalOption {
+	return physicalOption(func(pp *physicalPlanner) {
+		pp.heuristicPlannerPhysical.recordRules(rules)
+		pp.heuristicPlannerParallel.recordRules(rules)
+	})
+}
+
 // DisableValidation disables validation in the physical planner.
 func DisableValidation() PhysicalOption {
 	return physicalOption(func(p *physicalPlanner) {
@@ -132,6 +132,11 @@ type TransportProfile struct {
 
 	// Mean is the mean span time of this profile.
 	Mean float64 `json:"mean"`
+
+	// MaxAllocated holds the maximum number of bytes the operator
+	// had allocated at any point during execution.
+	// It is only recorded when the operator profiler is enabled.
+	MaxAllocated int64 `json:"max_allocated"`
 }
 
 // StartSpan will start a profile span to be recorded.
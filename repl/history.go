@@ -0,0 +1,120 @@
+package repl
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// maxHistory is the number of entries kept in the history file.
+const maxHistory = 1000
+
+// history is the input history of the REPL. When it has a path the
+// entries are appended to the file so they are kept across sessions.
+// Each entry is written as a quoted string on its own line so entries
+// that span multiple lines can be read back.
+type history struct {
+	path    string
+	entries []string
+}
+
+// DefaultHistoryFile returns the path of the history file
+// in the flux directory of the user configuration directory.
+func DefaultHistoryFile() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "flux", "repl_history"), nil
+}
+
+// loadHistory reads the history file at path. It is not an error
+// for the file to not exist. When the file has grown beyond the
+// maximum number of entries it is rewritten with the newest entries.
+func loadHistory(path string) (*history, error) {
+	h := &history{path: path}
+	if path == "" {
+		return h, nil
+	}
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return h, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 1024*1024)
+	for scanner.Scan() {
+		entry, err := strconv.Unquote(scanner.Text())
+		if err != nil {
+			// Skip lines that were not written by the REPL.
+			continue
+		}
+		h.entries = append(h.entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(h.entries) > maxHistory {
+		h.entries = h.entries[len(h.entries)-maxHistory:]
+		if err := h.rewrite(); err != nil {
+			return nil, err
+		}
+	}
+	return h, nil
+}
+
+// Add appends an entry to the history. An entry that repeats the
+// previous entry is not added.
+func (h *history) Add(entry string) error {
+	if strings.TrimSpace(entry) == "" {
+		return nil
+	}
+	if n := len(h.entries); n > 0 && h.entries[n-1] == entry {
+		return nil
+	}
+	h.entries = append(h.entries, entry)
+	if h.path == "" {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(h.path), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(h.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.WriteString(strconv.Quote(entry) + "\n"); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func (h *history) rewrite() error {
+	var sb strings.Builder
+	for _, entry := range h.entries {
+		sb.WriteString(strconv.Quote(entry))
+		sb.WriteByte('\n')
+	}
+	return os.WriteFile(h.path, []byte(sb.String()), 0600)
+}
+
+// Search returns the index of the newest entry before the index
+// that contains the query.
+func (h *history) Search(query string, before int) (int, bool) {
+	if before > len(h.entries) {
+		before = len(h.entries)
+	}
+	for i := before - 1; i >= 0; i-- {
+		if strings.Contains(h.entries[i], query) {
+			return i, true
+		}
+	}
+	return 0, false
+}
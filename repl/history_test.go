@@ -0,0 +1,96 @@
+package repl
+
+import (
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestHistory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "flux", "repl_history")
+
+	h, err := loadHistory(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range []string{
+		`x = 1`,
+		`x = 1`,
+		"from(bucket: \"b\")\n    |> range(start: -1h)",
+		``,
+		`y = "a\"b"`,
+	} {
+		if err := h.Add(entry); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	h, err = loadHistory(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		`x = 1`,
+		"from(bucket: \"b\")\n    |> range(start: -1h)",
+		`y = "a\"b"`,
+	}
+	if !cmp.Equal(want, h.entries) {
+		t.Fatalf("unexpected history -want/+got:\n%s", cmp.Diff(want, h.entries))
+	}
+
+	if i, ok := h.Search("range", len(h.entries)); !ok || i != 1 {
+		t.Errorf("unexpected search result %d %v", i, ok)
+	}
+	if i, ok := h.Search("x", 3); !ok || i != 0 {
+		t.Errorf("unexpected search result %d %v", i, ok)
+	}
+	if _, ok := h.Search("z", len(h.entries)); ok {
+		t.Error("expected no search result")
+	}
+}
+
+func TestHistory_Truncate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "repl_history")
+	h := &history{path: path}
+	for i := 0; i < maxHistory+10; i++ {
+		h.entries = append(h.entries, strconv.Itoa(i))
+	}
+	if err := h.rewrite(); err != nil {
+		t.Fatal(err)
+	}
+
+	h, err := loadHistory(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(h.entries) != maxHistory {
+		t.Fatalf("unexpected number of entries %d", len(h.entries))
+	}
+	if h.entries[0] != "10" {
+		t.Errorf("unexpected first entry %q", h.entries[0])
+	}
+}
+
+func TestScanBrackets(t *testing.T) {
+	for _, tc := range []struct {
+		src  string
+		open bool
+	}{
+		{src: `from(bucket: "b")`, open: false},
+		{src: `from(bucket: "b"`, open: true},
+		{src: `x = "(" // (`, open: false},
+		{src: `x = "abc`, open: true},
+		{src: `f = (r) => {`, open: true},
+		{src: `x = "a\"("`, open: false},
+	} {
+		if _, open := scanBrackets(tc.src); open != tc.open {
+			t.Errorf("unexpected open for %s: %v", tc.src, open)
+		}
+	}
+	if code, _ := scanBrackets("x |> // comment\n"); !strings.HasSuffix(strings.TrimSpace(code), "|>") {
+		t.Errorf("comment was not removed: %q", code)
+	}
+}
@@ -0,0 +1,107 @@
+package repl
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/influxdata/flux/ast"
+	"github.com/influxdata/flux/parser"
+)
+
+// continuationPattern matches input that ends with a token
+// that must be followed by more of the statement.
+var continuationPattern = regexp.MustCompile(`(\|>|=>|[-+*/%^=<>,:(\[{]|\b(and|or|not|if|then|else|return))\s*$`)
+
+// incomplete reports whether the input is a statement that continues on
+// the next line. Input that parses is complete. Input that does not parse
+// is incomplete when the parser reported an error at the end of the input,
+// a bracket or string was left open or it ends with an operator such as a
+// trailing |>. Any other invalid input is complete so its errors are reported.
+func incomplete(src string) bool {
+	if strings.TrimSpace(src) == "" {
+		return false
+	}
+	pkg := parser.ParseSource(src)
+	// The errors of the parser are checked before ast.Check
+	// adds the errors that do not depend on the end of the input.
+	if errorAtEnd(pkg, endPosition(src)) {
+		return true
+	}
+	if ast.Check(pkg) == 0 {
+		return false
+	}
+	code, open := scanBrackets(src)
+	return open || continuationPattern.MatchString(code)
+}
+
+// endPosition returns the position after the last
+// character of the input that is not a space.
+func endPosition(src string) ast.Position {
+	src = strings.TrimRightFunc(src, unicode.IsSpace)
+	return ast.Position{
+		Line:   strings.Count(src, "\n") + 1,
+		Column: len(src) - strings.LastIndex(src, "\n"),
+	}
+}
+
+// errorAtEnd reports whether the parser reported an error for a node that
+// extends to the end of the input. Such a node was still being parsed
+// when the parser reached the end of the input. Invalid statements are
+// not complete nodes so their errors are ignored.
+func errorAtEnd(pkg *ast.Package, end ast.Position) bool {
+	found := false
+	ast.Walk(ast.CreateVisitor(func(node ast.Node) {
+		if _, ok := node.(*ast.BadStatement); ok || len(node.Errs()) == 0 {
+			return
+		}
+		if loc := node.Location(); !loc.End.Less(end) {
+			found = true
+		}
+	}), pkg)
+	return found
+}
+
+// scanBrackets returns the input with comments and the contents of strings
+// removed and whether a bracket or string is still open at its end.
+func scanBrackets(src string) (string, bool) {
+	var (
+		code     strings.Builder
+		depth    int
+		inString bool
+	)
+	for i := 0; i < len(src); i++ {
+		c := src[i]
+		if inString {
+			switch c {
+			case '\\':
+				i++
+			case '"':
+				inString = false
+				code.WriteByte(c)
+			}
+			continue
+		}
+		switch c {
+		case '"':
+			inString = true
+		case '/':
+			if i+1 < len(src) && src[i+1] == '/' {
+				// Skip the comment to the end of the line.
+				for i < len(src) && src[i] != '\n' {
+					i++
+				}
+				if i < len(src) {
+					code.WriteByte('\n')
+				}
+				continue
+			}
+		case '(', '[', '{':
+			depth++
+		case ')', ']', '}':
+			depth--
+		}
+		code.WriteByte(c)
+	}
+	return code.String(), inString || depth > 0
+}
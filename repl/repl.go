@@ -8,21 +8,29 @@ import (
 	"os"
 	"os/signal"
 	"path/filepath"
-	"sort"
 	"strings"
 	"sync"
 	"syscall"
+	"time"
 
 	"github.com/c-bata/go-prompt"
 	"github.com/influxdata/flux"
+	"github.com/influxdata/flux/codes"
+	"github.com/influxdata/flux/complete"
+	"github.com/influxdata/flux/csv"
 	"github.com/influxdata/flux/dependency"
 	"github.com/influxdata/flux/execute"
+	"github.com/influxdata/flux/execute/table"
+	"github.com/influxdata/flux/internal/errors"
 	"github.com/influxdata/flux/internal/operation"
 	"github.com/influxdata/flux/internal/spec"
 	"github.com/influxdata/flux/interpreter"
+	"github.com/influxdata/flux/json"
 	"github.com/influxdata/flux/lang"
 	"github.com/influxdata/flux/libflux/go/libflux"
 	"github.com/influxdata/flux/memory"
+	"github.com/influxdata/flux/module"
+	"github.com/influxdata/flux/parser"
 	"github.com/influxdata/flux/plan"
 	"github.com/influxdata/flux/runtime"
 	"github.com/influxdata/flux/semantic"
@@ -37,11 +45,49 @@ type REPL struct {
 	itrp     *interpreter.Interpreter
 	analyzer *libflux.Analyzer
 	importer interpreter.Importer
+	// scratch analyzes the expressions given to :type and the completer
+	// so that their assignments are not bound in the session. It is kept in sync
+	// with the session by analyzing the statements it has not seen.
+	scratch     *libflux.Analyzer
+	scratchSeen int
 
 	cancelMu   sync.Mutex
 	cancelFunc context.CancelFunc
 
 	enableSuggestions bool
+
+	// statements are the inputs that were evaluated in this session.
+	statements []string
+	// lastPlan is the plan of the last query that was run.
+	lastPlan *plan.Spec
+
+	// out is where results and command output are written.
+	out     io.Writer
+	format  string
+	profile bool
+	timing  bool
+
+	historyFile string
+	history     *history
+	// pending is the input of a statement that continues on the next line.
+	pending string
+	// search is the state of the reverse history search.
+	search historySearch
+
+	stdlibPaths []string
+
+	// resolver finds the local packages imported in the session
+	// and sources are the sources of those that were imported.
+	resolver *module.Resolver
+	sources  map[string]string
+}
+
+// historySearch is the state of a reverse search through the history.
+// The search continues from the last match while the buffer still holds it.
+type historySearch struct {
+	query  string
+	index  int
+	result string
 }
 
 type Option interface {
@@ -49,41 +95,66 @@ type Option interface {
 }
 
 func New(ctx context.Context, opts ...Option) *REPL {
+	repl := &REPL{
+		ctx:    ctx,
+		out:    os.Stdout,
+		format: "table",
+	}
+	for _, opt := range opts {
+		opt.applyOption(repl)
+	}
+	if err := repl.reset(); err != nil {
+		panic(err)
+	}
+	return repl
+}
+
+// reset starts a new session with only the prelude in scope.
+func (r *REPL) reset() error {
 	scope := values.NewScope()
 	importer := runtime.StdLib()
+	if r.resolver != nil {
+		importer = runtime.StdLibWithLoader(r.resolver.Loader(r.ctx))
+	}
 	for _, p := range runtime.PreludeList {
 		pkg, err := importer.ImportPackageObject(p)
 		if err != nil {
-			panic(err)
+			return err
 		}
 		pkg.Range(scope.Set)
 	}
 
-	analyzer, err := libflux.NewAnalyzerWithOptions(libflux.NewOptions(ctx))
+	analyzer, err := libflux.NewAnalyzerWithOptions(libflux.NewOptions(r.ctx))
 	if err != nil {
-		panic(err)
+		return err
 	}
-
-	repl := &REPL{
-		ctx:      ctx,
-		scope:    scope,
-		itrp:     interpreter.NewInterpreter(nil, &lang.ExecOptsConfig{}),
-		analyzer: analyzer,
-		importer: importer,
+	if r.analyzer != nil {
+		r.analyzer.Free()
 	}
-	for _, opt := range opts {
-		opt.applyOption(repl)
+	if r.scratch != nil {
+		r.scratch.Free()
 	}
-	return repl
+
+	r.scope = scope
+	r.itrp = interpreter.NewInterpreter(nil, &lang.ExecOptsConfig{})
+	r.analyzer = analyzer
+	r.scratch = nil
+	r.scratchSeen = 0
+	r.importer = importer
+	r.sources = nil
+	r.statements = nil
+	r.lastPlan = nil
+	return nil
 }
 
 func (r *REPL) Run() {
-	p := prompt.New(
-		r.input,
-		r.completer,
-		prompt.OptionPrefix("> "),
-		prompt.OptionTitle("flux"),
-	)
+	h, err := loadHistory(r.historyFile)
+	if err != nil {
+		fmt.Println("Error: failed to load history:", err)
+		h = &history{}
+	}
+	r.history = h
+
 	sigs := make(chan os.Signal, 1)
 	signal.Notify(sigs, syscall.SIGINT)
 	go func() {
@@ -91,7 +162,67 @@ func (r *REPL) Run() {
 			r.cancel()
 		}
 	}()
-	p.Run()
+	for {
+		line, ok := r.readLine()
+		if !ok {
+			return
+		}
+		r.input(line)
+	}
+}
+
+// readLine reads a line of input and reports false when the input
+// ends. Each line is read by a new prompt with the history of the
+// session so that a statement that spans multiple lines is recalled
+// as a single entry, like it is kept in the history file.
+func (r *REPL) readLine() (string, bool) {
+	// The prompt returns an empty line both when enter is pressed
+	// and when the input ends, so enter is recorded by a key binding.
+	entered := false
+	enter := func(*prompt.Buffer) {
+		entered = true
+	}
+	line := prompt.Input(r.prefix(), r.completer,
+		prompt.OptionTitle("flux"),
+		prompt.OptionCompletionWordSeparator(completionWordSeparator),
+		prompt.OptionHistory(append([]string(nil), r.history.entries...)),
+		prompt.OptionAddKeyBind(
+			prompt.KeyBind{Key: prompt.ControlR, Fn: r.reverseSearch},
+			prompt.KeyBind{Key: prompt.Enter, Fn: enter},
+			prompt.KeyBind{Key: prompt.ControlJ, Fn: enter},
+			prompt.KeyBind{Key: prompt.ControlM, Fn: enter},
+		),
+	)
+	return line, line != "" || entered
+}
+
+// prefix returns the prompt, which changes while
+// a statement continues on the next line.
+func (r *REPL) prefix() string {
+	if r.pending != "" {
+		return ". "
+	}
+	return "> "
+}
+
+// reverseSearch replaces the buffer with the newest history entry that
+// contains the text of the buffer. Repeating the search while the buffer
+// holds the match continues with older entries.
+func (r *REPL) reverseSearch(buf *prompt.Buffer) {
+	text := buf.Text()
+	if r.search.result == "" || text != r.search.result {
+		r.search = historySearch{query: text, index: len(r.history.entries)}
+	}
+	i, ok := r.history.Search(r.search.query, r.search.index)
+	if !ok {
+		return
+	}
+	r.search.index = i
+	r.search.result = r.history.entries[i]
+
+	buf.CursorRight(len([]rune(text)))
+	buf.DeleteBeforeCursor(len([]rune(text)))
+	buf.InsertText(r.search.result, false, true)
 }
 
 func (r *REPL) cancel() {
@@ -114,18 +245,15 @@ func (r *REPL) clearCancel() {
 
 func (r *REPL) completer(d prompt.Document) []prompt.Suggest {
 	if r.enableSuggestions {
-		names := make([]string, 0, r.scope.Size())
-		r.scope.Range(func(k string, v values.Value) {
-			names = append(names, k)
-		})
-		sort.Strings(names)
-
-		s := make([]prompt.Suggest, 0, len(names))
-		for _, n := range names {
-			if n == "_" || !strings.HasPrefix(n, "_") {
-				s = append(s, prompt.Suggest{Text: n})
+		if strings.HasPrefix(d.Text, commandPrefix) && !strings.Contains(d.Text, " ") {
+			s := make([]prompt.Suggest, 0, len(commandNames))
+			for _, name := range commandNames {
+				s = append(s, prompt.Suggest{Text: commandPrefix + name})
 			}
+			return prompt.FilterHasPrefix(s, d.Text, true)
 		}
+
+		var s []prompt.Suggest
 		if d.Text == "" || strings.HasPrefix(d.Text, "@") {
 			root := "./" + strings.TrimPrefix(d.Text, "@")
 			fluxFiles, err := getFluxFiles(root)
@@ -140,12 +268,52 @@ func (r *REPL) completer(d prompt.Document) []prompt.Suggest {
 					s = append(s, prompt.Suggest{Text: "@" + fName + string(os.PathSeparator)})
 				}
 			}
+			if d.Text != "" {
+				return prompt.FilterHasPrefix(s, d.Text, true)
+			}
 		}
 
-		return prompt.FilterHasPrefix(s, d.GetWordBeforeCursor(), true)
+		// Complete the statement that continues on this line.
+		text := d.TextBeforeCursor()
+		if r.pending != "" {
+			text = r.pending + "\n" + text
+		}
+		c := complete.NewCompleter(r.scope)
+		c.TypeOf = r.typeOf
+		c.ImportPaths = r.importPaths()
+		for _, sug := range c.Complete(text) {
+			s = append(s, prompt.Suggest{Text: sug.Text, Description: sug.Description})
+		}
+		return s
 	}
 	return nil
+}
 
+// completionWordSeparator are the characters that end the word
+// that is replaced by a completion.
+const completionWordSeparator = " \t\n.,:()[]{}|>=\"+-*<!"
+
+// importPaths returns the paths of the packages in the standard library.
+func (r *REPL) importPaths() []string {
+	if r.stdlibPaths == nil {
+		r.stdlibPaths = runtime.StdlibPackages()
+	}
+	return r.stdlibPaths
+}
+
+// typeOf returns the type of the expression in the scope of the session.
+// It is called by the completer while the input is typed so the expression
+// is analyzed by the scratch analyzer and the session is not modified.
+func (r *REPL) typeOf(expr string) (semantic.MonoType, error) {
+	analyzer, err := r.scratchAnalyzer()
+	if err != nil {
+		return semantic.MonoType{}, err
+	}
+	a, _, err := r.typeAssignment(analyzer, expr)
+	if err != nil {
+		return semantic.MonoType{}, err
+	}
+	return a.Init.TypeOf(), nil
 }
 
 func (r *REPL) Input(t string) (*libflux.FluxError, error) {
@@ -154,6 +322,27 @@ func (r *REPL) Input(t string) (*libflux.FluxError, error) {
 
 // input processes a line of input and prints the result.
 func (r *REPL) input(t string) {
+	// Input that is syntactically incomplete continues on the next
+	// line. An empty line ends the statement even if it is incomplete.
+	end := false
+	if r.pending != "" {
+		if strings.TrimSpace(t) == "" {
+			t, end = r.pending, true
+		} else {
+			t = r.pending + "\n" + t
+		}
+		r.pending = ""
+	}
+	if !end && !strings.HasPrefix(strings.TrimSpace(t), commandPrefix) && incomplete(t) {
+		r.pending = t
+		return
+	}
+	if r.history != nil {
+		if err := r.history.Add(t); err != nil {
+			fmt.Println("Error: failed to save history:", err)
+		}
+	}
+
 	// Create a root span
 	span := opentracing.StartSpan("REPL.input")
 	r.ctx = opentracing.ContextWithSpan(r.ctx, span)
@@ -186,6 +375,9 @@ func (r *REPL) evalWithFluxError(t string) ([]interpreter.SideEffect, *libflux.F
 		t = q
 	}
 
+	if err := r.addSources(t); err != nil {
+		return nil, nil, err
+	}
 	pkg, fluxError, err := r.analyzeLine(t)
 	if err != nil {
 		return nil, fluxError, err
@@ -195,12 +387,26 @@ func (r *REPL) evalWithFluxError(t string) ([]interpreter.SideEffect, *libflux.F
 	defer span.Finish()
 
 	x, err := r.itrp.Eval(ctx, pkg, r.scope, r.importer)
+	if err == nil {
+		r.statements = append(r.statements, t)
+	}
 	return x, nil, err
 }
 
 // executeLine processes a line of input.
 // If the input evaluates to a valid value, that value is returned.
+// Lines that start with a colon are REPL commands.
 func (r *REPL) executeLine(t string) (*libflux.FluxError, error) {
+	if strings.HasPrefix(strings.TrimSpace(t), commandPrefix) {
+		return r.executeCommand(strings.TrimSpace(t))
+	}
+	if r.timing {
+		start := time.Now()
+		defer func() {
+			fmt.Fprintln(r.out, "Time:", time.Since(start))
+		}()
+	}
+
 	ses, fluxError, err := r.evalWithFluxError(t)
 	if err != nil {
 		return fluxError, err
@@ -224,9 +430,8 @@ func (r *REPL) executeLine(t string) (*libflux.FluxError, error) {
 				if err := r.doQuery(r.ctx, s); err != nil {
 					return nil, err
 				}
-			} else {
-				values.Display(os.Stdout, se.Value)
-				fmt.Println()
+			} else if err := r.writeValue(se.Value); err != nil {
+				return nil, err
 			}
 		}
 	}
@@ -234,7 +439,42 @@ func (r *REPL) executeLine(t string) (*libflux.FluxError, error) {
 }
 
 func (r *REPL) analyzeLine(t string) (*semantic.Package, *libflux.FluxError, error) {
-	pkg, fluxError := r.analyzer.AnalyzeString(t)
+	return analyze(r.analyzer, t)
+}
+
+// addSources gives the analyzers the sources of the local
+// packages imported by the input that they do not have yet.
+func (r *REPL) addSources(t string) error {
+	if r.resolver == nil {
+		return nil
+	}
+	sources, err := r.resolver.Sources(parser.ParseSource(t))
+	if err != nil {
+		return err
+	}
+	for path, src := range sources {
+		if _, ok := r.sources[path]; ok {
+			continue
+		}
+		for _, analyzer := range []*libflux.Analyzer{r.analyzer, r.scratch} {
+			if analyzer == nil {
+				continue
+			}
+			if err := analyzer.SetSource(path, src); err != nil {
+				return err
+			}
+		}
+		if r.sources == nil {
+			r.sources = make(map[string]string)
+		}
+		r.sources[path] = src
+	}
+	return nil
+}
+
+// analyze analyzes the source with the analyzer and returns its semantic graph.
+func analyze(analyzer *libflux.Analyzer, t string) (*semantic.Package, *libflux.FluxError, error) {
+	pkg, fluxError := analyzer.AnalyzeString(t)
 	if fluxError != nil {
 		return nil, fluxError, fluxError.GoError()
 	}
@@ -247,6 +487,31 @@ func (r *REPL) analyzeLine(t string) (*semantic.Package, *libflux.FluxError, err
 	return x, nil, err
 }
 
+// scratchAnalyzer returns an analyzer with the statements of the session
+// in scope whose bindings are discarded with it.
+func (r *REPL) scratchAnalyzer() (*libflux.Analyzer, error) {
+	if r.scratch == nil {
+		analyzer, err := libflux.NewAnalyzerWithOptions(libflux.NewOptions(r.ctx))
+		if err != nil {
+			return nil, err
+		}
+		for path, src := range r.sources {
+			if err := analyzer.SetSource(path, src); err != nil {
+				return nil, err
+			}
+		}
+		r.scratch = analyzer
+		r.scratchSeen = 0
+	}
+	// The statements were analyzed by the session
+	// so they are analyzed again without errors.
+	for _, stmt := range r.statements[r.scratchSeen:] {
+		r.scratch.AnalyzeString(stmt)
+	}
+	r.scratchSeen = len(r.statements)
+	return r.scratch, nil
+}
+
 func (r *REPL) doQuery(ctx context.Context, spec *operation.Spec) error {
 	// Setup cancel context
 	nextPlanNodeID := new(int)
@@ -257,6 +522,19 @@ func (r *REPL) doQuery(ctx context.Context, spec *operation.Spec) error {
 	defer cancelFunc()
 	defer r.clearCancel()
 
+	alloc := &memory.ResourceAllocator{}
+	var profilers []execute.Profiler
+	if r.profile {
+		deps := execute.NewExecutionDependencies(alloc, nil, nil)
+		var span *dependency.Span
+		ctx, span = dependency.Inject(ctx, deps)
+		defer span.Finish()
+
+		var eoc lang.ExecOptsConfig
+		eoc.ConfigureProfiler(ctx, []string{"query", "operator"})
+		profilers = deps.ExecutionOptions.Profilers
+	}
+
 	c := Compiler{
 		Spec: spec,
 	}
@@ -265,7 +543,7 @@ func (r *REPL) doQuery(ctx context.Context, spec *operation.Spec) error {
 	if err != nil {
 		return err
 	}
-	alloc := &memory.ResourceAllocator{}
+	r.lastPlan = program.(*lang.Program).PlanSpec
 
 	qry, err := program.Start(ctx, alloc)
 	if err != nil {
@@ -273,18 +551,72 @@ func (r *REPL) doQuery(ctx context.Context, spec *operation.Spec) error {
 	}
 	defer qry.Done()
 
-	for result := range qry.Results() {
-		tables := result.Tables()
-		fmt.Println("Result:", result.Name())
-		if err := tables.Do(func(tbl flux.Table) error {
-			_, err := execute.NewFormatter(tbl, nil).WriteTo(os.Stdout)
+	if err := r.writeResults(flux.NewResultIteratorFromQuery(qry)); err != nil {
+		return err
+	}
+	qry.Done()
+	if err := qry.Err(); err != nil {
+		return err
+	}
+	if len(profilers) > 0 {
+		return r.writeProfilerResults(qry, profilers, alloc)
+	}
+	return nil
+}
+
+// writeValue writes a value that is not a table stream.
+// The markdown format writes it as a code block.
+func (r *REPL) writeValue(v values.Value) error {
+	if r.format == "markdown" {
+		_, err := fmt.Fprintf(r.out, "```\n%s\n```\n", values.DisplayString(v))
+		return err
+	}
+	if err := values.Display(r.out, v); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintln(r.out)
+	return err
+}
+
+// writeResults writes the results in the output format of the session.
+func (r *REPL) writeResults(results flux.ResultIterator) error {
+	switch r.format {
+	case "csv":
+		_, err := csv.NewMultiResultEncoder(csv.DefaultEncoderConfig()).Encode(r.out, results)
+		return err
+	case "json":
+		_, err := json.NewMultiResultEncoder().Encode(r.out, results)
+		return err
+	}
+	opts := execute.DefaultFormatOptions()
+	opts.Markdown = r.format == "markdown"
+	for results.More() {
+		result := results.Next()
+		fmt.Fprintln(r.out, "Result:", result.Name())
+		if err := result.Tables().Do(func(tbl flux.Table) error {
+			if opts.Markdown {
+				fmt.Fprintln(r.out)
+			}
+			_, err := execute.NewFormatter(tbl, opts).WriteTo(r.out)
 			return err
 		}); err != nil {
 			return err
 		}
 	}
-	qry.Done()
-	return qry.Err()
+	return results.Err()
+}
+
+func (r *REPL) writeProfilerResults(q flux.Query, profilers []execute.Profiler, alloc memory.Allocator) error {
+	tables := make([]flux.Table, 0, len(profilers))
+	for _, p := range profilers {
+		tbl, err := p.GetResult(q, alloc)
+		if err != nil {
+			return err
+		}
+		tables = append(tables, tbl)
+	}
+	result := table.NewProfilerResult(tables...)
+	return r.writeResults(flux.NewSliceResultIterator([]flux.Result{&result}))
 }
 
 func getFluxFiles(path string) ([]string, error) {
@@ -337,8 +669,160 @@ func (o option) applyOption(r *REPL) {
 	o(r)
 }
 
+// HistoryFile sets the file the input history is kept in across
+// sessions. The history is not saved when the path is empty.
+func HistoryFile(path string) Option {
+	return option(func(r *REPL) {
+		r.historyFile = path
+	})
+}
+
+// Output sets where query results, values and command output are
+// written. The default is standard output.
+func Output(w io.Writer) Option {
+	return option(func(r *REPL) {
+		r.out = w
+	})
+}
+
+// Format sets the output format of query results, one of table,
+// csv, json or markdown. The default is table.
+func Format(format string) Option {
+	return option(func(r *REPL) {
+		r.format = format
+	})
+}
+
+// Resolver sets the resolver of the local packages
+// that can be imported in the session.
+func Resolver(resolver *module.Resolver) Option {
+	return option(func(r *REPL) {
+		r.resolver = resolver
+	})
+}
+
 func EnableSuggestions() Option {
 	return option(func(r *REPL) {
 		r.enableSuggestions = true
 	})
 }
+
+// commandPrefix starts a line that is a REPL command instead of Flux.
+const commandPrefix = ":"
+
+// typeIdentifier is the variable the expression given to :type is
+// assigned to so the analyzer infers its polytype.
+const typeIdentifier = "_type"
+
+// commandNames are the names of the REPL commands.
+var commandNames = []string{"type", "plan", "load", "save", "reset", "format", "profile", "time", "help"}
+
+const commandHelp = `:type <expr>              print the inferred type of an expression
+:plan                     print the plan of the last query
+:load <file.flux>         evaluate a Flux file in the session
+:save <file.flux>         save the statements of the session to a file
+:reset                    clear the session
+:format <format>          set the output format of query results: table,csv,json,markdown
+:profile on|off           print the query and operator profiles after each query
+:time on|off              print the time taken by each input
+:help                     print this help
+`
+
+// executeCommand runs a REPL command.
+func (r *REPL) executeCommand(line string) (*libflux.FluxError, error) {
+	name, arg, _ := strings.Cut(strings.TrimPrefix(line, commandPrefix), " ")
+	arg = strings.TrimSpace(arg)
+
+	var err error
+	switch name {
+	case "type":
+		return r.typeCommand(arg)
+	case "plan":
+		if r.lastPlan == nil {
+			return nil, errors.New(codes.FailedPrecondition, "no query has been run")
+		}
+		fmt.Fprintln(r.out, plan.Formatted(r.lastPlan, plan.WithDetails()))
+	case "load":
+		if arg == "" {
+			return nil, errors.New(codes.Invalid, "usage: :load <file.flux>")
+		}
+		data, err := os.ReadFile(arg)
+		if err != nil {
+			return nil, err
+		}
+		return r.executeLine(string(data))
+	case "save":
+		if arg == "" {
+			return nil, errors.New(codes.Invalid, "usage: :save <file.flux>")
+		}
+		src := strings.Join(r.statements, "\n")
+		if src != "" {
+			src += "\n"
+		}
+		err = os.WriteFile(arg, []byte(src), 0644)
+	case "reset":
+		err = r.reset()
+	case "format":
+		switch arg {
+		case "table", "csv", "json", "markdown":
+			r.format = arg
+		default:
+			err = errors.Newf(codes.Invalid, "unknown format %q, must be one of: table,csv,json,markdown", arg)
+		}
+	case "profile":
+		r.profile, err = parseSwitch(name, arg)
+	case "time":
+		r.timing, err = parseSwitch(name, arg)
+	case "help":
+		fmt.Fprint(r.out, commandHelp)
+	default:
+		err = errors.Newf(codes.Invalid, "unknown command %s%s, use :help to list the commands", commandPrefix, name)
+	}
+	return nil, err
+}
+
+// typeCommand prints the polytype of the expression in the scope of the session.
+// The expression is analyzed by the scratch analyzer and is not evaluated.
+func (r *REPL) typeCommand(expr string) (*libflux.FluxError, error) {
+	if expr == "" {
+		return nil, errors.New(codes.Invalid, "usage: :type <expr>")
+	}
+	analyzer, err := r.scratchAnalyzer()
+	if err != nil {
+		return nil, err
+	}
+	a, fluxError, err := r.typeAssignment(analyzer, expr)
+	if err != nil {
+		return fluxError, err
+	}
+	fmt.Fprintln(r.out, a.Typ.CanonicalString())
+	return nil, nil
+}
+
+// typeAssignment analyzes the assignment of the expression to a variable
+// so the analyzer infers its type. The expression is not evaluated.
+func (r *REPL) typeAssignment(analyzer *libflux.Analyzer, expr string) (*semantic.NativeVariableAssignment, *libflux.FluxError, error) {
+	pkg, fluxError, err := analyze(analyzer, typeIdentifier+" = "+expr)
+	if err != nil {
+		return nil, fluxError, err
+	}
+	for _, file := range pkg.Files {
+		for _, stmt := range file.Body {
+			if a, ok := stmt.(*semantic.NativeVariableAssignment); ok && a.Identifier.Name.LocalName == typeIdentifier {
+				return a, nil, nil
+			}
+		}
+	}
+	return nil, nil, errors.New(codes.Internal, "expression has no type")
+}
+
+func parseSwitch(name, arg string) (bool, error) {
+	switch arg {
+	case "on":
+		return true, nil
+	case "off":
+		return false, nil
+	default:
+		return false, errors.Newf(codes.Invalid, "usage: %s%s on|off", commandPrefix, name)
+	}
+}
@@ -0,0 +1,189 @@
+package repl
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/influxdata/flux/fluxinit"
+	"github.com/influxdata/flux/module"
+)
+
+func TestMain(m *testing.M) {
+	fluxinit.FluxInit()
+	os.Exit(m.Run())
+}
+
+func TestCommands(t *testing.T) {
+	dir := t.TempDir()
+	script := filepath.Join(dir, "script.flux")
+	if err := os.WriteFile(script, []byte("a = 1\nb = a + 1\nb\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	saved := filepath.Join(dir, "saved.flux")
+
+	for _, tc := range []struct {
+		name   string
+		inputs []string
+		want   string
+		// wantErr is contained in the error of the last input.
+		wantErr string
+		// wantFile is the content of the saved file.
+		wantFile string
+	}{
+		{
+			name:   "type",
+			inputs: []string{"x = 1", ":type x + 1"},
+			want:   "int\n",
+		},
+		{
+			name:   "type of function",
+			inputs: []string{":type (a) => a"},
+			want:   "(a: A) => A\n",
+		},
+		{
+			name:    "type is not bound",
+			inputs:  []string{":type 1", "_type"},
+			want:    "int\n",
+			wantErr: "undefined identifier _type",
+		},
+		{
+			name:    "type without expression",
+			inputs:  []string{":type"},
+			wantErr: "usage: :type <expr>",
+		},
+		{
+			name:   "load",
+			inputs: []string{":load " + script, "a"},
+			want:   "2\n1\n",
+		},
+		{
+			name:    "load missing file",
+			inputs:  []string{":load " + filepath.Join(dir, "missing.flux")},
+			wantErr: "open " + filepath.Join(dir, "missing.flux") + ": no such file or directory",
+		},
+		{
+			name:     "save",
+			inputs:   []string{"x = 1", "x + 1", ":type x", ":save " + saved},
+			want:     "2\nint\n",
+			wantFile: "x = 1\nx + 1\n",
+		},
+		{
+			name:    "save without file",
+			inputs:  []string{":save"},
+			wantErr: "usage: :save <file.flux>",
+		},
+		{
+			name:   "format",
+			inputs: []string{":format markdown", "1"},
+			want:   "```\n1\n```\n",
+		},
+		{
+			name:    "unknown format",
+			inputs:  []string{":format xml"},
+			wantErr: `unknown format "xml", must be one of: table,csv,json,markdown`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var out strings.Builder
+			r := New(context.Background(), Output(&out))
+			var err error
+			for _, input := range tc.inputs {
+				if _, err = r.Input(input); err != nil && input != tc.inputs[len(tc.inputs)-1] {
+					t.Fatalf("%q: %s", input, err)
+				}
+			}
+			if tc.wantErr == "" && err != nil {
+				t.Fatal(err)
+			} else if tc.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tc.wantErr)) {
+				t.Fatalf("unexpected error got %v want %q", err, tc.wantErr)
+			}
+			if got := out.String(); got != tc.want {
+				t.Errorf("unexpected output got %q want %q", got, tc.want)
+			}
+			if tc.wantFile != "" {
+				data, err := os.ReadFile(saved)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got := string(data); got != tc.wantFile {
+					t.Errorf("unexpected saved file got %q want %q", got, tc.wantFile)
+				}
+			}
+		})
+	}
+}
+
+func TestLocalPackages(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "math"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	src := "package math\n\noption factor = 2\n\ndouble = (x) => x * factor\n"
+	if err := os.WriteFile(filepath.Join(dir, "math", "math.flux"), []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+	resolver := module.NewResolver(&module.ModFile{Dir: dir, Module: "mycompany"}, nil)
+
+	var out strings.Builder
+	r := New(context.Background(), Output(&out), Resolver(resolver))
+	for _, input := range []string{
+		`import "mycompany/math"`,
+		"math.double(x: 2)",
+		":type math.double",
+		"option math.factor = 3",
+		"math.double(x: 2)",
+	} {
+		if _, err := r.Input(input); err != nil {
+			t.Fatalf("%q: %s", input, err)
+		}
+	}
+	if got, want := out.String(), "4\n(x: int) => int\n6\n"; got != want {
+		t.Errorf("unexpected output got %q want %q", got, want)
+	}
+}
+
+func TestIncomplete(t *testing.T) {
+	for _, tc := range []struct {
+		src  string
+		want bool
+	}{
+		{src: `x = 1`, want: false},
+		{src: `x =`, want: true},
+		{src: `from(bucket: "a")`, want: false},
+		{src: "from(bucket: \"a\")\n    |> range(", want: true},
+		{src: `f = (x) =>`, want: true},
+		{src: `x = {a: 1,`, want: true},
+		{src: `x = "abc`, want: true},
+		// Invalid input that does not continue is reported.
+		{src: `f(a: 1 b: 2)`, want: false},
+		{src: `x = 1 $`, want: false},
+		{src: ``, want: false},
+	} {
+		if got := incomplete(tc.src); got != tc.want {
+			t.Errorf("%q: got %v want %v", tc.src, got, tc.want)
+		}
+	}
+}
+
+func TestTypeOf(t *testing.T) {
+	r := New(context.Background(), Output(io.Discard))
+	if _, err := r.Eval(`x = {a: 1, b: "b"}`); err != nil {
+		t.Fatal(err)
+	}
+	typ, err := r.typeOf("x")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := typ.CanonicalString(), "{a: int, b: string}"; got != want {
+		t.Errorf("unexpected type got %s want %s", got, want)
+	}
+
+	// The completer does not bind the expression in the session.
+	if _, err := r.Eval(typeIdentifier); err == nil {
+		t.Errorf("expected %s to be undefined", typeIdentifier)
+	}
+}
@@ -16,10 +16,16 @@ func AnalyzeSource(ctx context.Context, fluxSrc string) (*semantic.Package, erro
 }
 
 func AnalyzePackage(ctx context.Context, astPkg flux.ASTHandle) (*semantic.Package, error) {
+	return AnalyzePackageWithOptions(astPkg, libflux.NewOptions(ctx))
+}
+
+// AnalyzePackageWithOptions analyzes the package using libflux with the
+// options. The options may give the sources of the packages it imports
+// that are not part of the standard library.
+func AnalyzePackageWithOptions(astPkg flux.ASTHandle, options libflux.Options) (*semantic.Package, error) {
 	hdl := astPkg.(*libflux.ASTPkg)
 	defer hdl.Free()
 
-	options := libflux.NewOptions(ctx)
 	sem, err := libflux.AnalyzeWithOptions(hdl, options)
 	if err != nil {
 		return nil, err
@@ -0,0 +1,174 @@
+package runtime
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	goruntime "runtime"
+	"runtime/debug"
+	"sort"
+	"strconv"
+)
+
+// StdlibCacheEnv is the environment variable with the directory of the
+// standard library cache. The cache is disabled when it is set to off.
+const StdlibCacheEnv = "FLUX_STDLIB_CACHE"
+
+const (
+	stdlibCacheFile = "stdlib.cache"
+	// stdlibCacheMagic starts every cache file and is changed
+	// whenever the layout of the file changes.
+	stdlibCacheMagic = "FLUXSTD2"
+)
+
+// SetStdlibCacheDir enables the on-disk cache of the standard library
+// in the directory. It must be called before FinalizeBuiltIns. The cache
+// holds the serialized semantic packages and the names of their builtin
+// statements so later processes do not need to compile the packages again
+// to check them against the registered builtins.
+// An empty directory uses the directory of $FLUX_STDLIB_CACHE.
+func SetStdlibCacheDir(dir string) {
+	Default.cacheDir = dir
+}
+
+// stdlibCacheKey identifies the standard library and the builtins
+// registered with it. The packages of a cache with a different key
+// are stale.
+func (r *runtime) stdlibCacheKey() []byte {
+	h := sha256.New()
+	write := func(s string) {
+		h.Write([]byte(s))
+		h.Write([]byte{0})
+	}
+	write(stdlibCacheMagic)
+	write(goruntime.Version())
+
+	// Released versions of flux identify the standard library.
+	// Development builds are identified by their executable.
+	version := "(devel)"
+	if info, ok := debug.ReadBuildInfo(); ok {
+		if info.Main.Path == "github.com/influxdata/flux" {
+			version = info.Main.Version
+		}
+		for _, dep := range info.Deps {
+			if dep.Path == "github.com/influxdata/flux" {
+				version = dep.Version
+				if dep.Replace != nil {
+					version = "(devel)"
+				}
+			}
+		}
+	}
+	write(version)
+	if version == "(devel)" || version == "" {
+		if exe, err := os.Executable(); err == nil {
+			if fi, err := os.Stat(exe); err == nil {
+				write(exe)
+				write(strconv.FormatInt(fi.Size(), 10))
+				write(strconv.FormatInt(fi.ModTime().UnixNano(), 10))
+			}
+		}
+	}
+
+	builtins := make([]string, 0, len(r.builtins))
+	for pkgpath, pkg := range r.builtins {
+		for name := range pkg {
+			builtins = append(builtins, pkgpath+"."+name)
+		}
+	}
+	sort.Strings(builtins)
+	for _, b := range builtins {
+		write(b)
+	}
+	return h.Sum(nil)
+}
+
+// loadStdlibCache returns the packages in the cache directory and the
+// names of the builtin statements of each package. It returns false if
+// the cache does not exist, is stale or is corrupt so the caller falls
+// back to the packages compiled into libflux.
+func loadStdlibCache(dir string, key []byte) (*stdlibPackages, map[string][]string, bool) {
+	payload, ok := readStdlibCache(dir, key)
+	if !ok || len(payload) < 8 {
+		return nil, nil, false
+	}
+	n := binary.BigEndian.Uint64(payload)
+	if n > uint64(len(payload)-8) {
+		return nil, nil, false
+	}
+	var builtins map[string][]string
+	if err := json.Unmarshal(payload[8:8+n], &builtins); err != nil {
+		return nil, nil, false
+	}
+	pkgs, err := newStdlibPackages(payload[8+n:])
+	if err != nil {
+		return nil, nil, false
+	}
+	for path := range builtins {
+		if !pkgs.has(path) {
+			return nil, nil, false
+		}
+	}
+	return pkgs, builtins, true
+}
+
+// readStdlibCache returns the payload of the cache file in the
+// directory. It returns false if the cache does not exist, is
+// stale or is corrupt.
+func readStdlibCache(dir string, key []byte) ([]byte, bool) {
+	data, err := os.ReadFile(filepath.Join(dir, stdlibCacheFile))
+	if err != nil {
+		return nil, false
+	}
+	header := len(stdlibCacheMagic) + 2*sha256.Size
+	if len(data) < header || string(data[:len(stdlibCacheMagic)]) != stdlibCacheMagic {
+		return nil, false
+	}
+	data = data[len(stdlibCacheMagic):]
+	if !bytes.Equal(data[:sha256.Size], key) {
+		return nil, false
+	}
+	sum, payload := data[sha256.Size:2*sha256.Size], data[2*sha256.Size:]
+	if actual := sha256.Sum256(payload); !bytes.Equal(sum, actual[:]) {
+		return nil, false
+	}
+	return payload, true
+}
+
+// writeStdlibCache writes the builtin statement names and the serialized
+// packages to the cache directory. The file is renamed into place so
+// concurrent processes never read a partially written cache.
+func writeStdlibCache(dir string, key []byte, builtins map[string][]string, data []byte) error {
+	names, err := json.Marshal(builtins)
+	if err != nil {
+		return err
+	}
+	payload := make([]byte, 8, 8+len(names)+len(data))
+	binary.BigEndian.PutUint64(payload, uint64(len(names)))
+	payload = append(payload, names...)
+	payload = append(payload, data...)
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(dir, stdlibCacheFile+".*")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(f.Name()) }()
+
+	sum := sha256.Sum256(payload)
+	for _, b := range [][]byte{[]byte(stdlibCacheMagic), key, sum[:], payload} {
+		if _, err := f.Write(b); err != nil {
+			_ = f.Close()
+			return err
+		}
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), filepath.Join(dir, stdlibCacheFile))
+}
@@ -0,0 +1,105 @@
+package runtime
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/influxdata/flux/libflux/go/libflux"
+	"github.com/influxdata/flux/values"
+)
+
+func TestStdlibCache(t *testing.T) {
+	dir := t.TempDir()
+	key := bytes.Repeat([]byte{1}, 32)
+	builtins := map[string][]string{"strings": {"title", "toUpper"}}
+	data := []byte("semantic packages")
+
+	if _, ok := readStdlibCache(dir, key); ok {
+		t.Fatal("expected a miss for a missing cache")
+	}
+	if err := writeStdlibCache(dir, key, builtins, data); err != nil {
+		t.Fatal(err)
+	}
+	if got, ok := readStdlibCache(dir, key); !ok || !bytes.HasSuffix(got, data) {
+		t.Fatalf("unexpected cache hit %v with payload %q", ok, got)
+	}
+
+	// A different key is a stale cache.
+	if _, ok := readStdlibCache(dir, bytes.Repeat([]byte{2}, 32)); ok {
+		t.Error("expected a miss for a stale cache")
+	}
+
+	// A corrupt or truncated file is ignored.
+	file := filepath.Join(dir, stdlibCacheFile)
+	contents, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	contents[len(contents)-1] ^= 0xff
+	if err := os.WriteFile(file, contents, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := readStdlibCache(dir, key); ok {
+		t.Error("expected a miss for a corrupt cache")
+	}
+	if err := os.WriteFile(file, contents[:10], 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := readStdlibCache(dir, key); ok {
+		t.Error("expected a miss for a truncated cache")
+	}
+}
+
+func TestLoadStdlibCache(t *testing.T) {
+	dir := t.TempDir()
+	key := bytes.Repeat([]byte{1}, 32)
+	builtins := map[string][]string{"strings": {"title", "toUpper"}}
+
+	// Packages that cannot be decoded fall back to libflux.
+	if err := writeStdlibCache(dir, key, builtins, []byte{0xff, 0xff}); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, ok := loadStdlibCache(dir, key); ok {
+		t.Error("expected a miss for invalid packages")
+	}
+
+	if err := writeStdlibCache(dir, key, builtins, libflux.SemanticPackagesData()); err != nil {
+		t.Fatal(err)
+	}
+	pkgs, got, ok := loadStdlibCache(dir, key)
+	if !ok {
+		t.Fatal("expected a cache hit")
+	}
+	if !cmp.Equal(builtins, got) {
+		t.Errorf("unexpected builtins -want/+got:\n%s", cmp.Diff(builtins, got))
+	}
+	if !pkgs.has("strings") {
+		t.Error("expected the cached packages to contain strings")
+	}
+
+	// Builtins of a package that is not in the cached packages fall back to libflux.
+	builtins["missing/package"] = []string{"value"}
+	if err := writeStdlibCache(dir, key, builtins, libflux.SemanticPackagesData()); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, ok := loadStdlibCache(dir, key); ok {
+		t.Error("expected a miss for a missing package")
+	}
+}
+
+func TestStdlibCacheKey(t *testing.T) {
+	r := &runtime{builtins: map[string]map[string]values.Value{
+		"strings": {"title": values.NewString("")},
+	}}
+	key := r.stdlibCacheKey()
+	if !bytes.Equal(key, r.stdlibCacheKey()) {
+		t.Error("expected the key to be stable")
+	}
+	r.builtins["strings"]["toUpper"] = values.NewString("")
+	if bytes.Equal(key, r.stdlibCacheKey()) {
+		t.Error("expected the key to change with the builtins")
+	}
+}
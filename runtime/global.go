@@ -30,6 +30,12 @@ func StdLib() interpreter.Importer {
 	return Default.Stdlib()
 }
 
+// StdLibWithLoader returns an importer for the Flux standard library
+// that imports the packages that are not part of it with the loader.
+func StdLibWithLoader(loader PackageLoader) interpreter.Importer {
+	return Default.StdlibWithLoader(loader)
+}
+
 // Prelude returns a scope object representing the Flux universe block
 func Prelude() values.Scope {
 	return Default.Prelude()
@@ -90,6 +96,12 @@ func options(astPkg *ast.Package) *ast.Package {
 	return trimmed
 }
 
+// StdlibPackageExists reports whether the import path is a package of
+// the standard library. It is always false before FinalizeBuiltIns.
+func StdlibPackageExists(pkgpath string) bool {
+	return Default.pkgs != nil && Default.pkgs.has(pkgpath)
+}
+
 // FinalizeBuiltIns must be called to complete registration.
 // Future calls to RegisterFunction or RegisterPackageValue will panic.
 func FinalizeBuiltIns() {
@@ -21,9 +21,19 @@ var (
 	}
 )
 
+// PackageLoader loads the packages that are not part of the standard library.
+type PackageLoader interface {
+	// LoadPackage returns the analyzed package with the import path.
+	// It reports false if the loader does not have the package.
+	LoadPackage(pkgpath string) (*semantic.Package, bool, error)
+}
+
 type importer struct {
 	r    *runtime
 	pkgs map[string]*interpreter.Package
+	// loader loads the packages that are not part of the
+	// standard library. It may be nil.
+	loader PackageLoader
 }
 
 func (imp *importer) Import(path string) (semantic.MonoType, error) {
@@ -60,8 +70,13 @@ func (imp *importer) ImportPackageObject(path string) (*interpreter.Package, err
 	}
 
 	// Find the package for the given import path.
-	semPkg, ok := imp.r.pkgs[path]
-	if !ok {
+	semPkg, ok, err := imp.r.pkgs.lookup(path)
+	if err == nil && !ok && imp.loader != nil {
+		semPkg, ok, err = imp.loader.LoadPackage(path)
+	}
+	if err != nil {
+		return nil, err
+	} else if !ok {
 		return nil, errors.Newf(codes.Invalid, "invalid import path %s", path)
 	}
 
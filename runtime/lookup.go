@@ -2,6 +2,8 @@ package runtime
 
 import (
 	"fmt"
+	"sort"
+	"strings"
 
 	flatbuffers "github.com/google/flatbuffers/go"
 	"github.com/influxdata/flux/codes"
@@ -41,6 +43,26 @@ func LookupBuiltinType(pkg, name string) (semantic.MonoType, error) {
 	return monotype, nil
 }
 
+// StdlibPackages returns the sorted paths of the packages in the
+// Flux standard library. Internal packages are not included.
+func StdlibPackages() []string {
+	seen := make(map[string]bool)
+	paths := make([]string, 0)
+	for key := range stdlibTypeEnvironment {
+		if seen[key.Package] || isInternalPackage(key.Package) {
+			continue
+		}
+		seen[key.Package] = true
+		paths = append(paths, key.Package)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+func isInternalPackage(path string) bool {
+	return path == "internal" || strings.HasPrefix(path, "internal/") || strings.Contains(path, "/internal/") || strings.HasSuffix(path, "/internal")
+}
+
 // MustLookupBuiltinType validates that call to LookupBuiltInType was
 // successful. If there is an error with lookup, then panic.
 func MustLookupBuiltinType(pkg, name string) semantic.MonoType {
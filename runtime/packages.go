@@ -0,0 +1,86 @@
+package runtime
+
+import (
+	"path"
+	"path/filepath"
+	"sync"
+
+	"github.com/influxdata/flux/codes"
+	"github.com/influxdata/flux/internal/errors"
+	"github.com/influxdata/flux/internal/fbsemantic"
+	"github.com/influxdata/flux/semantic"
+)
+
+// stdlibPackages holds the serialized semantic packages of the standard
+// library and deserializes each package the first time it is looked up.
+// A query only pays for the packages it imports instead of the whole
+// standard library.
+type stdlibPackages struct {
+	list  *fbsemantic.PackageList
+	index map[string]int
+
+	mu   sync.Mutex
+	pkgs map[string]*semantic.Package
+}
+
+func newStdlibPackages(data []byte) (p *stdlibPackages, err error) {
+	// The flatbuffers accessors panic when the data is malformed.
+	defer func() {
+		if r := recover(); r != nil {
+			p, err = nil, errors.Newf(codes.Internal, "invalid semantic package list: %v", r)
+		}
+	}()
+
+	p = &stdlibPackages{
+		list:  fbsemantic.GetRootAsPackageList(data, 0),
+		index: make(map[string]int),
+		pkgs:  make(map[string]*semantic.Package),
+	}
+	for i := 0; i < p.list.PackagesLength(); i++ {
+		var (
+			fbpkg fbsemantic.Package
+			file  fbsemantic.File
+		)
+		if !p.list.Packages(&fbpkg, i) || fbpkg.FilesLength() == 0 || !fbpkg.Files(&file, 0) {
+			return nil, errors.New(codes.Internal, "unable to extract semantic packages")
+		}
+		loc := file.Loc(nil)
+		if loc == nil {
+			return nil, errors.New(codes.Internal, "semantic package has no location")
+		}
+		p.index[path.Dir(filepath.ToSlash(string(loc.File())))] = i
+	}
+	return p, nil
+}
+
+// has reports whether the package exists without deserializing it.
+func (p *stdlibPackages) has(pkgpath string) bool {
+	_, ok := p.index[pkgpath]
+	return ok
+}
+
+// lookup returns the semantic package for the import path.
+func (p *stdlibPackages) lookup(pkgpath string) (*semantic.Package, bool, error) {
+	i, ok := p.index[pkgpath]
+	if !ok {
+		return nil, false, nil
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if pkg, ok := p.pkgs[pkgpath]; ok {
+		return pkg, true, nil
+	}
+	var (
+		fbpkg fbsemantic.Package
+		pkg   semantic.Package
+	)
+	if !p.list.Packages(&fbpkg, i) {
+		return nil, false, errors.Newf(codes.Internal, "unable to extract semantic package %s", pkgpath)
+	}
+	if err := pkg.FromBuf(&fbpkg); err != nil {
+		return nil, false, err
+	}
+	p.pkgs[pkgpath] = &pkg
+	return &pkg, true, nil
+}
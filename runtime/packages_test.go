@@ -0,0 +1,40 @@
+package runtime
+
+import (
+	"testing"
+
+	"github.com/influxdata/flux/libflux/go/libflux"
+)
+
+func TestNewStdlibPackages_Invalid(t *testing.T) {
+	if _, err := newStdlibPackages([]byte{0xff, 0xff}); err == nil {
+		t.Error("expected an error for invalid data")
+	}
+}
+
+// BenchmarkStdlibPackages compares loading the standard library lazily
+// and importing a single package with deserializing every package.
+func BenchmarkStdlibPackages(b *testing.B) {
+	b.Run("lazy", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			pkgs, err := newStdlibPackages(libflux.SemanticPackagesData())
+			if err != nil {
+				b.Fatal(err)
+			}
+			if _, ok, err := pkgs.lookup("strings"); err != nil {
+				b.Fatal(err)
+			} else if !ok {
+				b.Fatal("missing package strings")
+			}
+		}
+	})
+	b.Run("eager", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := libflux.SemanticPackages(); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
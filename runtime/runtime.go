@@ -2,6 +2,7 @@ package runtime
 
 import (
 	"context"
+	"os"
 
 	"github.com/influxdata/flux"
 	"github.com/influxdata/flux/codes"
@@ -19,9 +20,12 @@ var Default = &runtime{}
 // runtime contains the flux runtime for interpreting and
 // executing queries.
 type runtime struct {
-	pkgs      map[string]*semantic.Package
+	pkgs      *stdlibPackages
 	builtins  map[string]map[string]values.Value
 	finalized bool
+	// cacheDir is the directory of the on-disk
+	// cache of the standard library.
+	cacheDir string
 }
 
 func (r *runtime) Parse(ctx context.Context, flux string) (flux.ASTHandle, error) {
@@ -99,13 +103,35 @@ func (r *runtime) Eval(ctx context.Context, astPkg flux.ASTHandle, es interprete
 	if !r.finalized {
 		panic("runtime is not finalized - consider importing package fluxinit or fluxinit/static")
 	}
-	semPkg, err := AnalyzePackage(ctx, astPkg)
+	semPkg, err := r.Analyze(ctx, astPkg)
 	if err != nil {
 		return nil, nil, err
 	}
+	return r.EvalSemantic(ctx, semPkg, es, opts...)
+}
+
+// Analyze analyzes the package with the standard library.
+func (r *runtime) Analyze(ctx context.Context, astPkg flux.ASTHandle) (*semantic.Package, error) {
+	return AnalyzePackage(ctx, astPkg)
+}
+
+// EvalSemantic evaluates a semantic package that has already been analyzed
+// to produce a set of side effects and a scope. The package is not modified
+// so it may be evaluated any number of times and concurrently.
+func (r *runtime) EvalSemantic(ctx context.Context, semPkg *semantic.Package, es interpreter.ExecOptsConfig, opts ...flux.ScopeMutator) ([]interpreter.SideEffect, values.Scope, error) {
+	return r.EvalSemanticWithLoader(ctx, semPkg, nil, es, opts...)
+}
+
+// EvalSemanticWithLoader is like EvalSemantic but imports the packages
+// that are not part of the standard library with the loader. The loaded
+// packages share the standard library packages of the program.
+func (r *runtime) EvalSemanticWithLoader(ctx context.Context, semPkg *semantic.Package, loader PackageLoader, es interpreter.ExecOptsConfig, opts ...flux.ScopeMutator) ([]interpreter.SideEffect, values.Scope, error) {
+	if !r.finalized {
+		panic("runtime is not finalized - consider importing package fluxinit or fluxinit/static")
+	}
 
 	// Construct the initial scope for this package.
-	importer := &importer{r: r}
+	importer := &importer{r: r, loader: loader}
 	scope, err := r.newScopeFor("main", importer)
 	if err != nil {
 		return nil, nil, err
@@ -118,6 +144,7 @@ func (r *runtime) Eval(ctx context.Context, astPkg flux.ASTHandle, es interprete
 
 	// Execute the interpreter over the package.
 	itrp := interpreter.NewInterpreter(nil, es)
+	itrp.SetCoverage(interpreter.CoverageFromContext(ctx))
 	sideEffects, err := itrp.Eval(ctx, semPkg, scope, importer)
 	if err != nil {
 		return nil, nil, err
@@ -155,19 +182,16 @@ func (r *runtime) newScopeFor(pkgpath string, imp interpreter.Importer) (values.
 }
 
 func (r *runtime) Stdlib() interpreter.Importer {
-	if !r.finalized {
-		panic("builtins not finalized")
-	}
-	return &importer{r: r}
+	return r.StdlibWithLoader(nil)
 }
 
-func (r *runtime) compilePackages() error {
-	pkgs, err := libflux.SemanticPackages()
-	if err != nil {
-		return err
+// StdlibWithLoader returns an importer for the standard library that
+// imports the packages that are not part of it with the loader.
+func (r *runtime) StdlibWithLoader(loader PackageLoader) interpreter.Importer {
+	if !r.finalized {
+		panic("builtins not finalized")
 	}
-	r.pkgs = pkgs
-	return nil
+	return &importer{r: r, loader: loader}
 }
 
 func (r *runtime) Finalize() error {
@@ -176,16 +200,59 @@ func (r *runtime) Finalize() error {
 	}
 	r.finalized = true
 
-	if err := r.compilePackages(); err != nil {
+	dir := r.cacheDir
+	if dir == "" {
+		dir = os.Getenv(StdlibCacheEnv)
+	}
+	if dir == "off" {
+		dir = ""
+	}
+	var key []byte
+	if dir != "" {
+		key = r.stdlibCacheKey()
+		if pkgs, builtins, ok := loadStdlibCache(dir, key); ok {
+			r.pkgs = pkgs
+			return r.validateBuiltins(builtins)
+		}
+	}
+
+	data := libflux.SemanticPackagesData()
+	pkgs, err := newStdlibPackages(data)
+	if err != nil {
+		return err
+	}
+	builtins := make(map[string][]string, len(r.builtins))
+	for path := range r.builtins {
+		semPkg, ok, err := pkgs.lookup(path)
+		if err != nil {
+			return err
+		} else if !ok {
+			return errors.Newf(codes.Internal, "missing semantic package %s", path)
+		}
+		builtins[path] = builtinStatementNames(semPkg)
+	}
+	r.pkgs = pkgs
+	if err := r.validateBuiltins(builtins); err != nil {
 		return err
 	}
 
+	if dir != "" {
+		// The cache is only an optimization so failing
+		// to write it does not fail the initialization.
+		_ = writeStdlibCache(dir, key, builtins, data)
+	}
+	return nil
+}
+
+// validateBuiltins ensures that the registered builtins of every package
+// match the names of the builtin statements of the package.
+func (r *runtime) validateBuiltins(builtins map[string][]string) error {
 	for path, pkg := range r.builtins {
-		semPkg, ok := r.pkgs[path]
+		names, ok := builtins[path]
 		if !ok {
 			return errors.Newf(codes.Internal, "missing semantic package %s", path)
 		}
-		if err := validatePackageBuiltins(pkg, semPkg); err != nil {
+		if err := validateBuiltinNames(pkg, names); err != nil {
 			return err
 		}
 	}
@@ -194,12 +261,27 @@ func (r *runtime) Finalize() error {
 
 // validatePackageBuiltins ensures that all package builtins have both an AST builtin statement and a registered value.
 func validatePackageBuiltins(pkg map[string]values.Value, semPkg *semantic.Package) error {
-	builtinStmts := make(map[string]*semantic.BuiltinStatement)
+	return validateBuiltinNames(pkg, builtinStatementNames(semPkg))
+}
+
+// builtinStatementNames returns the names of the builtin statements of the package.
+func builtinStatementNames(semPkg *semantic.Package) []string {
+	var names []string
 	semantic.Walk(semantic.CreateVisitor(func(n semantic.Node) {
 		if bs, ok := n.(*semantic.BuiltinStatement); ok {
-			builtinStmts[bs.ID.Name.Name()] = bs
+			names = append(names, bs.ID.Name.Name())
 		}
 	}), semPkg)
+	return names
+}
+
+// validateBuiltinNames ensures that every builtin statement name has
+// a registered value and every registered value has a builtin statement.
+func validateBuiltinNames(pkg map[string]values.Value, names []string) error {
+	builtinStmts := make(map[string]struct{}, len(names))
+	for _, n := range names {
+		builtinStmts[n] = struct{}{}
+	}
 
 	missing := make([]string, 0, len(builtinStmts))
 	extra := make([]string, 0, len(builtinStmts))
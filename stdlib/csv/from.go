@@ -1,6 +1,7 @@
 package csv
 
 import (
+	"bufio"
 	"context"
 	"io"
 	"strings"
@@ -14,6 +15,7 @@ import (
 	"github.com/influxdata/flux/memory"
 	"github.com/influxdata/flux/plan"
 	"github.com/influxdata/flux/runtime"
+	"github.com/influxdata/flux/stdlib/universe"
 )
 
 const FromCSVKind = "fromCSV"
@@ -33,7 +35,9 @@ func init() {
 	fromCSVSignature := runtime.MustLookupBuiltinType("csv", "from")
 	runtime.RegisterPackageValue("csv", "from", flux.MustValue(flux.FunctionValue(FromCSVKind, createFromCSVOpSpec, fromCSVSignature)))
 	plan.RegisterProcedureSpec(FromCSVKind, newFromCSVProcedure, FromCSVKind)
+	plan.RegisterParallelizeRules(universe.ParallelizeSourceRule{Kind: FromCSVKind})
 	execute.RegisterSource(FromCSVKind, createFromCSVSource)
+	plan.RegisterJSONCodec[FromCSVProcedureSpec](FromCSVKind)
 }
 
 func createFromCSVOpSpec(args flux.Arguments, a *flux.Administration) (flux.OperationSpec, error) {
@@ -80,6 +84,11 @@ type FromCSVProcedureSpec struct {
 	CSV  string
 	File string
 	Mode string
+	// ParallelFactor is the number of parallel copies of the source
+	// when it is greater than one. The data is split into ranges of
+	// bytes of equal size and each copy decodes the annotation blocks
+	// that start in the range of its group.
+	ParallelFactor int
 }
 
 func newFromCSVProcedure(qs flux.OperationSpec, pa plan.Administration) (plan.ProcedureSpec, error) {
@@ -104,9 +113,31 @@ func (s *FromCSVProcedureSpec) Copy() plan.ProcedureSpec {
 	ns.CSV = s.CSV
 	ns.File = s.File
 	ns.Mode = s.Mode
+	ns.ParallelFactor = s.ParallelFactor
 	return ns
 }
 
+func (s *FromCSVProcedureSpec) OutputAttributes() plan.PhysicalAttributes {
+	if s.ParallelFactor > 1 {
+		return plan.PhysicalAttributes{
+			plan.ParallelRunKey: plan.ParallelRunAttribute{Factor: s.ParallelFactor},
+		}
+	}
+	return nil
+}
+
+// Parallelize splits the annotation blocks of the source into factor
+// partitions. The tables of the partitions are distinct so they are
+// merged as they are. Raw data is a single table so it is not split.
+func (s *FromCSVProcedureSpec) Parallelize(factor int) (plan.PhysicalProcedureSpec, *universe.PartitionMergeProcedureSpec) {
+	if s.Mode == rawMode {
+		return s, nil
+	}
+	ns := s.Copy().(*FromCSVProcedureSpec)
+	ns.ParallelFactor = factor
+	return ns, &universe.PartitionMergeProcedureSpec{Factor: factor}
+}
+
 func createFromCSVSource(prSpec plan.ProcedureSpec, dsid execute.DatasetID, a execute.Administration) (execute.Source, error) {
 	spec, ok := prSpec.(*FromCSVProcedureSpec)
 	if !ok {
@@ -116,7 +147,10 @@ func createFromCSVSource(prSpec plan.ProcedureSpec, dsid execute.DatasetID, a ex
 }
 
 func CreateSource(spec *FromCSVProcedureSpec, dsid execute.DatasetID, a execute.Administration) (execute.Source, error) {
-	var getDataStream func() (io.ReadCloser, error)
+	var (
+		getDataStream func() (io.ReadCloser, error)
+		getDataSize   func() (int64, error)
+	)
 	if spec.File != "" {
 		getDataStream = func() (io.ReadCloser, error) {
 			f, err := filesystem.OpenFile(a.Context(), spec.File)
@@ -125,17 +159,31 @@ func CreateSource(spec *FromCSVProcedureSpec, dsid execute.DatasetID, a execute.
 			}
 			return f, nil
 		}
+		getDataSize = func() (int64, error) {
+			fi, err := filesystem.Stat(a.Context(), spec.File)
+			if err != nil {
+				return 0, errors.Wrap(err, codes.Inherit, "csv.from() failed to read file")
+			}
+			return fi.Size(), nil
+		}
 	} else { // if spec.File is empty then spec.CSV is not empty
 		getDataStream = func() (io.ReadCloser, error) {
-			return io.NopCloser(strings.NewReader(spec.CSV)), nil
+			return nopSeekCloser{strings.NewReader(spec.CSV)}, nil
+		}
+		getDataSize = func() (int64, error) {
+			return int64(len(spec.CSV)), nil
 		}
 	}
 	csvSource := CSVSource{
 		id:            dsid,
 		getDataStream: getDataStream,
+		getDataSize:   getDataSize,
 		alloc:         a.Allocator(),
 		mode:          spec.Mode,
 	}
+	if popts := a.ParallelOpts(); popts.Factor > 1 {
+		csvSource.group, csvSource.factor = popts.Group, popts.Factor
+	}
 
 	return &csvSource, nil
 }
@@ -144,9 +192,14 @@ type CSVSource struct {
 	execute.ExecutionNode
 	id            execute.DatasetID
 	getDataStream func() (io.ReadCloser, error)
+	getDataSize   func() (int64, error)
 	ts            []execute.Transformation
 	alloc         memory.Allocator
 	mode          string
+
+	// group and factor select the range of bytes read by a
+	// parallel copy of the source when factor is greater than one.
+	group, factor int
 }
 
 func (c *CSVSource) AddTransformation(t execute.Transformation) {
@@ -158,6 +211,14 @@ func (c *CSVSource) Run(ctx context.Context) {
 	var max execute.Time
 	maxSet := false
 
+	getDataStream := c.getDataStream
+	if c.factor > 1 {
+		getDataStream, err = c.partition()
+		if err != nil {
+			goto FINISH
+		}
+	}
+
 	for _, t := range c.ts {
 		// For each downstream transformation, instantiate a new result
 		// decoder. This way a table instance goes to one and only one
@@ -175,7 +236,7 @@ func (c *CSVSource) Run(ctx context.Context) {
 		}
 		decoder := csv.NewMultiResultDecoder(config)
 		var data io.ReadCloser
-		data, err = c.getDataStream()
+		data, err = getDataStream()
 		if err != nil {
 			goto FINISH
 		}
@@ -198,17 +259,13 @@ func (c *CSVSource) Run(ctx context.Context) {
 		result := results.Next()
 
 		err = result.Tables().Do(func(tbl flux.Table) error {
-			err := t.Process(c.id, tbl)
-			if err != nil {
-				return err
-			}
 			if idx := execute.ColIdx(execute.DefaultStopColLabel, tbl.Key().Cols()); idx >= 0 {
 				if stop := tbl.Key().ValueTime(idx); !maxSet || stop > max {
 					max = stop
 					maxSet = true
 				}
 			}
-			return nil
+			return t.Process(c.id, tbl)
 		})
 		if err != nil {
 			goto FINISH
@@ -240,6 +297,108 @@ FINISH:
 	}
 }
 
+// partition returns a function that opens the range of bytes of the
+// data that is read by the group of the source. The data is split into
+// factor ranges of equal size and each range is extended to the
+// boundaries of the annotation blocks so every table is decoded by the
+// partition where its block starts.
+func (c *CSVSource) partition() (func() (io.ReadCloser, error), error) {
+	size, err := c.getDataSize()
+	if err != nil {
+		return nil, err
+	}
+	begin, err := blockStart(c.getDataStream, size*int64(c.group)/int64(c.factor), size)
+	if err != nil {
+		return nil, err
+	}
+	end, err := blockStart(c.getDataStream, size*int64(c.group+1)/int64(c.factor), size)
+	if err != nil {
+		return nil, err
+	}
+	return func() (io.ReadCloser, error) {
+		data, err := c.getDataStream()
+		if err != nil {
+			return nil, err
+		}
+		if err := skip(data, begin); err != nil {
+			_ = data.Close()
+			return nil, err
+		}
+		return limitReadCloser{Reader: io.LimitReader(data, end-begin), Closer: data}, nil
+	}, nil
+}
+
+// blockStart returns the offset of the first annotation block that
+// starts in a line after the line at offset. A block starts with an
+// annotation line that follows a line that is not an annotation.
+// The line at offset may start before it so neither it nor the line
+// after it are known to start a block. The copies of the source call
+// it with the same offsets so they agree on the blocks of their ranges.
+// It returns size when no block starts after offset.
+func blockStart(getDataStream func() (io.ReadCloser, error), offset, size int64) (int64, error) {
+	if offset <= 0 || offset >= size {
+		return offset, nil
+	}
+	data, err := getDataStream()
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = data.Close() }()
+	if err := skip(data, offset); err != nil {
+		return 0, err
+	}
+
+	r := bufio.NewReader(data)
+	pos, prevAnnotation := offset, true
+	for {
+		start, annotation := pos, false
+		line, err := r.ReadSlice('\n')
+		if len(line) > 0 {
+			annotation = line[0] == '#'
+		}
+		pos += int64(len(line))
+		// Skip the remainder of a line that does not fit in the buffer.
+		for err == bufio.ErrBufferFull {
+			line, err = r.ReadSlice('\n')
+			pos += int64(len(line))
+		}
+		if start > offset && annotation && !prevAnnotation {
+			return start, nil
+		}
+		if err == io.EOF {
+			return size, nil
+		} else if err != nil {
+			return 0, err
+		}
+		prevAnnotation = annotation || start == offset
+	}
+}
+
+// skip discards the first n bytes of r.
+func skip(r io.Reader, n int64) error {
+	if s, ok := r.(io.Seeker); ok {
+		_, err := s.Seek(n, io.SeekStart)
+		return err
+	}
+	_, err := io.CopyN(io.Discard, r, n)
+	return err
+}
+
+// limitReadCloser closes the data of a limited reader.
+type limitReadCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// nopSeekCloser is an io.ReadSeeker with a no-op Close method.
+type nopSeekCloser struct {
+	io.ReadSeeker
+}
+
+func (nopSeekCloser) Close() error {
+	return nil
+}
+
 // skipBOMReader wraps an io.ReadCloser and skips the BOM,
 // if it exists at the beginning of the stream.
 type skipBOMReader struct {
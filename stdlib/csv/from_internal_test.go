@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"errors"
 	"io"
+	"strings"
 	"testing"
 )
 
@@ -69,3 +70,51 @@ type errReader struct {
 func (e errReader) Read(_ []byte) (int, error) {
 	return 0, e.err
 }
+
+func TestBlockStart(t *testing.T) {
+	data := `#datatype,string,long,string,long
+#group,false,false,true,false
+#default,_result,,,
+,result,table,host,_value
+,,0,A,1
+,,1,B,2
+
+#datatype,string,long,string,double
+#group,false,false,true,false
+#default,_result,,,
+,result,table,host,_value
+,,2,C,1.5
+#datatype,string,long,string,string
+#group,false,false,true,false
+#default,_result,,,
+,result,table,host,_value
+,,3,D,#x
+`
+	getDataStream := func() (io.ReadCloser, error) {
+		return nopSeekCloser{strings.NewReader(data)}, nil
+	}
+	size := int64(len(data))
+
+	// Every split of the data is a sequence of whole annotation blocks.
+	for factor := 1; factor <= 32; factor++ {
+		var joined strings.Builder
+		for group := 0; group < factor; group++ {
+			begin, err := blockStart(getDataStream, size*int64(group)/int64(factor), size)
+			if err != nil {
+				t.Fatal(err)
+			}
+			end, err := blockStart(getDataStream, size*int64(group+1)/int64(factor), size)
+			if err != nil {
+				t.Fatal(err)
+			}
+			section := data[begin:end]
+			if section != "" && !strings.HasPrefix(section, "#datatype") {
+				t.Errorf("partition %d of %d does not start with a block: %q", group, factor, section)
+			}
+			joined.WriteString(section)
+		}
+		if got := joined.String(); got != data {
+			t.Errorf("partitions of %d do not cover the data: %q", factor, got)
+		}
+	}
+}
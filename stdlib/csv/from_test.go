@@ -2,6 +2,7 @@ package csv_test
 
 import (
 	"context"
+	"fmt"
 	"strings"
 	"testing"
 	"time"
@@ -14,6 +15,8 @@ import (
 	"github.com/influxdata/flux/internal/errors"
 	"github.com/influxdata/flux/internal/operation"
 	"github.com/influxdata/flux/mock"
+	"github.com/influxdata/flux/plan"
+	"github.com/influxdata/flux/plan/plantest"
 	"github.com/influxdata/flux/querytest"
 	"github.com/influxdata/flux/stdlib/csv"
 	"github.com/influxdata/flux/stdlib/universe"
@@ -401,3 +404,175 @@ func (n *noopTransformation) UpdateProcessingTime(id execute.DatasetID, t execut
 	return nil
 }
 func (n *noopTransformation) Finish(id execute.DatasetID, err error) {}
+
+// parallelAdministration is the administration of
+// a parallel copy of a source.
+type parallelAdministration struct {
+	*mock.Administration
+	opts execute.ParallelOpts
+}
+
+func (a parallelAdministration) ParallelOpts() execute.ParallelOpts {
+	return a.opts
+}
+
+func TestFromCSV_RunPartition(t *testing.T) {
+	// The middle of the data is in the first block so the first
+	// partition reads it and the second reads the second block.
+	spec := &csv.FromCSVProcedureSpec{
+		CSV: `#datatype,string,long,string,long
+#group,false,false,true,false
+#default,_result,,,
+,result,table,host,_value
+,,0,A,1
+,,0,A,2
+,,1,B,3
+
+#datatype,string,long,string,long
+#group,false,false,true,false
+#default,_result,,,
+,result,table,host,_value
+,,2,C,4
+,,2,C,5
+`,
+		ParallelFactor: 2,
+	}
+	cols := []flux.ColMeta{
+		{Label: "host", Type: flux.TString},
+		{Label: "_value", Type: flux.TInt},
+	}
+	for _, tc := range []struct {
+		group int
+		want  []*executetest.Table
+	}{
+		{
+			group: 0,
+			want: []*executetest.Table{
+				{
+					KeyCols: []string{"host"},
+					ColMeta: cols,
+					Data: [][]interface{}{
+						{"A", int64(1)},
+						{"A", int64(2)},
+					},
+				},
+				{
+					KeyCols: []string{"host"},
+					ColMeta: cols,
+					Data: [][]interface{}{
+						{"B", int64(3)},
+					},
+				},
+			},
+		},
+		{
+			group: 1,
+			want: []*executetest.Table{
+				{
+					KeyCols: []string{"host"},
+					ColMeta: cols,
+					Data: [][]interface{}{
+						{"C", int64(4)},
+						{"C", int64(5)},
+					},
+				},
+			},
+		},
+	} {
+		t.Run(fmt.Sprintf("group %d", tc.group), func(t *testing.T) {
+			executetest.RunSourceHelper(t,
+				context.Background(),
+				tc.want,
+				nil,
+				func(id execute.DatasetID) execute.Source {
+					a := parallelAdministration{
+						Administration: mock.AdministrationWithContext(context.Background()),
+						opts:           execute.ParallelOpts{Group: tc.group, Factor: 2},
+					}
+					s, err := csv.CreateSource(spec, id, a)
+					if err != nil {
+						t.Fatal(err)
+					}
+					return s
+				},
+			)
+		})
+	}
+}
+
+// partitionExecutor splits sources into factor partitions.
+type partitionExecutor struct {
+	factor int
+}
+
+func (e partitionExecutor) ExecutePartition(ctx context.Context, req *execute.PartitionRequest, f func(flux.Table) error) error {
+	return errors.New(codes.Unimplemented, "partitions are not executed")
+}
+
+func (e partitionExecutor) Factor() int {
+	return e.factor
+}
+
+func TestFromCSV_ParallelizeRule(t *testing.T) {
+	data := "#datatype,string,long,long\n#group,false,false,false\n#default,_result,,\n,result,table,_value\n,,0,1\n"
+	withPartitions := func(factor int) context.Context {
+		deps := execute.DefaultExecutionDependencies()
+		deps.ExecutionOptions.Partitions = partitionExecutor{factor: factor}
+		return deps.Inject(context.Background())
+	}
+	before := &plantest.PlanSpec{
+		Nodes: []plan.Node{
+			plan.CreatePhysicalNode("fromCSV", &csv.FromCSVProcedureSpec{CSV: data}),
+			plan.CreatePhysicalNode("sum", &universe.SumProcedureSpec{}),
+		},
+		Edges: [][2]int{{0, 1}},
+	}
+	tcs := []plantest.RuleTestCase{
+		{
+			Name:    "partitions",
+			Context: withPartitions(3),
+			Rules:   []plan.Rule{universe.ParallelizeSourceRule{Kind: csv.FromCSVKind}},
+			Before:  before,
+			After: &plantest.PlanSpec{
+				Nodes: []plan.Node{
+					plan.CreatePhysicalNode("fromCSV", &csv.FromCSVProcedureSpec{CSV: data, ParallelFactor: 3}),
+					plan.CreatePhysicalNode("partitionMerge", &universe.PartitionMergeProcedureSpec{Factor: 3}),
+					plan.CreatePhysicalNode("sum", &universe.SumProcedureSpec{}),
+				},
+				Edges: [][2]int{{0, 1}, {1, 2}},
+			},
+		},
+		{
+			Name:    "raw data",
+			Context: withPartitions(3),
+			Rules:   []plan.Rule{universe.ParallelizeSourceRule{Kind: csv.FromCSVKind}},
+			Before: &plantest.PlanSpec{
+				Nodes: []plan.Node{
+					plan.CreatePhysicalNode("fromCSV", &csv.FromCSVProcedureSpec{CSV: "_value\n1\n", Mode: "raw"}),
+					plan.CreatePhysicalNode("sum", &universe.SumProcedureSpec{}),
+				},
+				Edges: [][2]int{{0, 1}},
+			},
+			NoChange: true,
+		},
+		{
+			Name:     "no partition executor",
+			Rules:    []plan.Rule{universe.ParallelizeSourceRule{Kind: csv.FromCSVKind}},
+			Before:   before,
+			NoChange: true,
+		},
+		{
+			Name:     "single partition",
+			Context:  withPartitions(1),
+			Rules:    []plan.Rule{universe.ParallelizeSourceRule{Kind: csv.FromCSVKind}},
+			Before:   before,
+			NoChange: true,
+		},
+	}
+	for _, tc := range tcs {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			plantest.PhysicalRuleTestHelper(t, &tc)
+		})
+	}
+}
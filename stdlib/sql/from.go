@@ -57,6 +57,9 @@ func (s *FromSQLOpSpec) Kind() flux.OperationKind {
 	return FromSQLKind
 }
 
+// FromSQLProcedureSpec is not split into partitions. Each partition
+// would run the whole query against the database and the data source
+// name, with any credentials, would be sent to the workers in the plan.
 type FromSQLProcedureSpec struct {
 	plan.DefaultCost
 	DriverName     string
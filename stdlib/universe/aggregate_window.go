@@ -31,6 +31,7 @@ func init() {
 		AggregateWindowCreateEmptyRule{},
 	)
 	execute.RegisterTransformation(AggregateWindowKind, createAggregateWindowTransformation)
+	plan.RegisterJSONCodec[AggregateWindowProcedureSpec](AggregateWindowKind)
 }
 
 const AggregateWindowKind = "aggregateWindow"
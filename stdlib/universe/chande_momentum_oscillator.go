@@ -24,6 +24,7 @@ func init() {
 	runtime.RegisterPackageValue("universe", ChandeMomentumOscillatorKind, flux.MustValue(flux.FunctionValue(ChandeMomentumOscillatorKind, createChandeMomentumOscillatorOpSpec, chandeMomentumOscillatorSignature)))
 	plan.RegisterProcedureSpec(ChandeMomentumOscillatorKind, newChandeMomentumOscillatorProcedure, ChandeMomentumOscillatorKind)
 	execute.RegisterTransformation(ChandeMomentumOscillatorKind, createChandeMomentumOscillatorTransformation)
+	plan.RegisterJSONCodec[ChandeMomentumOscillatorProcedureSpec](ChandeMomentumOscillatorKind)
 }
 
 func createChandeMomentumOscillatorOpSpec(args flux.Arguments, a *flux.Administration) (flux.OperationSpec, error) {
@@ -0,0 +1,244 @@
+package universe_test
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/influxdata/flux"
+	"github.com/influxdata/flux/codes"
+	"github.com/influxdata/flux/execute"
+	"github.com/influxdata/flux/internal/errors"
+	"github.com/influxdata/flux/interpreter"
+	"github.com/influxdata/flux/plan"
+	"github.com/influxdata/flux/plan/plantest"
+	"github.com/influxdata/flux/semantic"
+	"github.com/influxdata/flux/stdlib/csv"
+	"github.com/influxdata/flux/stdlib/universe"
+	"github.com/influxdata/flux/values"
+)
+
+// TestProcedureSpecCodecs round trips a populated procedure spec
+// of every kind that has a registered codec through a serialized plan.
+func TestProcedureSpecCodecs(t *testing.T) {
+	now := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	selector := execute.SelectorConfig{Column: "_value"}
+	aggregate := execute.SimpleAggregateConfig{Columns: []string{"_value", "x"}}
+	specs := []plan.PhysicalProcedureSpec{
+		&plan.GeneratedYieldProcedureSpec{Name: "_result"},
+		&csv.FromCSVProcedureSpec{CSV: "#datatype,string\n,a\n", Mode: "raw"},
+		&universe.AggregateWindowProcedureSpec{
+			WindowSpec: &universe.WindowProcedureSpec{
+				Window:      plan.WindowSpec{Every: flux.ConvertDuration(time.Minute), Period: flux.ConvertDuration(time.Minute)},
+				TimeColumn:  "_time",
+				StartColumn: "_start",
+				StopColumn:  "_stop",
+			},
+			AggregateKind:       universe.MeanKind,
+			ValueCol:            "_value",
+			UseStart:            true,
+			ForceAggregate:      true,
+			ParallelMergeFactor: 2,
+		},
+		&universe.ChandeMomentumOscillatorProcedureSpec{N: 10, Columns: []string{"_value"}},
+		&universe.ColumnsProcedureSpec{Column: "_value"},
+		&universe.CountProcedureSpec{SimpleAggregateConfig: aggregate},
+		&universe.CovarianceProcedureSpec{PearsonCorrelation: true, ValueLabel: "cov", Columns: []string{"x", "y"}},
+		&universe.CumulativeSumProcedureSpec{Columns: []string{"_value"}},
+		&universe.DerivativeProcedureSpec{
+			Unit:        flux.ConvertDuration(time.Second),
+			NonNegative: true,
+			Columns:     []string{"_value"},
+			TimeColumn:  "_time",
+			InitialZero: true,
+		},
+		&universe.DifferenceProcedureSpec{NonNegative: true, Columns: []string{"_value"}, KeepFirst: true, InitialZero: true},
+		&universe.DistinctProcedureSpec{Column: "host"},
+		&universe.ElapsedProcedureSpec{Unit: flux.ConvertDuration(time.Millisecond), TimeColumn: "_time", ColumnName: "elapsed"},
+		&universe.ExactQuantileAggProcedureSpec{Quantile: 0.5, SimpleAggregateConfig: aggregate},
+		&universe.ExactQuantileSelectProcedureSpec{Quantile: 0.99, SelectorConfig: selector},
+		&universe.ExponentialMovingAverageProcedureSpec{N: 5},
+		&universe.FillProcedureSpec{Column: "_value", Value: values.NewFloat(0.5)},
+		&universe.FirstProcedureSpec{SelectorConfig: selector},
+		&universe.GroupProcedureSpec{GroupMode: flux.GroupModeExcept, GroupKeys: []string{"_time", "_value"}},
+		&universe.HistogramProcedureSpec{HistogramOpSpec: universe.HistogramOpSpec{
+			Column:           "_value",
+			UpperBoundColumn: "le",
+			CountColumn:      "_value",
+			Bins:             []float64{1, 2, math.Inf(1)},
+			Normalize:        true,
+		}},
+		&universe.HistogramQuantileProcedureSpec{
+			Quantile:         0.9,
+			CountColumn:      "_value",
+			UpperBoundColumn: "le",
+			ValueColumn:      "_value",
+			MinValue:         -1,
+			OnNonmonotonic:   "force",
+		},
+		&universe.HoltWintersProcedureSpec{
+			WithFit:    true,
+			Column:     "_value",
+			TimeColumn: "_time",
+			N:          10,
+			S:          4,
+			Interval:   flux.ConvertDuration(time.Minute),
+			WithMinSSE: true,
+		},
+		&universe.HourSelectionProcedureSpec{
+			Start:      9,
+			Stop:       17,
+			Location:   "Europe/Paris",
+			Offset:     values.ConvertDurationNsecs(time.Hour),
+			TimeColumn: "_time",
+		},
+		&universe.IntegralProcedureSpec{
+			Unit:                  flux.ConvertDuration(time.Minute),
+			TimeColumn:            "_time",
+			Interpolate:           true,
+			SimpleAggregateConfig: aggregate,
+		},
+		&universe.KamaProcedureSpec{N: 10, Column: "_value"},
+		&universe.KeysProcedureSpec{Column: "_value"},
+		&universe.LastProcedureSpec{SelectorConfig: selector},
+		&universe.LimitProcedureSpec{N: 10, Offset: 2},
+		&universe.MaxProcedureSpec{SelectorConfig: selector},
+		&universe.MeanProcedureSpec{SimpleAggregateConfig: aggregate},
+		&universe.MergeJoinProcedureSpec{TableNames: []string{"a", "b"}, On: []string{"_time", "host"}},
+		&universe.MinProcedureSpec{SelectorConfig: selector},
+		&universe.ModeProcedureSpec{Column: "_value"},
+		&universe.MovingAverageProcedureSpec{N: 5},
+		&universe.PartitionMergeProcedureSpec{Factor: 4},
+		&universe.PivotProcedureSpec{RowKey: []string{"_time"}, ColumnKey: []string{"_field"}, ValueColumn: "_value"},
+		&universe.TDigestQuantileProcedureSpec{Quantile: 0.5, Compression: 1000, SimpleAggregateConfig: aggregate},
+		&universe.RangeProcedureSpec{
+			Bounds: flux.Bounds{
+				Start: flux.Time{IsRelative: true, Relative: -time.Hour},
+				Stop:  flux.Time{Absolute: now},
+				Now:   now,
+			},
+			TimeColumn:  "_time",
+			StartColumn: "_start",
+			StopColumn:  "_stop",
+		},
+		&universe.RelativeStrengthIndexProcedureSpec{N: 14, Columns: []string{"_value"}},
+		&universe.SampleProcedureSpec{N: 5, Pos: 2, SelectorConfig: selector},
+		&universe.SchemaMutationProcedureSpec{Mutations: []universe.SchemaMutation{
+			&universe.RenameOpSpec{Columns: map[string]string{"a": "b"}},
+			&universe.DropOpSpec{Columns: []string{"c"}},
+			&universe.KeepOpSpec{Columns: []string{"b", "d"}},
+			&universe.DuplicateOpSpec{Column: "b", As: "e"},
+		}},
+		&universe.SetProcedureSpec{Key: "host", Value: "a"},
+		&universe.ShiftProcedureSpec{Shift: flux.ConvertDuration(-time.Hour), Columns: []string{"_time"}, Now: now},
+		&universe.SkewProcedureSpec{SimpleAggregateConfig: aggregate},
+		&universe.SortProcedureSpec{Columns: []string{"_value", "_time"}, Desc: true},
+		&universe.SortLimitProcedureSpec{
+			SortProcedureSpec: &universe.SortProcedureSpec{Columns: []string{"_value"}},
+			N:                 3,
+		},
+		&universe.SortedPivotProcedureSpec{RowKey: []string{"_time"}, ColumnKey: []string{"_field"}, ValueColumn: "_value"},
+		&universe.SpreadProcedureSpec{SimpleAggregateConfig: aggregate},
+		&universe.StddevProcedureSpec{Mode: "population", SimpleAggregateConfig: aggregate},
+		&universe.SumProcedureSpec{SimpleAggregateConfig: aggregate},
+		&universe.TailProcedureSpec{N: 10, Offset: 2},
+		&universe.TripleExponentialDerivativeProcedureSpec{N: 5},
+		&universe.UnionProcedureSpec{},
+		&universe.UniqueProcedureSpec{Column: "_value"},
+		&universe.WindowProcedureSpec{
+			Window: plan.WindowSpec{
+				Every:  flux.ConvertDuration(time.Minute),
+				Period: flux.ConvertDuration(2 * time.Minute),
+				Offset: flux.ConvertDuration(time.Second),
+				Location: plan.Location{
+					Name:   "America/New_York",
+					Offset: flux.ConvertDuration(time.Hour),
+				},
+			},
+			TimeColumn:  "_time",
+			StartColumn: "_start",
+			StopColumn:  "_stop",
+			CreateEmpty: true,
+		},
+		&universe.YieldProcedureSpec{Name: "custom"},
+	}
+
+	tested := make(map[plan.ProcedureKind]bool)
+	for _, spec := range specs {
+		tested[spec.Kind()] = true
+		t.Run(string(spec.Kind()), func(t *testing.T) {
+			ps := plan.NewPlanSpec()
+			ps.Roots[plan.CreatePhysicalNode("node", spec)] = struct{}{}
+			data, err := plan.MarshalSpec(ps)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := plan.UnmarshalSpec(data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for root := range got.Roots {
+				if !cmp.Equal(spec, root.ProcedureSpec(), plantest.CmpOptions...) {
+					t.Errorf("unexpected spec -want/+got:\n%s", cmp.Diff(spec, root.ProcedureSpec(), plantest.CmpOptions...))
+				}
+			}
+		})
+	}
+
+	for _, kind := range plan.ProcedureSpecCodecKinds() {
+		if !tested[kind] {
+			t.Errorf("no round trip test for procedure kind %v", kind)
+		}
+	}
+}
+
+// TestProcedureSpecCodecs_Functions checks that the plans with procedure
+// specs that carry a Flux function are rejected because the function
+// and its scope cannot be serialized.
+func TestProcedureSpecCodecs_Functions(t *testing.T) {
+	fn := interpreter.ResolvedFunction{Fn: &semantic.FunctionExpression{}}
+	for _, tc := range []struct {
+		spec plan.PhysicalProcedureSpec
+		want string
+	}{
+		{
+			spec: &universe.FilterProcedureSpec{Fn: fn},
+			want: "no codec for procedure kind filter",
+		},
+		{
+			spec: &universe.MapProcedureSpec{Fn: fn},
+			want: "no codec for procedure kind map",
+		},
+		{
+			spec: &universe.ReduceProcedureSpec{Fn: fn},
+			want: "no codec for procedure kind reduce",
+		},
+		{
+			spec: &universe.StateTrackingProcedureSpec{Fn: fn, CountColumn: "count"},
+			want: "no codec for procedure kind stateTracking",
+		},
+		{
+			spec: &universe.SchemaMutationProcedureSpec{Mutations: []universe.SchemaMutation{
+				&universe.DropOpSpec{Predicate: fn},
+			}},
+			want: "cannot serialize drop with a function",
+		},
+	} {
+		t.Run(string(tc.spec.Kind()), func(t *testing.T) {
+			ps := plan.NewPlanSpec()
+			ps.Roots[plan.CreatePhysicalNode("node", tc.spec)] = struct{}{}
+			_, err := plan.MarshalSpec(ps)
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if got := errors.Code(err); got != codes.Unimplemented {
+				t.Errorf("unexpected code %v", got)
+			}
+			if !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("expected error containing %q, got %q", tc.want, err)
+			}
+		})
+	}
+}
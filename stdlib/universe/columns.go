@@ -21,6 +21,7 @@ func init() {
 	runtime.RegisterPackageValue("universe", ColumnsKind, flux.MustValue(flux.FunctionValue(ColumnsKind, CreateColumnsOpSpec, columnsSignature)))
 	plan.RegisterProcedureSpec(ColumnsKind, newColumnsProcedure, ColumnsKind)
 	execute.RegisterTransformation(ColumnsKind, createColumnsTransformation)
+	plan.RegisterJSONCodec[ColumnsProcedureSpec](ColumnsKind)
 }
 
 func CreateColumnsOpSpec(args flux.Arguments, a *flux.Administration) (flux.OperationSpec, error) {
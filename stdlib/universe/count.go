@@ -21,6 +21,7 @@ func init() {
 	runtime.RegisterPackageValue("universe", CountKind, flux.MustValue(flux.FunctionValue(CountKind, CreateCountOpSpec, countSignature)))
 	plan.RegisterProcedureSpec(CountKind, newCountProcedure, CountKind)
 	execute.RegisterTransformation(CountKind, createCountTransformation)
+	plan.RegisterJSONCodec[CountProcedureSpec](CountKind)
 }
 
 func CreateCountOpSpec(args flux.Arguments, a *flux.Administration) (flux.OperationSpec, error) {
@@ -27,6 +27,7 @@ func init() {
 	runtime.RegisterPackageValue("universe", CumulativeSumKind, flux.MustValue(flux.FunctionValue(CumulativeSumKind, createCumulativeSumOpSpec, cumulativeSumSignature)))
 	plan.RegisterProcedureSpec(CumulativeSumKind, newCumulativeSumProcedure, CumulativeSumKind)
 	execute.RegisterTransformation(CumulativeSumKind, createCumulativeSumTransformation)
+	plan.RegisterJSONCodec[CumulativeSumProcedureSpec](CumulativeSumKind)
 }
 
 func createCumulativeSumOpSpec(args flux.Arguments, a *flux.Administration) (flux.OperationSpec, error) {
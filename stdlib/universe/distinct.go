@@ -22,6 +22,7 @@ func init() {
 	runtime.RegisterPackageValue("universe", DistinctKind, flux.MustValue(flux.FunctionValue(DistinctKind, CreateDistinctOpSpec, distinctSignature)))
 	plan.RegisterProcedureSpec(DistinctKind, newDistinctProcedure, DistinctKind)
 	execute.RegisterTransformation(DistinctKind, createDistinctTransformation)
+	plan.RegisterJSONCodec[DistinctProcedureSpec](DistinctKind)
 }
 
 func CreateDistinctOpSpec(args flux.Arguments, a *flux.Administration) (flux.OperationSpec, error) {
@@ -26,6 +26,7 @@ func init() {
 	runtime.RegisterPackageValue("universe", ElapsedKind, flux.MustValue(flux.FunctionValue(ElapsedKind, createElapsedOpSpec, elapsedSignature)))
 	plan.RegisterProcedureSpec(ElapsedKind, newElapsedProcedure, ElapsedKind)
 	execute.RegisterTransformation(ElapsedKind, createElapsedTransformation)
+	plan.RegisterJSONCodec[ElapsedProcedureSpec](ElapsedKind)
 }
 
 func createElapsedOpSpec(args flux.Arguments, a *flux.Administration) (flux.OperationSpec, error) {
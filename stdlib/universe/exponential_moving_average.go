@@ -22,6 +22,7 @@ func init() {
 	runtime.RegisterPackageValue("universe", ExponentialMovingAverageKind, flux.MustValue(flux.FunctionValue(ExponentialMovingAverageKind, createExponentialMovingAverageOpSpec, exponentialMovingAverageSignature)))
 	plan.RegisterProcedureSpec(ExponentialMovingAverageKind, newExponentialMovingAverageProcedure, ExponentialMovingAverageKind)
 	execute.RegisterTransformation(ExponentialMovingAverageKind, createExponentialMovingAverageTransformation)
+	plan.RegisterJSONCodec[ExponentialMovingAverageProcedureSpec](ExponentialMovingAverageKind)
 }
 
 func createExponentialMovingAverageOpSpec(args flux.Arguments, a *flux.Administration) (flux.OperationSpec, error) {
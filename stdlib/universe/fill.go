@@ -2,6 +2,7 @@ package universe
 
 import (
 	"context"
+	"encoding/json"
 	"strconv"
 
 	arrowmem "github.com/apache/arrow-go/v18/arrow/memory"
@@ -38,6 +39,7 @@ func init() {
 	runtime.RegisterPackageValue("universe", FillKind, flux.MustValue(flux.FunctionValue(FillKind, CreateFillOpSpec, fillSignature)))
 	plan.RegisterProcedureSpec(FillKind, newFillProcedure, FillKind)
 	execute.RegisterTransformation(FillKind, createFillTransformation)
+	plan.RegisterProcedureSpecCodec(FillKind, fillCodec{})
 }
 
 func CreateFillOpSpec(args flux.Arguments, a *flux.Administration) (flux.OperationSpec, error) {
@@ -159,6 +161,50 @@ func newFillProcedure(qs flux.OperationSpec, pa plan.Administration) (plan.Proce
 func (s *FillProcedureSpec) Kind() plan.ProcedureKind {
 	return FillKind
 }
+
+// fillCodec encodes a fill procedure spec as the operation spec
+// that creates it, which holds the value as a string with its type.
+type fillCodec struct{}
+
+func (fillCodec) Encode(spec plan.PhysicalProcedureSpec) (json.RawMessage, error) {
+	s, ok := spec.(*FillProcedureSpec)
+	if !ok {
+		return nil, errors.Newf(codes.Internal, "invalid spec type %T", spec)
+	}
+	os := FillOpSpec{Column: s.Column, UsePrevious: s.UsePrevious}
+	if !s.UsePrevious {
+		v := s.Value
+		switch v.Type().Nature() {
+		case semantic.Bool:
+			os.Type, os.Value = "bool", strconv.FormatBool(v.Bool())
+		case semantic.Int:
+			os.Type, os.Value = "int", strconv.FormatInt(v.Int(), 10)
+		case semantic.UInt:
+			os.Type, os.Value = "uint", strconv.FormatUint(v.UInt(), 10)
+		case semantic.Float:
+			os.Type, os.Value = "float", strconv.FormatFloat(v.Float(), 'g', -1, 64)
+		case semantic.String:
+			os.Type, os.Value = "string", v.Str()
+		case semantic.Time:
+			os.Type, os.Value = "time", v.Time().String()
+		default:
+			return nil, errors.Newf(codes.Unimplemented, "cannot serialize fill value of type %v", v.Type())
+		}
+	}
+	return json.Marshal(os)
+}
+
+func (fillCodec) Decode(data json.RawMessage) (plan.PhysicalProcedureSpec, error) {
+	var os FillOpSpec
+	if err := json.Unmarshal(data, &os); err != nil {
+		return nil, err
+	}
+	spec, err := newFillProcedure(&os, nil)
+	if err != nil {
+		return nil, err
+	}
+	return spec.(*FillProcedureSpec), nil
+}
 func (s *FillProcedureSpec) Copy() plan.ProcedureSpec {
 	ns := new(FillProcedureSpec)
 
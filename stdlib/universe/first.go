@@ -22,6 +22,7 @@ func init() {
 	runtime.RegisterPackageValue("universe", FirstKind, flux.MustValue(flux.FunctionValue(FirstKind, CreateFirstOpSpec, firstSignature)))
 	plan.RegisterProcedureSpec(FirstKind, newFirstProcedure, FirstKind)
 	execute.RegisterTransformation(FirstKind, createFirstTransformation)
+	plan.RegisterJSONCodec[FirstProcedureSpec](FirstKind)
 }
 
 func CreateFirstOpSpec(args flux.Arguments, a *flux.Administration) (flux.OperationSpec, error) {
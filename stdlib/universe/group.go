@@ -41,6 +41,7 @@ func init() {
 	plan.RegisterProcedureSpec(GroupKind, newGroupProcedure, GroupKind)
 	plan.RegisterLogicalRules(MergeGroupRule{})
 	execute.RegisterTransformation(GroupKind, createGroupTransformation)
+	plan.RegisterJSONCodec[GroupProcedureSpec](GroupKind)
 }
 
 func createGroupOpSpec(args flux.Arguments, a *flux.Administration) (flux.OperationSpec, error) {
@@ -2,9 +2,11 @@ package universe
 
 import (
 	"context"
+	"encoding/json"
 	"math"
 	"regexp"
 	"sort"
+	"strconv"
 
 	"github.com/influxdata/flux"
 	"github.com/influxdata/flux/codes"
@@ -35,6 +37,7 @@ func init() {
 	runtime.RegisterPackageValue("universe", "logarithmicBins", logarithmicBins{})
 	plan.RegisterProcedureSpec(HistogramKind, newHistogramProcedure, HistogramKind)
 	execute.RegisterTransformation(HistogramKind, createHistogramTransformation)
+	plan.RegisterProcedureSpecCodec(HistogramKind, histogramCodec{})
 }
 
 func CreateHistogramOpSpec(args flux.Arguments, a *flux.Administration) (flux.OperationSpec, error) {
@@ -105,6 +108,43 @@ func newHistogramProcedure(qs flux.OperationSpec, pa plan.Administration) (plan.
 func (s *HistogramProcedureSpec) Kind() plan.ProcedureKind {
 	return HistogramKind
 }
+
+// histogramCodec encodes the bins of a histogram procedure spec as
+// strings because the last bin is usually +Inf, which JSON lacks.
+type histogramCodec struct{}
+
+type histogramJSON struct {
+	HistogramOpSpec
+	Bins []string `json:"bins"`
+}
+
+func (histogramCodec) Encode(spec plan.PhysicalProcedureSpec) (json.RawMessage, error) {
+	s, ok := spec.(*HistogramProcedureSpec)
+	if !ok {
+		return nil, errors.Newf(codes.Internal, "invalid spec type %T", spec)
+	}
+	hj := histogramJSON{HistogramOpSpec: s.HistogramOpSpec}
+	for _, b := range s.Bins {
+		hj.Bins = append(hj.Bins, strconv.FormatFloat(b, 'g', -1, 64))
+	}
+	return json.Marshal(hj)
+}
+
+func (histogramCodec) Decode(data json.RawMessage) (plan.PhysicalProcedureSpec, error) {
+	var hj histogramJSON
+	if err := json.Unmarshal(data, &hj); err != nil {
+		return nil, err
+	}
+	spec := &HistogramProcedureSpec{HistogramOpSpec: hj.HistogramOpSpec}
+	for _, b := range hj.Bins {
+		f, err := strconv.ParseFloat(b, 64)
+		if err != nil {
+			return nil, err
+		}
+		spec.Bins = append(spec.Bins, f)
+	}
+	return spec, nil
+}
 func (s *HistogramProcedureSpec) Copy() plan.ProcedureSpec {
 	ns := new(HistogramProcedureSpec)
 	*ns = *s
@@ -27,6 +27,7 @@ func init() {
 	runtime.RegisterPackageValue("universe", HourSelectionKind, flux.MustValue(flux.FunctionValue(HourSelectionKind, createHourSelectionOpSpec, hourSelectionSignature)))
 	plan.RegisterProcedureSpec(HourSelectionKind, newHourSelectionProcedure, HourSelectionKind)
 	execute.RegisterTransformation(HourSelectionKind, createHourSelectionTransformation)
+	plan.RegisterJSONCodec[HourSelectionProcedureSpec](HourSelectionKind)
 }
 
 func createHourSelectionOpSpec(args flux.Arguments, a *flux.Administration) (flux.OperationSpec, error) {
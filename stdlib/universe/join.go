@@ -27,6 +27,7 @@ func init() {
 	// TODO(nathanielc): Allow for other types of join implementations
 	plan.RegisterProcedureSpec(MergeJoinKind, newMergeJoinProcedure, JoinKind)
 	execute.RegisterTransformation(MergeJoinKind, createMergeJoinTransformation)
+	plan.RegisterJSONCodec[MergeJoinProcedureSpec](MergeJoinKind)
 }
 
 // All supported join types in Flux
@@ -24,6 +24,7 @@ func init() {
 	runtime.RegisterPackageValue("universe", kamaKind, flux.MustValue(flux.FunctionValue(kamaKind, CreatekamaOpSpec, kamaSignature)))
 	plan.RegisterProcedureSpec(kamaKind, newkamaProcedure, kamaKind)
 	execute.RegisterTransformation(kamaKind, createkamaTransformation)
+	plan.RegisterJSONCodec[KamaProcedureSpec](kamaKind)
 }
 
 func CreatekamaOpSpec(args flux.Arguments, a *flux.Administration) (flux.OperationSpec, error) {
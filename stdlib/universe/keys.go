@@ -22,6 +22,7 @@ func init() {
 	runtime.RegisterPackageValue("universe", KeysKind, flux.MustValue(flux.FunctionValue(KeysKind, createKeysOpSpec, keysSignature)))
 	plan.RegisterProcedureSpec(KeysKind, newKeysProcedure, KeysKind)
 	execute.RegisterTransformation(KeysKind, createKeysTransformation)
+	plan.RegisterJSONCodec[KeysProcedureSpec](KeysKind)
 }
 
 func createKeysOpSpec(args flux.Arguments, a *flux.Administration) (flux.OperationSpec, error) {
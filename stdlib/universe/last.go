@@ -22,6 +22,7 @@ func init() {
 	runtime.RegisterPackageValue("universe", LastKind, flux.MustValue(flux.FunctionValue(LastKind, CreateLastOpSpec, lastSignature)))
 	plan.RegisterProcedureSpec(LastKind, newLastProcedure, LastKind)
 	execute.RegisterTransformation(LastKind, createLastTransformation)
+	plan.RegisterJSONCodec[LastProcedureSpec](LastKind)
 }
 
 func CreateLastOpSpec(args flux.Arguments, a *flux.Administration) (flux.OperationSpec, error) {
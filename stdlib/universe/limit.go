@@ -29,6 +29,7 @@ func init() {
 	plan.RegisterProcedureSpec(LimitKind, newLimitProcedure, LimitKind)
 	// TODO register a range transformation. Currently range is only supported if it is pushed down into a select procedure.
 	execute.RegisterTransformation(LimitKind, createLimitTransformation)
+	plan.RegisterJSONCodec[LimitProcedureSpec](LimitKind)
 }
 
 func createLimitOpSpec(args flux.Arguments, a *flux.Administration) (flux.OperationSpec, error) {
@@ -22,6 +22,7 @@ func init() {
 	runtime.RegisterPackageValue("universe", MinKind, flux.MustValue(flux.FunctionValue(MinKind, CreateMinOpSpec, minSignature)))
 	plan.RegisterProcedureSpec(MinKind, newMinProcedure, MinKind)
 	execute.RegisterTransformation(MinKind, createMinTransformation)
+	plan.RegisterJSONCodec[MinProcedureSpec](MinKind)
 }
 
 func CreateMinOpSpec(args flux.Arguments, a *flux.Administration) (flux.OperationSpec, error) {
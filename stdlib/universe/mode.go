@@ -24,6 +24,7 @@ func init() {
 	runtime.RegisterPackageValue("universe", ModeKind, flux.MustValue(flux.FunctionValue(ModeKind, CreateModeOpSpec, modeSignature)))
 	plan.RegisterProcedureSpec(ModeKind, newModeProcedure, ModeKind)
 	execute.RegisterTransformation(ModeKind, createModeTransformation)
+	plan.RegisterJSONCodec[ModeProcedureSpec](ModeKind)
 }
 
 func CreateModeOpSpec(args flux.Arguments, a *flux.Administration) (flux.OperationSpec, error) {
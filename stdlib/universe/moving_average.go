@@ -27,6 +27,7 @@ func init() {
 	runtime.RegisterPackageValue("universe", MovingAverageKind, flux.MustValue(flux.FunctionValue(MovingAverageKind, createMovingAverageOpSpec, movingAverageSignature)))
 	plan.RegisterProcedureSpec(MovingAverageKind, newMovingAverageProcedure, MovingAverageKind)
 	execute.RegisterTransformation(MovingAverageKind, createMovingAverageTransformation)
+	plan.RegisterJSONCodec[MovingAverageProcedureSpec](MovingAverageKind)
 }
 
 func createMovingAverageOpSpec(args flux.Arguments, a *flux.Administration) (flux.OperationSpec, error) {
@@ -7,8 +7,10 @@ import (
 	"sync"
 
 	"github.com/influxdata/flux"
+	"github.com/influxdata/flux/codes"
 	"github.com/influxdata/flux/execute"
 	"github.com/influxdata/flux/execute/table"
+	"github.com/influxdata/flux/internal/errors"
 	"github.com/influxdata/flux/memory"
 	"github.com/influxdata/flux/plan"
 	"github.com/opentracing/opentracing-go"
@@ -48,8 +50,69 @@ func (o *PartitionMergeProcedureSpec) Copy() plan.ProcedureSpec {
 	}
 }
 
+// ParallelSourceProcedureSpec is implemented by sources whose
+// parallel copies each read one partition of the data.
+type ParallelSourceProcedureSpec interface {
+	plan.PhysicalProcedureSpec
+	// Parallelize returns a copy of the source with factor parallel
+	// copies and the spec of the node that merges the copies.
+	// The merge is nil when the source cannot be split.
+	Parallelize(factor int) (plan.PhysicalProcedureSpec, *PartitionMergeProcedureSpec)
+}
+
+// ParallelizeSourceRule splits the sources of Kind into the partitions
+// of the partition executor of the execution, when there is one, and
+// merges the partitions before the successors of the source. The spec
+// of the source must implement ParallelSourceProcedureSpec.
+type ParallelizeSourceRule struct {
+	Kind plan.ProcedureKind
+}
+
+func (r ParallelizeSourceRule) Name() string {
+	return "ParallelizeSourceRule/" + string(r.Kind)
+}
+
+func (r ParallelizeSourceRule) Pattern() plan.Pattern {
+	return plan.MultiSuccessor(r.Kind)
+}
+
+func (r ParallelizeSourceRule) Rewrite(ctx context.Context, node plan.Node) (plan.Node, bool, error) {
+	factor := execute.PartitionFactor(ctx)
+	// A source without successors is the root of the plan
+	// and has nothing to merge its partitions into.
+	if factor <= 1 || len(node.Successors()) == 0 ||
+		plan.GetOutputAttribute(node, plan.ParallelRunKey) != nil {
+		return node, false, nil
+	}
+	spec, ok := node.ProcedureSpec().(ParallelSourceProcedureSpec)
+	if !ok {
+		return nil, false, errors.Newf(codes.Internal, "invalid spec type %T", node.ProcedureSpec())
+	}
+
+	src, mergeSpec := spec.Parallelize(factor)
+	if mergeSpec == nil {
+		return node, false, nil
+	}
+	if err := node.ReplaceSpec(src); err != nil {
+		return nil, false, err
+	}
+
+	// The merge takes the place of the source for its successors.
+	merge := plan.CreateUniquePhysicalNode(ctx, "partitionMerge", mergeSpec)
+	for _, succ := range node.Successors() {
+		i := plan.IndexOfNode(node, succ.Predecessors())
+		succ.Predecessors()[i] = merge
+	}
+	merge.AddSuccessors(node.Successors()...)
+	node.ClearSuccessors()
+	node.AddSuccessors(merge)
+	merge.AddPredecessors(node)
+	return node, true, nil
+}
+
 func init() {
 	execute.RegisterTransformation(ParallelMergeKind, createPartitionMergeTransformation)
+	plan.RegisterJSONCodec[PartitionMergeProcedureSpec](ParallelMergeKind)
 }
 
 func createPartitionMergeTransformation(id execute.DatasetID, mode execute.AccumulationMode, spec plan.ProcedureSpec, a execute.Administration) (execute.Transformation, execute.Dataset, error) {
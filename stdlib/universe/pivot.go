@@ -46,9 +46,11 @@ func init() {
 
 	plan.RegisterProcedureSpec(PivotKind, newPivotProcedure, PivotKind)
 	execute.RegisterTransformation(PivotKind, createPivotTransformation)
+	plan.RegisterJSONCodec[PivotProcedureSpec](PivotKind)
 
 	// optimized pivot
 	execute.RegisterTransformation(SortedPivotKind, createSortedPivotTransformation)
+	plan.RegisterJSONCodec[SortedPivotProcedureSpec](SortedPivotKind)
 }
 
 func createPivotOpSpec(args flux.Arguments, a *flux.Administration) (flux.OperationSpec, error) {
@@ -112,13 +114,13 @@ type PivotProcedureSpec struct {
 	// that can be used to determine if the parent is sorted by
 	// the given columns.
 	// TODO(jsternberg): See https://github.com/influxdata/flux/issues/2131 for details.
-	IsSortedByFunc func(cols []string, desc bool) bool
+	IsSortedByFunc func(cols []string, desc bool) bool `json:"-"`
 
 	// IsKeyColumnFunc is a function that can be set by the planner
 	// that can be used to determine if the given column would be
 	// part of the group key if it were present.
 	// TODO(jsternberg): See https://github.com/influxdata/flux/issues/2131 for details.
-	IsKeyColumnFunc func(label string) bool
+	IsKeyColumnFunc func(label string) bool `json:"-"`
 }
 
 func newPivotProcedure(qs flux.OperationSpec, pa plan.Administration) (plan.ProcedureSpec, error) {
@@ -46,6 +46,9 @@ func init() {
 	execute.RegisterTransformation(QuantileKind, createQuantileTransformation)
 	execute.RegisterTransformation(ExactQuantileAggKind, createExactQuantileAggTransformation)
 	execute.RegisterTransformation(ExactQuantileSelectKind, createExactQuantileSelectTransformation)
+	plan.RegisterJSONCodec[TDigestQuantileProcedureSpec](QuantileKind)
+	plan.RegisterJSONCodec[ExactQuantileAggProcedureSpec](ExactQuantileAggKind)
+	plan.RegisterJSONCodec[ExactQuantileSelectProcedureSpec](ExactQuantileSelectKind)
 }
 
 func CreateQuantileOpSpec(args flux.Arguments, a *flux.Administration) (flux.OperationSpec, error) {
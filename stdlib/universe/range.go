@@ -27,6 +27,7 @@ func init() {
 	plan.RegisterProcedureSpec(RangeKind, newRangeProcedure, RangeKind)
 	// TODO register a range transformation. Currently range is only supported if it is pushed down into a select procedure.
 	execute.RegisterTransformation(RangeKind, createRangeTransformation)
+	plan.RegisterJSONCodec[RangeProcedureSpec](RangeKind)
 }
 
 func createRangeOpSpec(args flux.Arguments, a *flux.Administration) (flux.OperationSpec, error) {
@@ -27,6 +27,7 @@ func init() {
 	runtime.RegisterPackageValue("universe", RelativeStrengthIndexKind, flux.MustValue(flux.FunctionValue(RelativeStrengthIndexKind, createRelativeStrengthIndexOpSpec, relativeStrengthIndexSignature)))
 	plan.RegisterProcedureSpec(RelativeStrengthIndexKind, newRelativeStrengthIndexProcedure, RelativeStrengthIndexKind)
 	execute.RegisterTransformation(RelativeStrengthIndexKind, createRelativeStrengthIndexTransformation)
+	plan.RegisterJSONCodec[RelativeStrengthIndexProcedureSpec](RelativeStrengthIndexKind)
 }
 
 func createRelativeStrengthIndexOpSpec(args flux.Arguments, a *flux.Administration) (flux.OperationSpec, error) {
@@ -26,6 +26,7 @@ func init() {
 	runtime.RegisterPackageValue("universe", SampleKind, flux.MustValue(flux.FunctionValue(SampleKind, createSampleOpSpec, sampleSignature)))
 	plan.RegisterProcedureSpec(SampleKind, newSampleProcedure, SampleKind)
 	execute.RegisterTransformation(SampleKind, createSampleTransformation)
+	plan.RegisterJSONCodec[SampleProcedureSpec](SampleKind)
 }
 
 func createSampleOpSpec(args flux.Arguments, a *flux.Administration) (flux.OperationSpec, error) {
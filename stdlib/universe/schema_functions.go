@@ -2,6 +2,7 @@ package universe
 
 import (
 	"context"
+	"encoding/json"
 
 	"github.com/influxdata/flux"
 	"github.com/influxdata/flux/array"
@@ -104,6 +105,7 @@ func init() {
 
 	plan.RegisterProcedureSpec(SchemaMutationKind, newSchemaMutationProcedure, SchemaMutationOps...)
 	execute.RegisterTransformation(SchemaMutationKind, createSchemaMutationTransformation)
+	plan.RegisterProcedureSpecCodec(SchemaMutationKind, schemaMutationCodec{})
 }
 
 func createRenameOpSpec(args flux.Arguments, a *flux.Administration) (flux.OperationSpec, error) {
@@ -385,6 +387,78 @@ func (s *SchemaMutationProcedureSpec) Copy() plan.ProcedureSpec {
 	}
 }
 
+// schemaMutationCodec encodes each mutation of a schema mutation
+// procedure spec with its kind. Mutations that filter columns
+// with a function cannot be serialized.
+type schemaMutationCodec struct{}
+
+type schemaMutationJSON struct {
+	Kind    flux.OperationKind `json:"kind"`
+	Columns []string           `json:"columns,omitempty"`
+	Renames map[string]string  `json:"renames,omitempty"`
+	Column  string             `json:"column,omitempty"`
+	As      string             `json:"as,omitempty"`
+}
+
+func (schemaMutationCodec) Encode(spec plan.PhysicalProcedureSpec) (json.RawMessage, error) {
+	s, ok := spec.(*SchemaMutationProcedureSpec)
+	if !ok {
+		return nil, errors.Newf(codes.Internal, "invalid spec type %T", spec)
+	}
+	mutations := make([]schemaMutationJSON, len(s.Mutations))
+	for i, m := range s.Mutations {
+		var (
+			mj schemaMutationJSON
+			fn interpreter.ResolvedFunction
+		)
+		switch m := m.(type) {
+		case *RenameOpSpec:
+			mj = schemaMutationJSON{Kind: RenameKind, Renames: m.Columns}
+			fn = m.Fn
+		case *DropOpSpec:
+			mj = schemaMutationJSON{Kind: DropKind, Columns: m.Columns}
+			fn = m.Predicate
+		case *KeepOpSpec:
+			mj = schemaMutationJSON{Kind: KeepKind, Columns: m.Columns}
+			fn = m.Predicate
+		case *DuplicateOpSpec:
+			mj = schemaMutationJSON{Kind: DuplicateKind, Column: m.Column, As: m.As}
+		default:
+			return nil, errors.Newf(codes.Unimplemented, "cannot serialize schema mutation %T", m)
+		}
+		if fn.Fn != nil {
+			return nil, errors.Newf(codes.Unimplemented, "cannot serialize %s with a function", mj.Kind)
+		}
+		mutations[i] = mj
+	}
+	return json.Marshal(mutations)
+}
+
+func (schemaMutationCodec) Decode(data json.RawMessage) (plan.PhysicalProcedureSpec, error) {
+	var mutations []schemaMutationJSON
+	if err := json.Unmarshal(data, &mutations); err != nil {
+		return nil, err
+	}
+	spec := &SchemaMutationProcedureSpec{
+		Mutations: make([]SchemaMutation, len(mutations)),
+	}
+	for i, mj := range mutations {
+		switch mj.Kind {
+		case RenameKind:
+			spec.Mutations[i] = &RenameOpSpec{Columns: mj.Renames}
+		case DropKind:
+			spec.Mutations[i] = &DropOpSpec{Columns: mj.Columns}
+		case KeepKind:
+			spec.Mutations[i] = &KeepOpSpec{Columns: mj.Columns}
+		case DuplicateKind:
+			spec.Mutations[i] = &DuplicateOpSpec{Column: mj.Column, As: mj.As}
+		default:
+			return nil, errors.Newf(codes.Invalid, "unknown schema mutation kind %q", mj.Kind)
+		}
+	}
+	return spec, nil
+}
+
 func newSchemaMutationProcedure(qs flux.OperationSpec, pa plan.Administration) (plan.ProcedureSpec, error) {
 	s, ok := qs.(SchemaMutation)
 	if !ok {
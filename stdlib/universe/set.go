@@ -28,6 +28,7 @@ func init() {
 	runtime.RegisterPackageValue("universe", SetKind, flux.MustValue(flux.FunctionValue(SetKind, createSetOpSpec, setSignature)))
 	plan.RegisterProcedureSpec(SetKind, newSetProcedure, SetKind)
 	execute.RegisterTransformation(SetKind, createSetTransformation)
+	plan.RegisterJSONCodec[SetProcedureSpec](SetKind)
 }
 
 func createSetOpSpec(args flux.Arguments, a *flux.Administration) (flux.OperationSpec, error) {
@@ -31,6 +31,7 @@ func init() {
 	runtime.RegisterPackageValue("universe", ShiftKind, flux.MustValue(flux.FunctionValue(ShiftKind, createShiftOpSpec, shiftSignature)))
 	plan.RegisterProcedureSpec(ShiftKind, newShiftProcedure, ShiftKind)
 	execute.RegisterTransformation(ShiftKind, createShiftTransformation)
+	plan.RegisterJSONCodec[ShiftProcedureSpec](ShiftKind)
 }
 
 func createShiftOpSpec(args flux.Arguments, a *flux.Administration) (flux.OperationSpec, error) {
@@ -24,6 +24,7 @@ func init() {
 	runtime.RegisterPackageValue("universe", SkewKind, flux.MustValue(flux.FunctionValue(SkewKind, CreateSkewOpSpec, skewSignature)))
 	plan.RegisterProcedureSpec(SkewKind, newSkewProcedure, SkewKind)
 	execute.RegisterTransformation(SkewKind, createSkewTransformation)
+	plan.RegisterJSONCodec[SkewProcedureSpec](SkewKind)
 }
 func CreateSkewOpSpec(args flux.Arguments, a *flux.Administration) (flux.OperationSpec, error) {
 	if err := a.AddParentFromArgs(args); err != nil {
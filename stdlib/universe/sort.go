@@ -35,6 +35,7 @@ func init() {
 	plan.RegisterProcedureSpec(SortKind, newSortProcedure, SortKind)
 	plan.RegisterPhysicalRules(RemoveRedundantSort{})
 	execute.RegisterTransformation(SortKind, createSortTransformation)
+	plan.RegisterJSONCodec[SortProcedureSpec](SortKind)
 }
 
 func createSortOpSpec(args flux.Arguments, a *flux.Administration) (flux.OperationSpec, error) {
@@ -18,6 +18,7 @@ import (
 func init() {
 	plan.RegisterPhysicalRules(SortLimitRule{})
 	execute.RegisterTransformation(SortLimitKind, createSortLimitTransformation)
+	plan.RegisterJSONCodec[SortLimitProcedureSpec](SortLimitKind)
 }
 
 const SortLimitKind = "sortLimit"
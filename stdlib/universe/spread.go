@@ -19,6 +19,7 @@ func init() {
 	runtime.RegisterPackageValue("universe", SpreadKind, flux.MustValue(flux.FunctionValue(SpreadKind, CreateSpreadOpSpec, spreadSignature)))
 	plan.RegisterProcedureSpec(SpreadKind, newSpreadProcedure, SpreadKind)
 	execute.RegisterTransformation(SpreadKind, createSpreadTransformation)
+	plan.RegisterJSONCodec[SpreadProcedureSpec](SpreadKind)
 }
 
 func CreateSpreadOpSpec(args flux.Arguments, a *flux.Administration) (flux.OperationSpec, error) {
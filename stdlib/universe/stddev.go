@@ -30,6 +30,7 @@ func init() {
 	runtime.RegisterPackageValue("universe", StddevKind, flux.MustValue(flux.FunctionValue(StddevKind, CreateStddevOpSpec, stddevSignature)))
 	plan.RegisterProcedureSpec(StddevKind, newStddevProcedure, StddevKind)
 	execute.RegisterTransformation(StddevKind, createStddevTransformation)
+	plan.RegisterJSONCodec[StddevProcedureSpec](StddevKind)
 }
 func CreateStddevOpSpec(args flux.Arguments, a *flux.Administration) (flux.OperationSpec, error) {
 	if err := a.AddParentFromArgs(args); err != nil {
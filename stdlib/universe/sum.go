@@ -23,6 +23,7 @@ func init() {
 	runtime.RegisterPackageValue("universe", SumKind, flux.MustValue(flux.FunctionValue(SumKind, CreateSumOpSpec, sumSignature)))
 	plan.RegisterProcedureSpec(SumKind, newSumProcedure, SumKind)
 	execute.RegisterTransformation(SumKind, createSumTransformation)
+	plan.RegisterJSONCodec[SumProcedureSpec](SumKind)
 }
 
 func CreateSumOpSpec(args flux.Arguments, a *flux.Administration) (flux.OperationSpec, error) {
@@ -24,6 +24,7 @@ func init() {
 	runtime.RegisterPackageValue("universe", TailKind, flux.MustValue(flux.FunctionValue(TailKind, createTailOpSpec, tailSignature)))
 	plan.RegisterProcedureSpec(TailKind, newTailProcedure, TailKind)
 	execute.RegisterTransformation(TailKind, createTailTransformation)
+	plan.RegisterJSONCodec[TailProcedureSpec](TailKind)
 }
 
 func createTailOpSpec(args flux.Arguments, a *flux.Administration) (flux.OperationSpec, error) {
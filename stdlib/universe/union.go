@@ -30,6 +30,7 @@ func init() {
 	runtime.RegisterPackageValue("universe", UnionKind, flux.MustValue(flux.FunctionValue(UnionKind, createUnionOpSpec, unionSignature)))
 	plan.RegisterProcedureSpec(UnionKind, newUnionProcedure, UnionKind)
 	execute.RegisterTransformation(UnionKind, createUnionTransformation)
+	plan.RegisterJSONCodec[UnionProcedureSpec](UnionKind)
 }
 
 func createUnionOpSpec(args flux.Arguments, a *flux.Administration) (flux.OperationSpec, error) {
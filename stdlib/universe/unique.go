@@ -21,6 +21,7 @@ func init() {
 	runtime.RegisterPackageValue("universe", UniqueKind, flux.MustValue(flux.FunctionValue(UniqueKind, CreateUniqueOpSpec, uniqueSignature)))
 	plan.RegisterProcedureSpec(UniqueKind, newUniqueProcedure, UniqueKind)
 	execute.RegisterTransformation(UniqueKind, createUniqueTransformation)
+	plan.RegisterJSONCodec[UniqueProcedureSpec](UniqueKind)
 }
 
 func CreateUniqueOpSpec(args flux.Arguments, a *flux.Administration) (flux.OperationSpec, error) {
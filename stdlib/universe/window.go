@@ -38,6 +38,7 @@ func init() {
 	plan.RegisterProcedureSpec(WindowKind, newWindowProcedure, WindowKind)
 	plan.RegisterPhysicalRules(WindowTriggerPhysicalRule{})
 	execute.RegisterTransformation(WindowKind, createWindowTransformation)
+	plan.RegisterJSONCodec[WindowProcedureSpec](WindowKind)
 }
 
 func CreateWindowOpSpec(args flux.Arguments, a *flux.Administration) (flux.OperationSpec, error) {
@@ -19,6 +19,7 @@ func init() {
 
 	runtime.RegisterPackageValue("universe", YieldKind, flux.MustValue(flux.FunctionValueWithSideEffect(YieldKind, createYieldOpSpec, yieldSignature)))
 	plan.RegisterProcedureSpecWithSideEffect(YieldKind, newYieldProcedure, YieldKind)
+	plan.RegisterJSONCodec[YieldProcedureSpec](YieldKind)
 }
 
 func createYieldOpSpec(args flux.Arguments, a *flux.Administration) (flux.OperationSpec, error) {